@@ -18,6 +18,19 @@ type Addr struct {
 	PreferedLft int
 	ValidLft    int
 	LinkIndex   int
+	// Priority is the metric of the prefix route the kernel creates for
+	// the address, or for its Peer when set. 0 keeps the kernel default.
+	Priority int
+}
+
+// AddrFlushOptions selects the addresses deleted by AddrFlush. The zero
+// value selects them all.
+type AddrFlushOptions struct {
+	// Scope, when set, only selects the addresses of that scope, one of
+	// the unix.RT_SCOPE_* values
+	Scope *int
+	// Label, when not empty, only selects the addresses with that label
+	Label string
 }
 
 // String returns $ip/$netmask $label
@@ -73,6 +73,12 @@ func (h *Handle) AddrDel(link Link, addr *Addr) error {
 }
 
 func (h *Handle) addrHandle(link Link, addr *Addr, req *nl.NetlinkRequest) error {
+	h.addrPrepare(link, addr, req)
+	_, err := req.Execute(unix.NETLINK_ROUTE, 0)
+	return err
+}
+
+func (h *Handle) addrPrepare(link Link, addr *Addr, req *nl.NetlinkRequest) {
 	family := nl.GetIPFamily(addr.IP)
 	msg := nl.NewIfAddrmsg(family)
 	msg.Scope = uint8(addr.Scope)
@@ -151,6 +157,10 @@ func (h *Handle) addrHandle(link Link, addr *Addr, req *nl.NetlinkRequest) error
 		}
 	}
 
+	if addr.Priority > 0 {
+		req.AddData(nl.NewRtAttr(unix.IFA_RT_PRIORITY, nl.Uint32Attr(uint32(addr.Priority))))
+	}
+
 	// 0 is the default value for these attributes. However, 0 means "expired", while the least-surprising default
 	// value should be "forever". To compensate for that, only add the attributes if at least one of the values is
 	// non-zero, which means the caller has explicitly set them
@@ -161,9 +171,80 @@ func (h *Handle) addrHandle(link Link, addr *Addr, req *nl.NetlinkRequest) error
 		}}
 		req.AddData(nl.NewRtAttr(unix.IFA_CACHEINFO, cachedata.Serialize()))
 	}
+}
+
+// addrFlushMaxRounds bounds the dump and delete rounds of AddrFlush, as in
+// iproute2.
+const addrFlushMaxRounds = 10
+
+// AddrFlush deletes the addresses of link, or of all links if link is nil,
+// in family that match opts, which may be nil, and returns how many were
+// deleted. As addresses may be added back meanwhile, e.g. by a DHCP client,
+// it dumps and deletes again until no address matches, for a bounded
+// number of rounds. The deletes of a round are batched, several per
+// write, rather than sent one round trip each.
+// Equivalent to: `ip addr flush dev $link scope $scope label $label`
+func AddrFlush(link Link, family int, opts *AddrFlushOptions) (int, error) {
+	return pkgHandle.AddrFlush(link, family, opts)
+}
+
+// AddrFlush deletes the addresses of link, or of all links if link is nil,
+// in family that match opts, which may be nil, and returns how many were
+// deleted. As addresses may be added back meanwhile, e.g. by a DHCP client,
+// it dumps and deletes again until no address matches, for a bounded
+// number of rounds. The deletes of a round are batched, several per
+// write, rather than sent one round trip each.
+// Equivalent to: `ip addr flush dev $link scope $scope label $label`
+func (h *Handle) AddrFlush(link Link, family int, opts *AddrFlushOptions) (int, error) {
+	if opts == nil {
+		opts = &AddrFlushOptions{}
+	}
+	deleted := 0
+	for round := 0; round < addrFlushMaxRounds; round++ {
+		addrs, err := h.AddrList(link, family)
+		if err != nil && !errors.Is(err, ErrDumpInterrupted) {
+			return deleted, err
+		}
 
-	_, err := req.Execute(unix.NETLINK_ROUTE, 0)
-	return err
+		var reqs []*nl.NetlinkRequest
+		for _, addr := range addrs {
+			if opts.Scope != nil && addr.Scope != *opts.Scope {
+				continue
+			}
+			if opts.Label != "" && addr.Label != opts.Label {
+				continue
+			}
+			req := h.newNetlinkRequest(unix.RTM_DELADDR, unix.NLM_F_ACK)
+			h.addrPrepare(nil, &Addr{
+				IPNet:     addr.IPNet,
+				Peer:      addr.Peer,
+				Label:     addr.Label,
+				Scope:     addr.Scope,
+				LinkIndex: addr.LinkIndex,
+			}, req)
+			reqs = append(reqs, req)
+		}
+		if len(reqs) == 0 && err == nil {
+			return deleted, nil
+		}
+
+		results, err := nl.ExecuteBatch(unix.NETLINK_ROUTE, reqs)
+		for _, result := range results {
+			switch {
+			case result == nil:
+				deleted++
+			case errors.Is(result, unix.EADDRNOTAVAIL), errors.Is(result, unix.ENOENT):
+				// already gone, e.g. a secondary address removed
+				// along with its primary
+			case err == nil:
+				err = result
+			}
+		}
+		if err != nil {
+			return deleted, err
+		}
+	}
+	return deleted, fmt.Errorf("addresses still present after %d flush rounds", addrFlushMaxRounds)
 }
 
 // AddrList gets a list of IP addresses in the system.
@@ -265,6 +346,8 @@ func parseAddr(m []byte) (addr Addr, family int, err error) {
 			ci := nl.DeserializeIfaCacheInfo(attr.Value)
 			addr.PreferedLft = int(ci.Prefered)
 			addr.ValidLft = int(ci.Valid)
+		case unix.IFA_RT_PRIORITY:
+			addr.Priority = int(native.Uint32(attr.Value[0:4]))
 		}
 	}
 
@@ -299,18 +382,29 @@ type AddrUpdate struct {
 	PreferedLft int
 	ValidLft    int
 	NewAddr     bool // true=added false=deleted
+	// Deprecated is set on the update sent when the address' preferred
+	// lifetime expires, which otherwise looks like a plain add.
+	Deprecated bool
+	// LinkName is the name of the link at LinkIndex. It is only set when
+	// subscribing with AddrSubscribeOptions.ResolveNames.
+	LinkName string
+	// NsID is the id, in the subscribing namespace, of the network
+	// namespace the update comes from. It is -1 for updates from the
+	// subscription's own namespace. Other namespaces are only heard from
+	// when subscribing with ListenAllNsid.
+	NsID int
 }
 
 // AddrSubscribe takes a chan down which notifications will be sent
 // when addresses change.  Close the 'done' chan to stop subscription.
 func AddrSubscribe(ch chan<- AddrUpdate, done <-chan struct{}) error {
-	return addrSubscribeAt(netns.None(), netns.None(), ch, done, nil, false, 0, nil, false)
+	return addrSubscribeAt(netns.None(), netns.None(), ch, done, nil, false, 0, nil, false, false, false)
 }
 
 // AddrSubscribeAt works like AddrSubscribe plus it allows the caller
 // to choose the network namespace in which to subscribe (ns).
 func AddrSubscribeAt(ns netns.NsHandle, ch chan<- AddrUpdate, done <-chan struct{}) error {
-	return addrSubscribeAt(ns, netns.None(), ch, done, nil, false, 0, nil, false)
+	return addrSubscribeAt(ns, netns.None(), ch, done, nil, false, 0, nil, false, false, false)
 }
 
 // AddrSubscribeOptions contains a set of options to use with
@@ -322,6 +416,14 @@ type AddrSubscribeOptions struct {
 	ReceiveBufferSize      int
 	ReceiveBufferForceSize bool
 	ReceiveTimeout         *unix.Timeval
+	// ResolveNames fills in AddrUpdate.LinkName. The subscription keeps an
+	// index to name cache, primed from a link dump and kept up to date by
+	// also listening to link notifications on the same socket.
+	ResolveNames bool
+	// ListenAllNsid sets NETLINK_LISTEN_ALL_NSID on the subscription
+	// socket so that updates from every namespace that has an id in the
+	// subscribing namespace are received too, tagged with their NsID.
+	ListenAllNsid bool
 }
 
 // AddrSubscribeWithOptions work like AddrSubscribe but enable to
@@ -333,15 +435,26 @@ func AddrSubscribeWithOptions(ch chan<- AddrUpdate, done <-chan struct{}, option
 		options.Namespace = &none
 	}
 	return addrSubscribeAt(*options.Namespace, netns.None(), ch, done, options.ErrorCallback, options.ListExisting,
-		options.ReceiveBufferSize, options.ReceiveTimeout, options.ReceiveBufferForceSize)
+		options.ReceiveBufferSize, options.ReceiveTimeout, options.ReceiveBufferForceSize, options.ResolveNames,
+		options.ListenAllNsid)
 }
 
 func addrSubscribeAt(newNs, curNs netns.NsHandle, ch chan<- AddrUpdate, done <-chan struct{}, cberr func(error), listExisting bool,
-	rcvbuf int, rcvTimeout *unix.Timeval, rcvBufForce bool) error {
-	s, err := nl.SubscribeAt(newNs, curNs, unix.NETLINK_ROUTE, unix.RTNLGRP_IPV4_IFADDR, unix.RTNLGRP_IPV6_IFADDR)
+	rcvbuf int, rcvTimeout *unix.Timeval, rcvBufForce bool, resolveNames bool, listenAllNsid bool) error {
+	groups := []uint{unix.RTNLGRP_IPV4_IFADDR, unix.RTNLGRP_IPV6_IFADDR}
+	if resolveNames {
+		groups = append(groups, unix.RTNLGRP_LINK)
+	}
+	s, err := nl.SubscribeAt(newNs, curNs, unix.NETLINK_ROUTE, groups...)
 	if err != nil {
 		return err
 	}
+	if listenAllNsid {
+		if err := s.SetListenAllNsid(true); err != nil {
+			s.Close()
+			return err
+		}
+	}
 	if rcvTimeout != nil {
 		if err := s.SetReceiveTimeout(rcvTimeout); err != nil {
 			return err
@@ -359,19 +472,38 @@ func addrSubscribeAt(newNs, curNs netns.NsHandle, ch chan<- AddrUpdate, done <-c
 			s.Close()
 		}()
 	}
-	if listExisting {
+	listAddrs := func() error {
 		req := pkgHandle.newNetlinkRequest(unix.RTM_GETADDR,
 			unix.NLM_F_DUMP)
 		infmsg := nl.NewIfInfomsg(unix.AF_UNSPEC)
 		req.AddData(infmsg)
+		return s.Send(req)
+	}
+	var linkNames map[int]string
+	if resolveNames {
+		linkNames = make(map[int]string)
+		req := pkgHandle.newNetlinkRequest(unix.RTM_GETLINK,
+			unix.NLM_F_DUMP)
+		infmsg := nl.NewIfInfomsg(unix.AF_UNSPEC)
+		req.AddData(infmsg)
 		if err := s.Send(req); err != nil {
 			return err
 		}
+		// The address dump, if any, is requested once the link dump
+		// is done since only one dump can run on a socket at a time.
+	} else if listExisting {
+		if err := listAddrs(); err != nil {
+			return err
+		}
 	}
 	go func() {
 		defer close(ch)
+		linkDumpDone := !resolveNames
+		// Address updates received while the link dump is still running
+		// are held back until every link name is known.
+		var pending []AddrUpdate
 		for {
-			msgs, from, err := s.Receive()
+			msgs, from, nsid, err := s.ReceiveNsid()
 			if err != nil {
 				if cberr != nil {
 					cberr(fmt.Errorf("Receive failed: %v",
@@ -387,6 +519,24 @@ func addrSubscribeAt(newNs, curNs netns.NsHandle, ch chan<- AddrUpdate, done <-c
 			}
 			for _, m := range msgs {
 				if m.Header.Type == unix.NLMSG_DONE {
+					if !linkDumpDone {
+						linkDumpDone = true
+						for _, update := range pending {
+							if update.NsID == -1 {
+								update.LinkName = linkNames[update.LinkIndex]
+							}
+							ch <- update
+						}
+						pending = nil
+						if listExisting {
+							if err := listAddrs(); err != nil {
+								if cberr != nil {
+									cberr(err)
+								}
+								return
+							}
+						}
+					}
 					continue
 				}
 				if m.Header.Type == unix.NLMSG_ERROR {
@@ -401,6 +551,16 @@ func addrSubscribeAt(newNs, curNs netns.NsHandle, ch chan<- AddrUpdate, done <-c
 					continue
 				}
 				msgType := m.Header.Type
+				if resolveNames && (msgType == unix.RTM_NEWLINK || msgType == unix.RTM_DELLINK) {
+					// The cache only covers the subscription's own namespace.
+					if nsid != -1 {
+						continue
+					}
+					if err := updateLinkNames(linkNames, msgType, m.Data); err != nil && cberr != nil {
+						cberr(err)
+					}
+					continue
+				}
 				if msgType != unix.RTM_NEWADDR && msgType != unix.RTM_DELADDR {
 					if cberr != nil {
 						cberr(fmt.Errorf("bad message type: %d", msgType))
@@ -416,16 +576,47 @@ func addrSubscribeAt(newNs, curNs netns.NsHandle, ch chan<- AddrUpdate, done <-c
 					continue
 				}
 
-				ch <- AddrUpdate{LinkAddress: *addr.IPNet,
+				update := AddrUpdate{LinkAddress: *addr.IPNet,
 					LinkIndex:   addr.LinkIndex,
 					NewAddr:     msgType == unix.RTM_NEWADDR,
 					Flags:       addr.Flags,
 					Scope:       addr.Scope,
 					PreferedLft: addr.PreferedLft,
-					ValidLft:    addr.ValidLft}
+					ValidLft:    addr.ValidLft,
+					Deprecated:  addr.Flags&unix.IFA_F_DEPRECATED != 0,
+					NsID:        nsid}
+				if !linkDumpDone {
+					pending = append(pending, update)
+					continue
+				}
+				if nsid == -1 {
+					update.LinkName = linkNames[addr.LinkIndex]
+				}
+				ch <- update
 			}
 		}
 	}()
 
 	return nil
 }
+
+// updateLinkNames applies a RTM_NEWLINK or RTM_DELLINK message to an
+// index to name cache.
+func updateLinkNames(names map[int]string, msgType uint16, m []byte) error {
+	msg := nl.DeserializeIfInfomsg(m)
+	index := int(msg.Index)
+	if msgType == unix.RTM_DELLINK {
+		delete(names, index)
+		return nil
+	}
+	attrs, err := nl.ParseRouteAttr(m[msg.Len():])
+	if err != nil {
+		return err
+	}
+	for _, attr := range attrs {
+		if attr.Attr.Type == unix.IFLA_IFNAME {
+			names[index] = string(attr.Value[:len(attr.Value)-1])
+		}
+	}
+	return nil
+}
@@ -238,7 +238,10 @@ func expectAddrUpdate(ch <-chan AddrUpdate, add bool, dst net.IP) bool {
 	for {
 		timeout := time.After(time.Minute)
 		select {
-		case update := <-ch:
+		case update, ok := <-ch:
+			if !ok {
+				return false
+			}
 			if update.NewAddr == add && update.LinkAddress.IP.Equal(dst) {
 				return true
 			}
@@ -248,6 +251,56 @@ func expectAddrUpdate(ch <-chan AddrUpdate, add bool, dst net.IP) bool {
 	}
 }
 
+func TestAddrFlush(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+
+	link := &Dummy{LinkAttrs{Name: "foo"}}
+	if err := LinkAdd(link); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"10.0.0.1/24", "10.0.1.1/24", "2001:db8::1/64", "2001:db8:1::1/64", "fe80::1/64"} {
+		addr, err := ParseAddr(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := AddrAdd(link, addr); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scope := unix.RT_SCOPE_LINK
+	n, err := AddrFlush(link, FAMILY_V6, &AddrFlushOptions{Scope: &scope})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf("expected 1 link scope address flushed, got %d", n)
+	}
+
+	n, err = AddrFlush(link, FAMILY_V6, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Fatalf("expected 2 IPv6 addresses flushed, got %d", n)
+	}
+
+	addrs, err := AddrList(link, FAMILY_V6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addrs) != 0 {
+		t.Fatalf("IPv6 addresses left after flush: %v", addrs)
+	}
+	addrs, err = AddrList(link, FAMILY_V4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addrs) != 2 {
+		t.Fatalf("expected the 2 IPv4 addresses to be kept, got %v", addrs)
+	}
+}
+
 func TestAddrSubscribeWithOptions(t *testing.T) {
 	t.Cleanup(setUpNetlinkTest(t))
 
@@ -283,6 +336,100 @@ func TestAddrSubscribeWithOptions(t *testing.T) {
 	if !expectAddrUpdate(ch, true, ip) {
 		t.Fatal("Add update not received as expected")
 	}
+
+	// an address with a short preferred lifetime is re-announced as
+	// deprecated once that lifetime runs out
+	ip6 := net.ParseIP("2001:db8::1")
+	addr := &Addr{
+		IPNet:       &net.IPNet{IP: ip6, Mask: net.CIDRMask(128, 128)},
+		Flags:       unix.IFA_F_NODAD,
+		PreferedLft: 1,
+		ValidLft:    60,
+	}
+	if err := AddrAdd(link, addr); err != nil {
+		t.Fatal(err)
+	}
+	timeout := time.After(time.Minute)
+	for deprecated := false; !deprecated; {
+		select {
+		case update, ok := <-ch:
+			if !ok {
+				t.Fatal("update channel closed unexpectedly")
+			}
+			if update.NewAddr && update.LinkAddress.IP.Equal(ip6) {
+				if update.ValidLft == 0 {
+					t.Fatal("ValidLft not reported in update")
+				}
+				deprecated = update.Deprecated
+			}
+		case <-timeout:
+			t.Fatal("Deprecation update not received as expected")
+		}
+	}
+}
+
+func expectAddrUpdateName(ch <-chan AddrUpdate, dst net.IP, name string) bool {
+	timeout := time.After(time.Minute)
+	for {
+		select {
+		case update, ok := <-ch:
+			if !ok {
+				return false
+			}
+			if update.NewAddr && update.LinkAddress.IP.Equal(dst) {
+				return update.LinkName == name
+			}
+		case <-timeout:
+			return false
+		}
+	}
+}
+
+func TestAddrSubscribeResolveNames(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+
+	link := &Veth{LinkAttrs: LinkAttrs{Name: "foo"}, PeerName: "bar"}
+	if err := LinkAdd(link); err != nil {
+		t.Fatal(err)
+	}
+
+	ch := make(chan AddrUpdate)
+	done := make(chan struct{})
+	defer close(done)
+	var lastError error
+	defer func() {
+		if lastError != nil {
+			t.Fatalf("Fatal error received during subscription: %v", lastError)
+		}
+	}()
+	if err := AddrSubscribeWithOptions(ch, done, AddrSubscribeOptions{
+		ResolveNames: true,
+		ErrorCallback: func(err error) {
+			lastError = err
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	ip := net.IPv4(192, 168, 10, 1)
+	if err := AddrAdd(link, &Addr{IPNet: &net.IPNet{IP: ip, Mask: net.CIDRMask(24, 32)}}); err != nil {
+		t.Fatal(err)
+	}
+	if !expectAddrUpdateName(ch, ip, "foo") {
+		t.Fatal("Add update with link name foo not received as expected")
+	}
+
+	if err := LinkSetName(link, "baz"); err != nil {
+		t.Fatal(err)
+	}
+
+	ip = net.IPv4(192, 168, 20, 1)
+	if err := AddrAdd(link, &Addr{IPNet: &net.IPNet{IP: ip, Mask: net.CIDRMask(24, 32)}}); err != nil {
+		t.Fatal(err)
+	}
+	if !expectAddrUpdateName(ch, ip, "baz") {
+		t.Fatal("Add update with renamed link baz not received as expected")
+	}
 }
 
 func TestAddrSubscribeListExisting(t *testing.T) {
@@ -323,3 +470,89 @@ func TestAddrSubscribeListExisting(t *testing.T) {
 		t.Fatal("Add update not received as expected")
 	}
 }
+
+func TestAddrPeerPriority(t *testing.T) {
+	minKernelRequired(t, 4, 18)
+
+	for _, tt := range []struct {
+		name   string
+		family int
+		local  *net.IPNet
+		peer   *net.IPNet
+	}{
+		{
+			name:   "IPv4",
+			family: FAMILY_V4,
+			local:  &net.IPNet{IP: net.ParseIP("192.0.2.1").To4(), Mask: net.CIDRMask(32, 32)},
+			peer:   &net.IPNet{IP: net.ParseIP("192.0.2.2").To4(), Mask: net.CIDRMask(32, 32)},
+		},
+		{
+			name:   "IPv6",
+			family: FAMILY_V6,
+			local:  &net.IPNet{IP: net.ParseIP("2001:db8::1"), Mask: net.CIDRMask(128, 128)},
+			peer:   &net.IPNet{IP: net.ParseIP("2001:db8::2"), Mask: net.CIDRMask(128, 128)},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(setUpNetlinkTest(t))
+
+			if err := LinkAdd(&Veth{LinkAttrs: LinkAttrs{Name: "foo"}, PeerName: "bar"}); err != nil {
+				t.Fatal(err)
+			}
+			link, err := LinkByName("foo")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := LinkSetUp(link); err != nil {
+				t.Fatal(err)
+			}
+
+			addr := &Addr{IPNet: tt.local, Peer: tt.peer, Priority: 300, Flags: unix.IFA_F_NODAD}
+			if err := AddrAdd(link, addr); err != nil {
+				t.Fatal(err)
+			}
+			addrs, err := AddrList(link, tt.family)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got *Addr
+			for i := range addrs {
+				if addrs[i].IP.Equal(tt.local.IP) {
+					got = &addrs[i]
+				}
+			}
+			if got == nil {
+				t.Fatalf("address %s not listed in %v", tt.local, addrs)
+			}
+			if got.Peer == nil || !got.PeerEqual(*addr) {
+				t.Fatalf("Peer is %v, should be %s", got.Peer, tt.peer)
+			}
+			if got.Priority != 300 {
+				t.Fatalf("Priority is %d, should be 300", got.Priority)
+			}
+
+			// the prefix route goes to the peer, with the metric. The IPv6
+			// one is added later by the DAD worker, even with NODAD.
+			var routes []Route
+			deadline := time.Now().Add(5 * time.Second)
+			for {
+				routes, err = RouteListFiltered(tt.family, &Route{LinkIndex: link.Attrs().Index, Dst: tt.peer},
+					RT_FILTER_OIF|RT_FILTER_DST)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(routes) != 0 || time.Now().After(deadline) {
+					break
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+			if len(routes) != 1 || routes[0].Priority != 300 {
+				t.Fatalf("no route to the peer with metric 300: %v", routes)
+			}
+
+			if err := AddrDel(link, addr); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -152,6 +152,117 @@ func (h *Handle) BridgeVlanList() (map[int32][]*nl.BridgeVlanInfo, error) {
 	return ret, executeErr
 }
 
+// BridgePort is a bridge port as seen by an AF_BRIDGE link dump, with its
+// port flags, STP state and vlans in a single entry.
+type BridgePort struct {
+	Index       int
+	Name        string
+	MasterIndex int
+	MTU         int
+	OperState   LinkOperState
+	State       uint8 // STP state, one of nl.BR_STATE_*
+	Protinfo    Protinfo
+	Vlans       []*nl.BridgeVlanInfo
+}
+
+// BridgePortList gets the ports enslaved to bridge along with their flags,
+// STP state and vlans. If bridge is nil the ports of all bridges are
+// returned.
+// Equivalent to: `bridge -d link show` and `bridge vlan show`
+//
+// If the returned error is [ErrDumpInterrupted], results may be inconsistent
+// or incomplete.
+func BridgePortList(bridge Link) ([]BridgePort, error) {
+	return pkgHandle.BridgePortList(bridge)
+}
+
+// BridgePortList gets the ports enslaved to bridge along with their flags,
+// STP state and vlans. If bridge is nil the ports of all bridges are
+// returned.
+// Equivalent to: `bridge -d link show` and `bridge vlan show`
+//
+// If the returned error is [ErrDumpInterrupted], results may be inconsistent
+// or incomplete.
+func (h *Handle) BridgePortList(bridge Link) ([]BridgePort, error) {
+	masterIndex := 0
+	if bridge != nil {
+		base := bridge.Attrs()
+		h.ensureIndex(base)
+		masterIndex = base.Index
+	}
+
+	req := h.newNetlinkRequest(unix.RTM_GETLINK, unix.NLM_F_DUMP)
+	msg := nl.NewIfInfomsg(unix.AF_BRIDGE)
+	req.AddData(msg)
+	req.AddData(nl.NewRtAttr(unix.IFLA_EXT_MASK, nl.Uint32Attr(uint32(nl.RTEXT_FILTER_BRVLAN))))
+
+	msgs, executeErr := req.Execute(unix.NETLINK_ROUTE, unix.RTM_NEWLINK)
+	if executeErr != nil && !errors.Is(executeErr, ErrDumpInterrupted) {
+		return nil, executeErr
+	}
+	var ret []BridgePort
+	for _, m := range msgs {
+		port, err := parseBridgePort(m)
+		if err != nil {
+			return nil, err
+		}
+		// the bridge device itself is reported as its own master
+		if port.MasterIndex == 0 || port.MasterIndex == port.Index {
+			continue
+		}
+		if masterIndex != 0 && port.MasterIndex != masterIndex {
+			continue
+		}
+		ret = append(ret, port)
+	}
+	return ret, executeErr
+}
+
+func parseBridgePort(m []byte) (BridgePort, error) {
+	msg := nl.DeserializeIfInfomsg(m)
+	port := BridgePort{Index: int(msg.Index)}
+
+	attrs, err := nl.ParseRouteAttr(m[msg.Len():])
+	if err != nil {
+		return port, err
+	}
+	for _, attr := range attrs {
+		switch attr.Attr.Type {
+		case unix.IFLA_IFNAME:
+			port.Name = string(attr.Value[:len(attr.Value)-1])
+		case unix.IFLA_MASTER:
+			port.MasterIndex = int(native.Uint32(attr.Value[0:4]))
+		case unix.IFLA_MTU:
+			port.MTU = int(native.Uint32(attr.Value[0:4]))
+		case unix.IFLA_OPERSTATE:
+			port.OperState = LinkOperState(uint8(attr.Value[0]))
+		case unix.IFLA_PROTINFO | unix.NLA_F_NESTED:
+			infos, err := nl.ParseRouteAttr(attr.Value)
+			if err != nil {
+				return port, err
+			}
+			port.Protinfo = parseProtinfo(infos)
+			for _, info := range infos {
+				if info.Attr.Type == nl.IFLA_BRPORT_STATE {
+					port.State = info.Value[0]
+				}
+			}
+		case unix.IFLA_AF_SPEC:
+			nestAttrs, err := nl.ParseRouteAttr(attr.Value)
+			if err != nil {
+				return port, fmt.Errorf("failed to parse nested attr %v", err)
+			}
+			for _, nestAttr := range nestAttrs {
+				if nestAttr.Attr.Type == nl.IFLA_BRIDGE_VLAN_INFO {
+					vlanInfo := *nl.DeserializeBridgeVlanInfo(nestAttr.Value)
+					port.Vlans = append(port.Vlans, &vlanInfo)
+				}
+			}
+		}
+	}
+	return port, nil
+}
+
 // BridgeVlanAddTunnelInfo adds a new vlan filter entry
 // Equivalent to: `bridge vlan add dev DEV vid VID tunnel_info id TUNID [ self ] [ master ]`
 func BridgeVlanAddTunnelInfo(link Link, vid uint16, tunid uint32, self, master bool) error {
@@ -297,3 +408,218 @@ func (h *Handle) bridgeVlanModify(cmd int, link Link, vid, vidEnd uint16, tunid,
 	_, err := req.Execute(unix.NETLINK_ROUTE, 0)
 	return err
 }
+
+// BridgeVlanOptions are the per-vlan options of the bridge vlan db. Nil
+// fields are left unchanged.
+type BridgeVlanOptions struct {
+	// State is the STP state of the vlan, one of nl.BR_STATE_*.
+	State *uint8
+	// McastSnooping and McastQuerier are global vlan options, they can
+	// only be set on the bridge device itself.
+	McastSnooping *bool
+	McastQuerier  *bool
+}
+
+// BridgeVlanDbEntry is a vlan of a bridge or bridge port as reported by
+// the bridge vlan db. Consecutive vlans sharing the same options are
+// reported as a single entry ending at VidEnd.
+type BridgeVlanDbEntry struct {
+	Index  int
+	Vid    uint16
+	VidEnd uint16
+	Flags  uint16 // nl.BRIDGE_VLAN_INFO_*
+	State  uint8  // nl.BR_STATE_*
+}
+
+// BridgeVlanGlobalOptions are the global options of a bridge vlan (range).
+type BridgeVlanGlobalOptions struct {
+	Index         int
+	Vid           uint16
+	VidEnd        uint16
+	McastSnooping bool
+	McastQuerier  bool
+}
+
+// BridgeVlanDbSet sets the options of an existing vlan of a bridge or
+// bridge port through the bridge vlan db.
+// Equivalent to: `bridge vlan set dev DEV vid VID [ state STATE ]` and
+// `bridge vlan global set dev DEV vid VID [ mcast_snooping BOOL ] [ mcast_querier BOOL ]`
+func BridgeVlanDbSet(link Link, vid uint16, opts BridgeVlanOptions) error {
+	return pkgHandle.BridgeVlanDbSet(link, vid, opts)
+}
+
+// BridgeVlanDbSet sets the options of an existing vlan of a bridge or
+// bridge port through the bridge vlan db.
+// Equivalent to: `bridge vlan set dev DEV vid VID [ state STATE ]` and
+// `bridge vlan global set dev DEV vid VID [ mcast_snooping BOOL ] [ mcast_querier BOOL ]`
+func (h *Handle) BridgeVlanDbSet(link Link, vid uint16, opts BridgeVlanOptions) error {
+	base := link.Attrs()
+	h.ensureIndex(base)
+	req := h.newNetlinkRequest(nl.RTM_NEWVLAN, unix.NLM_F_ACK)
+	req.AddData(nl.NewBrVlanMsg(unix.AF_BRIDGE, base.Index))
+
+	if opts.State != nil {
+		entry := nl.NewRtAttr(nl.BRIDGE_VLANDB_ENTRY|unix.NLA_F_NESTED, nil)
+		info := &nl.BridgeVlanInfo{Flags: nl.BRIDGE_VLAN_INFO_ONLY_OPTS, Vid: vid}
+		entry.AddRtAttr(nl.BRIDGE_VLANDB_ENTRY_INFO, info.Serialize())
+		entry.AddRtAttr(nl.BRIDGE_VLANDB_ENTRY_STATE, nl.Uint8Attr(*opts.State))
+		req.AddData(entry)
+	}
+	if opts.McastSnooping != nil || opts.McastQuerier != nil {
+		gopts := nl.NewRtAttr(nl.BRIDGE_VLANDB_GLOBAL_OPTIONS|unix.NLA_F_NESTED, nil)
+		gopts.AddRtAttr(nl.BRIDGE_VLANDB_GOPTS_ID, nl.Uint16Attr(vid))
+		if opts.McastSnooping != nil {
+			gopts.AddRtAttr(nl.BRIDGE_VLANDB_GOPTS_MCAST_SNOOPING, boolAttr(*opts.McastSnooping))
+		}
+		if opts.McastQuerier != nil {
+			gopts.AddRtAttr(nl.BRIDGE_VLANDB_GOPTS_MCAST_QUERIER, boolAttr(*opts.McastQuerier))
+		}
+		req.AddData(gopts)
+	}
+
+	_, err := req.Execute(unix.NETLINK_ROUTE, 0)
+	return err
+}
+
+// BridgeVlanDbList gets the vlans of link from the bridge vlan db. If link
+// is nil the vlans of all bridges and bridge ports are returned.
+// Equivalent to: `bridge vlan show [ dev DEV ]`
+//
+// If the returned error is [ErrDumpInterrupted], results may be inconsistent
+// or incomplete.
+func BridgeVlanDbList(link Link) ([]BridgeVlanDbEntry, error) {
+	return pkgHandle.BridgeVlanDbList(link)
+}
+
+// BridgeVlanDbList gets the vlans of link from the bridge vlan db. If link
+// is nil the vlans of all bridges and bridge ports are returned.
+// Equivalent to: `bridge vlan show [ dev DEV ]`
+//
+// If the returned error is [ErrDumpInterrupted], results may be inconsistent
+// or incomplete.
+func (h *Handle) BridgeVlanDbList(link Link) ([]BridgeVlanDbEntry, error) {
+	msgs, executeErr := h.bridgeVlanDbDump(link, 0)
+	if executeErr != nil && !errors.Is(executeErr, ErrDumpInterrupted) {
+		return nil, executeErr
+	}
+	var ret []BridgeVlanDbEntry
+	for _, m := range msgs {
+		msg := nl.DeserializeBrVlanMsg(m)
+		attrs, err := nl.ParseRouteAttr(m[msg.Len():])
+		if err != nil {
+			return nil, err
+		}
+		for _, attr := range attrs {
+			if attr.Attr.Type&^unix.NLA_F_NESTED != nl.BRIDGE_VLANDB_ENTRY {
+				continue
+			}
+			entry, err := parseBridgeVlanDbEntry(attr.Value)
+			if err != nil {
+				return nil, err
+			}
+			entry.Index = int(msg.Ifindex)
+			ret = append(ret, entry)
+		}
+	}
+	return ret, executeErr
+}
+
+// BridgeVlanGlobalList gets the global vlan options of bridge from the
+// bridge vlan db. If bridge is nil the options of all bridges are returned.
+// Equivalent to: `bridge vlan global show [ dev DEV ]`
+//
+// If the returned error is [ErrDumpInterrupted], results may be inconsistent
+// or incomplete.
+func BridgeVlanGlobalList(bridge Link) ([]BridgeVlanGlobalOptions, error) {
+	return pkgHandle.BridgeVlanGlobalList(bridge)
+}
+
+// BridgeVlanGlobalList gets the global vlan options of bridge from the
+// bridge vlan db. If bridge is nil the options of all bridges are returned.
+// Equivalent to: `bridge vlan global show [ dev DEV ]`
+//
+// If the returned error is [ErrDumpInterrupted], results may be inconsistent
+// or incomplete.
+func (h *Handle) BridgeVlanGlobalList(bridge Link) ([]BridgeVlanGlobalOptions, error) {
+	msgs, executeErr := h.bridgeVlanDbDump(bridge, nl.BRIDGE_VLANDB_DUMPF_GLOBAL)
+	if executeErr != nil && !errors.Is(executeErr, ErrDumpInterrupted) {
+		return nil, executeErr
+	}
+	var ret []BridgeVlanGlobalOptions
+	for _, m := range msgs {
+		msg := nl.DeserializeBrVlanMsg(m)
+		attrs, err := nl.ParseRouteAttr(m[msg.Len():])
+		if err != nil {
+			return nil, err
+		}
+		for _, attr := range attrs {
+			if attr.Attr.Type&^unix.NLA_F_NESTED != nl.BRIDGE_VLANDB_GLOBAL_OPTIONS {
+				continue
+			}
+			opts, err := parseBridgeVlanGlobalOptions(attr.Value)
+			if err != nil {
+				return nil, err
+			}
+			opts.Index = int(msg.Ifindex)
+			ret = append(ret, opts)
+		}
+	}
+	return ret, executeErr
+}
+
+func (h *Handle) bridgeVlanDbDump(link Link, flags uint32) ([][]byte, error) {
+	index := 0
+	if link != nil {
+		base := link.Attrs()
+		h.ensureIndex(base)
+		index = base.Index
+	}
+	req := h.newNetlinkRequest(nl.RTM_GETVLAN, unix.NLM_F_DUMP)
+	req.AddData(nl.NewBrVlanMsg(unix.AF_BRIDGE, index))
+	if flags != 0 {
+		req.AddData(nl.NewRtAttr(nl.BRIDGE_VLANDB_DUMP_FLAGS, nl.Uint32Attr(flags)))
+	}
+	return req.Execute(unix.NETLINK_ROUTE, nl.RTM_NEWVLAN)
+}
+
+func parseBridgeVlanDbEntry(data []byte) (BridgeVlanDbEntry, error) {
+	var entry BridgeVlanDbEntry
+	attrs, err := nl.ParseRouteAttr(data)
+	if err != nil {
+		return entry, err
+	}
+	for _, attr := range attrs {
+		switch attr.Attr.Type {
+		case nl.BRIDGE_VLANDB_ENTRY_INFO:
+			info := nl.DeserializeBridgeVlanInfo(attr.Value)
+			entry.Vid = info.Vid
+			entry.Flags = info.Flags
+		case nl.BRIDGE_VLANDB_ENTRY_RANGE:
+			entry.VidEnd = native.Uint16(attr.Value[0:2])
+		case nl.BRIDGE_VLANDB_ENTRY_STATE:
+			entry.State = attr.Value[0]
+		}
+	}
+	return entry, nil
+}
+
+func parseBridgeVlanGlobalOptions(data []byte) (BridgeVlanGlobalOptions, error) {
+	var opts BridgeVlanGlobalOptions
+	attrs, err := nl.ParseRouteAttr(data)
+	if err != nil {
+		return opts, err
+	}
+	for _, attr := range attrs {
+		switch attr.Attr.Type {
+		case nl.BRIDGE_VLANDB_GOPTS_ID:
+			opts.Vid = native.Uint16(attr.Value[0:2])
+		case nl.BRIDGE_VLANDB_GOPTS_RANGE:
+			opts.VidEnd = native.Uint16(attr.Value[0:2])
+		case nl.BRIDGE_VLANDB_GOPTS_MCAST_SNOOPING:
+			opts.McastSnooping = attr.Value[0] != 0
+		case nl.BRIDGE_VLANDB_GOPTS_MCAST_QUERIER:
+			opts.McastQuerier = attr.Value[0] != 0
+		}
+	}
+	return opts, nil
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"io/ioutil"
 	"testing"
+
+	"github.com/vishvananda/netlink/nl"
 )
 
 func TestBridgeVlan(t *testing.T) {
@@ -78,6 +80,62 @@ func TestBridgeVlan(t *testing.T) {
 	}
 }
 
+func TestEnableEVPNArpSuppression(t *testing.T) {
+	minKernelRequired(t, 4, 15)
+	t.Cleanup(setUpNetlinkTest(t))
+
+	bridge := &Bridge{LinkAttrs: LinkAttrs{Name: "br0"}}
+	if err := LinkAdd(bridge); err != nil {
+		t.Fatal(err)
+	}
+	vxlan := &Vxlan{
+		LinkAttrs: LinkAttrs{Name: "vxlan0"},
+		FlowBased: true,
+		Port:      4789,
+	}
+	if err := LinkAdd(vxlan); err != nil {
+		t.Fatal(err)
+	}
+	if err := EnableEVPNArpSuppression(bridge, vxlan); err == nil {
+		t.Fatal("expected an error for a vxlan that is not a bridge port")
+	}
+	if err := LinkSetMaster(vxlan, bridge); err != nil {
+		t.Fatal(err)
+	}
+	if err := EnableEVPNArpSuppression(bridge, vxlan); err != nil {
+		t.Fatal(err)
+	}
+
+	ports, err := BridgePortList(bridge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ports) != 1 || ports[0].Name != "vxlan0" {
+		t.Fatalf("expected vxlan0 as the only port, got %+v", ports)
+	}
+	p := ports[0].Protinfo
+	if !p.VlanTunnel {
+		t.Error("vlan_tunnel should be on")
+	}
+	if !p.NeighSuppress {
+		t.Error("neigh_suppress should be on")
+	}
+	if p.Learning {
+		t.Error("learning should be off")
+	}
+
+	if err := LinkSetBrNeighSuppress(vxlan, false); err != nil {
+		t.Fatal(err)
+	}
+	pi, err := LinkGetProtinfo(vxlan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pi.NeighSuppress {
+		t.Error("neigh_suppress should be off")
+	}
+}
+
 func TestBridgeVlanTunnelInfo(t *testing.T) {
 	minKernelRequired(t, 4, 11)
 	t.Cleanup(setUpNetlinkTest(t))
@@ -235,3 +293,147 @@ func TestBridgeGroupFwdMask(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestBridgePortList(t *testing.T) {
+	minKernelRequired(t, 4, 4)
+	t.Cleanup(setUpNetlinkTest(t))
+
+	vlanFiltering := true
+	bridge := &Bridge{LinkAttrs: LinkAttrs{Name: "foo"}, VlanFiltering: &vlanFiltering}
+	if err := LinkAdd(bridge); err != nil {
+		t.Fatal(err)
+	}
+	port1 := &Dummy{LinkAttrs{Name: "dum1", MasterIndex: bridge.Index}}
+	if err := LinkAdd(port1); err != nil {
+		t.Fatal(err)
+	}
+	port2 := &Dummy{LinkAttrs{Name: "dum2", MasterIndex: bridge.Index}}
+	if err := LinkAdd(port2); err != nil {
+		t.Fatal(err)
+	}
+	if err := BridgeVlanAdd(port1, 10, false, false, false, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := BridgeVlanAdd(port2, 20, true, true, false, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkSetLearning(port2, false); err != nil {
+		t.Fatal(err)
+	}
+
+	ports, err := BridgePortList(bridge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ports) != 2 {
+		t.Fatalf("expected 2 bridge ports, got %d: %+v", len(ports), ports)
+	}
+	byName := make(map[string]BridgePort)
+	for _, port := range ports {
+		if port.MasterIndex != bridge.Index {
+			t.Fatalf("port %s has master %d, expected %d", port.Name, port.MasterIndex, bridge.Index)
+		}
+		byName[port.Name] = port
+	}
+
+	hasVlan := func(port BridgePort, vid uint16, pvid, untagged bool) bool {
+		for _, v := range port.Vlans {
+			if v.Vid == vid && v.PortVID() == pvid && v.EngressUntag() == untagged {
+				return true
+			}
+		}
+		return false
+	}
+
+	p1, ok := byName["dum1"]
+	if !ok {
+		t.Fatal("dum1 missing from bridge port list")
+	}
+	if p1.Index != port1.Index {
+		t.Fatalf("dum1 index %d, expected %d", p1.Index, port1.Index)
+	}
+	if !p1.Protinfo.Learning {
+		t.Fatal("learning should be enabled on dum1")
+	}
+	if !hasVlan(p1, 1, true, true) || !hasVlan(p1, 10, false, false) {
+		t.Fatalf("unexpected vlans on dum1: %v", p1.Vlans)
+	}
+
+	p2, ok := byName["dum2"]
+	if !ok {
+		t.Fatal("dum2 missing from bridge port list")
+	}
+	if p2.Protinfo.Learning {
+		t.Fatal("learning should be disabled on dum2")
+	}
+	if !hasVlan(p2, 20, true, true) || !hasVlan(p2, 1, false, true) {
+		t.Fatalf("unexpected vlans on dum2: %v", p2.Vlans)
+	}
+	if p2.State != nl.BR_STATE_DISABLED {
+		t.Fatalf("dum2 is down, expected STP state %d, got %d", nl.BR_STATE_DISABLED, p2.State)
+	}
+
+	all, err := BridgePortList(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("expected 2 bridge ports without a bridge filter, got %d", len(all))
+	}
+}
+
+func TestBridgeVlanDbSetState(t *testing.T) {
+	minKernelRequired(t, 5, 18)
+
+	t.Cleanup(setUpNetlinkTest(t))
+	bridge := &Bridge{LinkAttrs: LinkAttrs{Name: "foo"}}
+	if err := LinkAdd(bridge); err != nil {
+		t.Fatal(err)
+	}
+	if err := BridgeSetVlanFiltering(bridge, true); err != nil {
+		t.Fatal(err)
+	}
+	link, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if br, ok := link.(*Bridge); !ok || br.VlanFiltering == nil || !*br.VlanFiltering {
+		t.Fatalf("vlan filtering not enabled on %+v", link)
+	}
+	dummy := &Dummy{LinkAttrs: LinkAttrs{Name: "dum1"}}
+	if err := LinkAdd(dummy); err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkSetMaster(dummy, bridge); err != nil {
+		t.Fatal(err)
+	}
+	if err := BridgeVlanAdd(dummy, 100, false, false, false, false); err != nil {
+		t.Fatal(err)
+	}
+
+	state := uint8(nl.BR_STATE_BLOCKING)
+	if err := BridgeVlanDbSet(dummy, 100, BridgeVlanOptions{State: &state}); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := BridgeVlanDbList(dummy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, entry := range entries {
+		if entry.Index != dummy.Index {
+			t.Fatalf("unexpected entry for link %d: %+v", entry.Index, entry)
+		}
+		if entry.Vid != 100 {
+			continue
+		}
+		found = true
+		if entry.State != nl.BR_STATE_BLOCKING {
+			t.Fatalf("expected vlan 100 state %d, got %d", nl.BR_STATE_BLOCKING, entry.State)
+		}
+	}
+	if !found {
+		t.Fatalf("vlan 100 not found in %+v", entries)
+	}
+}
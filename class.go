@@ -1,15 +1,33 @@
 package netlink
 
 import (
+	"errors"
 	"fmt"
 )
 
+var (
+	// ErrClassExists is returned by ClassAdd when the class is already
+	// present. It wraps the kernel's EEXIST.
+	ErrClassExists = errors.New("class already exists")
+	// ErrClassNotFound is returned by ClassChange and ClassDel when the
+	// class, or a parent it refers to, does not exist. It wraps the
+	// kernel's ENOENT.
+	ErrClassNotFound = errors.New("class not found")
+)
+
 // Class interfaces for all classes
 type Class interface {
 	Attrs() *ClassAttrs
 	Type() string
 }
 
+// ClassUpdate is sent when a class changes - type is RTM_NEWTCLASS or
+// RTM_DELTCLASS.
+type ClassUpdate struct {
+	Type  uint16
+	Class Class
+}
+
 // Generic networking statistics for netlink users.
 // This file contains "gnet_" prefixed structs and relevant functions.
 // See Documentation/networking/getn_stats.txt in Linux source code for more details.
@@ -85,6 +103,14 @@ type HtbClassAttrs struct {
 	Quantum uint32
 	Level   uint32
 	Prio    uint32
+	// LinkLayer is one of the nl.LINKLAYER_* values, Ethernet when unset.
+	// Set it to nl.LINKLAYER_ATM to account for ATM cell framing, along
+	// with the per packet Overhead in bytes.
+	LinkLayer int
+	Overhead  uint16
+	// CellLog is the log2 of the rate table cell size, derived from
+	// the MTU when zero. The kernel does not report it back.
+	CellLog int
 }
 
 func (q HtbClassAttrs) String() string {
@@ -94,13 +120,16 @@ func (q HtbClassAttrs) String() string {
 // HtbClass represents an Htb class
 type HtbClass struct {
 	ClassAttrs
-	Rate    uint64
-	Ceil    uint64
-	Buffer  uint32
-	Cbuffer uint32
-	Quantum uint32
-	Level   uint32
-	Prio    uint32
+	Rate      uint64
+	Ceil      uint64
+	Buffer    uint32
+	Cbuffer   uint32
+	Quantum   uint32
+	Level     uint32
+	Prio      uint32
+	LinkLayer int
+	Overhead  uint16
+	CellLog   int
 }
 
 func (q HtbClass) String() string {
@@ -164,6 +193,18 @@ func (c *ServiceCurve) Rate() uint32 {
 	return c.m2
 }
 
+// NewServiceCurve returns a service curve that allows a burst rate of m1
+// bits per second for the first d milliseconds and a rate of m2 bits per
+// second afterwards, the same units `tc` uses for "m1 ... d ... m2 ...".
+func NewServiceCurve(m1 uint32, d uint32, m2 uint32) ServiceCurve {
+	return ServiceCurve{m1: m1, d: d * 1000, m2: m2}
+}
+
+// DelayMs returns the delay (d) of the curve in milliseconds
+func (c *ServiceCurve) DelayMs() uint32 {
+	return c.d / 1000
+}
+
 // HfscClass is a representation of the HFSC class
 type HfscClass struct {
 	ClassAttrs
@@ -172,20 +213,20 @@ type HfscClass struct {
 	Usc ServiceCurve
 }
 
-// SetUsc sets the USC curve. The bandwidth (m1 and m2) is specified in bits and the delay in
-// seconds.
+// SetUsc sets the USC curve. The bandwidth (m1 and m2) is specified in bits per second and the
+// delay in microseconds.
 func (hfsc *HfscClass) SetUsc(m1 uint32, d uint32, m2 uint32) {
 	hfsc.Usc = ServiceCurve{m1: m1, d: d, m2: m2}
 }
 
-// SetFsc sets the Fsc curve. The bandwidth (m1 and m2) is specified in bits and the delay in
-// seconds.
+// SetFsc sets the Fsc curve. The bandwidth (m1 and m2) is specified in bits per second and the
+// delay in microseconds.
 func (hfsc *HfscClass) SetFsc(m1 uint32, d uint32, m2 uint32) {
 	hfsc.Fsc = ServiceCurve{m1: m1, d: d, m2: m2}
 }
 
-// SetRsc sets the Rsc curve. The bandwidth (m1 and m2) is specified in bits and the delay in
-// seconds.
+// SetRsc sets the Rsc curve. The bandwidth (m1 and m2) is specified in bits per second and the
+// delay in microseconds.
 func (hfsc *HfscClass) SetRsc(m1 uint32, d uint32, m2 uint32) {
 	hfsc.Rsc = ServiceCurve{m1: m1, d: d, m2: m2}
 }
@@ -239,3 +280,20 @@ func (hfsc *HfscClass) Attrs() *ClassAttrs {
 func (hfsc *HfscClass) Type() string {
 	return "hfsc"
 }
+
+// QfqClass represents a class of the QFQ qdisc.
+type QfqClass struct {
+	ClassAttrs
+	Weight uint32 // relative share of the link, 1 to 1024
+	MaxPkt uint32 // maximum packet size in bytes, called lmax by the kernel
+}
+
+// Attrs return the class attributes
+func (class *QfqClass) Attrs() *ClassAttrs {
+	return &class.ClassAttrs
+}
+
+// Type return the class type
+func (class *QfqClass) Type() string {
+	return "qfq"
+}
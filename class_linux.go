@@ -7,8 +7,10 @@ import (
 	"errors"
 	"fmt"
 	"syscall"
+	"time"
 
 	"github.com/vishvananda/netlink/nl"
+	"github.com/vishvananda/netns"
 	"golang.org/x/sys/unix"
 )
 
@@ -59,6 +61,9 @@ func NewHtbClass(attrs ClassAttrs, cattrs HtbClassAttrs) *HtbClass {
 		Level:      0,
 		Prio:       cattrs.Prio,
 		Quantum:    cattrs.Quantum,
+		LinkLayer:  cattrs.LinkLayer,
+		Overhead:   cattrs.Overhead,
+		CellLog:    cattrs.CellLog,
 	}
 }
 
@@ -139,6 +144,12 @@ func (h *Handle) classModify(cmd, flags int, class Class) error {
 		}
 	}
 	_, err := req.Execute(unix.NETLINK_ROUTE, 0)
+	switch {
+	case errors.Is(err, unix.EEXIST):
+		return fmt.Errorf("%w: %w", ErrClassExists, err)
+	case errors.Is(err, unix.ENOENT):
+		return fmt.Errorf("%w: %w", ErrClassNotFound, err)
+	}
 	return err
 }
 
@@ -158,17 +169,23 @@ func classPayload(req *nl.NetlinkRequest, class Class) error {
 		// TODO: Handle Debug properly. For now default to 0
 		/* Calculate {R,C}Tab and set Rate and Ceil */
 		cellLog := -1
-		ccellLog := -1
+		if htb.CellLog != 0 {
+			cellLog = htb.CellLog
+		}
+		ccellLog := cellLog
 		linklayer := nl.LINKLAYER_ETHERNET
+		if htb.LinkLayer != nl.LINKLAYER_UNSPEC {
+			linklayer = htb.LinkLayer
+		}
 		mtu := 1600
 		var rtab [256]uint32
 		var ctab [256]uint32
-		tcrate := nl.TcRateSpec{Rate: uint32(htb.Rate)}
+		tcrate := nl.TcRateSpec{Rate: uint32(htb.Rate), Overhead: htb.Overhead}
 		if CalcRtable(&tcrate, rtab[:], cellLog, uint32(mtu), linklayer) < 0 {
 			return errors.New("HTB: failed to calculate rate table")
 		}
 		opt.Rate = tcrate
-		tcceil := nl.TcRateSpec{Rate: uint32(htb.Ceil)}
+		tcceil := nl.TcRateSpec{Rate: uint32(htb.Ceil), Overhead: htb.Overhead}
 		if CalcRtable(&tcceil, ctab[:], ccellLog, uint32(mtu), linklayer) < 0 {
 			return errors.New("HTB: failed to calculate ceil rate table")
 		}
@@ -194,6 +211,14 @@ func classPayload(req *nl.NetlinkRequest, class Class) error {
 		options.AddRtAttr(nl.TCA_HFSC_RSC, nl.SerializeHfscCurve(&opt.Rsc))
 		options.AddRtAttr(nl.TCA_HFSC_FSC, nl.SerializeHfscCurve(&opt.Fsc))
 		options.AddRtAttr(nl.TCA_HFSC_USC, nl.SerializeHfscCurve(&opt.Usc))
+	case "qfq":
+		qfq := class.(*QfqClass)
+		if qfq.Weight != 0 {
+			options.AddRtAttr(nl.TCA_QFQ_WEIGHT, nl.Uint32Attr(qfq.Weight))
+		}
+		if qfq.MaxPkt != 0 {
+			options.AddRtAttr(nl.TCA_QFQ_LMAX, nl.Uint32Attr(qfq.MaxPkt))
+		}
 	}
 	req.AddData(options)
 	return nil
@@ -235,73 +260,94 @@ func (h *Handle) ClassList(link Link, parent uint32) ([]Class, error) {
 
 	var res []Class
 	for _, m := range msgs {
-		msg := nl.DeserializeTcMsg(m)
-
-		attrs, err := nl.ParseRouteAttr(m[msg.Len():])
+		class, err := classDeserialize(m)
 		if err != nil {
 			return nil, err
 		}
+		res = append(res, class)
+	}
 
-		base := ClassAttrs{
-			LinkIndex:  int(msg.Ifindex),
-			Handle:     msg.Handle,
-			Parent:     msg.Parent,
-			Statistics: nil,
-		}
+	return res, executeErr
+}
 
-		var class Class
-		classType := ""
-		for _, attr := range attrs {
-			switch attr.Attr.Type {
-			case nl.TCA_KIND:
-				classType = string(attr.Value[:len(attr.Value)-1])
-				switch classType {
-				case "htb":
-					class = &HtbClass{}
-				case "hfsc":
-					class = &HfscClass{}
-				default:
-					class = &GenericClass{ClassType: classType}
+// classDeserialize decodes the tcmsg and attributes of a RTM_*TCLASS
+// message into a Class.
+func classDeserialize(m []byte) (Class, error) {
+	msg := nl.DeserializeTcMsg(m)
+
+	attrs, err := nl.ParseRouteAttr(m[msg.Len():])
+	if err != nil {
+		return nil, err
+	}
+
+	base := ClassAttrs{
+		LinkIndex:  int(msg.Ifindex),
+		Handle:     msg.Handle,
+		Parent:     msg.Parent,
+		Statistics: nil,
+	}
+
+	var class Class
+	classType := ""
+	for _, attr := range attrs {
+		switch attr.Attr.Type {
+		case nl.TCA_KIND:
+			classType = string(attr.Value[:len(attr.Value)-1])
+			switch classType {
+			case "htb":
+				class = &HtbClass{}
+			case "hfsc":
+				class = &HfscClass{}
+			case "qfq":
+				class = &QfqClass{}
+			default:
+				class = &GenericClass{ClassType: classType}
+			}
+		case nl.TCA_OPTIONS:
+			switch classType {
+			case "htb":
+				data, err := nl.ParseRouteAttr(attr.Value)
+				if err != nil {
+					return nil, err
 				}
-			case nl.TCA_OPTIONS:
-				switch classType {
-				case "htb":
-					data, err := nl.ParseRouteAttr(attr.Value)
-					if err != nil {
-						return nil, err
-					}
-					_, err = parseHtbClassData(class, data)
-					if err != nil {
-						return nil, err
-					}
-				case "hfsc":
-					data, err := nl.ParseRouteAttr(attr.Value)
-					if err != nil {
-						return nil, err
-					}
-					_, err = parseHfscClassData(class, data)
-					if err != nil {
-						return nil, err
-					}
+				_, err = parseHtbClassData(class, data)
+				if err != nil {
+					return nil, err
 				}
-			// For backward compatibility.
-			case nl.TCA_STATS:
-				base.Statistics, err = parseTcStats(attr.Value)
+			case "hfsc":
+				data, err := nl.ParseRouteAttr(attr.Value)
 				if err != nil {
 					return nil, err
 				}
-			case nl.TCA_STATS2:
-				base.Statistics, err = parseTcStats2(attr.Value)
+				_, err = parseHfscClassData(class, data)
 				if err != nil {
 					return nil, err
 				}
+			case "qfq":
+				data, err := nl.ParseRouteAttr(attr.Value)
+				if err != nil {
+					return nil, err
+				}
+				parseQfqClassData(class, data)
+			}
+		// For backward compatibility.
+		case nl.TCA_STATS:
+			base.Statistics, err = parseTcStats(attr.Value)
+			if err != nil {
+				return nil, err
+			}
+		case nl.TCA_STATS2:
+			base.Statistics, err = parseTcStats2(attr.Value)
+			if err != nil {
+				return nil, err
 			}
 		}
-		*class.Attrs() = base
-		res = append(res, class)
 	}
-
-	return res, executeErr
+	if class == nil {
+		return nil, fmt.Errorf("class %s without TCA_KIND", HandleStr(base.Handle))
+	}
+	*class.Attrs() = base
+	return class, nil
 }
 
 func parseHtbClassData(class Class, data []syscall.NetlinkRouteAttr) (bool, error) {
@@ -318,6 +364,8 @@ func parseHtbClassData(class Class, data []syscall.NetlinkRouteAttr) (bool, erro
 			htb.Quantum = opt.Quantum
 			htb.Level = opt.Level
 			htb.Prio = opt.Prio
+			htb.LinkLayer = int(opt.Rate.Linklayer) & nl.TC_LINKLAYER_MASK
+			htb.Overhead = opt.Rate.Overhead
 		case nl.TCA_HTB_RATE64:
 			htb.Rate = native.Uint64(datum.Value[0:8])
 		case nl.TCA_HTB_CEIL64:
@@ -344,6 +392,18 @@ func parseHfscClassData(class Class, data []syscall.NetlinkRouteAttr) (bool, err
 	return detailed, nil
 }
 
+func parseQfqClassData(class Class, data []syscall.NetlinkRouteAttr) {
+	qfq := class.(*QfqClass)
+	for _, datum := range data {
+		switch datum.Attr.Type {
+		case nl.TCA_QFQ_WEIGHT:
+			qfq.Weight = native.Uint32(datum.Value[0:4])
+		case nl.TCA_QFQ_LMAX:
+			qfq.MaxPkt = native.Uint32(datum.Value[0:4])
+		}
+	}
+}
+
 func parseTcStats(data []byte) (*ClassStatistics, error) {
 	buf := &bytes.Buffer{}
 	buf.Write(data)
@@ -404,3 +464,168 @@ func parseTcStats2(data []byte) (*ClassStatistics, error) {
 
 	return stats, nil
 }
+
+// ClassSubscribe takes a chan down which notifications will be sent
+// when traffic control classes are added, changed or removed. Close the
+// 'done' chan to stop subscription.
+func ClassSubscribe(ch chan<- ClassUpdate, done <-chan struct{}) error {
+	return classSubscribeAt(netns.None(), netns.None(), ch, done, nil, false, 0)
+}
+
+// ClassSubscribeOptions contains a set of options to use with
+// ClassSubscribeWithOptions.
+type ClassSubscribeOptions struct {
+	Namespace     *netns.NsHandle
+	ErrorCallback func(error)
+	ListExisting  bool
+	// CoalesceInterval, when not zero, delivers at most one update per
+	// class in each interval: the first update of a class starts the
+	// interval and only the last update received for the class until it
+	// ends is sent on the channel. This keeps consumers of classes whose
+	// rates are changed in quick succession from lagging behind, they
+	// still see the final values.
+	CoalesceInterval time.Duration
+}
+
+// ClassSubscribeWithOptions work like ClassSubscribe but enable to
+// provide additional options to modify the behavior.
+//
+// When options.ListExisting is true, options.ErrorCallback may be
+// called with [ErrDumpInterrupted] to indicate that results from
+// the initial dump of classes may be inconsistent or incomplete.
+func ClassSubscribeWithOptions(ch chan<- ClassUpdate, done <-chan struct{}, options ClassSubscribeOptions) error {
+	if options.Namespace == nil {
+		none := netns.None()
+		options.Namespace = &none
+	}
+	return classSubscribeAt(*options.Namespace, netns.None(), ch, done, options.ErrorCallback, options.ListExisting,
+		options.CoalesceInterval)
+}
+
+func classSubscribeAt(newNs, curNs netns.NsHandle, ch chan<- ClassUpdate, done <-chan struct{}, cberr func(error), listExisting bool,
+	coalesce time.Duration) error {
+	s, err := nl.SubscribeAt(newNs, curNs, unix.NETLINK_ROUTE, unix.RTNLGRP_TC)
+	if err != nil {
+		return err
+	}
+	if done != nil {
+		go func() {
+			<-done
+			s.Close()
+		}()
+	}
+	if listExisting {
+		req := pkgHandle.newNetlinkRequest(unix.RTM_GETTCLASS, unix.NLM_F_DUMP)
+		req.AddData(&nl.TcMsg{Family: nl.FAMILY_ALL})
+		if err := s.Send(req); err != nil {
+			return err
+		}
+	}
+	updates := ch
+	if coalesce > 0 {
+		in := make(chan ClassUpdate)
+		go coalesceClassUpdates(in, ch, done, coalesce)
+		updates = in
+	}
+	go func() {
+		defer close(updates)
+		for {
+			msgs, from, err := s.Receive()
+			if err != nil {
+				if cberr != nil {
+					cberr(fmt.Errorf("Receive failed: %v",
+						err))
+				}
+				return
+			}
+			if from.Pid != nl.PidKernel {
+				if cberr != nil {
+					cberr(fmt.Errorf("Wrong sender portid %d, expected %d", from.Pid, nl.PidKernel))
+				}
+				continue
+			}
+			for _, m := range msgs {
+				if m.Header.Flags&unix.NLM_F_DUMP_INTR != 0 && cberr != nil {
+					cberr(ErrDumpInterrupted)
+				}
+				switch m.Header.Type {
+				case unix.RTM_NEWTCLASS, unix.RTM_DELTCLASS:
+				case unix.NLMSG_ERROR:
+					if nError := int32(native.Uint32(m.Data[0:4])); nError != 0 && cberr != nil {
+						cberr(syscall.Errno(-nError))
+					}
+					continue
+				default:
+					// qdisc, filter and chain notifications share the group
+					continue
+				}
+				class, err := classDeserialize(m.Data)
+				if err != nil {
+					if cberr != nil {
+						cberr(err)
+					}
+					continue
+				}
+				updates <- ClassUpdate{Type: m.Header.Type, Class: class}
+			}
+		}
+	}()
+
+	return nil
+}
+
+// coalesceClassUpdates forwards the updates from in to out, keeping only
+// the last update of each class received within interval of its first
+// one. out is closed once in is closed and the pending updates are sent.
+// Once done is closed, updates are dropped rather than sent so that a
+// consumer which stopped reading does not block the subscription.
+func coalesceClassUpdates(in <-chan ClassUpdate, out chan<- ClassUpdate, done <-chan struct{}, interval time.Duration) {
+	type classKey struct {
+		linkIndex int
+		handle    uint32
+	}
+	pending := make(map[classKey]ClassUpdate)
+	var order []classKey
+	expired := make(chan classKey)
+	stopped := make(chan struct{})
+	defer close(stopped)
+	defer close(out)
+	send := func(update ClassUpdate) {
+		select {
+		case out <- update:
+		case <-done:
+		}
+	}
+	for {
+		select {
+		case update, ok := <-in:
+			if !ok {
+				for _, key := range order {
+					send(pending[key])
+				}
+				return
+			}
+			attrs := update.Class.Attrs()
+			key := classKey{attrs.LinkIndex, attrs.Handle}
+			if _, ok := pending[key]; !ok {
+				order = append(order, key)
+				time.AfterFunc(interval, func() {
+					select {
+					case expired <- key:
+					case <-stopped:
+					}
+				})
+			}
+			pending[key] = update
+		case key := <-expired:
+			send(pending[key])
+			delete(pending, key)
+			for i, k := range order {
+				if k == key {
+					order = append(order[:i], order[i+1:]...)
+					break
+				}
+			}
+		}
+	}
+}
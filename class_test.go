@@ -6,6 +6,10 @@ package netlink
 import (
 	"reflect"
 	"testing"
+	"time"
+
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
 )
 
 func SafeQdiscList(link Link) ([]Qdisc, error) {
@@ -343,6 +347,8 @@ func TestHtbClassAddHtbClassChangeDel(t *testing.T) {
 	// It should work with the same handle
 	classattrs.Handle = oldHandle
 	htbclassattrs.Rate = 4321000
+	htbclassattrs.LinkLayer = nl.LINKLAYER_ATM
+	htbclassattrs.Overhead = 20
 	class = NewHtbClass(classattrs, htbclassattrs)
 	if err := ClassChange(class); err != nil {
 		t.Fatal(err)
@@ -367,6 +373,9 @@ func TestHtbClassAddHtbClassChangeDel(t *testing.T) {
 	if htb.Rate != class.Rate {
 		t.Fatal("Rate did not get changed while changing the class.")
 	}
+	if htb.LinkLayer != nl.LINKLAYER_ATM || htb.Overhead != 20 {
+		t.Fatalf("LinkLayer %d and Overhead %d did not get changed", htb.LinkLayer, htb.Overhead)
+	}
 
 	// Check that we still have the netem child qdisc
 	qdiscs, err = SafeQdiscList(link)
@@ -678,3 +687,233 @@ func TestClassHfsc(t *testing.T) {
 	}
 
 }
+
+func TestNewServiceCurve(t *testing.T) {
+	sc := NewServiceCurve(10e6, 20, 5e6)
+	if m1, d, m2 := sc.Attrs(); m1 != 10e6 || d != 20000 || m2 != 5e6 {
+		t.Fatalf("unexpected curve m1=%d d=%d m2=%d", m1, d, m2)
+	}
+	if sc.DelayMs() != 20 {
+		t.Fatalf("DelayMs() = %d, expected 20", sc.DelayMs())
+	}
+}
+
+func TestClassHfscServiceCurve(t *testing.T) {
+	t.Cleanup(setUpNetlinkTestWithKModule(t, "sch_hfsc"))
+
+	if err := LinkAdd(&Ifb{LinkAttrs{Name: "foo"}}); err != nil {
+		t.Fatal(err)
+	}
+	link, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkSetUp(link); err != nil {
+		t.Fatal(err)
+	}
+
+	hfscQdisc := NewHfsc(QdiscAttrs{
+		LinkIndex: link.Attrs().Index,
+		Handle:    MakeHandle(1, 0),
+		Parent:    HANDLE_ROOT,
+	})
+	hfscQdisc.Defcls = 2
+	if err := QdiscAdd(hfscQdisc); err != nil {
+		t.Fatal(err)
+	}
+
+	parent := NewHfscClass(ClassAttrs{
+		LinkIndex: link.Attrs().Index,
+		Parent:    MakeHandle(1, 0),
+		Handle:    MakeHandle(1, 1),
+	})
+	parent.Fsc = NewServiceCurve(0, 0, 100e6)
+	parent.Usc = NewServiceCurve(0, 0, 100e6)
+	if err := ClassAdd(parent); err != nil {
+		t.Fatal(err)
+	}
+
+	leaf := NewHfscClass(ClassAttrs{
+		LinkIndex: link.Attrs().Index,
+		Parent:    MakeHandle(1, 1),
+		Handle:    MakeHandle(1, 2),
+	})
+	leaf.Rsc = NewServiceCurve(20e6, 10, 8e6)
+	leaf.Fsc = NewServiceCurve(0, 0, 50e6)
+	if err := ClassAdd(leaf); err != nil {
+		t.Fatal(err)
+	}
+
+	classes, err := SafeClassList(link, MakeHandle(1, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := 0
+	for _, c := range classes {
+		class, ok := c.(*HfscClass)
+		if !ok {
+			t.Fatal("Wrong type of class")
+		}
+		var want *HfscClass
+		switch class.Handle {
+		case parent.Handle:
+			want = parent
+		case leaf.Handle:
+			want = leaf
+		default:
+			continue
+		}
+		found++
+		if class.Rsc != want.Rsc || class.Fsc != want.Fsc || class.Usc != want.Usc {
+			t.Fatalf("curves don't match, got %s, expected %s", class, want)
+		}
+	}
+	if found != 2 {
+		t.Fatalf("expected 2 hfsc classes, found %d", found)
+	}
+}
+
+func TestQfqClassAddDel(t *testing.T) {
+	t.Cleanup(setUpNetlinkTestWithKModule(t, "sch_qfq"))
+	if err := LinkAdd(&Ifb{LinkAttrs{Name: "foo"}}); err != nil {
+		t.Fatal(err)
+	}
+	link, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkSetUp(link); err != nil {
+		t.Fatal(err)
+	}
+
+	qdisc := &Qfq{
+		QdiscAttrs: QdiscAttrs{
+			LinkIndex: link.Attrs().Index,
+			Handle:    MakeHandle(1, 0),
+			Parent:    HANDLE_ROOT,
+		},
+	}
+	if err := QdiscAdd(qdisc); err != nil {
+		t.Fatal(err)
+	}
+	qdiscs, err := SafeQdiscList(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(qdiscs) != 1 {
+		t.Fatal("Failed to add qdisc")
+	}
+	if _, ok := qdiscs[0].(*Qfq); !ok {
+		t.Fatalf("Qdisc is the wrong type: %T", qdiscs[0])
+	}
+
+	class := &QfqClass{
+		ClassAttrs: ClassAttrs{
+			LinkIndex: link.Attrs().Index,
+			Parent:    MakeHandle(1, 0),
+			Handle:    MakeHandle(1, 1),
+		},
+		Weight: 10,
+		MaxPkt: 1514,
+	}
+	if err := ClassAdd(class); err != nil {
+		t.Fatal(err)
+	}
+	classes, err := SafeClassList(link, MakeHandle(1, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(classes) != 1 {
+		t.Fatal("Failed to add class")
+	}
+	qfq, ok := classes[0].(*QfqClass)
+	if !ok {
+		t.Fatalf("Class is the wrong type: %T", classes[0])
+	}
+	if qfq.Weight != class.Weight {
+		t.Fatalf("Weight doesn't match, got %d, expected %d", qfq.Weight, class.Weight)
+	}
+	if qfq.MaxPkt != class.MaxPkt {
+		t.Fatalf("MaxPkt doesn't match, got %d, expected %d", qfq.MaxPkt, class.MaxPkt)
+	}
+
+	if err := ClassDel(class); err != nil {
+		t.Fatal(err)
+	}
+	if err := QdiscDel(qdisc); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClassSubscribeCoalesce(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+	if err := LinkAdd(&Ifb{LinkAttrs{Name: "foo"}}); err != nil {
+		t.Fatal(err)
+	}
+	link, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkSetUp(link); err != nil {
+		t.Fatal(err)
+	}
+	qdisc := NewHtb(QdiscAttrs{
+		LinkIndex: link.Attrs().Index,
+		Handle:    MakeHandle(1, 0),
+		Parent:    HANDLE_ROOT,
+	})
+	if err := QdiscAdd(qdisc); err != nil {
+		t.Fatal(err)
+	}
+	classattrs := ClassAttrs{
+		LinkIndex: link.Attrs().Index,
+		Parent:    MakeHandle(1, 0),
+		Handle:    MakeHandle(1, 1),
+	}
+	class := NewHtbClass(classattrs, HtbClassAttrs{Rate: 1000000})
+	if err := ClassAdd(class); err != nil {
+		t.Fatal(err)
+	}
+
+	ch := make(chan ClassUpdate)
+	done := make(chan struct{})
+	defer close(done)
+	if err := ClassSubscribeWithOptions(ch, done, ClassSubscribeOptions{
+		CoalesceInterval: 200 * time.Millisecond,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	const changes = 100
+	for i := 1; i <= changes; i++ {
+		class = NewHtbClass(classattrs, HtbClassAttrs{Rate: uint64(1000000 + i*8000)})
+		if err := ClassChange(class); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	received := 0
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case update := <-ch:
+			received++
+			if update.Type != unix.RTM_NEWTCLASS {
+				t.Fatalf("unexpected update type %d", update.Type)
+			}
+			htb, ok := update.Class.(*HtbClass)
+			if !ok {
+				t.Fatalf("class is the wrong type %T", update.Class)
+			}
+			if htb.Rate != class.Rate {
+				continue
+			}
+			if received > changes/10 {
+				t.Fatalf("received %d updates for %d changes", received, changes)
+			}
+			return
+		case <-timeout:
+			t.Fatalf("update with the final rate not received after %d updates", received)
+		}
+	}
+}
@@ -1,10 +1,16 @@
 package netlink
 
 import (
+	"errors"
 	"fmt"
 	"net"
 )
 
+// ErrFilterNotFound is returned by FilterGet when no filter matches the
+// requested parent, priority, protocol and handle. It wraps the kernel's
+// ENOENT.
+var ErrFilterNotFound = errors.New("filter not found")
+
 type Filter interface {
 	Attrs() *FilterAttrs
 	Type() string
@@ -20,8 +26,27 @@ type FilterAttrs struct {
 	Priority  uint16 // lower is higher priority
 	Protocol  uint16 // unix.ETH_P_*
 	Chain     *uint32
+	// Block is the index of a shared filter block, see
+	// QdiscAttrs.IngressBlock. When set the filter is attached to the
+	// block and LinkIndex and Parent are ignored.
+	Block *uint32
+	// Flags is a combination of TC_CLS_FLAGS_*, used by flower, matchall
+	// and u32. TC_CLS_FLAGS_IN_HW and TC_CLS_FLAGS_NOT_IN_HW are read only
+	// and report whether the filter was offloaded.
+	Flags uint32
+	// InHwCount is the number of devices the filter is offloaded to.
+	// Read only, reported for flower.
+	InHwCount uint32
 }
 
+const (
+	TC_CLS_FLAGS_SKIP_HW   uint32 = 1 << 0 // don't offload filter to HW
+	TC_CLS_FLAGS_SKIP_SW   uint32 = 1 << 1 // don't use filter in SW
+	TC_CLS_FLAGS_IN_HW     uint32 = 1 << 2 // filter is offloaded to HW
+	TC_CLS_FLAGS_NOT_IN_HW uint32 = 1 << 3 // filter isn't offloaded to HW
+	TC_CLS_FLAGS_VERBOSE   uint32 = 1 << 4 // verbose logging
+)
+
 func (q FilterAttrs) String() string {
 	return fmt.Sprintf("{LinkIndex: %d, Handle: %s, Parent: %s, Priority: %d, Protocol: %d}", q.LinkIndex, HandleStr(q.Handle), HandleStr(q.Parent), q.Priority, q.Protocol)
 }
@@ -125,7 +150,28 @@ type ActionAttrs struct {
 	Bindcnt    int
 	Statistics *ActionStatistic
 	Timestamp  *ActionTimestamp
-}
+	// Cookie is opaque user data stored with the action, at most
+	// 16 bytes (TC_COOKIE_MAX_SIZE).
+	Cookie []byte
+	// HwStatsType selects the hardware stats the action may use when
+	// offloaded. Nil leaves the kernel default, TCA_ACT_HW_STATS_ANY,
+	// which is also reported as nil.
+	HwStatsType *ActHwStats
+	// UsedHwStats is the hardware stats type the driver picked. Read only,
+	// nil unless the action is offloaded.
+	UsedHwStats *ActHwStats
+}
+
+// ActHwStats is a combination of TCA_ACT_HW_STATS_* flags; an empty set
+// disables hardware stats.
+type ActHwStats uint32
+
+const (
+	TCA_ACT_HW_STATS_DISABLED  ActHwStats = 0
+	TCA_ACT_HW_STATS_IMMEDIATE ActHwStats = 1 << 0 // stats are read from hardware on request
+	TCA_ACT_HW_STATS_DELAYED   ActHwStats = 1 << 1 // stats are synced periodically
+	TCA_ACT_HW_STATS_ANY       ActHwStats = TCA_ACT_HW_STATS_IMMEDIATE | TCA_ACT_HW_STATS_DELAYED
+)
 
 func (q ActionAttrs) String() string {
 	return fmt.Sprintf("{Index: %d, Capab: %x, Action: %s, Refcnt: %d, Bindcnt: %d}", q.Index, q.Capab, q.Action.String(), q.Refcnt, q.Bindcnt)
@@ -283,6 +329,15 @@ const (
 	TCA_INGRESS_MIRROR MirredAct = 4 /* mirror packet to INGRESS */
 )
 
+var (
+	// ErrMirredTargetNotFound is returned by MirredAction.Validate when
+	// the target link does not exist.
+	ErrMirredTargetNotFound = errors.New("mirred target link not found")
+	// ErrMirredTargetDown is returned by MirredAction.Validate when the
+	// target link is not up.
+	ErrMirredTargetDown = errors.New("mirred target link is down")
+)
+
 type MirredAction struct {
 	ActionAttrs
 	MirredAction MirredAct
@@ -364,6 +419,76 @@ func NewSkbEditAction() *SkbEditAction {
 	}
 }
 
+type SkbModFlags uint64
+
+const (
+	SKBMOD_F_DMAC    SkbModFlags = 0x1
+	SKBMOD_F_SMAC    SkbModFlags = 0x2
+	SKBMOD_F_ETYPE   SkbModFlags = 0x4
+	SKBMOD_F_SWAPMAC SkbModFlags = 0x8
+	SKBMOD_F_ECN     SkbModFlags = 0x10
+)
+
+// SkbModAction rewrites the ethernet header. SKBMOD_F_DMAC, SKBMOD_F_SMAC
+// and SKBMOD_F_ETYPE are implied by the fields that are set; the kernel
+// ignores them when SKBMOD_F_SWAPMAC or SKBMOD_F_ECN is given.
+type SkbModAction struct {
+	ActionAttrs
+	Flags SkbModFlags
+	DMac  net.HardwareAddr
+	SMac  net.HardwareAddr
+	EType uint16
+}
+
+func (action *SkbModAction) Type() string {
+	return "skbmod"
+}
+
+func (action *SkbModAction) Attrs() *ActionAttrs {
+	return &action.ActionAttrs
+}
+
+func NewSkbModAction() *SkbModAction {
+	return &SkbModAction{
+		ActionAttrs: ActionAttrs{
+			Action: TC_ACT_PIPE,
+		},
+	}
+}
+
+// CtInfoAction restores the DSCP and/or the packet mark from the conntrack
+// mark of the connection. DscpMask selects six contiguous bits of the
+// conntrack mark; DscpStateMask only restores the DSCP when those bits are
+// set. A zero mask disables the corresponding mode.
+type CtInfoAction struct {
+	ActionAttrs
+	Zone          uint16
+	DscpMask      uint32
+	DscpStateMask uint32
+	CpMarkMask    uint32
+
+	// Read only counters
+	DscpSet   uint64
+	DscpError uint64
+	CpMarkSet uint64
+}
+
+func (action *CtInfoAction) Type() string {
+	return "ctinfo"
+}
+
+func (action *CtInfoAction) Attrs() *ActionAttrs {
+	return &action.ActionAttrs
+}
+
+func NewCtInfoAction() *CtInfoAction {
+	return &CtInfoAction{
+		ActionAttrs: ActionAttrs{
+			Action: TC_ACT_PIPE,
+		},
+	}
+}
+
 type PoliceAction struct {
 	ActionAttrs
 	Rate            uint32 // in byte per second
@@ -421,6 +546,92 @@ func NewSampleAction() *SampleAction {
 	}
 }
 
+type MplsAct int32
+
+const (
+	TCA_MPLS_ACT_POP      MplsAct = 1 // pop the outermost label
+	TCA_MPLS_ACT_PUSH     MplsAct = 2 // push a label after the l2 header
+	TCA_MPLS_ACT_MODIFY   MplsAct = 3 // modify the outermost label
+	TCA_MPLS_ACT_DEC_TTL  MplsAct = 4 // decrement the ttl of the outermost label
+	TCA_MPLS_ACT_MAC_PUSH MplsAct = 5 // push a label before the l2 header
+)
+
+const (
+	MPLS_LABEL_MAX = 0xfffff
+	MPLS_TC_MAX    = 7
+	MPLS_BOS_MAX   = 1
+)
+
+// MplsAction pushes, pops or modifies MPLS labels. Proto is the ethertype
+// of the packet after a pop, or of the pushed label (ETH_P_MPLS_UC by
+// default). Nil fields are left as they are by the kernel.
+type MplsAction struct {
+	ActionAttrs
+	Action MplsAct
+	Proto  uint16
+	Label  *uint32
+	TC     *uint8
+	TTL    uint8
+	BOS    *uint8
+}
+
+func (action *MplsAction) Type() string {
+	return "mpls"
+}
+
+func (action *MplsAction) Attrs() *ActionAttrs {
+	return &action.ActionAttrs
+}
+
+func NewMplsAction() *MplsAction {
+	return &MplsAction{
+		ActionAttrs: ActionAttrs{
+			Action: TC_ACT_PIPE,
+		},
+	}
+}
+
+// GateEntry is one interval of a gate action schedule. A nil IPV keeps the
+// packet's priority, a nil MaxOctets does not limit the interval.
+type GateEntry struct {
+	Open      bool
+	Interval  uint32 // in nanoseconds
+	IPV       *int32
+	MaxOctets *int32
+}
+
+// GateAction passes packets only while the current entry of its schedule
+// is open (IEEE 802.1Qci). Times are in nanoseconds of ClockID; a zero
+// CycleTime makes the cycle the sum of the entry intervals.
+type GateAction struct {
+	ActionAttrs
+	Priority     int32
+	BaseTime     uint64
+	CycleTime    uint64
+	CycleTimeExt uint64
+	Flags        uint32
+	ClockID      int32
+	Entries      []GateEntry
+}
+
+func (action *GateAction) Type() string {
+	return "gate"
+}
+
+func (action *GateAction) Attrs() *ActionAttrs {
+	return &action.ActionAttrs
+}
+
+func NewGateAction() *GateAction {
+	return &GateAction{
+		ActionAttrs: ActionAttrs{
+			Action: TC_ACT_PIPE,
+		},
+		Priority: -1,
+		ClockID:  11, // CLOCK_TAI
+	}
+}
+
 // MatchAll filters match all packets
 type MatchAll struct {
 	FilterAttrs
@@ -436,11 +647,16 @@ func (filter *MatchAll) Type() string {
 	return "matchall"
 }
 
+// FwFilter matches the packets whose fwmark, ANDed with Mask, equals the
+// Handle of the filter. All the fw filters of a priority share the mask
+// of the first one, 0 sends none and keeps the kernel default 0xffffffff.
 type FwFilter struct {
 	FilterAttrs
 	ClassId uint32
 	InDev   string
 	Mask    uint32
+	// Police is a police attached the old way, in TCA_FW_POLICE. It
+	// follows the same rules as U32.Police.
 	Police  *PoliceAction
 	Actions []Action
 }
@@ -41,7 +41,12 @@ type U32 struct {
 	RedirIndex int
 	Sel        *TcU32Sel
 	Actions    []Action
-	Police     *PoliceAction
+	// Police is a police attached the old way, in TCA_U32_POLICE. A
+	// police attached with the action API is one of Actions instead, and
+	// Police is sent that way, as the first action, when Actions is not
+	// empty. Filters read back have the police in the field matching the
+	// way it was attached.
+	Police *PoliceAction
 }
 
 func (filter *U32) Attrs() *FilterAttrs {
@@ -52,6 +57,177 @@ func (filter *U32) Type() string {
 	return "u32"
 }
 
+// NewU32Sel builds a terminal TcU32Sel from groups of keys, such as the
+// ones returned by the U32Match* helpers. Keys at the same offset are
+// merged into one and keys with an empty mask are dropped, like tc does.
+// An error is returned if two keys require different values for the same
+// bits.
+func NewU32Sel(keys ...[]TcU32Key) (*TcU32Sel, error) {
+	sel := &TcU32Sel{
+		Flags: TC_U32_TERMINAL,
+	}
+	for _, group := range keys {
+		for _, key := range group {
+			if key.Mask == 0 {
+				continue
+			}
+			merged := false
+			for i := range sel.Keys {
+				k := &sel.Keys[i]
+				if k.Off != key.Off || k.OffMask != key.OffMask {
+					continue
+				}
+				if (k.Val^key.Val)&k.Mask&key.Mask != 0 {
+					return nil, fmt.Errorf("u32 keys at offset %d conflict: %08x/%08x and %08x/%08x",
+						key.Off, k.Val, k.Mask, key.Val, key.Mask)
+				}
+				k.Mask |= key.Mask
+				k.Val |= key.Val
+				merged = true
+				break
+			}
+			if !merged {
+				sel.Keys = append(sel.Keys, key)
+			}
+		}
+	}
+	sel.Nkeys = uint8(len(sel.Keys))
+	return sel, nil
+}
+
+// U32MatchIPSrc returns the keys matching the source address of an IPv4 or
+// IPv6 packet against ipnet.
+// Equivalent to: `match ip src $ipnet` or `match ip6 src $ipnet`.
+func U32MatchIPSrc(ipnet *net.IPNet) []TcU32Key {
+	if ipnet.IP.To4() != nil {
+		return u32MatchAddr(ipnet, 12)
+	}
+	return u32MatchAddr(ipnet, 8)
+}
+
+// U32MatchIPDst returns the keys matching the destination address of an
+// IPv4 or IPv6 packet against ipnet.
+// Equivalent to: `match ip dst $ipnet` or `match ip6 dst $ipnet`.
+func U32MatchIPDst(ipnet *net.IPNet) []TcU32Key {
+	if ipnet.IP.To4() != nil {
+		return u32MatchAddr(ipnet, 16)
+	}
+	return u32MatchAddr(ipnet, 24)
+}
+
+// U32MatchIPProto returns the key matching the protocol of an IPv4 packet,
+// or the next header of an IPv6 packet, for family FAMILY_V4 or FAMILY_V6.
+// Equivalent to: `match ip protocol $proto 0xff`.
+func U32MatchIPProto(family int, proto uint8) []TcU32Key {
+	if family == FAMILY_V6 {
+		return []TcU32Key{{Mask: 0x0000ff00, Val: uint32(proto) << 8, Off: 4}}
+	}
+	return []TcU32Key{{Mask: 0x00ff0000, Val: uint32(proto) << 16, Off: 8}}
+}
+
+// U32MatchL4SrcPort returns the keys matching the protocol and the source
+// port of a TCP, UDP or SCTP packet for family FAMILY_V4 or FAMILY_V6.
+// Like tc, the port is expected right after a 20 byte IPv4 header without
+// options, or after the 40 byte IPv6 header without extension headers.
+// Equivalent to: `match ip protocol $proto 0xff match ip sport $port 0xffff`.
+func U32MatchL4SrcPort(family int, proto uint8, port uint16) []TcU32Key {
+	return append(U32MatchIPProto(family, proto),
+		TcU32Key{Mask: 0xffff0000, Val: uint32(port) << 16, Off: u32L4Offset(family)})
+}
+
+// U32MatchL4DstPort returns the keys matching the protocol and the
+// destination port of a TCP, UDP or SCTP packet for family FAMILY_V4 or
+// FAMILY_V6, with the same header length assumptions as U32MatchL4SrcPort.
+// Equivalent to: `match ip protocol $proto 0xff match ip dport $port 0xffff`.
+func U32MatchL4DstPort(family int, proto uint8, port uint16) []TcU32Key {
+	return append(U32MatchIPProto(family, proto),
+		TcU32Key{Mask: 0x0000ffff, Val: uint32(port), Off: u32L4Offset(family)})
+}
+
+func u32L4Offset(family int) int32 {
+	if family == FAMILY_V6 {
+		return 40
+	}
+	return 20
+}
+
+// u32MatchAddr returns one key per 32 bit word of ipnet that its mask
+// covers, starting at off.
+func u32MatchAddr(ipnet *net.IPNet, off int32) []TcU32Key {
+	ip, mask := ipnet.IP.To4(), ipnet.Mask
+	if ip == nil {
+		ip = ipnet.IP.To16()
+	}
+	if len(mask) == net.IPv6len && len(ip) == net.IPv4len {
+		mask = mask[12:]
+	}
+	var keys []TcU32Key
+	for i := 0; i+4 <= len(ip) && i+4 <= len(mask); i += 4 {
+		m := binary.BigEndian.Uint32(mask[i:])
+		if m == 0 {
+			continue
+		}
+		keys = append(keys, TcU32Key{
+			Mask: m,
+			Val:  binary.BigEndian.Uint32(ip[i:]) & m,
+			Off:  off + int32(i),
+		})
+	}
+	return keys
+}
+
+// U32VarOffsetIPv4HeaderLen returns a selector that skips the IPv4 header,
+// options included, before the filters of the hash table it links to run,
+// so that their keys see the transport header at offset 0. The length is
+// read from the IHL field: the first 16 bits of the header masked with
+// 0x0f00 and shifted right by 6 give IHL * 4. The selector matches every
+// packet; pass it to LinkHashTable or set it as the Sel of a U32 with Link.
+// Equivalent to: `match u32 0 0 offset at 0 mask 0f00 shift 6 eat`.
+func U32VarOffsetIPv4HeaderLen() *TcU32Sel {
+	return &TcU32Sel{
+		Flags:    TC_U32_VAROFFSET | TC_U32_EAT,
+		Offmask:  0x0f00,
+		Offshift: 6,
+		Nkeys:    1,
+		Keys:     []TcU32Key{{}},
+	}
+}
+
+// NewU32HashTable returns a u32 filter creating the hash table htid with
+// divisor buckets, which must be a power of 2. htid is the 12 bit table
+// id, not the handle.
+// Equivalent to: `tc filter add ... handle $htid: u32 divisor $divisor`.
+func NewU32HashTable(attrs FilterAttrs, htid, divisor uint32) *U32 {
+	attrs.Handle = htid << 20
+	return &U32{
+		FilterAttrs: attrs,
+		Divisor:     divisor,
+	}
+}
+
+// U32HashBucket returns the Hash of a u32 filter going in bucket of the
+// hash table htid.
+// Equivalent to: `ht $htid:$bucket:`.
+func U32HashBucket(htid, bucket uint32) uint32 {
+	return htid<<20 | (bucket&0xff)<<12
+}
+
+// LinkHashTable makes the filter jump to the hash table htid when its
+// selector matches, picking the bucket from the 32 bits at hoff masked
+// with hmask, in host order, as tc does with `hashkey mask $hmask at
+// $hoff`. The filter gets a selector matching all packets if it has none.
+// The kernel follows the link of a matching filter whether its selector
+// is terminal or not.
+// Equivalent to: `link $htid: hashkey mask $hmask at $hoff`.
+func (filter *U32) LinkHashTable(htid uint32, hoff int16, hmask uint32) {
+	if filter.Sel == nil {
+		filter.Sel = &TcU32Sel{Nkeys: 1, Keys: []TcU32Key{{}}}
+	}
+	filter.Sel.Hoff = hoff
+	filter.Sel.Hmask = hmask
+	filter.Link = htid << 20
+}
+
 type Flower struct {
 	FilterAttrs
 	ClassId         uint32
@@ -190,14 +366,14 @@ func (filter *Flower) encode(parent *nl.RtAttr) error {
 		parent.AddRtAttr(nl.TCA_FLOWER_CLASSID, nl.Uint32Attr(filter.ClassId))
 	}
 
-	var flags uint32 = 0
+	flags := filterFlags(filter.Attrs())
 	if filter.SkipHw {
 		flags |= nl.TCA_CLS_FLAGS_SKIP_HW
 	}
 	if filter.SkipSw {
 		flags |= nl.TCA_CLS_FLAGS_SKIP_SW
 	}
-	parent.AddRtAttr(nl.TCA_FLOWER_FLAGS, htonl(flags))
+	parent.AddRtAttr(nl.TCA_FLOWER_FLAGS, nl.Uint32Attr(flags))
 
 	actionsAttr := parent.AddRtAttr(nl.TCA_FLOWER_ACT, nil)
 	if err := EncodeActions(actionsAttr, filter.Actions); err != nil {
@@ -256,15 +432,11 @@ func (filter *Flower) decode(data []syscall.NetlinkRouteAttr) error {
 				return err
 			}
 		case nl.TCA_FLOWER_FLAGS:
-			attr := nl.DeserializeUint32Bitfield(datum.Value)
-			skipSw := attr.Value & nl.TCA_CLS_FLAGS_SKIP_HW
-			skipHw := attr.Value & nl.TCA_CLS_FLAGS_SKIP_SW
-			if skipSw != 0 {
-				filter.SkipSw = true
-			}
-			if skipHw != 0 {
-				filter.SkipHw = true
-			}
+			filter.Flags = native.Uint32(datum.Value[0:4])
+			filter.SkipHw = filter.Flags&nl.TCA_CLS_FLAGS_SKIP_HW != 0
+			filter.SkipSw = filter.Flags&nl.TCA_CLS_FLAGS_SKIP_SW != 0
+		case nl.TCA_FLOWER_IN_HW_COUNT:
+			filter.InHwCount = native.Uint32(datum.Value[0:4])
 		case nl.TCA_FLOWER_KEY_PORT_SRC_MIN:
 			filter.SrcPortRangeMin = ntohs(datum.Value)
 		case nl.TCA_FLOWER_KEY_PORT_SRC_MAX:
@@ -292,31 +464,143 @@ func (h *Handle) FilterDel(filter Filter) error {
 	return h.filterModify(filter, unix.RTM_DELTFILTER, 0)
 }
 
+// FilterDelByAttrs deletes filters on link selected by attrs alone,
+// without naming a filter kind or handle. With a Priority, the whole
+// priority band is removed, i.e. every filter of that priority (and
+// Protocol, if set) under Parent. With no Priority either, the kernel
+// flushes all filters of the chain. Handle, if set, narrows the
+// deletion to a single filter.
+// Equivalent to: `tc filter del dev $link parent $parent prio $prio protocol $proto`
+func FilterDelByAttrs(link Link, attrs FilterAttrs) error {
+	return pkgHandle.FilterDelByAttrs(link, attrs)
+}
+
+// FilterDelByAttrs deletes filters on link selected by attrs alone,
+// without naming a filter kind or handle. With a Priority, the whole
+// priority band is removed, i.e. every filter of that priority (and
+// Protocol, if set) under Parent. With no Priority either, the kernel
+// flushes all filters of the chain. Handle, if set, narrows the
+// deletion to a single filter.
+// Equivalent to: `tc filter del dev $link parent $parent prio $prio protocol $proto`
+func (h *Handle) FilterDelByAttrs(link Link, attrs FilterAttrs) error {
+	req := h.newNetlinkRequest(unix.RTM_DELTFILTER, unix.NLM_F_ACK)
+	msg := &nl.TcMsg{
+		Family:  nl.FAMILY_ALL,
+		Ifindex: int32(attrs.LinkIndex),
+		Handle:  attrs.Handle,
+		Parent:  attrs.Parent,
+		Info:    MakeHandle(attrs.Priority, nl.Swap16(attrs.Protocol)),
+	}
+	if link != nil {
+		base := link.Attrs()
+		h.ensureIndex(base)
+		msg.Ifindex = int32(base.Index)
+	}
+	if attrs.Block != nil {
+		msg.Ifindex = blockIfindex()
+		msg.Parent = *attrs.Block
+	}
+	req.AddData(msg)
+	if attrs.Chain != nil {
+		req.AddData(nl.NewRtAttr(nl.TCA_CHAIN, nl.Uint32Attr(*attrs.Chain)))
+	}
+	_, err := req.Execute(unix.NETLINK_ROUTE, 0)
+	return err
+}
+
 // FilterAdd will add a filter to the system.
+// If the filter's Handle or Priority is zero, it is updated with the
+// value assigned by the kernel.
 // Equivalent to: `tc filter add $filter`
 func FilterAdd(filter Filter) error {
 	return pkgHandle.FilterAdd(filter)
 }
 
 // FilterAdd will add a filter to the system.
+// If the filter's Handle or Priority is zero, it is updated with the
+// value assigned by the kernel.
 // Equivalent to: `tc filter add $filter`
 func (h *Handle) FilterAdd(filter Filter) error {
 	return h.filterModify(filter, unix.RTM_NEWTFILTER, unix.NLM_F_CREATE|unix.NLM_F_EXCL)
 }
 
+// FilterChange will change an existing filter in place. It fails if
+// the filter does not exist.
+// Equivalent to: `tc filter change $filter`
+func FilterChange(filter Filter) error {
+	return pkgHandle.FilterChange(filter)
+}
+
+// FilterChange will change an existing filter in place. It fails if
+// the filter does not exist.
+// Equivalent to: `tc filter change $filter`
+func (h *Handle) FilterChange(filter Filter) error {
+	return h.filterModify(filter, unix.RTM_NEWTFILTER, 0)
+}
+
 // FilterReplace will replace a filter.
+// If the filter's Handle or Priority is zero, it is updated with the
+// value assigned by the kernel.
 // Equivalent to: `tc filter replace $filter`
 func FilterReplace(filter Filter) error {
 	return pkgHandle.FilterReplace(filter)
 }
 
 // FilterReplace will replace a filter.
+// If the filter's Handle or Priority is zero, it is updated with the
+// value assigned by the kernel.
 // Equivalent to: `tc filter replace $filter`
 func (h *Handle) FilterReplace(filter Filter) error {
 	return h.filterModify(filter, unix.RTM_NEWTFILTER, unix.NLM_F_CREATE)
 }
 
+// Validate checks that the target link of the action exists and is up,
+// as packets redirected or mirrored to a link that is down are dropped.
+// It returns an error wrapping ErrMirredTargetNotFound or
+// ErrMirredTargetDown otherwise. A nil h uses the default handle.
+func (action *MirredAction) Validate(h *Handle) error {
+	if h == nil {
+		h = pkgHandle
+	}
+	link, err := h.LinkByIndex(action.Ifindex)
+	if err != nil {
+		var notFound LinkNotFoundError
+		if errors.As(err, &notFound) {
+			return fmt.Errorf("%w: ifindex %d", ErrMirredTargetNotFound, action.Ifindex)
+		}
+		return err
+	}
+	if link.Attrs().Flags&net.FlagUp == 0 {
+		return fmt.Errorf("%w: %s", ErrMirredTargetDown, link.Attrs().Name)
+	}
+	return nil
+}
+
+// filterActions returns the actions of the filter types that have any.
+func filterActions(filter Filter) []Action {
+	switch filter := filter.(type) {
+	case *U32:
+		return filter.Actions
+	case *Flower:
+		return filter.Actions
+	case *MatchAll:
+		return filter.Actions
+	case *FwFilter:
+		return filter.Actions
+	}
+	return nil
+}
+
 func (h *Handle) filterModify(filter Filter, proto, flags int) error {
+	if h.options.checkMirred && proto != unix.RTM_DELTFILTER {
+		for _, action := range filterActions(filter) {
+			if mirred, ok := action.(*MirredAction); ok {
+				if err := mirred.Validate(h); err != nil {
+					return err
+				}
+			}
+		}
+	}
 	req := h.newNetlinkRequest(proto, flags|unix.NLM_F_ACK)
 	base := filter.Attrs()
 	msg := &nl.TcMsg{
@@ -326,6 +610,10 @@ func (h *Handle) filterModify(filter Filter, proto, flags int) error {
 		Parent:  base.Parent,
 		Info:    MakeHandle(base.Priority, nl.Swap16(base.Protocol)),
 	}
+	if base.Block != nil {
+		msg.Ifindex = blockIfindex()
+		msg.Parent = *base.Block
+	}
 	req.AddData(msg)
 	if filter.Attrs().Chain != nil {
 		req.AddData(nl.NewRtAttr(nl.TCA_CHAIN, nl.Uint32Attr(*filter.Attrs().Chain)))
@@ -379,21 +667,22 @@ func (h *Handle) filterModify(filter Filter, proto, flags int) error {
 		if filter.Link != 0 {
 			options.AddRtAttr(nl.TCA_U32_LINK, nl.Uint32Attr(filter.Link))
 		}
-		if filter.Police != nil {
-			police := options.AddRtAttr(nl.TCA_U32_POLICE, nil)
-			if err := encodePolice(police, filter.Police); err != nil {
-				return err
-			}
+		if flags := filterFlags(filter.Attrs()); flags != 0 {
+			options.AddRtAttr(nl.TCA_U32_FLAGS, nl.Uint32Attr(flags))
 		}
-		actionsAttr := options.AddRtAttr(nl.TCA_U32_ACT, nil)
 		// backwards compatibility
 		if filter.RedirIndex != 0 {
 			filter.Actions = append([]Action{NewMirredAction(filter.RedirIndex)}, filter.Actions...)
 		}
-		if err := EncodeActions(actionsAttr, filter.Actions); err != nil {
+		if err := encodePoliceAndActions(options, nl.TCA_U32_POLICE, nl.TCA_U32_ACT, filter.Police, filter.Actions); err != nil {
 			return err
 		}
 	case *FwFilter:
+		// the handle is the mark to compare to, bits outside of the mask
+		// would never match
+		if filter.Mask != 0 && filter.Handle&^filter.Mask != 0 {
+			return fmt.Errorf("fw filter handle %#x has bits outside of mask %#x", filter.Handle, filter.Mask)
+		}
 		if filter.Mask != 0 {
 			b := make([]byte, 4)
 			native.PutUint32(b, filter.Mask)
@@ -402,19 +691,12 @@ func (h *Handle) filterModify(filter Filter, proto, flags int) error {
 		if filter.InDev != "" {
 			options.AddRtAttr(nl.TCA_FW_INDEV, nl.ZeroTerminated(filter.InDev))
 		}
-		if filter.Police != nil {
-			police := options.AddRtAttr(nl.TCA_FW_POLICE, nil)
-			if err := encodePolice(police, filter.Police); err != nil {
-				return err
-			}
-		}
 		if filter.ClassId != 0 {
 			b := make([]byte, 4)
 			native.PutUint32(b, filter.ClassId)
 			options.AddRtAttr(nl.TCA_FW_CLASSID, b)
 		}
-		actionsAttr := options.AddRtAttr(nl.TCA_FW_ACT, nil)
-		if err := EncodeActions(actionsAttr, filter.Actions); err != nil {
+		if err := encodePoliceAndActions(options, nl.TCA_FW_POLICE, nl.TCA_FW_ACT, filter.Police, filter.Actions); err != nil {
 			return err
 		}
 	case *BpfFilter:
@@ -440,14 +722,38 @@ func (h *Handle) filterModify(filter Filter, proto, flags int) error {
 		if filter.ClassId != 0 {
 			options.AddRtAttr(nl.TCA_MATCHALL_CLASSID, nl.Uint32Attr(filter.ClassId))
 		}
+		if flags := filterFlags(filter.Attrs()); flags != 0 {
+			options.AddRtAttr(nl.TCA_MATCHALL_FLAGS, nl.Uint32Attr(flags))
+		}
 	case *Flower:
 		if err := filter.encode(options); err != nil {
 			return err
 		}
 	}
 	req.AddData(options)
-	_, err := req.Execute(unix.NETLINK_ROUTE, 0)
-	return err
+
+	if proto != unix.RTM_NEWTFILTER {
+		_, err := req.Execute(unix.NETLINK_ROUTE, 0)
+		return err
+	}
+
+	// Ask the kernel to echo the new filter back so that the handle and
+	// priority it picked can be reported to the caller.
+	req.Flags |= unix.NLM_F_ECHO
+	msgs, err := req.Execute(unix.NETLINK_ROUTE, unix.RTM_NEWTFILTER)
+	if err != nil {
+		return err
+	}
+	if len(msgs) > 0 {
+		echo := nl.DeserializeTcMsg(msgs[0])
+		if base.Handle == 0 {
+			base.Handle = echo.Handle
+		}
+		if base.Priority == 0 {
+			base.Priority = uint16(echo.Info >> 16)
+		}
+	}
+	return nil
 }
 
 // FilterList gets a list of filters in the system.
@@ -478,7 +784,10 @@ func (h *Handle) FilterList(link Link, parent uint32) ([]Filter, error) {
 		msg.Ifindex = int32(base.Index)
 	}
 	req.AddData(msg)
+	return h.filterDump(req)
+}
 
+func (h *Handle) filterDump(req *nl.NetlinkRequest) ([]Filter, error) {
 	msgs, executeErr := req.Execute(unix.NETLINK_ROUTE, unix.RTM_NEWTFILTER)
 	if executeErr != nil && !errors.Is(executeErr, ErrDumpInterrupted) {
 		return nil, executeErr
@@ -486,91 +795,187 @@ func (h *Handle) FilterList(link Link, parent uint32) ([]Filter, error) {
 
 	var res []Filter
 	for _, m := range msgs {
-		msg := nl.DeserializeTcMsg(m)
-
-		attrs, err := nl.ParseRouteAttr(m[msg.Len():])
+		filter, detailed, err := parseFilter(m)
 		if err != nil {
 			return nil, err
 		}
+		// only return the detailed version of the filter
+		if detailed {
+			res = append(res, filter)
+		}
+	}
+
+	return res, executeErr
+}
+
+// FilterListBlock gets the filters of the shared filter block with the
+// given index.
+// Equivalent to: `tc filter show block $block`.
+//
+// If the returned error is [ErrDumpInterrupted], results may be inconsistent
+// or incomplete.
+func FilterListBlock(block uint32) ([]Filter, error) {
+	return pkgHandle.FilterListBlock(block)
+}
+
+// FilterListBlock gets the filters of the shared filter block with the
+// given index.
+// Equivalent to: `tc filter show block $block`.
+//
+// If the returned error is [ErrDumpInterrupted], results may be inconsistent
+// or incomplete.
+func (h *Handle) FilterListBlock(block uint32) ([]Filter, error) {
+	req := h.newNetlinkRequest(unix.RTM_GETTFILTER, unix.NLM_F_DUMP)
+	msg := &nl.TcMsg{
+		Family:  nl.FAMILY_ALL,
+		Ifindex: blockIfindex(),
+		Parent:  block,
+	}
+	req.AddData(msg)
+	return h.filterDump(req)
+}
+
+// blockIfindex returns nl.TCM_IFINDEX_MAGIC_BLOCK as a tcm_ifindex.
+func blockIfindex() int32 {
+	magic := uint32(nl.TCM_IFINDEX_MAGIC_BLOCK)
+	return int32(magic)
+}
+
+// FilterGet gets the filter of link with the given parent, handle,
+// priority and protocol in chain 0, without dumping every filter of the
+// parent. A missing handle or priority returns [ErrFilterNotFound]; the
+// kernel reports EINVAL instead once the chain has no filters left.
+// Equivalent to: `tc filter get dev $link parent $parent handle $handle prio $priority protocol $protocol $kind`.
+func FilterGet(link Link, parent, handle uint32, priority uint16, protocol uint16) (Filter, error) {
+	return pkgHandle.FilterGet(link, parent, handle, priority, protocol)
+}
 
-		base := FilterAttrs{
-			LinkIndex: int(msg.Ifindex),
-			Handle:    msg.Handle,
-			Parent:    msg.Parent,
+// FilterGet gets the filter of link with the given parent, handle,
+// priority and protocol in chain 0, without dumping every filter of the
+// parent. A missing handle or priority returns [ErrFilterNotFound]; the
+// kernel reports EINVAL instead once the chain has no filters left.
+// Equivalent to: `tc filter get dev $link parent $parent handle $handle prio $priority protocol $protocol $kind`.
+func (h *Handle) FilterGet(link Link, parent, handle uint32, priority uint16, protocol uint16) (Filter, error) {
+	base := link.Attrs()
+	h.ensureIndex(base)
+	req := h.newNetlinkRequest(unix.RTM_GETTFILTER, 0)
+	msg := &nl.TcMsg{
+		Family:  nl.FAMILY_ALL,
+		Ifindex: int32(base.Index),
+		Handle:  handle,
+		Parent:  parent,
+		Info:    MakeHandle(priority, nl.Swap16(protocol)),
+	}
+	req.AddData(msg)
+
+	msgs, err := req.Execute(unix.NETLINK_ROUTE, unix.RTM_NEWTFILTER)
+	if err != nil {
+		if errors.Is(err, unix.ENOENT) {
+			return nil, fmt.Errorf("%w: %w", ErrFilterNotFound, err)
 		}
-		base.Priority, base.Protocol = MajorMinor(msg.Info)
-		base.Protocol = nl.Swap16(base.Protocol)
-
-		var filter Filter
-		filterType := ""
-		detailed := false
-		for _, attr := range attrs {
-			attrType := attr.Attr.Type & nl.NLA_TYPE_MASK
-			switch attrType {
-			case nl.TCA_KIND:
-				filterType = string(attr.Value[:len(attr.Value)-1])
-				switch filterType {
-				case "u32":
-					filter = &U32{}
-				case "fw":
-					filter = &FwFilter{}
-				case "bpf":
-					filter = &BpfFilter{}
-				case "matchall":
-					filter = &MatchAll{}
-				case "flower":
-					filter = &Flower{}
-				default:
-					filter = &GenericFilter{FilterType: filterType}
+		return nil, err
+	}
+	if len(msgs) != 1 {
+		return nil, fmt.Errorf("expected 1 filter, got %d", len(msgs))
+	}
+	filter, _, err := parseFilter(msgs[0])
+	return filter, err
+}
+
+// parseFilter decodes a RTM_NEWTFILTER message. detailed is false for
+// messages without filter options, such as the u32 hash table roots.
+func parseFilter(m []byte) (Filter, bool, error) {
+	msg := nl.DeserializeTcMsg(m)
+
+	attrs, err := nl.ParseRouteAttr(m[msg.Len():])
+	if err != nil {
+		return nil, false, err
+	}
+
+	base := FilterAttrs{
+		LinkIndex: int(msg.Ifindex),
+		Handle:    msg.Handle,
+		Parent:    msg.Parent,
+	}
+	if uint32(msg.Ifindex) == nl.TCM_IFINDEX_MAGIC_BLOCK {
+		block := msg.Parent
+		base.LinkIndex = 0
+		base.Parent = 0
+		base.Block = &block
+	}
+	base.Priority, base.Protocol = MajorMinor(msg.Info)
+	base.Protocol = nl.Swap16(base.Protocol)
+
+	var filter Filter
+	filterType := ""
+	detailed := false
+	for _, attr := range attrs {
+		attrType := attr.Attr.Type & nl.NLA_TYPE_MASK
+		switch attrType {
+		case nl.TCA_KIND:
+			filterType = string(attr.Value[:len(attr.Value)-1])
+			switch filterType {
+			case "u32":
+				filter = &U32{}
+			case "fw":
+				filter = &FwFilter{}
+			case "bpf":
+				filter = &BpfFilter{}
+			case "matchall":
+				filter = &MatchAll{}
+			case "flower":
+				filter = &Flower{}
+			default:
+				filter = &GenericFilter{FilterType: filterType}
+			}
+		case nl.TCA_OPTIONS:
+			data, err := nl.ParseRouteAttr(attr.Value)
+			if err != nil {
+				return nil, false, err
+			}
+			switch filterType {
+			case "u32":
+				detailed, err = parseU32Data(filter, data)
+				if err != nil {
+					return nil, false, err
 				}
-			case nl.TCA_OPTIONS:
-				data, err := nl.ParseRouteAttr(attr.Value)
+			case "fw":
+				detailed, err = parseFwData(filter, data)
 				if err != nil {
-					return nil, err
+					return nil, false, err
 				}
-				switch filterType {
-				case "u32":
-					detailed, err = parseU32Data(filter, data)
-					if err != nil {
-						return nil, err
-					}
-				case "fw":
-					detailed, err = parseFwData(filter, data)
-					if err != nil {
-						return nil, err
-					}
-				case "bpf":
-					detailed, err = parseBpfData(filter, data)
-					if err != nil {
-						return nil, err
-					}
-				case "matchall":
-					detailed, err = parseMatchAllData(filter, data)
-					if err != nil {
-						return nil, err
-					}
-				case "flower":
-					detailed, err = parseFlowerData(filter, data)
-					if err != nil {
-						return nil, err
-					}
-				default:
-					detailed = true
+			case "bpf":
+				detailed, err = parseBpfData(filter, data)
+				if err != nil {
+					return nil, false, err
 				}
-			case nl.TCA_CHAIN:
-				val := new(uint32)
-				*val = native.Uint32(attr.Value)
-				base.Chain = val
+			case "matchall":
+				detailed, err = parseMatchAllData(filter, data)
+				if err != nil {
+					return nil, false, err
+				}
+			case "flower":
+				detailed, err = parseFlowerData(filter, data)
+				if err != nil {
+					return nil, false, err
+				}
+			default:
+				detailed = true
 			}
-		}
-		// only return the detailed version of the filter
-		if detailed {
-			*filter.Attrs() = base
-			res = append(res, filter)
+		case nl.TCA_CHAIN:
+			val := new(uint32)
+			*val = native.Uint32(attr.Value)
+			base.Chain = val
 		}
 	}
-
-	return res, executeErr
+	if !detailed {
+		return filter, false, nil
+	}
+	// keep the offload flags decoded from the options
+	base.Flags = filter.Attrs().Flags
+	base.InHwCount = filter.Attrs().InHwCount
+	*filter.Attrs() = base
+	return filter, true, nil
 }
 
 func toTcGen(attrs *ActionAttrs, tcgen *nl.TcGen) {
@@ -597,6 +1002,33 @@ func toTimeStamp(tcf *nl.Tcf) *ActionTimestamp {
 		FirstUsed: tcf.FirstUse}
 }
 
+// encodePoliceAndActions adds the police and actions of a u32 or fw filter
+// to its options. The police goes in the policeType attribute when it is
+// alone. The kernel ignores the actions of a filter with such a police
+// though, so alongside actions it is sent as the first of them instead,
+// which is where the kernel puts it either way.
+func encodePoliceAndActions(options *nl.RtAttr, policeType, actType int, police *PoliceAction, actions []Action) error {
+	if police != nil {
+		if len(actions) == 0 {
+			return encodePolice(options.AddRtAttr(policeType, nil), police)
+		}
+		actions = append([]Action{police}, actions...)
+	}
+	return EncodeActions(options.AddRtAttr(actType, nil), actions)
+}
+
+// parseFilterPolice decodes the TCA_U32_POLICE or TCA_FW_POLICE of a
+// filter, a police added the old way, which the kernel reports apart from
+// the actions.
+func parseFilterPolice(data []byte) *PoliceAction {
+	var police PoliceAction
+	adata, _ := nl.ParseRouteAttr(data)
+	for _, aattr := range adata {
+		parsePolice(aattr, &police)
+	}
+	return &police
+}
+
 func encodePolice(attr *nl.RtAttr, action *PoliceAction) error {
 	var rtab [256]uint32
 	var ptab [256]uint32
@@ -644,24 +1076,64 @@ func encodePolice(attr *nl.RtAttr, action *PoliceAction) error {
 	return nil
 }
 
+func encodeGate(attr *nl.RtAttr, action *GateAction) error {
+	if len(action.Entries) == 0 {
+		return fmt.Errorf("gate action requires at least one entry")
+	}
+	attr.AddRtAttr(nl.TCA_GATE_PRIORITY, nl.Uint32Attr(uint32(action.Priority)))
+	attr.AddRtAttr(nl.TCA_GATE_BASE_TIME, nl.Uint64Attr(action.BaseTime))
+	if action.CycleTime != 0 {
+		attr.AddRtAttr(nl.TCA_GATE_CYCLE_TIME, nl.Uint64Attr(action.CycleTime))
+	}
+	if action.CycleTimeExt != 0 {
+		attr.AddRtAttr(nl.TCA_GATE_CYCLE_TIME_EXT, nl.Uint64Attr(action.CycleTimeExt))
+	}
+	if action.Flags != 0 {
+		attr.AddRtAttr(nl.TCA_GATE_FLAGS, nl.Uint32Attr(action.Flags))
+	}
+	attr.AddRtAttr(nl.TCA_GATE_CLOCKID, nl.Uint32Attr(uint32(action.ClockID)))
+
+	list := attr.AddRtAttr(nl.TCA_GATE_ENTRY_LIST|unix.NLA_F_NESTED, nil)
+	for i, entry := range action.Entries {
+		if entry.Interval == 0 {
+			return fmt.Errorf("gate entry %d has a zero interval", i)
+		}
+		e := list.AddRtAttr(nl.TCA_GATE_ONE_ENTRY|unix.NLA_F_NESTED, nil)
+		e.AddRtAttr(nl.TCA_GATE_ENTRY_INDEX, nl.Uint32Attr(uint32(i)))
+		if entry.Open {
+			e.AddRtAttr(nl.TCA_GATE_ENTRY_GATE, []byte{})
+		}
+		e.AddRtAttr(nl.TCA_GATE_ENTRY_INTERVAL, nl.Uint32Attr(entry.Interval))
+		if entry.IPV != nil {
+			e.AddRtAttr(nl.TCA_GATE_ENTRY_IPV, nl.Uint32Attr(uint32(*entry.IPV)))
+		}
+		if entry.MaxOctets != nil {
+			e.AddRtAttr(nl.TCA_GATE_ENTRY_MAX_OCTETS, nl.Uint32Attr(uint32(*entry.MaxOctets)))
+		}
+	}
+	return nil
+}
+
 func EncodeActions(attr *nl.RtAttr, actions []Action) error {
 	tabIndex := int(nl.TCA_ACT_TAB)
 
 	for _, action := range actions {
+		cookie := action.Attrs().Cookie
+		if len(cookie) > nl.TC_COOKIE_MAX_SIZE {
+			return fmt.Errorf("cookie of %s action is %d bytes, max %d", action.Type(), len(cookie), nl.TC_COOKIE_MAX_SIZE)
+		}
+		table := attr.AddRtAttr(tabIndex, nil)
+		tabIndex++
 		switch action := action.(type) {
 		default:
 			return fmt.Errorf("unknown action type %s", action.Type())
 		case *PoliceAction:
-			table := attr.AddRtAttr(tabIndex, nil)
-			tabIndex++
 			table.AddRtAttr(nl.TCA_ACT_KIND, nl.ZeroTerminated("police"))
 			aopts := table.AddRtAttr(nl.TCA_ACT_OPTIONS, nil)
 			if err := encodePolice(aopts, action); err != nil {
 				return err
 			}
 		case *MirredAction:
-			table := attr.AddRtAttr(tabIndex, nil)
-			tabIndex++
 			table.AddRtAttr(nl.TCA_ACT_KIND, nl.ZeroTerminated("mirred"))
 			aopts := table.AddRtAttr(nl.TCA_ACT_OPTIONS, nil)
 			mirred := nl.TcMirred{
@@ -671,8 +1143,6 @@ func EncodeActions(attr *nl.RtAttr, actions []Action) error {
 			toTcGen(action.Attrs(), &mirred.TcGen)
 			aopts.AddRtAttr(nl.TCA_MIRRED_PARMS, mirred.Serialize())
 		case *VlanAction:
-			table := attr.AddRtAttr(tabIndex, nil)
-			tabIndex++
 			table.AddRtAttr(nl.TCA_ACT_KIND, nl.ZeroTerminated("vlan"))
 			aopts := table.AddRtAttr(nl.TCA_ACT_OPTIONS, nil)
 			vlan := nl.TcVlan{
@@ -687,8 +1157,6 @@ func EncodeActions(attr *nl.RtAttr, actions []Action) error {
 				aopts.AddRtAttr(nl.TCA_VLAN_PUSH_VLAN_ID, nl.Uint16Attr(action.VlanID))
 			}
 		case *TunnelKeyAction:
-			table := attr.AddRtAttr(tabIndex, nil)
-			tabIndex++
 			table.AddRtAttr(nl.TCA_ACT_KIND, nl.ZeroTerminated("tunnel_key"))
 			aopts := table.AddRtAttr(nl.TCA_ACT_OPTIONS, nil)
 			tun := nl.TcTunnelKey{
@@ -717,8 +1185,6 @@ func EncodeActions(attr *nl.RtAttr, actions []Action) error {
 				}
 			}
 		case *SkbEditAction:
-			table := attr.AddRtAttr(tabIndex, nil)
-			tabIndex++
 			table.AddRtAttr(nl.TCA_ACT_KIND, nl.ZeroTerminated("skbedit"))
 			aopts := table.AddRtAttr(nl.TCA_ACT_OPTIONS, nil)
 			skbedit := nl.TcSkbEdit{}
@@ -739,9 +1205,101 @@ func EncodeActions(attr *nl.RtAttr, actions []Action) error {
 			if action.Mask != nil {
 				aopts.AddRtAttr(nl.TCA_SKBEDIT_MASK, nl.Uint32Attr(*action.Mask))
 			}
+		case *MplsAction:
+			table.AddRtAttr(nl.TCA_ACT_KIND, nl.ZeroTerminated("mpls"))
+			aopts := table.AddRtAttr(nl.TCA_ACT_OPTIONS, nil)
+			mpls := nl.TcMpls{
+				MAction: int32(action.Action),
+			}
+			toTcGen(action.Attrs(), &mpls.TcGen)
+			aopts.AddRtAttr(nl.TCA_MPLS_PARMS, mpls.Serialize())
+			if (action.Action == TCA_MPLS_ACT_PUSH || action.Action == TCA_MPLS_ACT_MAC_PUSH) && action.Label == nil {
+				return fmt.Errorf("mpls label is required for push action")
+			}
+			if action.Action == TCA_MPLS_ACT_POP && action.Proto == 0 {
+				return fmt.Errorf("mpls proto is required for pop action")
+			}
+			if action.Proto != 0 {
+				aopts.AddRtAttr(nl.TCA_MPLS_PROTO, htons(action.Proto))
+			}
+			if action.Label != nil {
+				if *action.Label > MPLS_LABEL_MAX {
+					return fmt.Errorf("mpls label %d out of range, max %d", *action.Label, MPLS_LABEL_MAX)
+				}
+				aopts.AddRtAttr(nl.TCA_MPLS_LABEL, nl.Uint32Attr(*action.Label))
+			}
+			if action.TC != nil {
+				if *action.TC > MPLS_TC_MAX {
+					return fmt.Errorf("mpls tc %d out of range, max %d", *action.TC, MPLS_TC_MAX)
+				}
+				aopts.AddRtAttr(nl.TCA_MPLS_TC, nl.Uint8Attr(*action.TC))
+			}
+			if action.TTL != 0 {
+				aopts.AddRtAttr(nl.TCA_MPLS_TTL, nl.Uint8Attr(action.TTL))
+			}
+			if action.BOS != nil {
+				if *action.BOS > MPLS_BOS_MAX {
+					return fmt.Errorf("mpls bos %d out of range, max %d", *action.BOS, MPLS_BOS_MAX)
+				}
+				aopts.AddRtAttr(nl.TCA_MPLS_BOS, nl.Uint8Attr(*action.BOS))
+			}
+		case *GateAction:
+			table.AddRtAttr(nl.TCA_ACT_KIND, nl.ZeroTerminated("gate"))
+			aopts := table.AddRtAttr(nl.TCA_ACT_OPTIONS, nil)
+			gate := nl.TcGate{}
+			toTcGen(action.Attrs(), &gate.TcGen)
+			aopts.AddRtAttr(nl.TCA_GATE_PARMS, gate.Serialize())
+			if err := encodeGate(aopts, action); err != nil {
+				return err
+			}
+		case *SkbModAction:
+			table.AddRtAttr(nl.TCA_ACT_KIND, nl.ZeroTerminated("skbmod"))
+			aopts := table.AddRtAttr(nl.TCA_ACT_OPTIONS, nil)
+			skbmod := nl.TcSkbMod{
+				Flags: uint64(action.Flags),
+			}
+			if action.DMac != nil {
+				skbmod.Flags |= uint64(SKBMOD_F_DMAC)
+			}
+			if action.SMac != nil {
+				skbmod.Flags |= uint64(SKBMOD_F_SMAC)
+			}
+			if action.EType != 0 {
+				skbmod.Flags |= uint64(SKBMOD_F_ETYPE)
+			}
+			if skbmod.Flags == 0 {
+				return fmt.Errorf("skbmod action requires a mac, an ethertype or a flag")
+			}
+			toTcGen(action.Attrs(), &skbmod.TcGen)
+			aopts.AddRtAttr(nl.TCA_SKBMOD_PARMS, skbmod.Serialize())
+			if action.DMac != nil {
+				aopts.AddRtAttr(nl.TCA_SKBMOD_DMAC, []byte(action.DMac))
+			}
+			if action.SMac != nil {
+				aopts.AddRtAttr(nl.TCA_SKBMOD_SMAC, []byte(action.SMac))
+			}
+			if action.EType != 0 {
+				aopts.AddRtAttr(nl.TCA_SKBMOD_ETYPE, nl.Uint16Attr(action.EType))
+			}
+		case *CtInfoAction:
+			table.AddRtAttr(nl.TCA_ACT_KIND, nl.ZeroTerminated("ctinfo"))
+			aopts := table.AddRtAttr(nl.TCA_ACT_OPTIONS, nil)
+			ctinfo := nl.TcCtInfo{}
+			toTcGen(action.Attrs(), &ctinfo.TcGen)
+			aopts.AddRtAttr(nl.TCA_CTINFO_ACT, ctinfo.Serialize())
+			aopts.AddRtAttr(nl.TCA_CTINFO_ZONE, nl.Uint16Attr(action.Zone))
+			if action.DscpMask != 0 {
+				aopts.AddRtAttr(nl.TCA_CTINFO_PARMS_DSCP_MASK, nl.Uint32Attr(action.DscpMask))
+				if action.DscpStateMask != 0 {
+					aopts.AddRtAttr(nl.TCA_CTINFO_PARMS_DSCP_STATEMASK, nl.Uint32Attr(action.DscpStateMask))
+				}
+			} else if action.DscpStateMask != 0 {
+				return fmt.Errorf("ctinfo dscp state mask requires a dscp mask")
+			}
+			if action.CpMarkMask != 0 {
+				aopts.AddRtAttr(nl.TCA_CTINFO_PARMS_CPMARK_MASK, nl.Uint32Attr(action.CpMarkMask))
+			}
 		case *ConnmarkAction:
-			table := attr.AddRtAttr(tabIndex, nil)
-			tabIndex++
 			table.AddRtAttr(nl.TCA_ACT_KIND, nl.ZeroTerminated("connmark"))
 			aopts := table.AddRtAttr(nl.TCA_ACT_OPTIONS, nil)
 			connmark := nl.TcConnmark{
@@ -750,8 +1308,6 @@ func EncodeActions(attr *nl.RtAttr, actions []Action) error {
 			toTcGen(action.Attrs(), &connmark.TcGen)
 			aopts.AddRtAttr(nl.TCA_CONNMARK_PARMS, connmark.Serialize())
 		case *CsumAction:
-			table := attr.AddRtAttr(tabIndex, nil)
-			tabIndex++
 			table.AddRtAttr(nl.TCA_ACT_KIND, nl.ZeroTerminated("csum"))
 			aopts := table.AddRtAttr(nl.TCA_ACT_OPTIONS, nil)
 			csum := nl.TcCsum{
@@ -760,8 +1316,6 @@ func EncodeActions(attr *nl.RtAttr, actions []Action) error {
 			toTcGen(action.Attrs(), &csum.TcGen)
 			aopts.AddRtAttr(nl.TCA_CSUM_PARMS, csum.Serialize())
 		case *BpfAction:
-			table := attr.AddRtAttr(tabIndex, nil)
-			tabIndex++
 			table.AddRtAttr(nl.TCA_ACT_KIND, nl.ZeroTerminated("bpf"))
 			aopts := table.AddRtAttr(nl.TCA_ACT_OPTIONS, nil)
 			gen := nl.TcGen{}
@@ -770,8 +1324,6 @@ func EncodeActions(attr *nl.RtAttr, actions []Action) error {
 			aopts.AddRtAttr(nl.TCA_ACT_BPF_FD, nl.Uint32Attr(uint32(action.Fd)))
 			aopts.AddRtAttr(nl.TCA_ACT_BPF_NAME, nl.ZeroTerminated(action.Name))
 		case *SampleAction:
-			table := attr.AddRtAttr(tabIndex, nil)
-			tabIndex++
 			table.AddRtAttr(nl.TCA_ACT_KIND, nl.ZeroTerminated("sample"))
 			aopts := table.AddRtAttr(nl.TCA_ACT_OPTIONS, nil)
 			gen := nl.TcGen{}
@@ -781,16 +1333,12 @@ func EncodeActions(attr *nl.RtAttr, actions []Action) error {
 			aopts.AddRtAttr(nl.TCA_ACT_SAMPLE_PSAMPLE_GROUP, nl.Uint32Attr(action.Group))
 			aopts.AddRtAttr(nl.TCA_ACT_SAMPLE_TRUNC_SIZE, nl.Uint32Attr(action.TruncSize))
 		case *GenericAction:
-			table := attr.AddRtAttr(tabIndex, nil)
-			tabIndex++
 			table.AddRtAttr(nl.TCA_ACT_KIND, nl.ZeroTerminated("gact"))
 			aopts := table.AddRtAttr(nl.TCA_ACT_OPTIONS, nil)
 			gen := nl.TcGen{}
 			toTcGen(action.Attrs(), &gen)
 			aopts.AddRtAttr(nl.TCA_GACT_PARMS, gen.Serialize())
 		case *PeditAction:
-			table := attr.AddRtAttr(tabIndex, nil)
-			tabIndex++
 			pedit := nl.TcPedit{}
 			toTcGen(action.Attrs(), &pedit.Sel.TcGen)
 			if action.SrcMacAddr != nil {
@@ -813,6 +1361,16 @@ func EncodeActions(attr *nl.RtAttr, actions []Action) error {
 			}
 			pedit.Encode(table)
 		}
+		if len(cookie) > 0 {
+			table.AddRtAttr(nl.TCA_ACT_COOKIE, cookie)
+		}
+		if hwStats := action.Attrs().HwStatsType; hwStats != nil {
+			if *hwStats&^TCA_ACT_HW_STATS_ANY != 0 {
+				return fmt.Errorf("invalid hw stats type %#x for %s action", uint32(*hwStats), action.Type())
+			}
+			bitfield := nl.Uint32Bitfield{Value: uint32(*hwStats), Selector: nl.TCA_ACT_HW_STATS_ANY}
+			table.AddRtAttr(nl.TCA_ACT_HW_STATS, bitfield.Serialize())
+		}
 	}
 	return nil
 }
@@ -837,9 +1395,47 @@ func parsePolice(data syscall.NetlinkRouteAttr, police *PoliceAction) {
 		police.Mtu = p.Mtu
 		police.LinkLayer = int(p.Rate.Linklayer) & nl.TC_LINKLAYER_MASK
 		police.Overhead = p.Rate.Overhead
+		police.Mpu = p.Rate.Mpu
 	}
 }
 
+func parseGateEntries(data []byte) ([]GateEntry, error) {
+	list, err := nl.ParseRouteAttr(data)
+	if err != nil {
+		return nil, err
+	}
+	var entries []GateEntry
+	for _, item := range list {
+		if item.Attr.Type&nl.NLA_TYPE_MASK != nl.TCA_GATE_ONE_ENTRY {
+			continue
+		}
+		eattrs, err := nl.ParseRouteAttr(item.Value)
+		if err != nil {
+			return nil, err
+		}
+		var entry GateEntry
+		for _, eattr := range eattrs {
+			switch eattr.Attr.Type {
+			case nl.TCA_GATE_ENTRY_GATE:
+				entry.Open = true
+			case nl.TCA_GATE_ENTRY_INTERVAL:
+				entry.Interval = native.Uint32(eattr.Value[0:4])
+			case nl.TCA_GATE_ENTRY_IPV:
+				// -1 is the kernel's wildcard
+				if ipv := int32(native.Uint32(eattr.Value[0:4])); ipv >= 0 {
+					entry.IPV = &ipv
+				}
+			case nl.TCA_GATE_ENTRY_MAX_OCTETS:
+				if maxOctets := int32(native.Uint32(eattr.Value[0:4])); maxOctets >= 0 {
+					entry.MaxOctets = &maxOctets
+				}
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
 func parseActions(tables []syscall.NetlinkRouteAttr) ([]Action, error) {
 	var actions []Action
 	for _, table := range tables {
@@ -847,6 +1443,8 @@ func parseActions(tables []syscall.NetlinkRouteAttr) ([]Action, error) {
 		var actionType string
 		var actionnStatistic *ActionStatistic
 		var actionTimestamp *ActionTimestamp
+		var actionCookie []byte
+		var actionHwStats, actionUsedHwStats *ActHwStats
 		aattrs, err := nl.ParseRouteAttr(table.Value)
 		if err != nil {
 			return nil, err
@@ -876,10 +1474,18 @@ func parseActions(tables []syscall.NetlinkRouteAttr) ([]Action, error) {
 					action = &TunnelKeyAction{}
 				case "skbedit":
 					action = &SkbEditAction{}
+				case "skbmod":
+					action = &SkbModAction{}
+				case "ctinfo":
+					action = &CtInfoAction{}
 				case "police":
 					action = &PoliceAction{}
 				case "pedit":
 					action = &PeditAction{}
+				case "mpls":
+					action = &MplsAction{}
+				case "gate":
+					action = &GateAction{}
 				default:
 					break nextattr
 				}
@@ -957,6 +1563,99 @@ func parseActions(tables []syscall.NetlinkRouteAttr) ([]Action, error) {
 							tcTs := nl.DeserializeTcf(adatum.Value)
 							actionTimestamp = toTimeStamp(tcTs)
 						}
+					case "mpls":
+						switch adatum.Attr.Type {
+						case nl.TCA_MPLS_PARMS:
+							mpls := *nl.DeserializeTcMpls(adatum.Value)
+							action.(*MplsAction).ActionAttrs = ActionAttrs{}
+							toAttrs(&mpls.TcGen, action.Attrs())
+							action.(*MplsAction).Action = MplsAct(mpls.MAction)
+						case nl.TCA_MPLS_PROTO:
+							action.(*MplsAction).Proto = ntohs(adatum.Value)
+						case nl.TCA_MPLS_LABEL:
+							label := native.Uint32(adatum.Value[0:4])
+							action.(*MplsAction).Label = &label
+						case nl.TCA_MPLS_TC:
+							tc := adatum.Value[0]
+							action.(*MplsAction).TC = &tc
+						case nl.TCA_MPLS_TTL:
+							action.(*MplsAction).TTL = adatum.Value[0]
+						case nl.TCA_MPLS_BOS:
+							bos := adatum.Value[0]
+							action.(*MplsAction).BOS = &bos
+						case nl.TCA_MPLS_TM:
+							tcTs := nl.DeserializeTcf(adatum.Value)
+							actionTimestamp = toTimeStamp(tcTs)
+						}
+					case "gate":
+						switch adatum.Attr.Type & nl.NLA_TYPE_MASK {
+						case nl.TCA_GATE_PARMS:
+							gate := *nl.DeserializeTcGate(adatum.Value)
+							action.(*GateAction).ActionAttrs = ActionAttrs{}
+							toAttrs(&gate.TcGen, action.Attrs())
+						case nl.TCA_GATE_PRIORITY:
+							action.(*GateAction).Priority = int32(native.Uint32(adatum.Value[0:4]))
+						case nl.TCA_GATE_BASE_TIME:
+							action.(*GateAction).BaseTime = native.Uint64(adatum.Value[0:8])
+						case nl.TCA_GATE_CYCLE_TIME:
+							action.(*GateAction).CycleTime = native.Uint64(adatum.Value[0:8])
+						case nl.TCA_GATE_CYCLE_TIME_EXT:
+							action.(*GateAction).CycleTimeExt = native.Uint64(adatum.Value[0:8])
+						case nl.TCA_GATE_FLAGS:
+							action.(*GateAction).Flags = native.Uint32(adatum.Value[0:4])
+						case nl.TCA_GATE_CLOCKID:
+							action.(*GateAction).ClockID = int32(native.Uint32(adatum.Value[0:4]))
+						case nl.TCA_GATE_ENTRY_LIST:
+							entries, err := parseGateEntries(adatum.Value)
+							if err != nil {
+								return nil, err
+							}
+							action.(*GateAction).Entries = entries
+						case nl.TCA_GATE_TM:
+							tcTs := nl.DeserializeTcf(adatum.Value)
+							actionTimestamp = toTimeStamp(tcTs)
+						}
+					case "skbmod":
+						switch adatum.Attr.Type {
+						case nl.TCA_SKBMOD_PARMS:
+							skbmod := *nl.DeserializeTcSkbMod(adatum.Value)
+							action.(*SkbModAction).ActionAttrs = ActionAttrs{}
+							toAttrs(&skbmod.TcGen, action.Attrs())
+							action.(*SkbModAction).Flags = SkbModFlags(skbmod.Flags)
+						case nl.TCA_SKBMOD_DMAC:
+							action.(*SkbModAction).DMac = net.HardwareAddr(adatum.Value[0:6])
+						case nl.TCA_SKBMOD_SMAC:
+							action.(*SkbModAction).SMac = net.HardwareAddr(adatum.Value[0:6])
+						case nl.TCA_SKBMOD_ETYPE:
+							action.(*SkbModAction).EType = native.Uint16(adatum.Value[0:2])
+						case nl.TCA_SKBMOD_TM:
+							tcTs := nl.DeserializeTcf(adatum.Value)
+							actionTimestamp = toTimeStamp(tcTs)
+						}
+					case "ctinfo":
+						switch adatum.Attr.Type {
+						case nl.TCA_CTINFO_ACT:
+							ctinfo := *nl.DeserializeTcCtInfo(adatum.Value)
+							action.(*CtInfoAction).ActionAttrs = ActionAttrs{}
+							toAttrs(&ctinfo.TcGen, action.Attrs())
+						case nl.TCA_CTINFO_ZONE:
+							action.(*CtInfoAction).Zone = native.Uint16(adatum.Value[0:2])
+						case nl.TCA_CTINFO_PARMS_DSCP_MASK:
+							action.(*CtInfoAction).DscpMask = native.Uint32(adatum.Value[0:4])
+						case nl.TCA_CTINFO_PARMS_DSCP_STATEMASK:
+							action.(*CtInfoAction).DscpStateMask = native.Uint32(adatum.Value[0:4])
+						case nl.TCA_CTINFO_PARMS_CPMARK_MASK:
+							action.(*CtInfoAction).CpMarkMask = native.Uint32(adatum.Value[0:4])
+						case nl.TCA_CTINFO_STATS_DSCP_SET:
+							action.(*CtInfoAction).DscpSet = native.Uint64(adatum.Value[0:8])
+						case nl.TCA_CTINFO_STATS_DSCP_ERROR:
+							action.(*CtInfoAction).DscpError = native.Uint64(adatum.Value[0:8])
+						case nl.TCA_CTINFO_STATS_CPMARK_SET:
+							action.(*CtInfoAction).CpMarkSet = native.Uint64(adatum.Value[0:8])
+						case nl.TCA_CTINFO_TM:
+							tcTs := nl.DeserializeTcf(adatum.Value)
+							actionTimestamp = toTimeStamp(tcTs)
+						}
 					case "bpf":
 						switch adatum.Attr.Type {
 						case nl.TCA_ACT_BPF_PARMS:
@@ -1020,6 +1719,14 @@ func parseActions(tables []syscall.NetlinkRouteAttr) ([]Action, error) {
 						parsePolice(adatum, action.(*PoliceAction))
 					}
 				}
+			case nl.TCA_ACT_COOKIE:
+				actionCookie = append([]byte(nil), aattr.Value...)
+			case nl.TCA_ACT_HW_STATS:
+				hwStats := ActHwStats(nl.DeserializeUint32Bitfield(aattr.Value).Value)
+				actionHwStats = &hwStats
+			case nl.TCA_ACT_USED_HW_STATS:
+				usedHwStats := ActHwStats(nl.DeserializeUint32Bitfield(aattr.Value).Value)
+				actionUsedHwStats = &usedHwStats
 			case nl.TCA_ACT_STATS:
 				s, err := parseTcStats2(aattr.Value)
 				if err != nil {
@@ -1031,6 +1738,9 @@ func parseActions(tables []syscall.NetlinkRouteAttr) ([]Action, error) {
 		if action != nil {
 			action.Attrs().Statistics = actionnStatistic
 			action.Attrs().Timestamp = actionTimestamp
+			action.Attrs().Cookie = actionCookie
+			action.Attrs().HwStatsType = actionHwStats
+			action.Attrs().UsedHwStats = actionUsedHwStats
 			actions = append(actions, action)
 		}
 	}
@@ -1070,12 +1780,7 @@ func parseU32Data(filter Filter, data []syscall.NetlinkRouteAttr) (bool, error)
 				}
 			}
 		case nl.TCA_U32_POLICE:
-			var police PoliceAction
-			adata, _ := nl.ParseRouteAttr(datum.Value)
-			for _, aattr := range adata {
-				parsePolice(aattr, &police)
-			}
-			u32.Police = &police
+			u32.Police = parseFilterPolice(datum.Value)
 		case nl.TCA_U32_CLASSID:
 			u32.ClassId = native.Uint32(datum.Value)
 		case nl.TCA_U32_DIVISOR:
@@ -1084,6 +1789,8 @@ func parseU32Data(filter Filter, data []syscall.NetlinkRouteAttr) (bool, error)
 			u32.Hash = native.Uint32(datum.Value)
 		case nl.TCA_U32_LINK:
 			u32.Link = native.Uint32(datum.Value)
+		case nl.TCA_U32_FLAGS:
+			u32.Flags = native.Uint32(datum.Value[0:4])
 		}
 	}
 	return detailed, nil
@@ -1101,12 +1808,7 @@ func parseFwData(filter Filter, data []syscall.NetlinkRouteAttr) (bool, error) {
 		case nl.TCA_FW_INDEV:
 			fw.InDev = string(datum.Value[:len(datum.Value)-1])
 		case nl.TCA_FW_POLICE:
-			var police PoliceAction
-			adata, _ := nl.ParseRouteAttr(datum.Value)
-			for _, aattr := range adata {
-				parsePolice(aattr, &police)
-			}
-			fw.Police = &police
+			fw.Police = parseFilterPolice(datum.Value)
 		case nl.TCA_FW_ACT:
 			tables, err := nl.ParseRouteAttr(datum.Value)
 			if err != nil {
@@ -1153,6 +1855,8 @@ func parseMatchAllData(filter Filter, data []syscall.NetlinkRouteAttr) (bool, er
 		switch datum.Attr.Type {
 		case nl.TCA_MATCHALL_CLASSID:
 			matchall.ClassId = native.Uint32(datum.Value[0:4])
+		case nl.TCA_MATCHALL_FLAGS:
+			matchall.Flags = native.Uint32(datum.Value[0:4])
 		case nl.TCA_MATCHALL_ACT:
 			tables, err := nl.ParseRouteAttr(datum.Value)
 			if err != nil {
@@ -1167,6 +1871,12 @@ func parseMatchAllData(filter Filter, data []syscall.NetlinkRouteAttr) (bool, er
 	return detailed, nil
 }
 
+// filterFlags returns the TCA_CLS_FLAGS_* of attrs that may be sent to the
+// kernel, dropping the read only offload status bits.
+func filterFlags(attrs *FilterAttrs) uint32 {
+	return attrs.Flags &^ (nl.TCA_CLS_FLAGS_IN_HW | nl.TCA_CLS_FLAGS_NOT_IN_HW)
+}
+
 func parseFlowerData(filter Filter, data []syscall.NetlinkRouteAttr) (bool, error) {
 	return true, filter.(*Flower).decode(data)
 }
@@ -4,6 +4,9 @@
 package netlink
 
 import (
+	"bytes"
+	"encoding/binary"
+	"errors"
 	"net"
 	"reflect"
 	"testing"
@@ -84,6 +87,21 @@ func TestFilterAddDel(t *testing.T) {
 	if u32.ClassId != classId {
 		t.Fatalf("ClassId of the filter is the wrong value")
 	}
+	got, err := FilterGet(link, u32.Parent, u32.Handle, u32.Priority, u32.Protocol)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotU32, ok := got.(*U32)
+	if !ok {
+		t.Fatal("FilterGet returned the wrong type")
+	}
+	if gotU32.Handle != u32.Handle || gotU32.ClassId != u32.ClassId || gotU32.Priority != u32.Priority ||
+		gotU32.Protocol != u32.Protocol || !reflect.DeepEqual(gotU32.Sel, u32.Sel) {
+		t.Fatalf("FilterGet %+v doesn't match FilterList %+v", gotU32, u32)
+	}
+	if _, err := FilterGet(link, u32.Parent, u32.Handle+1, u32.Priority, u32.Protocol); !errors.Is(err, ErrFilterNotFound) {
+		t.Fatalf("expected ErrFilterNotFound, got %v", err)
+	}
 	if err := FilterDel(filter); err != nil {
 		t.Fatal(err)
 	}
@@ -166,6 +184,217 @@ func TestFilterReplace(t *testing.T) {
 	}
 }
 
+func TestFilterAddReturnsHandle(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+	if err := LinkAdd(&Ifb{LinkAttrs{Name: "foo"}}); err != nil {
+		t.Fatal(err)
+	}
+	link, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkSetUp(link); err != nil {
+		t.Fatal(err)
+	}
+	qdisc := &Ingress{
+		QdiscAttrs: QdiscAttrs{
+			LinkIndex: link.Attrs().Index,
+			Handle:    MakeHandle(0xffff, 0),
+			Parent:    HANDLE_INGRESS,
+		},
+	}
+	if err := QdiscAdd(qdisc); err != nil {
+		t.Fatal(err)
+	}
+
+	filter := &U32{
+		FilterAttrs: FilterAttrs{
+			LinkIndex: link.Attrs().Index,
+			Parent:    MakeHandle(0xffff, 0),
+			Priority:  1,
+			Protocol:  unix.ETH_P_IP,
+		},
+		ClassId: MakeHandle(1, 1),
+	}
+	if err := FilterChange(filter); err == nil {
+		t.Fatal("FilterChange of a missing filter succeeded")
+	}
+	if err := FilterAdd(filter); err != nil {
+		t.Fatal(err)
+	}
+	if filter.Handle == 0 {
+		t.Fatal("FilterAdd did not report the kernel assigned handle")
+	}
+
+	filter.ClassId = MakeHandle(1, 2)
+	if err := FilterChange(filter); err != nil {
+		t.Fatal(err)
+	}
+	filters, err := FilterList(link, MakeHandle(0xffff, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filters) != 1 {
+		t.Fatal("Failed to add filter")
+	}
+	u32, ok := filters[0].(*U32)
+	if !ok {
+		t.Fatal("Filter is the wrong type")
+	}
+	if u32.Handle != filter.Handle || u32.ClassId != filter.ClassId {
+		t.Fatalf("Filter not changed in place, got handle %x classid %x", u32.Handle, u32.ClassId)
+	}
+
+	// delete by the echoed handle only, leaving the selector unset
+	toDel := &U32{
+		FilterAttrs: FilterAttrs{
+			LinkIndex: link.Attrs().Index,
+			Parent:    MakeHandle(0xffff, 0),
+			Handle:    filter.Handle,
+			Priority:  filter.Priority,
+			Protocol:  unix.ETH_P_IP,
+		},
+	}
+	if err := FilterDel(toDel); err != nil {
+		t.Fatal(err)
+	}
+	filters, err = FilterList(link, MakeHandle(0xffff, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filters) != 0 {
+		t.Fatal("Failed to remove filter")
+	}
+}
+
+func TestFilterClsFlags(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+	if err := LinkAdd(&Veth{LinkAttrs: LinkAttrs{Name: "foo"}, PeerName: "bar"}); err != nil {
+		t.Fatal(err)
+	}
+	link, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkSetUp(link); err != nil {
+		t.Fatal(err)
+	}
+	qdisc := &Ingress{
+		QdiscAttrs: QdiscAttrs{
+			LinkIndex: link.Attrs().Index,
+			Handle:    MakeHandle(0xffff, 0),
+			Parent:    HANDLE_INGRESS,
+		},
+	}
+	if err := QdiscAdd(qdisc); err != nil {
+		t.Fatal(err)
+	}
+
+	filter := &U32{
+		FilterAttrs: FilterAttrs{
+			LinkIndex: link.Attrs().Index,
+			Parent:    MakeHandle(0xffff, 0),
+			Priority:  1,
+			Protocol:  unix.ETH_P_IP,
+			// the read only status bits must be ignored on add
+			Flags: TC_CLS_FLAGS_SKIP_HW | TC_CLS_FLAGS_IN_HW,
+		},
+		ClassId: MakeHandle(1, 1),
+	}
+	if err := FilterAdd(filter); err != nil {
+		t.Fatal(err)
+	}
+	filters, err := FilterList(link, MakeHandle(0xffff, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, f := range filters {
+		u32, ok := f.(*U32)
+		if !ok || u32.ClassId != filter.ClassId {
+			continue
+		}
+		found = true
+		if u32.Flags&TC_CLS_FLAGS_SKIP_HW == 0 {
+			t.Fatalf("skip_hw flag not reported, got flags %#x", u32.Flags)
+		}
+		// a veth cannot offload, so the filter can only be not_in_hw
+		if u32.Flags&TC_CLS_FLAGS_IN_HW != 0 {
+			t.Fatalf("software only filter reported in_hw, got flags %#x", u32.Flags)
+		}
+	}
+	if !found {
+		t.Fatal("Failed to add filter")
+	}
+}
+
+func TestFilterActionHwStats(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+	if err := LinkAdd(&Veth{LinkAttrs: LinkAttrs{Name: "foo"}, PeerName: "bar"}); err != nil {
+		t.Fatal(err)
+	}
+	link, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	redir, err := LinkByName("bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	qdisc := &Ingress{
+		QdiscAttrs: QdiscAttrs{
+			LinkIndex: link.Attrs().Index,
+			Handle:    MakeHandle(0xffff, 0),
+			Parent:    HANDLE_INGRESS,
+		},
+	}
+	if err := QdiscAdd(qdisc); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, hwStats := range []ActHwStats{TCA_ACT_HW_STATS_DELAYED, TCA_ACT_HW_STATS_DISABLED} {
+		mirred := NewMirredAction(redir.Attrs().Index)
+		mirred.HwStatsType = &hwStats
+		filter := &U32{
+			FilterAttrs: FilterAttrs{
+				LinkIndex: link.Attrs().Index,
+				Parent:    MakeHandle(0xffff, 0),
+				Priority:  1,
+				Protocol:  unix.ETH_P_ALL,
+			},
+			Actions: []Action{mirred},
+		}
+		if err := FilterAdd(filter); err != nil {
+			t.Fatal(err)
+		}
+		filters, err := FilterList(link, MakeHandle(0xffff, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(filters) != 1 || len(filters[0].(*U32).Actions) != 1 {
+			t.Fatal("Failed to add filter")
+		}
+		attrs := filters[0].(*U32).Actions[0].Attrs()
+		if attrs.HwStatsType == nil || *attrs.HwStatsType != hwStats {
+			t.Fatalf("hw stats type %#x not echoed, got %v", hwStats, attrs.HwStatsType)
+		}
+		// a veth never offloads, so the driver does not report a type
+		if attrs.UsedHwStats != nil {
+			t.Fatalf("unexpected used hw stats %#x", *attrs.UsedHwStats)
+		}
+		if err := FilterDel(filter); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	invalid := ActHwStats(1 << 2)
+	mirred := NewMirredAction(redir.Attrs().Index)
+	mirred.HwStatsType = &invalid
+	if err := EncodeActions(nl.NewRtAttr(nl.TCA_U32_ACT, nil), []Action{mirred}); err == nil {
+		t.Fatal("invalid hw stats type should fail")
+	}
+}
+
 func TestAdvancedFilterAddDel(t *testing.T) {
 	t.Cleanup(setUpNetlinkTest(t))
 	if err := LinkAdd(&Ifb{LinkAttrs{Name: "baz"}}); err != nil {
@@ -214,9 +443,18 @@ func TestAdvancedFilterAddDel(t *testing.T) {
 		Buffer: 32 * 1024,
 	}
 	htbClass := NewHtbClass(classAttrs, htbClassAttrs)
+	if err = ClassChange(htbClass); !errors.Is(err, ErrClassNotFound) {
+		t.Fatalf("ClassChange of a missing class returned %v, expected ErrClassNotFound", err)
+	}
 	if err = ClassReplace(htbClass); err != nil {
 		t.Fatalf("Failed to add a HTB class: %v", err)
 	}
+	if err = ClassAdd(htbClass); !errors.Is(err, ErrClassExists) || !errors.Is(err, unix.EEXIST) {
+		t.Fatalf("ClassAdd of an existing class returned %v, expected ErrClassExists", err)
+	}
+	if err = ClassChange(htbClass); err != nil {
+		t.Fatalf("Failed to change a HTB class: %v", err)
+	}
 	classes, err := SafeClassList(link, qdiscHandle)
 	if err != nil {
 		t.Fatal(err)
@@ -435,7 +673,7 @@ func TestFilterFwAddDel(t *testing.T) {
 	filterattrs := FilterAttrs{
 		LinkIndex: link.Attrs().Index,
 		Parent:    MakeHandle(0xffff, 0),
-		Handle:    MakeHandle(0, 0x6),
+		Handle:    MakeHandle(0, 0x10),
 		Priority:  1,
 		Protocol:  unix.ETH_P_IP,
 	}
@@ -443,9 +681,17 @@ func TestFilterFwAddDel(t *testing.T) {
 	filter := FwFilter{
 		FilterAttrs: filterattrs,
 		ClassId:     MakeHandle(0xffff, 2),
+		Mask:        0xf0,
 		Police:      police,
 	}
 
+	// the handle may only carry the masked mark
+	outside := filter
+	outside.Handle = MakeHandle(0, 0x11)
+	if err := FilterAdd(&outside); err == nil {
+		t.Fatal("filter with handle bits outside of the mask added")
+	}
+
 	if err := FilterAdd(&filter); err != nil {
 		t.Fatal(err)
 	}
@@ -467,6 +713,12 @@ func TestFilterFwAddDel(t *testing.T) {
 	if fw.ClassId != filter.ClassId {
 		t.Fatal("ClassId doesn't match")
 	}
+	if fw.Handle != filter.Handle {
+		t.Fatalf("Handle %#x doesn't match %#x", fw.Handle, filter.Handle)
+	}
+	if fw.Mask != filter.Mask {
+		t.Fatalf("Mask %#x doesn't match %#x", fw.Mask, filter.Mask)
+	}
 	if fw.InDev != filter.InDev {
 		t.Fatal("InDev doesn't match")
 	}
@@ -565,6 +817,7 @@ func TestFilterFwActAddDel(t *testing.T) {
 			&MirredAction{
 				ActionAttrs: ActionAttrs{
 					Action: TC_ACT_STOLEN,
+					Cookie: make([]byte, nl.TC_COOKIE_MAX_SIZE+1),
 				},
 				MirredAction: TCA_EGRESS_REDIR,
 				Ifindex:      redir.Attrs().Index,
@@ -572,6 +825,11 @@ func TestFilterFwActAddDel(t *testing.T) {
 		},
 	}
 
+	if err := FilterAdd(filter); err == nil {
+		t.Fatal("FilterAdd should fail for an oversized action cookie")
+	}
+	cookie := []byte("0123456789abcdef")
+	filter.Actions[0].Attrs().Cookie = cookie
 	if err := FilterAdd(filter); err != nil {
 		t.Fatal(err)
 	}
@@ -612,6 +870,10 @@ func TestFilterFwActAddDel(t *testing.T) {
 		t.Fatal("Unmatched redirect index")
 	}
 
+	if !bytes.Equal(mia.Cookie, cookie) {
+		t.Fatalf("Action cookie doesn't match, got %x", mia.Cookie)
+	}
+
 	if err := FilterDel(filter); err != nil {
 		t.Fatal(err)
 	}
@@ -635,6 +897,233 @@ func TestFilterFwActAddDel(t *testing.T) {
 	}
 }
 
+func TestU32MatchKeys(t *testing.T) {
+	mustParseCIDR := func(s string) *net.IPNet {
+		_, ipnet, err := net.ParseCIDR(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ipnet
+	}
+	tests := []struct {
+		name string
+		keys [][]TcU32Key
+		// keys as printed by `tc filter show` for the equivalent tc matches
+		want []TcU32Key
+	}{
+		{
+			// match ip src 10.1.0.0/16 match ip dst 192.168.1.1/32
+			// match ip protocol 6 0xff match ip dport 80 0xffff
+			// match ip sport 1234 0xffff
+			name: "ipv4",
+			keys: [][]TcU32Key{
+				U32MatchIPSrc(mustParseCIDR("10.1.0.0/16")),
+				U32MatchIPDst(mustParseCIDR("192.168.1.1/32")),
+				U32MatchL4DstPort(FAMILY_V4, unix.IPPROTO_TCP, 80),
+				U32MatchL4SrcPort(FAMILY_V4, unix.IPPROTO_TCP, 1234),
+			},
+			want: []TcU32Key{
+				{Val: 0x0a010000, Mask: 0xffff0000, Off: 12},
+				{Val: 0xc0a80101, Mask: 0xffffffff, Off: 16},
+				{Val: 0x00060000, Mask: 0x00ff0000, Off: 8},
+				{Val: 0x04d20050, Mask: 0xffffffff, Off: 20},
+			},
+		},
+		{
+			// match ip dst 10.0.0.0/12
+			name: "ipv4 prefix",
+			keys: [][]TcU32Key{U32MatchIPDst(mustParseCIDR("10.0.0.0/12"))},
+			want: []TcU32Key{{Val: 0x0a000000, Mask: 0xfff00000, Off: 16}},
+		},
+		{
+			// match ip6 src 2001:db8::/32 match ip6 dst 2001:db8:1:2::1/128
+			// match ip6 protocol 17 0xff match ip6 dport 53 0xffff
+			// match ip6 sport 5353 0xffff
+			name: "ipv6",
+			keys: [][]TcU32Key{
+				U32MatchIPSrc(mustParseCIDR("2001:db8::/32")),
+				U32MatchIPDst(mustParseCIDR("2001:db8:1:2::1/128")),
+				U32MatchL4DstPort(FAMILY_V6, unix.IPPROTO_UDP, 53),
+				U32MatchL4SrcPort(FAMILY_V6, unix.IPPROTO_UDP, 5353),
+			},
+			want: []TcU32Key{
+				{Val: 0x20010db8, Mask: 0xffffffff, Off: 8},
+				{Val: 0x20010db8, Mask: 0xffffffff, Off: 24},
+				{Val: 0x00010002, Mask: 0xffffffff, Off: 28},
+				{Val: 0x00000000, Mask: 0xffffffff, Off: 32},
+				{Val: 0x00000001, Mask: 0xffffffff, Off: 36},
+				{Val: 0x00001100, Mask: 0x0000ff00, Off: 4},
+				{Val: 0x14e90035, Mask: 0xffffffff, Off: 40},
+			},
+		},
+		{
+			// match ip6 src 2001:db8:aa00::/40
+			name: "ipv6 prefix",
+			keys: [][]TcU32Key{U32MatchIPSrc(mustParseCIDR("2001:db8:aa00::/40"))},
+			want: []TcU32Key{
+				{Val: 0x20010db8, Mask: 0xffffffff, Off: 8},
+				{Val: 0xaa000000, Mask: 0xff000000, Off: 12},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sel, err := NewU32Sel(tt.keys...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sel.Flags != TC_U32_TERMINAL || int(sel.Nkeys) != len(tt.want) {
+				t.Fatalf("unexpected sel flags %#x with %d keys", sel.Flags, sel.Nkeys)
+			}
+			if !reflect.DeepEqual(sel.Keys, tt.want) {
+				t.Fatalf("got keys %+v, want %+v", sel.Keys, tt.want)
+			}
+		})
+	}
+
+	if _, err := NewU32Sel(
+		U32MatchL4DstPort(FAMILY_V4, unix.IPPROTO_TCP, 80),
+		U32MatchL4DstPort(FAMILY_V4, unix.IPPROTO_UDP, 80),
+	); err == nil {
+		t.Fatal("conflicting protocol keys should fail")
+	}
+}
+
+func TestU32HashTableHelpers(t *testing.T) {
+	htid := uint32(10)
+	attrs := FilterAttrs{Parent: MakeHandle(0xffff, 0), Priority: 200}
+	table := NewU32HashTable(attrs, htid, 8)
+	if table.Handle != htid<<20 || table.Divisor != 8 || table.Parent != attrs.Parent || table.Priority != 200 {
+		t.Fatalf("unexpected hash table filter: %+v", table)
+	}
+	if h := U32HashBucket(htid, 3); h != htid<<20|3<<12 {
+		t.Fatalf("expected hash %#x, got %#x", htid<<20|3<<12, h)
+	}
+
+	sel := U32VarOffsetIPv4HeaderLen()
+	if sel.Flags != TC_U32_VAROFFSET|TC_U32_EAT || sel.Offmask != 0x0f00 || sel.Offshift != 6 ||
+		sel.Offoff != 0 || sel.Nkeys != 1 || len(sel.Keys) != 1 || sel.Keys[0] != (TcU32Key{}) {
+		t.Fatalf("unexpected selector: %+v", sel)
+	}
+	// The kernel adds ntohs(offmask & data) >> offshift to the offset.
+	for ihl, want := range map[byte]uint16{5: 20, 6: 24, 15: 60} {
+		hdr := []byte{0x40 | ihl, 0}
+		if got := (binary.BigEndian.Uint16(hdr) & sel.Offmask) >> sel.Offshift; got != want {
+			t.Errorf("ihl %d: expected offset %d, got %d", ihl, want, got)
+		}
+	}
+
+	u32 := &U32{Sel: sel}
+	u32.LinkHashTable(htid, 0, 0x0000ff00)
+	if u32.Link != htid<<20 || u32.Sel.Hoff != 0 || u32.Sel.Hmask != 0x0000ff00 {
+		t.Fatalf("unexpected link: %+v %+v", u32, u32.Sel)
+	}
+
+	u32 = &U32{Sel: &TcU32Sel{Flags: TC_U32_TERMINAL | TC_U32_VAROFFSET}}
+	u32.LinkHashTable(htid, 4, 0xff)
+	if u32.Sel.Flags != TC_U32_TERMINAL|TC_U32_VAROFFSET || u32.Sel.Hoff != 4 || u32.Sel.Hmask != 0xff {
+		t.Fatalf("expected the selector flags to be kept, got %+v", u32.Sel)
+	}
+	u32 = &U32{}
+	u32.LinkHashTable(htid, 0, 0xff)
+	if u32.Sel == nil || u32.Sel.Nkeys != 1 || u32.Sel.Flags != 0 {
+		t.Fatalf("expected a match all selector, got %+v", u32.Sel)
+	}
+}
+
+func TestFilterU32MatchPolice(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+	if err := LinkAdd(&Veth{LinkAttrs: LinkAttrs{Name: "foo"}, PeerName: "bar"}); err != nil {
+		t.Fatal(err)
+	}
+	link, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	peer, err := LinkByName("bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkSetUp(link); err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkSetUp(peer); err != nil {
+		t.Fatal(err)
+	}
+	qdisc := &Ingress{
+		QdiscAttrs: QdiscAttrs{
+			LinkIndex: peer.Attrs().Index,
+			Handle:    MakeHandle(0xffff, 0),
+			Parent:    HANDLE_INGRESS,
+		},
+	}
+	if err := QdiscAdd(qdisc); err != nil {
+		t.Fatal(err)
+	}
+
+	sel, err := NewU32Sel(
+		U32MatchIPDst(&net.IPNet{IP: net.IPv4(10, 9, 9, 2), Mask: net.CIDRMask(32, 32)}),
+		U32MatchL4DstPort(FAMILY_V4, unix.IPPROTO_UDP, 4789),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	police := NewPoliceAction()
+	police.Rate = 1000
+	police.Burst = 1000
+	police.ExceedAction = TC_POLICE_SHOT
+	filter := &U32{
+		FilterAttrs: FilterAttrs{
+			LinkIndex: peer.Attrs().Index,
+			Parent:    MakeHandle(0xffff, 0),
+			Priority:  1,
+			Protocol:  unix.ETH_P_IP,
+		},
+		Sel:     sel,
+		Actions: []Action{police},
+	}
+	if err := FilterAdd(filter); err != nil {
+		t.Fatal(err)
+	}
+
+	udpFrame := func(dport uint16) []byte {
+		frame := make([]byte, 14+20+8+100)
+		copy(frame, peer.Attrs().HardwareAddr)
+		copy(frame[6:], link.Attrs().HardwareAddr)
+		frame[12], frame[13] = 0x08, 0x00
+		ip := frame[14:]
+		ip[0], ip[8], ip[9] = 0x45, 64, unix.IPPROTO_UDP
+		binary.BigEndian.PutUint16(ip[2:], uint16(len(ip)))
+		copy(ip[12:], net.IPv4(10, 9, 9, 1).To4())
+		copy(ip[16:], net.IPv4(10, 9, 9, 2).To4())
+		udp := ip[20:]
+		binary.BigEndian.PutUint16(udp[0:], 1000)
+		binary.BigEndian.PutUint16(udp[2:], dport)
+		binary.BigEndian.PutUint16(udp[4:], uint16(len(udp)))
+		return frame
+	}
+	sendRawFrames(t, link, udpFrame(4789), 20)
+	sendRawFrames(t, link, udpFrame(4790), 5)
+
+	filters, err := FilterList(peer, MakeHandle(0xffff, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filters) != 1 || len(filters[0].(*U32).Actions) != 1 {
+		t.Fatal("Failed to add filter")
+	}
+	stats := filters[0].(*U32).Actions[0].Attrs().Statistics
+	if stats == nil || stats.Basic == nil || stats.Queue == nil {
+		t.Fatal("police action has no statistics")
+	}
+	if stats.Basic.Packets != 20 {
+		t.Fatalf("expected the 20 matching packets to reach the police action, got %d", stats.Basic.Packets)
+	}
+	if stats.Queue.Drops == 0 {
+		t.Fatal("expected the police action to drop packets over the rate")
+	}
+}
+
 func TestFilterU32BpfAddDel(t *testing.T) {
 	t.Skipf("Fd does not match in ci")
 	t.Cleanup(setUpNetlinkTest(t))
@@ -826,6 +1315,7 @@ func TestFilterU32ConnmarkAddDel(t *testing.T) {
 				ActionAttrs: ActionAttrs{
 					Action: TC_ACT_PIPE,
 				},
+				Zone: 7,
 			},
 			&MirredAction{
 				ActionAttrs: ActionAttrs{
@@ -873,6 +1363,10 @@ func TestFilterU32ConnmarkAddDel(t *testing.T) {
 		t.Fatal("Connmark action isn't TC_ACT_PIPE")
 	}
 
+	if cma.Zone != 7 {
+		t.Fatalf("Connmark zone doesn't match: expected 7, got %d", cma.Zone)
+	}
+
 	mia, ok := u32.Actions[0].(*MirredAction)
 	if !ok {
 		mia, ok = u32.Actions[1].(*MirredAction)
@@ -1134,47 +1628,313 @@ func TestFilterClsActBpfAddDel(t *testing.T) {
 		t.Fatal("Failed to remove filter")
 	}
 
-	if err := QdiscDel(qdisc); err != nil {
+	if err := QdiscDel(qdisc); err != nil {
+		t.Fatal(err)
+	}
+	qdiscs, err := SafeQdiscList(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(qdiscs) != 0 {
+		t.Fatal("Failed to remove qdisc")
+	}
+}
+
+func TestFilterMatchAllAddDel(t *testing.T) {
+	// This classifier was added in kernel 4.7
+	minKernelRequired(t, 4, 7)
+
+	t.Cleanup(setUpNetlinkTest(t))
+	_, link := setupLinkForTestWithQdisc(t, "foo")
+	_, link2 := setupLinkForTestWithQdisc(t, "bar")
+	filter := &MatchAll{
+		FilterAttrs: FilterAttrs{
+			LinkIndex: link.Attrs().Index,
+			Parent:    HANDLE_MIN_EGRESS,
+			Priority:  32000,
+			Protocol:  unix.ETH_P_ALL,
+		},
+		Actions: []Action{
+			&MirredAction{
+				ActionAttrs: ActionAttrs{
+					Action: TC_ACT_STOLEN,
+				},
+				MirredAction: TCA_EGRESS_REDIR,
+				Ifindex:      link2.Attrs().Index,
+			},
+		},
+	}
+	if err := FilterAdd(filter); err != nil {
+		t.Fatal(err)
+	}
+
+	filters, err := FilterList(link, HANDLE_MIN_EGRESS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filters) != 1 {
+		t.Fatal("Failed to add filter")
+	}
+	matchall, ok := filters[0].(*MatchAll)
+	if !ok {
+		t.Fatal("Filter is the wrong type")
+	}
+
+	if matchall.Priority != 32000 {
+		t.Fatal("Filter priority does not match")
+	}
+
+	if len(matchall.Actions) != 1 {
+		t.Fatal("Filter has no actions")
+	}
+
+	mirredAction, ok := matchall.Actions[0].(*MirredAction)
+	if !ok {
+		t.Fatal("Action does not match")
+	}
+
+	if mirredAction.Ifindex != link2.Attrs().Index {
+		t.Fatal("Action ifindex does not match")
+	}
+
+	if err := FilterDel(filter); err != nil {
+		t.Fatal(err)
+	}
+	filters, err = FilterList(link, HANDLE_MIN_EGRESS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filters) != 0 {
+		t.Fatal("Failed to remove filter")
+	}
+
+}
+
+func TestFilterMatchAllSharedBlock(t *testing.T) {
+	// Shared blocks for clsact were added in kernel 4.16
+	minKernelRequired(t, 4, 16)
+
+	t.Cleanup(setUpNetlinkTest(t))
+	if err := LinkAdd(&Veth{LinkAttrs: LinkAttrs{Name: "foo"}, PeerName: "bar"}); err != nil {
+		t.Fatal(err)
+	}
+	var links []Link
+	block := uint32(22)
+	// a device can't bind the same block for ingress and egress
+	egressBlock := uint32(23)
+	for _, name := range []string{"foo", "bar"} {
+		link, err := LinkByName(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		qdisc := &Clsact{
+			QdiscAttrs: QdiscAttrs{
+				LinkIndex:    link.Attrs().Index,
+				Handle:       MakeHandle(0xffff, 0),
+				Parent:       HANDLE_CLSACT,
+				IngressBlock: &block,
+				EgressBlock:  &egressBlock,
+			},
+		}
+		if err := QdiscAdd(qdisc); err != nil {
+			t.Fatal(err)
+		}
+		qdiscs, err := SafeQdiscList(link)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(qdiscs) != 1 {
+			t.Fatal("Failed to add qdisc", len(qdiscs))
+		}
+		q := qdiscs[0].Attrs()
+		if q.IngressBlock == nil || *q.IngressBlock != block || q.EgressBlock == nil || *q.EgressBlock != egressBlock {
+			t.Fatalf("qdisc blocks do not match: %+v", q)
+		}
+		links = append(links, link)
+	}
+
+	filter := &MatchAll{
+		FilterAttrs: FilterAttrs{
+			Block:    &block,
+			Priority: 100,
+			Protocol: unix.ETH_P_ALL,
+		},
+		Actions: []Action{
+			&GenericAction{
+				ActionAttrs: ActionAttrs{
+					Action: TC_ACT_SHOT,
+				},
+			},
+		},
+	}
+	if err := FilterAdd(filter); err != nil {
+		t.Fatal(err)
+	}
+
+	filters, err := FilterListBlock(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filters) != 1 {
+		t.Fatalf("expected 1 filter in block %d, got %d", block, len(filters))
+	}
+	if _, ok := filters[0].(*MatchAll); !ok {
+		t.Fatal("Filter is the wrong type")
+	}
+	if b := filters[0].Attrs().Block; b == nil || *b != block || filters[0].Attrs().LinkIndex != 0 {
+		t.Fatalf("unexpected block filter attrs: %+v", filters[0].Attrs())
+	}
+
+	for _, link := range links {
+		filters, err := FilterList(link, HANDLE_MIN_INGRESS)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(filters) != 1 {
+			t.Fatalf("expected 1 filter on %s, got %d", link.Attrs().Name, len(filters))
+		}
+		if _, ok := filters[0].(*MatchAll); !ok {
+			t.Fatal("Filter is the wrong type")
+		}
+		if filters[0].Attrs().Priority != 100 {
+			t.Fatal("Filter priority does not match")
+		}
+	}
+
+	if err := FilterDel(filter); err != nil {
+		t.Fatal(err)
+	}
+	filters, err = FilterListBlock(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filters) != 0 {
+		t.Fatal("Failed to remove filter")
+	}
+}
+
+func TestFilterMatchAllMplsAddDel(t *testing.T) {
+	// This action was added in kernel 5.3
+	minKernelRequired(t, 5, 3)
+
+	t.Cleanup(setUpNetlinkTest(t))
+	_, link := setupLinkForTestWithQdisc(t, "foo")
+	label := uint32(1000)
+	tc := uint8(5)
+	bos := uint8(1)
+	mpls := NewMplsAction()
+	mpls.Action = TCA_MPLS_ACT_PUSH
+	mpls.Label = &label
+	mpls.TC = &tc
+	mpls.TTL = 64
+	mpls.BOS = &bos
+	filter := &MatchAll{
+		FilterAttrs: FilterAttrs{
+			LinkIndex: link.Attrs().Index,
+			Parent:    HANDLE_MIN_EGRESS,
+			Priority:  1,
+			Protocol:  unix.ETH_P_ALL,
+		},
+		Actions: []Action{mpls},
+	}
+
+	badLabel := uint32(MPLS_LABEL_MAX + 1)
+	mpls.Label = &badLabel
+	if err := FilterAdd(filter); err == nil {
+		t.Fatal("FilterAdd should fail for an out of range label")
+	}
+	mpls.Label = nil
+	if err := FilterAdd(filter); err == nil {
+		t.Fatal("FilterAdd should fail for a push without label")
+	}
+	mpls.Label = &label
+
+	if err := FilterAdd(filter); err != nil {
+		t.Fatal(err)
+	}
+	filters, err := FilterList(link, HANDLE_MIN_EGRESS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filters) != 1 {
+		t.Fatal("Failed to add filter")
+	}
+	matchall, ok := filters[0].(*MatchAll)
+	if !ok {
+		t.Fatal("Filter is the wrong type")
+	}
+	if len(matchall.Actions) != 1 {
+		t.Fatal("Filter has no actions")
+	}
+	got, ok := matchall.Actions[0].(*MplsAction)
+	if !ok {
+		t.Fatal("Action does not match")
+	}
+	if got.Action != TCA_MPLS_ACT_PUSH {
+		t.Fatalf("Mpls action does not match, got %d", got.Action)
+	}
+	if got.Attrs().Action != TC_ACT_PIPE {
+		t.Fatal("Mpls control action does not match")
+	}
+	if got.Proto != unix.ETH_P_MPLS_UC {
+		t.Fatalf("Mpls proto does not match, got %#x", got.Proto)
+	}
+	if got.Label == nil || *got.Label != label {
+		t.Fatal("Mpls label does not match")
+	}
+	if got.TC == nil || *got.TC != tc {
+		t.Fatal("Mpls tc does not match")
+	}
+	if got.TTL != 64 {
+		t.Fatal("Mpls ttl does not match")
+	}
+	if got.BOS == nil || *got.BOS != bos {
+		t.Fatal("Mpls bos does not match")
+	}
+
+	if err := FilterDel(filter); err != nil {
 		t.Fatal(err)
 	}
-	qdiscs, err := SafeQdiscList(link)
+	filters, err = FilterList(link, HANDLE_MIN_EGRESS)
 	if err != nil {
 		t.Fatal(err)
 	}
-	if len(qdiscs) != 0 {
-		t.Fatal("Failed to remove qdisc")
+	if len(filters) != 0 {
+		t.Fatal("Failed to remove filter")
 	}
 }
 
-func TestFilterMatchAllAddDel(t *testing.T) {
-	// This classifier was added in kernel 4.7
-	minKernelRequired(t, 4, 7)
+func TestFilterMatchAllGateAddDel(t *testing.T) {
+	// This action was added in kernel 5.8
+	minKernelRequired(t, 5, 8)
 
 	t.Cleanup(setUpNetlinkTest(t))
 	_, link := setupLinkForTestWithQdisc(t, "foo")
-	_, link2 := setupLinkForTestWithQdisc(t, "bar")
+	ipv := int32(3)
+	gate := NewGateAction()
+	gate.BaseTime = 1000000000
+	gate.Entries = []GateEntry{
+		{Open: true, Interval: 0},
+		{Open: false, Interval: 300000, IPV: &ipv},
+	}
 	filter := &MatchAll{
 		FilterAttrs: FilterAttrs{
 			LinkIndex: link.Attrs().Index,
-			Parent:    HANDLE_MIN_EGRESS,
-			Priority:  32000,
+			Parent:    HANDLE_MIN_INGRESS,
+			Priority:  1,
 			Protocol:  unix.ETH_P_ALL,
 		},
-		Actions: []Action{
-			&MirredAction{
-				ActionAttrs: ActionAttrs{
-					Action: TC_ACT_STOLEN,
-				},
-				MirredAction: TCA_EGRESS_REDIR,
-				Ifindex:      link2.Attrs().Index,
-			},
-		},
+		Actions: []Action{gate},
 	}
+	if err := FilterAdd(filter); err == nil {
+		t.Fatal("FilterAdd should fail for a zero gate interval")
+	}
+	gate.Entries[0].Interval = 200000
+
 	if err := FilterAdd(filter); err != nil {
 		t.Fatal(err)
 	}
-
-	filters, err := FilterList(link, HANDLE_MIN_EGRESS)
+	filters, err := FilterList(link, HANDLE_MIN_INGRESS)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -1185,35 +1945,42 @@ func TestFilterMatchAllAddDel(t *testing.T) {
 	if !ok {
 		t.Fatal("Filter is the wrong type")
 	}
-
-	if matchall.Priority != 32000 {
-		t.Fatal("Filter priority does not match")
-	}
-
 	if len(matchall.Actions) != 1 {
 		t.Fatal("Filter has no actions")
 	}
-
-	mirredAction, ok := matchall.Actions[0].(*MirredAction)
+	got, ok := matchall.Actions[0].(*GateAction)
 	if !ok {
 		t.Fatal("Action does not match")
 	}
-
-	if mirredAction.Ifindex != link2.Attrs().Index {
-		t.Fatal("Action ifindex does not match")
+	if got.BaseTime != gate.BaseTime {
+		t.Fatalf("Gate base time does not match, got %d", got.BaseTime)
+	}
+	if got.CycleTime != 500000 {
+		t.Fatalf("Gate cycle time should be the sum of the intervals, got %d", got.CycleTime)
+	}
+	if got.ClockID != gate.ClockID || got.Priority != gate.Priority {
+		t.Fatal("Gate clock id or priority does not match")
+	}
+	if len(got.Entries) != 2 {
+		t.Fatalf("Gate has %d entries, expected 2", len(got.Entries))
+	}
+	if !got.Entries[0].Open || got.Entries[0].Interval != 200000 || got.Entries[0].IPV != nil || got.Entries[0].MaxOctets != nil {
+		t.Fatalf("First gate entry does not match: %+v", got.Entries[0])
+	}
+	if got.Entries[1].Open || got.Entries[1].Interval != 300000 || got.Entries[1].IPV == nil || *got.Entries[1].IPV != ipv {
+		t.Fatalf("Second gate entry does not match: %+v", got.Entries[1])
 	}
 
 	if err := FilterDel(filter); err != nil {
 		t.Fatal(err)
 	}
-	filters, err = FilterList(link, HANDLE_MIN_EGRESS)
+	filters, err = FilterList(link, HANDLE_MIN_INGRESS)
 	if err != nil {
 		t.Fatal(err)
 	}
 	if len(filters) != 0 {
 		t.Fatal("Failed to remove filter")
 	}
-
 }
 
 func TestFilterU32TunnelKeyAddDel(t *testing.T) {
@@ -1563,6 +2330,136 @@ func TestFilterU32SkbEditAddDel(t *testing.T) {
 	}
 }
 
+// addU32ActionFilter attaches action to a u32 filter on the ingress of a new
+// ifb link and returns the action as reported by FilterList. The filter is
+// deleted again before returning.
+func addU32ActionFilter(t *testing.T, action Action) Action {
+	t.Helper()
+	if err := LinkAdd(&Ifb{LinkAttrs{Name: "foo"}}); err != nil {
+		t.Fatal(err)
+	}
+	link, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkSetUp(link); err != nil {
+		t.Fatal(err)
+	}
+	qdisc := &Ingress{
+		QdiscAttrs: QdiscAttrs{
+			LinkIndex: link.Attrs().Index,
+			Handle:    MakeHandle(0xffff, 0),
+			Parent:    HANDLE_INGRESS,
+		},
+	}
+	if err := QdiscAdd(qdisc); err != nil {
+		t.Fatal(err)
+	}
+
+	filter := &U32{
+		FilterAttrs: FilterAttrs{
+			LinkIndex: link.Attrs().Index,
+			Parent:    MakeHandle(0xffff, 0),
+			Priority:  1,
+			Protocol:  unix.ETH_P_ALL,
+		},
+		ClassId: MakeHandle(1, 1),
+		Actions: []Action{action},
+	}
+	if err := FilterAdd(filter); err != nil {
+		t.Fatal(err)
+	}
+	filters, err := FilterList(link, MakeHandle(0xffff, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filters) != 1 {
+		t.Fatal("Failed to add filter")
+	}
+	u32, ok := filters[0].(*U32)
+	if !ok {
+		t.Fatal("Filter is the wrong type")
+	}
+	if len(u32.Actions) != 1 {
+		t.Fatalf("Filter has %d actions, expected 1", len(u32.Actions))
+	}
+
+	if err := FilterDel(filter); err != nil {
+		t.Fatal(err)
+	}
+	filters, err = FilterList(link, MakeHandle(0xffff, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filters) != 0 {
+		t.Fatal("Failed to remove filter")
+	}
+	return u32.Actions[0]
+}
+
+func TestFilterU32SkbModAddDel(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+
+	if err := EncodeActions(nl.NewRtAttr(nl.TCA_U32_ACT, nil), []Action{NewSkbModAction()}); err == nil {
+		t.Fatal("skbmod action without anything to modify should fail")
+	}
+
+	skbmod := NewSkbModAction()
+	skbmod.DMac, _ = net.ParseMAC("02:00:00:00:00:01")
+	skbmod.SMac, _ = net.ParseMAC("02:00:00:00:00:02")
+	skbmod.EType = unix.ETH_P_8021Q
+
+	mod, ok := addU32ActionFilter(t, skbmod).(*SkbModAction)
+	if !ok {
+		t.Fatal("Unable to find skbmod action")
+	}
+	if mod.Attrs().Action != TC_ACT_PIPE {
+		t.Fatal("SkbMod action isn't TC_ACT_PIPE")
+	}
+	if mod.Flags != SKBMOD_F_DMAC|SKBMOD_F_SMAC|SKBMOD_F_ETYPE {
+		t.Fatalf("Action Flags don't match, got %#x", mod.Flags)
+	}
+	if mod.DMac.String() != skbmod.DMac.String() {
+		t.Fatal("Action DMac doesn't match")
+	}
+	if mod.SMac.String() != skbmod.SMac.String() {
+		t.Fatal("Action SMac doesn't match")
+	}
+	if mod.EType != skbmod.EType {
+		t.Fatal("Action EType doesn't match")
+	}
+}
+
+func TestFilterU32CtInfoAddDel(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+
+	ctinfo := NewCtInfoAction()
+	ctinfo.Zone = 1
+	ctinfo.DscpMask = 0xfc000000
+	ctinfo.DscpStateMask = 0x01000000
+	ctinfo.CpMarkMask = 0x00ffffff
+
+	info, ok := addU32ActionFilter(t, ctinfo).(*CtInfoAction)
+	if !ok {
+		t.Fatal("Unable to find ctinfo action")
+	}
+	if info.Attrs().Action != TC_ACT_PIPE {
+		t.Fatal("CtInfo action isn't TC_ACT_PIPE")
+	}
+	if info.Zone != ctinfo.Zone {
+		t.Fatal("Action Zone doesn't match")
+	}
+	if info.DscpMask != ctinfo.DscpMask {
+		t.Fatal("Action DscpMask doesn't match")
+	}
+	if info.DscpStateMask != ctinfo.DscpStateMask {
+		t.Fatal("Action DscpStateMask doesn't match")
+	}
+	if info.CpMarkMask != ctinfo.CpMarkMask {
+		t.Fatal("Action CpMarkMask doesn't match")
+	}
+}
+
 func TestFilterU32LinkOption(t *testing.T) {
 	t.Cleanup(setUpNetlinkTest(t))
 	if err := LinkAdd(&Ifb{LinkAttrs{Name: "foo"}}); err != nil {
@@ -2440,6 +3337,49 @@ func TestFilterU32PoliceAddDel(t *testing.T) {
 	}
 }
 
+func TestCalcRtableATM(t *testing.T) {
+	// Expected values were captured from the requests sent by iproute2 6.1:
+	//   tc ... action police rate 1mbit burst 10k mtu 2047 mpu 64 overhead 10 linklayer atm
+	//   tc class add ... htb rate 2mbit linklayer atm overhead 20
+	// An ATM cell carries 48 bytes of payload, six 8 byte slots of the table.
+	tests := []struct {
+		name     string
+		rate     nl.TcRateSpec
+		mtu      uint32
+		expected map[int]uint32
+	}{
+		{
+			name:     "police",
+			rate:     nl.TcRateSpec{Rate: 125000, Mpu: 64, Overhead: 10},
+			mtu:      2047,
+			expected: map[int]uint32{0: 13250, 11: 13250, 12: 19875, 17: 19875, 18: 26500, 251: 278250, 252: 284875, 255: 284875},
+		},
+		{
+			name:     "htb",
+			rate:     nl.TcRateSpec{Rate: 250000, Overhead: 20},
+			mtu:      1600,
+			expected: map[int]uint32{0: 3312, 5: 3312, 6: 6625, 11: 6625, 12: 9937, 255: 142437},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rtab [256]uint32
+			rate := tt.rate
+			if cellLog := CalcRtable(&rate, rtab[:], -1, tt.mtu, nl.LINKLAYER_ATM); cellLog != 3 {
+				t.Fatalf("expected cell log 3, got %d", cellLog)
+			}
+			if rate.Linklayer != nl.LINKLAYER_ATM || rate.CellAlign != -1 || rate.Overhead != tt.rate.Overhead {
+				t.Fatalf("unexpected rate spec %+v", rate)
+			}
+			for i, expected := range tt.expected {
+				if rtab[i] != expected {
+					t.Errorf("rtab[%d] = %d, expected %d", i, rtab[i], expected)
+				}
+			}
+		})
+	}
+}
+
 func TestFilterU32DirectPoliceAddDel(t *testing.T) {
 	t.Cleanup(setUpNetlinkTest(t))
 	if err := LinkAdd(&Ifb{LinkAttrs{Name: "foo"}}); err != nil {
@@ -2764,3 +3704,213 @@ func TestFilterSampleAddDel(t *testing.T) {
 		t.Fatal("Failed to remove qdisc")
 	}
 }
+
+func TestFilterDelByAttrs(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+	if err := LinkAdd(&Ifb{LinkAttrs{Name: "foo"}}); err != nil {
+		t.Fatal(err)
+	}
+	link, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkSetUp(link); err != nil {
+		t.Fatal(err)
+	}
+	qdisc := &Ingress{
+		QdiscAttrs: QdiscAttrs{
+			LinkIndex: link.Attrs().Index,
+			Handle:    MakeHandle(0xffff, 0),
+			Parent:    HANDLE_INGRESS,
+		},
+	}
+	if err := QdiscAdd(qdisc); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, dst := range []string{"10.0.0.1", "10.0.0.2"} {
+		filter := &Flower{
+			FilterAttrs: FilterAttrs{
+				LinkIndex: link.Attrs().Index,
+				Parent:    MakeHandle(0xffff, 0),
+				Priority:  5,
+				Protocol:  unix.ETH_P_IP,
+			},
+			EthType:    unix.ETH_P_IP,
+			DestIP:     net.ParseIP(dst),
+			DestIPMask: net.CIDRMask(32, 32),
+			Actions: []Action{
+				&GenericAction{ActionAttrs: ActionAttrs{Action: TC_ACT_SHOT}},
+			},
+		}
+		if err := FilterAdd(filter); err != nil {
+			t.Fatal(err)
+		}
+	}
+	filters, err := FilterList(link, MakeHandle(0xffff, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filters) != 2 {
+		t.Fatalf("expected 2 filters, got %d", len(filters))
+	}
+
+	if err := FilterDelByAttrs(link, FilterAttrs{
+		Parent:   MakeHandle(0xffff, 0),
+		Priority: 5,
+		Protocol: unix.ETH_P_IP,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	filters, err = FilterList(link, MakeHandle(0xffff, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filters) != 0 {
+		t.Fatalf("expected no filters after deleting the band, got %d", len(filters))
+	}
+}
+
+func TestMirredActionValidate(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+	if err := LinkAdd(&Ifb{LinkAttrs{Name: "foo"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkAdd(&Ifb{LinkAttrs{Name: "bar"}}); err != nil {
+		t.Fatal(err)
+	}
+	link, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkSetUp(link); err != nil {
+		t.Fatal(err)
+	}
+	target, err := LinkByName("bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	missing := &MirredAction{MirredAction: TCA_EGRESS_REDIR, Ifindex: 0x7fffffff}
+	if err := missing.Validate(nil); !errors.Is(err, ErrMirredTargetNotFound) {
+		t.Fatalf("expected ErrMirredTargetNotFound, got %v", err)
+	}
+	redir := NewMirredAction(target.Attrs().Index)
+	if err := redir.Validate(nil); !errors.Is(err, ErrMirredTargetDown) {
+		t.Fatalf("expected ErrMirredTargetDown, got %v", err)
+	}
+
+	h, err := NewHandle()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	h.ValidateMirredTargets()
+	filter := &U32{
+		FilterAttrs: FilterAttrs{
+			LinkIndex: link.Attrs().Index,
+			Parent:    MakeHandle(0xffff, 0),
+			Priority:  1,
+			Protocol:  unix.ETH_P_ALL,
+		},
+		Actions: []Action{redir},
+	}
+	if err := h.FilterAdd(filter); !errors.Is(err, ErrMirredTargetDown) {
+		t.Fatalf("expected FilterAdd to fail with ErrMirredTargetDown, got %v", err)
+	}
+
+	if err := LinkSetUp(target); err != nil {
+		t.Fatal(err)
+	}
+	if err := redir.Validate(h); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFilterPoliceAndActionsEncoding(t *testing.T) {
+	police := NewPoliceAction()
+	police.Rate = 0x40000000
+	police.Burst = 0x19000
+	police.ExceedAction = TC_POLICE_SHOT
+	mirred := NewMirredAction(7)
+
+	encode := func(police *PoliceAction, actions []Action) *nl.RtAttr {
+		options := nl.NewRtAttr(nl.TCA_OPTIONS, nil)
+		if err := encodePoliceAndActions(options, nl.TCA_U32_POLICE, nl.TCA_U32_ACT, police, actions); err != nil {
+			t.Fatal(err)
+		}
+		return options
+	}
+	decode := func(options *nl.RtAttr) *U32 {
+		attrs, err := nl.ParseRouteAttr(options.Serialize())
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := nl.ParseRouteAttr(attrs[0].Value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		u32 := &U32{}
+		if _, err := parseU32Data(u32, data); err != nil {
+			t.Fatal(err)
+		}
+		return u32
+	}
+
+	// a police alone is sent and read back the old way
+	u32 := decode(encode(police, nil))
+	if u32.Police == nil || u32.Police.Rate != police.Rate || u32.Police.ExceedAction != police.ExceedAction || len(u32.Actions) != 0 {
+		t.Fatalf("expected the police alone in Police, got %+v and %v", u32.Police, u32.Actions)
+	}
+
+	// along with actions it is sent as the first action, which the
+	// decoded filter re-serializes to the same bytes
+	both := encode(police, []Action{mirred})
+	u32 = decode(both)
+	if u32.Police != nil || len(u32.Actions) != 2 {
+		t.Fatalf("expected the police among the actions, got %+v and %v", u32.Police, u32.Actions)
+	}
+	decodedPolice, ok := u32.Actions[0].(*PoliceAction)
+	if !ok || decodedPolice.Rate != police.Rate || decodedPolice.ExceedAction != police.ExceedAction {
+		t.Fatalf("expected the police first, got %+v", u32.Actions[0])
+	}
+	if _, ok := u32.Actions[1].(*MirredAction); !ok {
+		t.Fatalf("expected the mirred action second, got %+v", u32.Actions[1])
+	}
+	if !bytes.Equal(both.Serialize(), encode(nil, []Action{police, mirred}).Serialize()) {
+		t.Fatal("police with actions encoded differently from the police as the first action")
+	}
+}
+
+func TestConntrackZoneActionsEncoding(t *testing.T) {
+	connmark := NewConnmarkAction()
+	connmark.Zone = 7
+	ctinfo := NewCtInfoAction()
+	ctinfo.Zone = 9
+
+	attr := nl.NewRtAttr(nl.TCA_U32_ACT, nil)
+	if err := EncodeActions(attr, []Action{connmark, ctinfo}); err != nil {
+		t.Fatal(err)
+	}
+	attrs, err := nl.ParseRouteAttr(attr.Serialize())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tables, err := nl.ParseRouteAttr(attrs[0].Value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actions, err := parseActions(tables)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actions) != 2 {
+		t.Fatalf("expected 2 actions, got %d", len(actions))
+	}
+	if cm, ok := actions[0].(*ConnmarkAction); !ok || cm.Zone != 7 {
+		t.Fatalf("expected connmark in zone 7, got %+v", actions[0])
+	}
+	if ci, ok := actions[1].(*CtInfoAction); !ok || ci.Zone != 9 {
+		t.Fatalf("expected ctinfo in zone 9, got %+v", actions[1])
+	}
+}
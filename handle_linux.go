@@ -1,7 +1,12 @@
 package netlink
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/vishvananda/netlink/nl"
@@ -15,6 +20,17 @@ var pkgHandle = &Handle{}
 type HandleOptions struct {
 	lookupByDump  bool
 	collectVFInfo bool
+	dumpRetries   int
+	trace         nl.TraceFunc
+	keepExtras    bool
+	skipBadMsgs   bool
+	checkMirred   bool
+
+	// socket options, set again on the sockets opened by Reconnect
+	sockTimeout time.Duration
+	rcvBufSize  int
+	rcvBufForce bool
+	strictCheck *bool
 }
 
 // Handle is a handle for the netlink requests on a
@@ -24,6 +40,10 @@ type HandleOptions struct {
 type Handle struct {
 	sockets map[int]*nl.SocketHandle
 	options HandleOptions
+	stats   nl.Stats
+	// nsIno is the inode of the network namespace the handle was created
+	// in, 0 for the namespace of the caller.
+	nsIno uint64
 }
 
 // DisableVFInfoCollection configures the handle to skip VF information fetching
@@ -32,6 +52,71 @@ func (h *Handle) DisableVFInfoCollection() *Handle {
 	return h
 }
 
+// RetryInterruptedDumps configures the handle to transparently re-issue
+// dump requests interrupted by concurrent changes (NLM_F_DUMP_INTR), up to
+// retries times. When the retries are exhausted, list functions return the
+// last partial result together with ErrDumpInterrupted, which is also the
+// behaviour when retries is 0 (the default). The Iter variants of the list
+// functions are not retried, their callback has already seen the entries
+// of the interrupted dump.
+func (h *Handle) RetryInterruptedDumps(retries int) *Handle {
+	h.options.dumpRetries = retries
+	return h
+}
+
+// RetainUnknownAttributes configures the handle to keep the top-level
+// attributes of links and routes which are newer than this library in
+// LinkAttrs.Extras and Route.Extras, so that they survive a read-modify-
+// write cycle. It is off by default because of the memory it costs.
+func (h *Handle) RetainUnknownAttributes() *Handle {
+	h.options.keepExtras = true
+	return h
+}
+
+// ContinueOnDecodeError configures the handle so that LinkList,
+// RouteList and RouteListFiltered(Iter) skip the messages they fail to
+// decode, instead of failing the whole dump. The entries decoded are
+// returned together with a *MultiDecodeError describing the messages
+// skipped.
+func (h *Handle) ContinueOnDecodeError() *Handle {
+	h.options.skipBadMsgs = true
+	return h
+}
+
+// ValidateMirredTargets configures the handle so that FilterAdd,
+// FilterChange and FilterReplace check the target link of every
+// MirredAction of the filter with MirredAction.Validate before sending
+// it, and fail when the target is missing or down. The kernel accepts
+// such filters, but the redirected or mirrored packets are dropped.
+func (h *Handle) ValidateMirredTargets() *Handle {
+	h.options.checkMirred = true
+	return h
+}
+
+// SetTraceFunc installs f to be called with every request sent through
+// the handle and every message received in response, before decoding,
+// which helps diagnosing errors such as EINVAL without strace.
+// nl.TraceWriter provides a TraceFunc printing the messages with
+// nl.FormatMessage. A nil f disables tracing.
+func (h *Handle) SetTraceFunc(f nl.TraceFunc) *Handle {
+	h.options.trace = f
+	return h
+}
+
+// Stats returns the counters of the requests executed through the handle
+// since it was created or last reset: requests sent by message type,
+// errors by errno, bytes sent and received and dump messages. They are
+// maintained with atomic operations and always enabled. The package level
+// functions share one handle.
+func (h *Handle) Stats() nl.StatsSnapshot {
+	return h.stats.Snapshot()
+}
+
+// ResetStats sets the counters returned by Stats back to zero.
+func (h *Handle) ResetStats() {
+	h.stats.Reset()
+}
+
 // SetSocketTimeout configures timeout for default netlink sockets
 func SetSocketTimeout(to time.Duration) error {
 	if to < time.Microsecond {
@@ -79,6 +164,7 @@ func (h *Handle) SetSocketTimeout(to time.Duration) error {
 			return err
 		}
 	}
+	h.options.sockTimeout = to
 	return nil
 }
 
@@ -97,6 +183,7 @@ func (h *Handle) SetSocketReceiveBufferSize(size int, force bool) error {
 			return err
 		}
 	}
+	h.options.rcvBufSize, h.options.rcvBufForce = size, force
 	return nil
 }
 
@@ -130,6 +217,7 @@ func (h *Handle) SetStrictCheck(state bool) error {
 			return err
 		}
 	}
+	h.options.strictCheck = &state
 	return nil
 }
 
@@ -162,9 +250,165 @@ func newHandle(newNs, curNs netns.NsHandle, nlFamilies ...int) (*Handle, error)
 		}
 		h.sockets[f] = &nl.SocketHandle{Socket: s}
 	}
+	if newNs.IsOpen() {
+		var st unix.Stat_t
+		if err := unix.Fstat(int(newNs), &st); err == nil {
+			h.nsIno = st.Ino
+		}
+	}
 	return h, nil
 }
 
+// Reconnect rebinds the handle to the network namespace ns, typically
+// after CheckNamespace returned ErrNamespaceGone, replacing its sockets
+// with new ones for the same netlink families. The options of the handle
+// are kept, including the socket timeout, receive buffer size and strict
+// check set on the previous sockets. If ns=netns.None(), the current
+// network namespace will be assumed. Reconnect must not be called
+// concurrently with other requests through the handle.
+func (h *Handle) Reconnect(ns netns.NsHandle) error {
+	fams := make([]int, 0, len(h.sockets))
+	for f := range h.sockets {
+		fams = append(fams, f)
+	}
+	nh, err := newHandle(ns, netns.None(), fams...)
+	if err != nil {
+		return err
+	}
+	nh.options = h.options
+	if err := nh.setSocketOptions(); err != nil {
+		nh.Close()
+		return err
+	}
+	old := h.sockets
+	h.sockets, h.nsIno = nh.sockets, nh.nsIno
+	for _, sh := range old {
+		sh.Close()
+	}
+	return nil
+}
+
+// setSocketOptions sets the socket options recorded in the options of
+// the handle on its sockets.
+func (h *Handle) setSocketOptions() error {
+	if h.options.sockTimeout != 0 {
+		if err := h.SetSocketTimeout(h.options.sockTimeout); err != nil {
+			return err
+		}
+	}
+	if h.options.rcvBufSize != 0 {
+		if err := h.SetSocketReceiveBufferSize(h.options.rcvBufSize, h.options.rcvBufForce); err != nil {
+			return err
+		}
+	}
+	if h.options.strictCheck != nil {
+		if err := h.SetStrictCheck(*h.options.strictCheck); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckNamespace returns ErrNamespaceGone if the handle was created in, or
+// reconnected to, another network namespace which was since deleted: it
+// is not bind mounted, as done by `ip netns add`, in the mount namespace
+// of the caller, and no thread of any process is in it or holds a file
+// descriptor to it. The sockets of the handle keep such a namespace
+// alive, so requests through the handle still succeed, but they act on a
+// namespace nobody else can reach. The check walks /proc and needs the
+// privileges to inspect the other processes: if some of them can't be
+// inspected and the namespace wasn't found elsewhere, ErrNamespaceUnchecked
+// is returned instead. Bind mounts made in other mount namespaces, for
+// instance by a container runtime, are not seen, so a namespace only kept
+// alive by one of them is reported gone. The check is meant to be run when
+// the namespace may have been deleted rather than before every request.
+func (h *Handle) CheckNamespace() error {
+	if h.nsIno == 0 {
+		return nil
+	}
+	name := fmt.Sprintf("net:[%d]", h.nsIno)
+	mounted, err := nsfsMounted(name)
+	if err != nil {
+		return err
+	}
+	if mounted {
+		return nil
+	}
+	procs, err := filepath.Glob("/proc/[0-9]*")
+	if err != nil {
+		return err
+	}
+	// Entries of processes which exit during the walk vanish, anything
+	// else that can't be read leaves the result open.
+	var unreadable error
+	readDir := func(dir string) []os.DirEntry {
+		entries, err := os.ReadDir(dir)
+		if err != nil && !errors.Is(err, os.ErrNotExist) && unreadable == nil {
+			unreadable = err
+		}
+		return entries
+	}
+	refersTo := func(path string) bool {
+		target, err := os.Readlink(path)
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) && unreadable == nil {
+				unreadable = err
+			}
+			return false
+		}
+		return target == name
+	}
+	for _, proc := range procs {
+		for _, task := range readDir(filepath.Join(proc, "task")) {
+			if refersTo(filepath.Join(proc, "task", task.Name(), "ns", "net")) {
+				return nil
+			}
+		}
+		for _, fd := range readDir(filepath.Join(proc, "fd")) {
+			if refersTo(filepath.Join(proc, "fd", fd.Name())) {
+				return nil
+			}
+		}
+	}
+	if unreadable != nil {
+		return fmt.Errorf("%w: %v", ErrNamespaceUnchecked, unreadable)
+	}
+	return ErrNamespaceGone
+}
+
+// inCallerNamespace reports whether the handle is in the network
+// namespace of the calling thread, so that what the thread reads from
+// /proc/sys/net describes the namespace of the handle.
+func (h *Handle) inCallerNamespace() bool {
+	if h.nsIno == 0 {
+		return true
+	}
+	var st unix.Stat_t
+	if err := unix.Stat(fmt.Sprintf("/proc/%d/task/%d/ns/net", os.Getpid(), unix.Gettid()), &st); err != nil {
+		return false
+	}
+	return st.Ino == h.nsIno
+}
+
+// nsfsMounted reports whether the namespace name, like "net:[4026531840]",
+// is bind mounted in the mount namespace of the caller.
+func nsfsMounted(name string) (bool, error) {
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// the fourth field is the root of the mount in its filesystem
+		fields := strings.Fields(scanner.Text())
+		if len(fields) > 3 && fields[3] == name {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
+
 // Close closes all netlink sockets held by this Handle.
 func (h *Handle) Close() error {
 	var firstErr error
@@ -188,7 +432,11 @@ func (h *Handle) Delete() {
 func (h *Handle) newNetlinkRequest(proto, flags int) *nl.NetlinkRequest {
 	// Do this so that package API still use nl package variable nextSeqNr
 	if h.sockets == nil {
-		return nl.NewNetlinkRequest(proto, flags)
+		req := nl.NewNetlinkRequest(proto, flags)
+		req.DumpRetries = h.options.dumpRetries
+		req.Trace = h.options.trace
+		req.Stats = &h.stats
+		return req
 	}
 	return &nl.NetlinkRequest{
 		NlMsghdr: unix.NlMsghdr{
@@ -196,6 +444,9 @@ func (h *Handle) newNetlinkRequest(proto, flags int) *nl.NetlinkRequest {
 			Type:  uint16(proto),
 			Flags: unix.NLM_F_REQUEST | uint16(flags),
 		},
-		Sockets: h.sockets,
+		Sockets:     h.sockets,
+		DumpRetries: h.options.dumpRetries,
+		Trace:       h.options.trace,
+		Stats:       &h.stats,
 	}
 }
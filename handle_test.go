@@ -6,16 +6,21 @@ package netlink
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"net"
+	"runtime"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
+	"unsafe"
 
 	"github.com/vishvananda/netlink/nl"
 	"github.com/vishvananda/netns"
+	"golang.org/x/sys/unix"
 )
 
 func TestHandleCreateClose(t *testing.T) {
@@ -140,6 +145,220 @@ func verifySockTimeVal(t *testing.T, socket *nl.NetlinkSocket, expTimeout time.D
 	}
 }
 
+func TestHandleReconnect(t *testing.T) {
+	skipUnlessRoot(t)
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	origNs, err := netns.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer origNs.Close()
+	defer netns.Set(origNs)
+
+	id := make([]byte, 4)
+	if _, err := io.ReadFull(rand.Reader, id); err != nil {
+		t.Fatal(err)
+	}
+	nsName := "gone-" + hex.EncodeToString(id)
+	ns, err := netns.NewNamed(nsName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := netns.Set(origNs); err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := NewHandleAt(ns, unix.NETLINK_ROUTE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	const timeout = time.Second
+	if err := h.SetSocketTimeout(timeout); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.SetStrictCheck(true); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.CheckNamespace(); err != nil {
+		t.Fatalf("namespace reported gone while mounted: %v", err)
+	}
+	if h.inCallerNamespace() {
+		t.Fatal("handle of another namespace reported in the namespace of the caller")
+	}
+	if !pkgHandle.inCallerNamespace() {
+		t.Fatal("package handle not reported in the namespace of the caller")
+	}
+
+	ns.Close()
+	if err := netns.DeleteNamed(nsName); err != nil {
+		t.Fatal(err)
+	}
+	switch err := h.CheckNamespace(); {
+	case errors.Is(err, ErrNamespaceUnchecked):
+		t.Logf("namespace not checked, other processes can't be inspected: %v", err)
+	case !errors.Is(err, ErrNamespaceGone):
+		t.Fatalf("expected ErrNamespaceGone, got %v", err)
+	}
+
+	newNs, err := netns.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer newNs.Close()
+	if err := netns.Set(origNs); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Reconnect(newNs); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.CheckNamespace(); err != nil {
+		t.Fatalf("namespace reported gone after reconnect: %v", err)
+	}
+	if len(h.sockets) != 1 || h.sockets[unix.NETLINK_ROUTE] == nil {
+		t.Fatalf("expected a single route socket, got %v", h.sockets)
+	}
+	sock := h.sockets[unix.NETLINK_ROUTE].Socket
+	verifySockTimeVal(t, sock, timeout)
+	if v, err := unix.GetsockoptInt(sock.GetFd(), unix.SOL_NETLINK, unix.NETLINK_GET_STRICT_CHK); err != nil || v != 1 {
+		t.Fatalf("expected strict check to be kept, got %d, %v", v, err)
+	}
+
+	if err := h.LinkAdd(&Ifb{LinkAttrs{Name: "foo"}}); err != nil {
+		t.Fatal(err)
+	}
+	nh, err := NewHandleAt(newNs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer nh.Close()
+	if _, err := nh.LinkByName("foo"); err != nil {
+		t.Fatalf("link added after reconnect not found in the new namespace: %v", err)
+	}
+}
+
+func TestHandleStats(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+
+	h, err := NewHandle(unix.NETLINK_ROUTE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	link := &Veth{LinkAttrs: LinkAttrs{Name: "foo"}, PeerName: "bar"}
+	if err := h.LinkAdd(link); err != nil {
+		t.Fatal(err)
+	}
+	links, err := h.LinkList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.LinkDel(link); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.LinkDel(link); !errors.Is(err, unix.ENODEV) || !errors.As(err, &LinkNotFoundError{}) {
+		t.Fatalf("expected LinkNotFoundError wrapping ENODEV deleting the link twice, got %v", err)
+	}
+
+	stats := h.Stats()
+	if stats.Requests[unix.RTM_NEWLINK] == 0 {
+		t.Fatalf("RTM_NEWLINK request not counted: %+v", stats)
+	}
+	if stats.Requests[unix.RTM_GETLINK] == 0 {
+		t.Fatalf("RTM_GETLINK request not counted: %+v", stats)
+	}
+	if n := stats.Requests[unix.RTM_DELLINK]; n != 2 {
+		t.Fatalf("expected 2 RTM_DELLINK requests, got %d", n)
+	}
+	if n := stats.Errors[unix.ENODEV]; n != 1 {
+		t.Fatalf("expected 1 ENODEV error, got %d", n)
+	}
+	if stats.Acks < 3 {
+		t.Fatalf("expected at least 3 acks, got %d", stats.Acks)
+	}
+	if stats.DumpMessages < uint64(len(links)) {
+		t.Fatalf("expected at least %d dump messages, got %d", len(links), stats.DumpMessages)
+	}
+	if stats.BytesSent == 0 || stats.BytesReceived == 0 {
+		t.Fatalf("bytes not counted: %+v", stats)
+	}
+
+	h.ResetStats()
+	stats = h.Stats()
+	if len(stats.Requests) != 0 || len(stats.Errors) != 0 || stats.Acks != 0 ||
+		stats.BytesSent != 0 || stats.BytesReceived != 0 || stats.DumpMessages != 0 {
+		t.Fatalf("counters not reset: %+v", stats)
+	}
+}
+
+func TestHandleTrace(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+
+	h, err := NewHandle(unix.NETLINK_ROUTE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	type traced struct {
+		dir   nl.Direction
+		proto int
+		msg   []byte
+	}
+	var msgs []traced
+	h.SetTraceFunc(func(dir nl.Direction, proto int, msg []byte) {
+		msgs = append(msgs, traced{dir, proto, append([]byte(nil), msg...)})
+	})
+	if err := h.LinkAdd(&Veth{LinkAttrs: LinkAttrs{Name: "foo"}, PeerName: "bar"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var req, ack *unix.NlMsghdr
+	for _, m := range msgs {
+		if m.proto != unix.NETLINK_ROUTE {
+			t.Fatalf("unexpected protocol %d", m.proto)
+		}
+		hdr := (*unix.NlMsghdr)(unsafe.Pointer(&m.msg[0]))
+		if int(hdr.Len) != len(m.msg) {
+			t.Fatalf("traced message length %d, header says %d", len(m.msg), hdr.Len)
+		}
+		switch {
+		case m.dir == nl.DirectionSend && hdr.Type == unix.RTM_NEWLINK:
+			req = hdr
+			out := nl.FormatMessage(m.proto, m.msg)
+			if !strings.HasPrefix(out, "RTM_NEWLINK ") || !strings.Contains(out, `IFLA_IFNAME (3) len=8: "foo"`) {
+				t.Fatalf("unexpected formatted request:\n%s", out)
+			}
+		case m.dir == nl.DirectionReceive && hdr.Type == unix.NLMSG_ERROR:
+			ack = hdr
+			if errno := nl.NativeEndian().Uint32(m.msg[unix.SizeofNlMsghdr:]); errno != 0 {
+				t.Fatalf("LinkAdd acked with error %d", int32(errno))
+			}
+			if out := nl.FormatMessage(m.proto, m.msg); !strings.Contains(out, "  ack\n") {
+				t.Fatalf("unexpected formatted ack:\n%s", out)
+			}
+		}
+	}
+	if req == nil || ack == nil {
+		t.Fatalf("trace missed the request or the ack: %d messages", len(msgs))
+	}
+	if req.Seq != ack.Seq {
+		t.Fatalf("ack seq %d does not match request seq %d", ack.Seq, req.Seq)
+	}
+
+	h.SetTraceFunc(nil)
+	n := len(msgs)
+	if _, err := h.LinkByName("foo"); err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != n {
+		t.Fatal("messages traced after tracing was disabled")
+	}
+}
+
 func TestHandleReceiveBuffer(t *testing.T) {
 	h, err := NewHandle()
 	if err != nil {
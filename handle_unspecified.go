@@ -36,6 +36,14 @@ func (h *Handle) SetSocketTimeout(to time.Duration) error {
 	return ErrNotImplemented
 }
 
+func (h *Handle) Reconnect(ns netns.NsHandle) error {
+	return ErrNotImplemented
+}
+
+func (h *Handle) CheckNamespace() error {
+	return ErrNotImplemented
+}
+
 func (h *Handle) SetPromiscOn(link Link) error {
 	return ErrNotImplemented
 }
@@ -44,6 +52,14 @@ func (h *Handle) SetPromiscOff(link Link) error {
 	return ErrNotImplemented
 }
 
+func (h *Handle) LinkSetPromiscOn(link Link) error {
+	return ErrNotImplemented
+}
+
+func (h *Handle) LinkSetPromiscOff(link Link) error {
+	return ErrNotImplemented
+}
+
 func (h *Handle) LinkSetUp(link Link) error {
 	return ErrNotImplemented
 }
@@ -60,6 +76,10 @@ func (h *Handle) LinkSetName(link Link, name string) error {
 	return ErrNotImplemented
 }
 
+func (h *Handle) LinkSetMap(link Link, m *LinkMap) error {
+	return ErrNotImplemented
+}
+
 func (h *Handle) LinkSetAlias(link Link, name string) error {
 	return ErrNotImplemented
 }
@@ -120,6 +140,10 @@ func (h *Handle) LinkDel(link Link) error {
 	return ErrNotImplemented
 }
 
+func (h *Handle) LinkDelIdempotent(link Link) error {
+	return ErrNotImplemented
+}
+
 func (h *Handle) LinkByName(name string) (Link, error) {
 	return nil, ErrNotImplemented
 }
@@ -128,6 +152,10 @@ func (h *Handle) LinkByAlias(alias string) (Link, error) {
 	return nil, ErrNotImplemented
 }
 
+func (h *Handle) LinkByHardwareAddr(addr net.HardwareAddr) (Link, error) {
+	return nil, ErrNotImplemented
+}
+
 func (h *Handle) LinkByIndex(index int) (Link, error) {
 	return nil, ErrNotImplemented
 }
@@ -144,6 +172,18 @@ func (h *Handle) LinkSetGuard(link Link, mode bool) error {
 	return ErrNotImplemented
 }
 
+func (h *Handle) LinkSetBrPortBackup(link Link, backup Link) error {
+	return ErrNotImplemented
+}
+
+func (h *Handle) LinkClearBrPortBackup(link Link) error {
+	return ErrNotImplemented
+}
+
+func (h *Handle) LinkSetBrPortMcastRouter(link Link, mode int) error {
+	return ErrNotImplemented
+}
+
 func (h *Handle) LinkSetFastLeave(link Link, mode bool) error {
 	return ErrNotImplemented
 }
@@ -176,6 +216,14 @@ func (h *Handle) LinkSetGROMaxSize(link Link, maxSize int) error {
 	return ErrNotImplemented
 }
 
+func (h *Handle) VethSetGSOMaxSize(link *Veth, maxSize int, both bool) error {
+	return ErrNotImplemented
+}
+
+func (h *Handle) VethSetGROMaxSize(link *Veth, maxSize int, both bool) error {
+	return ErrNotImplemented
+}
+
 func (h *Handle) LinkSetGSOIPv4MaxSize(link Link, maxSize int) error {
 	return ErrNotImplemented
 }
@@ -200,6 +248,10 @@ func (h *Handle) AddrDel(link Link, addr *Addr) error {
 	return ErrNotImplemented
 }
 
+func (h *Handle) AddrFlush(link Link, family int, opts *AddrFlushOptions) (int, error) {
+	return 0, ErrNotImplemented
+}
+
 func (h *Handle) AddrList(link Link, family int) ([]Addr, error) {
 	return nil, ErrNotImplemented
 }
@@ -228,6 +280,10 @@ func (h *Handle) FilterDel(filter Filter) error {
 	return ErrNotImplemented
 }
 
+func (h *Handle) FilterDelByAttrs(link Link, attrs FilterAttrs) error {
+	return ErrNotImplemented
+}
+
 func (h *Handle) FilterAdd(filter Filter) error {
 	return ErrNotImplemented
 }
@@ -236,6 +292,10 @@ func (h *Handle) FilterList(link Link, parent uint32) ([]Filter, error) {
 	return nil, ErrNotImplemented
 }
 
+func (h *Handle) FilterListBlock(block uint32) ([]Filter, error) {
+	return nil, ErrNotImplemented
+}
+
 func (h *Handle) NeighAdd(neigh *Neigh) error {
 	return ErrNotImplemented
 }
@@ -244,6 +304,10 @@ func (h *Handle) NeighSet(neigh *Neigh) error {
 	return ErrNotImplemented
 }
 
+func (h *Handle) NeighSetBatch(entries []*Neigh, opts BatchOptions) ([]error, error) {
+	return nil, ErrNotImplemented
+}
+
 func (h *Handle) NeighAppend(neigh *Neigh) error {
 	return ErrNotImplemented
 }
@@ -252,10 +316,18 @@ func (h *Handle) NeighDel(neigh *Neigh) error {
 	return ErrNotImplemented
 }
 
+func (h *Handle) NeighFlush(link Link, family int, stateMask uint16) (int, error) {
+	return 0, ErrNotImplemented
+}
+
 func (h *Handle) NeighList(linkIndex, family int) ([]Neigh, error) {
 	return nil, ErrNotImplemented
 }
 
+func (h *Handle) NetconfGet(family int, ifindex int) (*Netconf, error) {
+	return nil, ErrNotImplemented
+}
+
 func (h *Handle) NeighProxyList(linkIndex, family int) ([]Neigh, error) {
 	return nil, ErrNotImplemented
 }
@@ -288,6 +360,30 @@ func (h *Handle) RouteListFiltered(family int, filter *Route, filterMask uint64)
 	return nil, ErrNotImplemented
 }
 
+func (h *Handle) NexthopAdd(nh *Nexthop) error {
+	return ErrNotImplemented
+}
+
+func (h *Handle) NexthopReplace(nh *Nexthop) error {
+	return ErrNotImplemented
+}
+
+func (h *Handle) NexthopDel(nh *Nexthop) error {
+	return ErrNotImplemented
+}
+
+func (h *Handle) NexthopGet(id uint32) (*Nexthop, error) {
+	return nil, ErrNotImplemented
+}
+
+func (h *Handle) NexthopList() ([]Nexthop, error) {
+	return nil, ErrNotImplemented
+}
+
+func (h *Handle) NexthopBucketList(groupID uint32) ([]NexthopBucket, error) {
+	return nil, ErrNotImplemented
+}
+
 func (h *Handle) RouteReplace(route *Route) error {
 	return ErrNotImplemented
 }
@@ -219,6 +219,68 @@ func TestIpsetCreateListAddDelDestroy(t *testing.T) {
 	}
 }
 
+func TestIpsetTimeoutEntriesRoundTrip(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+	setname := "my-test-ipset-timeout"
+	timeout := uint32(60)
+	err := IpsetCreate(setname, "hash:ip,port", IpsetCreateOptions{
+		Replace:  true,
+		Timeout:  &timeout,
+		Counters: true,
+		Comments: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := []IPSetEntry{
+		{IP: net.ParseIP("10.1.1.1").To4(), Port: &[]uint16{80}[0], Timeout: &[]uint32{30}[0], Comment: "first"},
+		{IP: net.ParseIP("10.1.1.2").To4(), Port: &[]uint16{443}[0], Timeout: &[]uint32{45}[0], Comment: "second"},
+	}
+	for i := range entries {
+		if err := IpsetAdd(setname, &entries[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := IpsetList(setname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Entries) != len(entries) {
+		t.Fatalf("expected %d entries, got %d", len(entries), len(result.Entries))
+	}
+	for _, e := range result.Entries {
+		if e.Timeout == nil || *e.Timeout == 0 || *e.Timeout > 45 {
+			t.Errorf("unexpected timeout for entry %s: %v", e.IP, e.Timeout)
+		}
+		if e.Packets == nil || e.Bytes == nil {
+			t.Errorf("expected counters for entry %s", e.IP)
+		}
+	}
+
+	for _, e := range entries {
+		exist, err := IpsetTest(setname, &IPSetEntry{IP: e.IP, Port: e.Port})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !exist {
+			t.Errorf("entry %s,%d should exist", e.IP, *e.Port)
+		}
+	}
+	exist, err := IpsetTest(setname, &IPSetEntry{IP: net.ParseIP("10.1.1.1").To4(), Port: &[]uint16{443}[0]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exist {
+		t.Error("entry 10.1.1.1,443 should not exist")
+	}
+
+	if err := IpsetDestroy(setname); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestIpsetCreateListAddDelDestroyWithTestCases(t *testing.T) {
 	timeout := uint32(3)
 	protocalTCP := uint8(unix.IPPROTO_TCP)
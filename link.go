@@ -2,9 +2,11 @@ package netlink
 
 import (
 	"fmt"
+	"math"
 	"net"
 	"os"
 	"strconv"
+	"time"
 )
 
 // Link represents a link device from netlink. Shared link attributes
@@ -22,27 +24,38 @@ type (
 
 // LinkAttrs represents data shared by most link types
 type LinkAttrs struct {
-	Index          int
-	MTU            int
-	TxQLen         int // Transmit Queue Length
-	Name           string
-	HardwareAddr   net.HardwareAddr
-	Flags          net.Flags
-	RawFlags       uint32
-	ParentIndex    int         // index of the parent link device
-	MasterIndex    int         // must be the index of a bridge
-	Namespace      interface{} // nil | NsPid | NsFd
-	Alias          string
-	AltNames       []string
-	Statistics     *LinkStatistics
-	Promisc        int
-	Allmulti       int
-	Multi          int
-	Xdp            *LinkXdp
-	EncapType      string
-	Protinfo       *Protinfo
-	OperState      LinkOperState
-	PhysSwitchID   int
+	Index        int
+	MTU          int
+	TxQLen       int // Transmit Queue Length
+	Name         string
+	HardwareAddr net.HardwareAddr
+	Flags        net.Flags
+	// RawFlags holds the IFF_* flags as reported by the kernel. Only
+	// IFF_UP, IFF_NOARP, IFF_MULTICAST, IFF_PROMISC and IFF_ALLMULTI can be
+	// changed through the LinkSet* helpers. IFF_RUNNING, IFF_LOWER_UP,
+	// IFF_DORMANT and IFF_ECHO are managed by the kernel and the driver
+	// from the carrier and operational state, and cannot be set.
+	RawFlags     uint32
+	ParentIndex  int         // index of the parent link device
+	MasterIndex  int         // must be the index of a bridge
+	Namespace    interface{} // nil | NsPid | NsFd
+	Alias        string
+	AltNames     []string
+	Statistics   *LinkStatistics
+	Map          *LinkMap // legacy device resources, nil if not reported
+	Promisc      int      // promiscuity counter, non-zero when IFF_PROMISC is set
+	Allmulti     int      // 1 when IFF_ALLMULTI is set
+	Multi        int      // 1 when IFF_MULTICAST is set
+	Xdp          *LinkXdp
+	EncapType    string
+	Protinfo     *Protinfo
+	OperState    LinkOperState
+	PhysSwitchID int
+	// NetNsID is the IFLA_LINK_NETNSID of the link, the id, as seen from
+	// the namespace of the link, of the namespace ParentIndex refers to,
+	// for instance where the peer of a veth or netkit lives. It is -1 when
+	// ParentIndex is in the same namespace. GetNetNsIdByFd maps a namespace
+	// to its id.
 	NetNsID        int
 	NumTxQueues    int
 	NumRxQueues    int
@@ -54,11 +67,25 @@ type LinkAttrs struct {
 	GSOIPv4MaxSize uint32
 	GROIPv4MaxSize uint32
 	Vfs            []VfInfo // virtual functions available on link
-	Group          uint32
-	PermHWAddr     net.HardwareAddr
-	ParentDev      string
-	ParentDevBus   string
+	// NumVfs is the number of VFs of the device, reported along with Vfs
+	// when the VF information is collected. Vfs has fewer entries if the
+	// kernel could not fit them all in the message.
+	NumVfs       int
+	Group        uint32
+	PermHWAddr   net.HardwareAddr
+	ParentDev    string
+	ParentDevBus string
+	// NetNsImmutable is true when the link can't be moved to another
+	// network namespace, as is the case of lo. It is read-only and only
+	// reported by kernels 6.14 and newer.
+	NetNsImmutable bool
 	Slave          LinkSlave
+	// Extras holds the top-level attributes newer than this library, keyed
+	// by type as received (NLA_F_NESTED included), when read through a
+	// Handle set to RetainUnknownAttributes.
+	// They are sent back by LinkModify, but the kernel refuses the ones it
+	// only ever reports, which have to be deleted first.
+	Extras map[uint16][]byte
 }
 
 // LinkSlave represents a slave device.
@@ -124,6 +151,17 @@ func (s LinkOperState) String() string {
 	}
 }
 
+// LinkMap represents the IFLA_MAP link attribute, the I/O resources of
+// legacy ISA-style devices. Most devices report all zeros.
+type LinkMap struct {
+	MemStart uint64
+	MemEnd   uint64
+	BaseAddr uint64
+	Irq      uint16
+	Dma      uint8
+	Port     uint8
+}
+
 // NewLinkAttrs returns LinkAttrs structure filled with default values
 func NewLinkAttrs() LinkAttrs {
 	return LinkAttrs{
@@ -220,6 +258,62 @@ type LinkStatistics64 struct {
 	TxCompressed      uint64
 }
 
+func (s *LinkStatistics) counters() []*uint64 {
+	return []*uint64{
+		&s.RxPackets, &s.TxPackets, &s.RxBytes, &s.TxBytes,
+		&s.RxErrors, &s.TxErrors, &s.RxDropped, &s.TxDropped,
+		&s.Multicast, &s.Collisions,
+		&s.RxLengthErrors, &s.RxOverErrors, &s.RxCrcErrors, &s.RxFrameErrors,
+		&s.RxFifoErrors, &s.RxMissedErrors,
+		&s.TxAbortedErrors, &s.TxCarrierErrors, &s.TxFifoErrors,
+		&s.TxHeartbeatErrors, &s.TxWindowErrors,
+		&s.RxCompressed, &s.TxCompressed,
+	}
+}
+
+// is64bit reports whether any counter of s is too large for the 32 bit
+// rtnl_link_stats, i.e. whether the device keeps 64 bit counters.
+func (s *LinkStatistics) is64bit() bool {
+	for _, c := range s.counters() {
+		if *c > math.MaxUint32 {
+			return true
+		}
+	}
+	return false
+}
+
+// LinkStatsDelta returns the per counter difference between two readings
+// of the statistics of the same link, cur being the most recent one. A
+// counter lower in cur than in prev is assumed to have wrapped around
+// once, at 2^64 if is64bit is set and at 2^32 otherwise. The kernel
+// reports 32 bit driver counters zero extended, so is64bit must reflect
+// the width of the counters kept by the device and not the netlink
+// attribute they were read from.
+func LinkStatsDelta(prev, cur *LinkStatistics, is64bit bool) LinkStatistics {
+	var delta LinkStatistics
+	p, c, d := prev.counters(), cur.counters(), delta.counters()
+	for i := range d {
+		if is64bit {
+			*d[i] = *c[i] - *p[i]
+		} else {
+			*d[i] = uint64(uint32(*c[i]) - uint32(*p[i]))
+		}
+	}
+	return delta
+}
+
+// LinkStatsSample is sent by LinkStatsPoll once per interval.
+type LinkStatsSample struct {
+	// Time is when the statistics were read.
+	Time time.Time
+	// Interval is the time elapsed since the previous sample.
+	Interval time.Duration
+	// Statistics holds the cumulative counters of the link.
+	Statistics *LinkStatistics
+	// Delta holds the change of the counters over Interval.
+	Delta LinkStatistics
+}
+
 type LinkXdp struct {
 	Fd         int
 	Attached   bool
@@ -268,6 +362,20 @@ func (ifb *Ifb) Type() string {
 	return "ifb"
 }
 
+// Team links aggregate ports like bonds, their runner and ports are managed
+// through the team generic netlink family, see TeamSetRunner.
+type Team struct {
+	LinkAttrs
+}
+
+func (team *Team) Attrs() *LinkAttrs {
+	return &team.LinkAttrs
+}
+
+func (team *Team) Type() string {
+	return "team"
+}
+
 // Bridge links are simple linux bridges
 type Bridge struct {
 	LinkAttrs
@@ -328,6 +436,9 @@ type Macvlan struct {
 	// MACAddrs is only populated for Macvlan SOURCE links
 	MACAddrs []net.HardwareAddr
 
+	// BCQueueLen is the requested broadcast queue length, it can be
+	// changed at runtime with LinkModify. UsedBCQueueLen is the length in
+	// use, the largest requested by the macvlans of the same parent.
 	BCQueueLen     uint32
 	UsedBCQueueLen uint32
 }
@@ -437,6 +548,14 @@ type Veth struct {
 	PeerNumTxQueues  uint32
 	PeerNumRxQueues  uint32
 	PeerMTU          uint32
+	// PeerIndex is the index of the peer, read only. It is an index in
+	// the namespace PeerNetNsID when that is set, so it may equal the
+	// index of the veth itself.
+	PeerIndex int
+	// PeerNetNsID is the id, as seen from the namespace of the veth, of
+	// the namespace of the peer. It is nil when the peer is in the same
+	// namespace. Read only.
+	PeerNetNsID *int32
 }
 
 func NewVeth(attr LinkAttrs) *Veth {
@@ -468,10 +587,12 @@ func (wg *Wireguard) Type() string {
 }
 
 // GenericLink links represent types that are not currently understood
-// by this netlink library.
+// by this netlink library. Data holds the raw IFLA_INFO_DATA payload
+// reported by the kernel and is sent back verbatim by LinkAdd.
 type GenericLink struct {
 	LinkAttrs
 	LinkType string
+	Data     []byte
 }
 
 func (generic *GenericLink) Attrs() *LinkAttrs {
@@ -506,6 +627,29 @@ type Vxlan struct {
 	Port           int
 	PortLow        int
 	PortHigh       int
+	DF             VxlanDF
+	VniFilter      bool
+}
+
+// VxlanDF is the don't-fragment policy of a vxlan device.
+type VxlanDF uint8
+
+const (
+	VXLAN_DF_UNSET VxlanDF = iota
+	VXLAN_DF_SET
+	VXLAN_DF_INHERIT
+)
+
+func (df VxlanDF) String() string {
+	switch df {
+	case VXLAN_DF_UNSET:
+		return "unset"
+	case VXLAN_DF_SET:
+		return "set"
+	case VXLAN_DF_INHERIT:
+		return "inherit"
+	}
+	return fmt.Sprintf("unknown(%d)", uint8(df))
 }
 
 func (vxlan *Vxlan) Attrs() *LinkAttrs {
@@ -897,6 +1041,7 @@ type Bond struct {
 	UseCarrier      int
 	ArpInterval     int
 	ArpIpTargets    []net.IP
+	NsIP6Targets    []net.IP // IPv6 neighbor solicitation targets, kernel 5.19+
 	ArpValidate     BondArpValidate
 	ArpAllTargets   BondArpAllTargets
 	Primary         int
@@ -930,6 +1075,7 @@ func NewLinkBond(atr LinkAttrs) *Bond {
 		UseCarrier:      -1,
 		ArpInterval:     -1,
 		ArpIpTargets:    nil,
+		NsIP6Targets:    nil,
 		ArpValidate:     -1,
 		ArpAllTargets:   -1,
 		Primary:         -1,
@@ -1047,12 +1193,15 @@ type BondSlave struct {
 	AggregatorId           uint16
 	AdActorOperPortState   uint8
 	AdPartnerOperPortState uint16
+	Prio                   int32 // failover priority, kernel 6.0+
 }
 
 func (b *BondSlave) SlaveType() string {
 	return "bond"
 }
 
+// VrfSlave is the slave data of a link enslaved to a VRF. Table is the
+// routing table of the VRF master.
 type VrfSlave struct {
 	Table uint32
 }
@@ -1116,6 +1265,11 @@ type Gretap struct {
 	EncapFlags uint16
 	Link       uint32
 	FlowBased  bool
+	// EncapLimit and FlowInfo only apply to ip6gretap. FlowInfo holds the
+	// traffic class and flow label laid out as in the IPv6 header
+	// (tclass << 20 | flowlabel).
+	EncapLimit uint8
+	FlowInfo   uint32
 }
 
 func (gretap *Gretap) Attrs() *LinkAttrs {
@@ -1155,20 +1309,29 @@ func (iptun *Iptun) Type() string {
 
 type Ip6tnl struct {
 	LinkAttrs
-	Link       uint32
-	Local      net.IP
-	Remote     net.IP
-	Ttl        uint8
-	Tos        uint8
-	Flags      uint32
-	Proto      uint8
-	FlowInfo   uint32
-	EncapLimit uint8
-	EncapType  uint16
-	EncapFlags uint16
-	EncapSport uint16
-	EncapDport uint16
-	FlowBased  bool
+	Link     uint32
+	Local    net.IP
+	Remote   net.IP
+	Ttl      uint8
+	Tos      uint8
+	Flags    uint32
+	Proto    uint8
+	FlowInfo uint32
+	// EncapLimit is the tunnel encapsulation limit, nil uses the default
+	// of 4 like iproute2. Set IP6_TNL_F_IGN_ENCAP_LIMIT in Flags to send
+	// none.
+	EncapLimit *uint8
+	// FlowLabel is the 20 bit flow label of the outer header, it is merged
+	// into FlowInfo on the wire.
+	FlowLabel uint32
+	// TClassInherit copies the traffic class of the inner packet instead
+	// of the one in FlowInfo, it maps to IP6_TNL_F_USE_ORIG_TCLASS.
+	TClassInherit bool
+	EncapType     uint16
+	EncapFlags    uint16
+	EncapSport    uint16
+	EncapDport    uint16
+	FlowBased     bool
 }
 
 func (ip6tnl *Ip6tnl) Attrs() *LinkAttrs {
@@ -1201,13 +1364,13 @@ const (
 type IP6TunnelFlag uint16
 
 const (
-	IP6_TNL_F_IGN_ENCAP_LIMIT    IP6TunnelFlag = 1  // don't add encapsulation limit if one isn't present in inner packet
-	IP6_TNL_F_USE_ORIG_TCLASS                  = 2  // copy the traffic class field from the inner packet
-	IP6_TNL_F_USE_ORIG_FLOWLABEL               = 4  // copy the flowlabel from the inner packet
-	IP6_TNL_F_MIP6_DEV                         = 8  // being used for Mobile IPv6
-	IP6_TNL_F_RCV_DSCP_COPY                    = 10 // copy DSCP from the outer packet
-	IP6_TNL_F_USE_ORIG_FWMARK                  = 20 // copy fwmark from inner packet
-	IP6_TNL_F_ALLOW_LOCAL_REMOTE               = 40 // allow remote endpoint on the local node
+	IP6_TNL_F_IGN_ENCAP_LIMIT    IP6TunnelFlag = 1    // don't add encapsulation limit if one isn't present in inner packet
+	IP6_TNL_F_USE_ORIG_TCLASS                  = 2    // copy the traffic class field from the inner packet
+	IP6_TNL_F_USE_ORIG_FLOWLABEL               = 4    // copy the flowlabel from the inner packet
+	IP6_TNL_F_MIP6_DEV                         = 8    // being used for Mobile IPv6
+	IP6_TNL_F_RCV_DSCP_COPY                    = 0x10 // copy DSCP from the outer packet
+	IP6_TNL_F_USE_ORIG_FWMARK                  = 0x20 // copy fwmark from inner packet
+	IP6_TNL_F_ALLOW_LOCAL_REMOTE               = 0x40 // allow remote endpoint on the local node
 )
 
 type Sittun struct {
@@ -1224,6 +1387,9 @@ type Sittun struct {
 	EncapFlags uint16
 	EncapSport uint16
 	EncapDport uint16
+	// SixRdPrefix and SixRdRelayPrefix configure 6rd (RFC 5969).
+	SixRdPrefix      *net.IPNet
+	SixRdRelayPrefix *net.IPNet
 }
 
 func (sittun *Sittun) Attrs() *LinkAttrs {
@@ -1236,11 +1402,17 @@ func (sittun *Sittun) Type() string {
 
 type Vti struct {
 	LinkAttrs
+	// IKey and OKey are the xfrm marks of the tunnel. The kernel always
+	// reports both keys and treats 0 as no key, so a keyless tunnel reads
+	// back with zero keys.
 	IKey   uint32
 	OKey   uint32
 	Link   uint32
 	Local  net.IP
 	Remote net.IP
+	// Fwmark is the fwmark used for the route lookup of encapsulated
+	// packets.
+	Fwmark uint32
 }
 
 func (vti *Vti) Attrs() *LinkAttrs {
@@ -1271,6 +1443,11 @@ type Gretun struct {
 	EncapSport uint16
 	EncapDport uint16
 	FlowBased  bool
+	// EncapLimit and FlowInfo only apply to ip6gre. FlowInfo holds the
+	// traffic class and flow label laid out as in the IPv6 header
+	// (tclass << 20 | flowlabel).
+	EncapLimit uint8
+	FlowInfo   uint32
 }
 
 func (gretun *Gretun) Attrs() *LinkAttrs {
@@ -1319,6 +1496,9 @@ func (gtp *GTP) Type() string {
 type Xfrmi struct {
 	LinkAttrs
 	Ifid uint32
+	// FlowBased (external) mode takes the if_id from the packet
+	// metadata, so Ifid and ParentIndex must be left unset.
+	FlowBased bool
 }
 
 func (xfrm *Xfrmi) Attrs() *LinkAttrs {
@@ -1423,12 +1603,17 @@ func (ipoib *IPoIB) Type() string {
 	return "ipoib"
 }
 
+// BareUDP is a UDP tunnel for L3 protocols. Port defaults to 6635 and a
+// zero SrcPortMin leaves the source port range to the kernel. MultiProto
+// is only meaningful with EtherType ETH_P_IP (adds IPv6) or ETH_P_MPLS_UC
+// (adds multicast MPLS).
 type BareUDP struct {
 	LinkAttrs
-	Port       uint16
-	EtherType  uint16
-	SrcPortMin uint16
-	MultiProto bool
+	Port              uint16
+	EtherType         uint16
+	SrcPortMin        uint16
+	MultiProto        bool
+	RxCollectMetadata bool
 }
 
 func (bareudp *BareUDP) Attrs() *LinkAttrs {
@@ -1452,3 +1637,9 @@ func (bareudp *BareUDP) Type() string {
 type LinkNotFoundError struct {
 	error
 }
+
+// Unwrap returns the underlying error, so that errors.Is can match the
+// errno it wraps, if any.
+func (e LinkNotFoundError) Unwrap() error {
+	return e.error
+}
@@ -0,0 +1,71 @@
+package netlink
+
+import (
+	"fmt"
+	"sync"
+	"syscall"
+
+	"github.com/vishvananda/netlink/nl"
+)
+
+// LinkCodec encodes and decodes the kind specific data (IFLA_INFO_DATA) of
+// a link kind that is not natively supported by this package, such as one
+// provided by an out-of-tree kernel driver.
+type LinkCodec interface {
+	// Encode returns the IFLA_INFO_DATA attributes to send when adding or
+	// modifying link.
+	Encode(link Link) ([]*nl.RtAttr, error)
+	// Decode builds a Link from the IFLA_INFO_DATA attributes reported by
+	// the kernel. data is nil if the kernel did not report any. The common
+	// link attributes are filled in by the caller.
+	Decode(data []syscall.NetlinkRouteAttr) (Link, error)
+}
+
+var (
+	linkKindsMu sync.RWMutex
+	linkKinds   = map[string]LinkCodec{}
+)
+
+// RegisterLinkKind registers codec for links of the given kind. Codecs are
+// only consulted for kinds this package does not handle itself; a Link
+// returned by codec.Decode must report kind from its Type method so that
+// LinkAdd can find the codec again. Registering the same kind twice
+// replaces the previous codec, and a nil codec removes the registration.
+func RegisterLinkKind(kind string, codec LinkCodec) {
+	linkKindsMu.Lock()
+	defer linkKindsMu.Unlock()
+	if codec == nil {
+		delete(linkKinds, kind)
+		return
+	}
+	linkKinds[kind] = codec
+}
+
+func lookupLinkKind(kind string) LinkCodec {
+	linkKindsMu.RLock()
+	defer linkKindsMu.RUnlock()
+	return linkKinds[kind]
+}
+
+func addRegisteredLinkKindAttrs(codec LinkCodec, link Link, linkInfo *nl.RtAttr) error {
+	attrs, err := codec.Encode(link)
+	if err != nil {
+		return err
+	}
+	data := linkInfo.AddRtAttr(nl.IFLA_INFO_DATA, nil)
+	for _, attr := range attrs {
+		data.AddChild(attr)
+	}
+	return nil
+}
+
+func decodeRegisteredLinkKind(codec LinkCodec, kind string, data []syscall.NetlinkRouteAttr) (Link, error) {
+	link, err := codec.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	if link == nil {
+		return nil, fmt.Errorf("codec for link kind %q returned no link", kind)
+	}
+	return link, nil
+}
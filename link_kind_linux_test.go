@@ -0,0 +1,114 @@
+package netlink
+
+import (
+	"bytes"
+	"syscall"
+	"testing"
+
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+const fakeLinkAttrID = 1
+
+type fakeLink struct {
+	LinkAttrs
+	ID uint32
+}
+
+func (f *fakeLink) Attrs() *LinkAttrs {
+	return &f.LinkAttrs
+}
+
+func (f *fakeLink) Type() string {
+	return "fakekind"
+}
+
+type fakeLinkCodec struct{}
+
+func (fakeLinkCodec) Encode(link Link) ([]*nl.RtAttr, error) {
+	return []*nl.RtAttr{nl.NewRtAttr(fakeLinkAttrID, nl.Uint32Attr(link.(*fakeLink).ID))}, nil
+}
+
+func (fakeLinkCodec) Decode(data []syscall.NetlinkRouteAttr) (Link, error) {
+	link := &fakeLink{}
+	for _, datum := range data {
+		switch datum.Attr.Type {
+		case fakeLinkAttrID:
+			link.ID = native.Uint32(datum.Value[0:4])
+		}
+	}
+	return link, nil
+}
+
+func TestRegisterLinkKind(t *testing.T) {
+	RegisterLinkKind("fakekind", fakeLinkCodec{})
+	t.Cleanup(func() { RegisterLinkKind("fakekind", nil) })
+
+	// Build an RTM_NEWLINK payload as the kernel would report a link of
+	// the registered kind.
+	linkInfo := nl.NewRtAttr(unix.IFLA_LINKINFO, nil)
+	linkInfo.AddRtAttr(nl.IFLA_INFO_KIND, nl.ZeroTerminated("fakekind"))
+	if err := addRegisteredLinkKindAttrs(fakeLinkCodec{}, &fakeLink{ID: 42}, linkInfo); err != nil {
+		t.Fatal(err)
+	}
+	msg := nl.NewIfInfomsg(unix.AF_UNSPEC)
+	msg.Index = 7
+	b := msg.Serialize()
+	b = append(b, nl.NewRtAttr(unix.IFLA_IFNAME, nl.ZeroTerminated("fake0")).Serialize()...)
+	b = append(b, linkInfo.Serialize()...)
+
+	link, err := LinkDeserialize(nil, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake, ok := link.(*fakeLink)
+	if !ok {
+		t.Fatalf("expected *fakeLink, got %T", link)
+	}
+	if fake.ID != 42 {
+		t.Fatalf("expected ID 42, got %d", fake.ID)
+	}
+	if fake.Name != "fake0" || fake.Index != 7 {
+		t.Fatalf("common link attributes not filled in: %+v", fake.LinkAttrs)
+	}
+
+	RegisterLinkKind("fakekind", nil)
+	link, err = LinkDeserialize(nil, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := link.(*GenericLink); !ok {
+		t.Fatalf("expected *GenericLink after unregistering, got %T", link)
+	}
+}
+
+func TestGenericLinkKeepsData(t *testing.T) {
+	data := nl.NewRtAttr(nl.IFLA_INFO_DATA, nil)
+	data.AddRtAttr(1, nl.Uint32Attr(42))
+	data.AddRtAttr(2, nl.ZeroTerminated("opaque"))
+	linkInfo := nl.NewRtAttr(unix.IFLA_LINKINFO, nil)
+	linkInfo.AddRtAttr(nl.IFLA_INFO_KIND, nl.ZeroTerminated("unknownkind"))
+	linkInfo.AddChild(data)
+
+	msg := nl.NewIfInfomsg(unix.AF_UNSPEC)
+	b := msg.Serialize()
+	b = append(b, nl.NewRtAttr(unix.IFLA_IFNAME, nl.ZeroTerminated("unk0")).Serialize()...)
+	b = append(b, linkInfo.Serialize()...)
+
+	link, err := LinkDeserialize(nil, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	generic, ok := link.(*GenericLink)
+	if !ok {
+		t.Fatalf("expected *GenericLink, got %T", link)
+	}
+	if generic.LinkType != "unknownkind" {
+		t.Fatalf("expected LinkType unknownkind, got %s", generic.LinkType)
+	}
+	expected := data.Serialize()[unix.SizeofRtAttr:]
+	if !bytes.Equal(generic.Data, expected) {
+		t.Fatalf("expected Data %x, got %x", expected, generic.Data)
+	}
+}
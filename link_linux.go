@@ -11,6 +11,7 @@ import (
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 	"unsafe"
 
 	"github.com/vishvananda/netlink/nl"
@@ -84,18 +85,7 @@ func (h *Handle) ensureIndex(link *LinkAttrs) {
 }
 
 func (h *Handle) LinkSetARPOff(link Link) error {
-	base := link.Attrs()
-	h.ensureIndex(base)
-	req := h.newNetlinkRequest(unix.RTM_SETLINK, unix.NLM_F_ACK)
-
-	msg := nl.NewIfInfomsg(unix.AF_UNSPEC)
-	msg.Change |= unix.IFF_NOARP
-	msg.Flags |= unix.IFF_NOARP
-	msg.Index = int32(base.Index)
-	req.AddData(msg)
-
-	_, err := req.Execute(unix.NETLINK_ROUTE, 0)
-	return err
+	return h.linkSetFlag(link, unix.IFF_NOARP, true)
 }
 
 func LinkSetARPOff(link Link) error {
@@ -103,37 +93,76 @@ func LinkSetARPOff(link Link) error {
 }
 
 func (h *Handle) LinkSetARPOn(link Link) error {
-	base := link.Attrs()
-	h.ensureIndex(base)
-	req := h.newNetlinkRequest(unix.RTM_SETLINK, unix.NLM_F_ACK)
-
-	msg := nl.NewIfInfomsg(unix.AF_UNSPEC)
-	msg.Change |= unix.IFF_NOARP
-	msg.Flags &= ^uint32(unix.IFF_NOARP)
-	msg.Index = int32(base.Index)
-	req.AddData(msg)
-
-	_, err := req.Execute(unix.NETLINK_ROUTE, 0)
-	return err
+	return h.linkSetFlag(link, unix.IFF_NOARP, false)
 }
 
 func LinkSetARPOn(link Link) error {
 	return pkgHandle.LinkSetARPOn(link)
 }
 
-func (h *Handle) SetPromiscOn(link Link) error {
+// linkSetFlag sets or clears a single interface flag on the link device.
+// On success the flag fields of link.Attrs() are updated from the change,
+// so Flags, RawFlags, Promisc, Allmulti and Multi stay consistent with each
+// other. Changes of the other flags since link was read are not picked up.
+func (h *Handle) linkSetFlag(link Link, flag uint32, on bool) error {
 	base := link.Attrs()
 	h.ensureIndex(base)
-	req := h.newNetlinkRequest(unix.RTM_SETLINK, unix.NLM_F_ACK)
+	req := h.newNetlinkRequest(unix.RTM_NEWLINK, unix.NLM_F_ACK)
 
 	msg := nl.NewIfInfomsg(unix.AF_UNSPEC)
-	msg.Change = unix.IFF_PROMISC
-	msg.Flags = unix.IFF_PROMISC
+	msg.Change = flag
+	if on {
+		msg.Flags = flag
+	}
 	msg.Index = int32(base.Index)
 	req.AddData(msg)
 
-	_, err := req.Execute(unix.NETLINK_ROUTE, 0)
-	return err
+	if _, err := req.Execute(unix.NETLINK_ROUTE, 0); err != nil {
+		return err
+	}
+
+	rawFlags := base.RawFlags&^msg.Change | msg.Flags
+	// IFF_PROMISC holds one reference on the promiscuity counter.
+	if flag == unix.IFF_PROMISC && base.RawFlags&flag != rawFlags&flag {
+		if on {
+			base.Promisc++
+		} else if base.Promisc > 0 {
+			base.Promisc--
+		}
+	}
+	setLinkFlags(base, rawFlags)
+	return nil
+}
+
+// LinkSetPromiscOn enables promiscuous mode for the link device.
+// Equivalent to: `ip link set $link promisc on`
+func LinkSetPromiscOn(link Link) error {
+	return pkgHandle.LinkSetPromiscOn(link)
+}
+
+// LinkSetPromiscOn enables promiscuous mode for the link device.
+// Equivalent to: `ip link set $link promisc on`
+func (h *Handle) LinkSetPromiscOn(link Link) error {
+	return h.linkSetFlag(link, unix.IFF_PROMISC, true)
+}
+
+// LinkSetPromiscOff disables promiscuous mode for the link device.
+// Equivalent to: `ip link set $link promisc off`
+func LinkSetPromiscOff(link Link) error {
+	return pkgHandle.LinkSetPromiscOff(link)
+}
+
+// LinkSetPromiscOff disables promiscuous mode for the link device.
+// Equivalent to: `ip link set $link promisc off`
+func (h *Handle) LinkSetPromiscOff(link Link) error {
+	return h.linkSetFlag(link, unix.IFF_PROMISC, false)
+}
+
+// SetPromiscOn enables promiscuous mode for the link device.
+//
+// Deprecated: use LinkSetPromiscOn.
+func (h *Handle) SetPromiscOn(link Link) error {
+	return h.LinkSetPromiscOn(link)
 }
 
 // LinkSetAllmulticastOn enables the reception of all hardware multicast packets for the link device.
@@ -145,18 +174,7 @@ func LinkSetAllmulticastOn(link Link) error {
 // LinkSetAllmulticastOn enables the reception of all hardware multicast packets for the link device.
 // Equivalent to: `ip link set $link allmulticast on`
 func (h *Handle) LinkSetAllmulticastOn(link Link) error {
-	base := link.Attrs()
-	h.ensureIndex(base)
-	req := h.newNetlinkRequest(unix.RTM_NEWLINK, unix.NLM_F_ACK)
-
-	msg := nl.NewIfInfomsg(unix.AF_UNSPEC)
-	msg.Change = unix.IFF_ALLMULTI
-	msg.Flags = unix.IFF_ALLMULTI
-	msg.Index = int32(base.Index)
-	req.AddData(msg)
-
-	_, err := req.Execute(unix.NETLINK_ROUTE, 0)
-	return err
+	return h.linkSetFlag(link, unix.IFF_ALLMULTI, true)
 }
 
 // LinkSetAllmulticastOff disables the reception of all hardware multicast packets for the link device.
@@ -168,17 +186,7 @@ func LinkSetAllmulticastOff(link Link) error {
 // LinkSetAllmulticastOff disables the reception of all hardware multicast packets for the link device.
 // Equivalent to: `ip link set $link allmulticast off`
 func (h *Handle) LinkSetAllmulticastOff(link Link) error {
-	base := link.Attrs()
-	h.ensureIndex(base)
-	req := h.newNetlinkRequest(unix.RTM_NEWLINK, unix.NLM_F_ACK)
-
-	msg := nl.NewIfInfomsg(unix.AF_UNSPEC)
-	msg.Change = unix.IFF_ALLMULTI
-	msg.Index = int32(base.Index)
-	req.AddData(msg)
-
-	_, err := req.Execute(unix.NETLINK_ROUTE, 0)
-	return err
+	return h.linkSetFlag(link, unix.IFF_ALLMULTI, false)
 }
 
 // LinkSetMulticastOn enables the reception of multicast packets for the link device.
@@ -190,40 +198,19 @@ func LinkSetMulticastOn(link Link) error {
 // LinkSetMulticastOn enables the reception of multicast packets for the link device.
 // Equivalent to: `ip link set $link multicast on`
 func (h *Handle) LinkSetMulticastOn(link Link) error {
-	base := link.Attrs()
-	h.ensureIndex(base)
-	req := h.newNetlinkRequest(unix.RTM_NEWLINK, unix.NLM_F_ACK)
-
-	msg := nl.NewIfInfomsg(unix.AF_UNSPEC)
-	msg.Change = unix.IFF_MULTICAST
-	msg.Flags = unix.IFF_MULTICAST
-	msg.Index = int32(base.Index)
-	req.AddData(msg)
-
-	_, err := req.Execute(unix.NETLINK_ROUTE, 0)
-	return err
+	return h.linkSetFlag(link, unix.IFF_MULTICAST, true)
 }
 
-// LinkSetAllmulticastOff disables the reception of multicast packets for the link device.
+// LinkSetMulticastOff disables the reception of multicast packets for the link device.
 // Equivalent to: `ip link set $link multicast off`
 func LinkSetMulticastOff(link Link) error {
 	return pkgHandle.LinkSetMulticastOff(link)
 }
 
-// LinkSetAllmulticastOff disables the reception of multicast packets for the link device.
+// LinkSetMulticastOff disables the reception of multicast packets for the link device.
 // Equivalent to: `ip link set $link multicast off`
 func (h *Handle) LinkSetMulticastOff(link Link) error {
-	base := link.Attrs()
-	h.ensureIndex(base)
-	req := h.newNetlinkRequest(unix.RTM_NEWLINK, unix.NLM_F_ACK)
-
-	msg := nl.NewIfInfomsg(unix.AF_UNSPEC)
-	msg.Change = unix.IFF_MULTICAST
-	msg.Index = int32(base.Index)
-	req.AddData(msg)
-
-	_, err := req.Execute(unix.NETLINK_ROUTE, 0)
-	return err
+	return h.linkSetFlag(link, unix.IFF_MULTICAST, false)
 }
 
 func MacvlanMACAddrAdd(link Link, addr net.HardwareAddr) error {
@@ -356,24 +343,23 @@ func (h *Handle) BridgeSetVlanDefaultPVID(link Link, pvid uint16) error {
 	return h.linkModify(bridge, unix.NLM_F_ACK)
 }
 
+// SetPromiscOn enables promiscuous mode for the link device.
+//
+// Deprecated: use LinkSetPromiscOn.
 func SetPromiscOn(link Link) error {
 	return pkgHandle.SetPromiscOn(link)
 }
 
+// SetPromiscOff disables promiscuous mode for the link device.
+//
+// Deprecated: use LinkSetPromiscOff.
 func (h *Handle) SetPromiscOff(link Link) error {
-	base := link.Attrs()
-	h.ensureIndex(base)
-	req := h.newNetlinkRequest(unix.RTM_SETLINK, unix.NLM_F_ACK)
-
-	msg := nl.NewIfInfomsg(unix.AF_UNSPEC)
-	msg.Change = unix.IFF_PROMISC
-	msg.Index = int32(base.Index)
-	req.AddData(msg)
-
-	_, err := req.Execute(unix.NETLINK_ROUTE, 0)
-	return err
+	return h.LinkSetPromiscOff(link)
 }
 
+// SetPromiscOff disables promiscuous mode for the link device.
+//
+// Deprecated: use LinkSetPromiscOff.
 func SetPromiscOff(link Link) error {
 	return pkgHandle.SetPromiscOff(link)
 }
@@ -387,18 +373,7 @@ func LinkSetUp(link Link) error {
 // LinkSetUp enables the link device.
 // Equivalent to: `ip link set $link up`
 func (h *Handle) LinkSetUp(link Link) error {
-	base := link.Attrs()
-	h.ensureIndex(base)
-	req := h.newNetlinkRequest(unix.RTM_NEWLINK, unix.NLM_F_ACK)
-
-	msg := nl.NewIfInfomsg(unix.AF_UNSPEC)
-	msg.Change = unix.IFF_UP
-	msg.Flags = unix.IFF_UP
-	msg.Index = int32(base.Index)
-	req.AddData(msg)
-
-	_, err := req.Execute(unix.NETLINK_ROUTE, 0)
-	return err
+	return h.linkSetFlag(link, unix.IFF_UP, true)
 }
 
 // LinkSetDown disables link device.
@@ -410,17 +385,7 @@ func LinkSetDown(link Link) error {
 // LinkSetDown disables link device.
 // Equivalent to: `ip link set $link down`
 func (h *Handle) LinkSetDown(link Link) error {
-	base := link.Attrs()
-	h.ensureIndex(base)
-	req := h.newNetlinkRequest(unix.RTM_NEWLINK, unix.NLM_F_ACK)
-
-	msg := nl.NewIfInfomsg(unix.AF_UNSPEC)
-	msg.Change = unix.IFF_UP
-	msg.Index = int32(base.Index)
-	req.AddData(msg)
-
-	_, err := req.Execute(unix.NETLINK_ROUTE, 0)
-	return err
+	return h.linkSetFlag(link, unix.IFF_UP, false)
 }
 
 // LinkSetMTU sets the mtu of the link device.
@@ -450,6 +415,39 @@ func (h *Handle) LinkSetMTU(link Link, mtu int) error {
 	return err
 }
 
+// LinkSetMap sets the I/O resources of a legacy link device.
+// Only drivers implementing ndo_set_config accept it, the others fail
+// with unix.EOPNOTSUPP, which can be checked with errors.Is.
+func LinkSetMap(link Link, m *LinkMap) error {
+	return pkgHandle.LinkSetMap(link, m)
+}
+
+// LinkSetMap sets the I/O resources of a legacy link device.
+// Only drivers implementing ndo_set_config accept it, the others fail
+// with unix.EOPNOTSUPP, which can be checked with errors.Is.
+func (h *Handle) LinkSetMap(link Link, m *LinkMap) error {
+	base := link.Attrs()
+	h.ensureIndex(base)
+	req := h.newNetlinkRequest(unix.RTM_SETLINK, unix.NLM_F_ACK)
+
+	msg := nl.NewIfInfomsg(unix.AF_UNSPEC)
+	msg.Index = int32(base.Index)
+	req.AddData(msg)
+
+	ifmap := nl.RtnlLinkIfmap{
+		MemStart: m.MemStart,
+		MemEnd:   m.MemEnd,
+		BaseAddr: m.BaseAddr,
+		Irq:      m.Irq,
+		Dma:      m.Dma,
+		Port:     m.Port,
+	}
+	req.AddData(nl.NewRtAttr(unix.IFLA_MAP, ifmap.Serialize()))
+
+	_, err := req.Execute(unix.NETLINK_ROUTE, 0)
+	return err
+}
+
 // LinkSetName sets the name of the link device.
 // Equivalent to: `ip link set $link name $name`
 func LinkSetName(link Link, name string) error {
@@ -550,16 +548,74 @@ func (h *Handle) LinkDelAltName(link Link, name string) error {
 	return err
 }
 
+// ErrBadHardwareAddrLen is returned, wrapped with the expected length, when
+// a hardware address does not fit the device it is set on.
+var ErrBadHardwareAddrLen = errors.New("bad hardware address length")
+
+// LinkSetHardwareAddrOptions contains the options of
+// LinkSetHardwareAddrWithOptions.
+type LinkSetHardwareAddrOptions struct {
+	// Force skips the length check, for devices whose address length is
+	// not known from their attributes.
+	Force bool
+}
+
+// hardwareAddrLen returns the length of the hardware addresses of the link
+// described by base, 0 when unknown: the length of its current address,
+// or else the one of its encapsulation.
+func hardwareAddrLen(base *LinkAttrs) int {
+	if len(base.HardwareAddr) > 0 {
+		return len(base.HardwareAddr)
+	}
+	switch base.EncapType {
+	case "ether":
+		return 6
+	case "infiniband":
+		return 20
+	}
+	return 0
+}
+
+func validateHardwareAddr(base *LinkAttrs, hwaddr net.HardwareAddr) error {
+	if expected := hardwareAddrLen(base); expected != 0 && len(hwaddr) != expected {
+		return fmt.Errorf("%w: %d bytes, link %s expects %d", ErrBadHardwareAddrLen, len(hwaddr), base.Name, expected)
+	}
+	return nil
+}
+
 // LinkSetHardwareAddr sets the hardware address of the link device.
+// Its length is first checked against the one of the current address of
+// the link, or of its EncapType, failing with ErrBadHardwareAddrLen.
 // Equivalent to: `ip link set $link address $hwaddr`
 func LinkSetHardwareAddr(link Link, hwaddr net.HardwareAddr) error {
 	return pkgHandle.LinkSetHardwareAddr(link, hwaddr)
 }
 
 // LinkSetHardwareAddr sets the hardware address of the link device.
+// Its length is first checked against the one of the current address of
+// the link, or of its EncapType, failing with ErrBadHardwareAddrLen.
 // Equivalent to: `ip link set $link address $hwaddr`
 func (h *Handle) LinkSetHardwareAddr(link Link, hwaddr net.HardwareAddr) error {
+	return h.LinkSetHardwareAddrWithOptions(link, hwaddr, nil)
+}
+
+// LinkSetHardwareAddrWithOptions works like LinkSetHardwareAddr, with
+// options.Force sending hwaddr whatever its length.
+// Equivalent to: `ip link set $link address $hwaddr`
+func LinkSetHardwareAddrWithOptions(link Link, hwaddr net.HardwareAddr, options *LinkSetHardwareAddrOptions) error {
+	return pkgHandle.LinkSetHardwareAddrWithOptions(link, hwaddr, options)
+}
+
+// LinkSetHardwareAddrWithOptions works like LinkSetHardwareAddr, with
+// options.Force sending hwaddr whatever its length.
+// Equivalent to: `ip link set $link address $hwaddr`
+func (h *Handle) LinkSetHardwareAddrWithOptions(link Link, hwaddr net.HardwareAddr, options *LinkSetHardwareAddrOptions) error {
 	base := link.Attrs()
+	if options == nil || !options.Force {
+		if err := validateHardwareAddr(base, hwaddr); err != nil {
+			return err
+		}
+	}
 	h.ensureIndex(base)
 	req := h.newNetlinkRequest(unix.RTM_SETLINK, unix.NLM_F_ACK)
 
@@ -666,14 +722,29 @@ func (h *Handle) LinkSetVfVlanQos(link Link, vf, vlan, qos int) error {
 }
 
 // LinkSetVfVlanQosProto sets the vlan, qos and protocol of a vf for the link.
+// proto is either VLAN_PROTOCOL_8021Q, the default when 0, or
+// VLAN_PROTOCOL_8021AD for QinQ. It is set through IFLA_VF_VLAN_LIST, and
+// drivers that only support the legacy 802.1Q form fail with an error
+// wrapping EOPNOTSUPP or EPROTONOSUPPORT.
 // Equivalent to: `ip link set $link vf $vf vlan $vlan qos $qos proto $proto`
 func LinkSetVfVlanQosProto(link Link, vf, vlan, qos, proto int) error {
 	return pkgHandle.LinkSetVfVlanQosProto(link, vf, vlan, qos, proto)
 }
 
 // LinkSetVfVlanQosProto sets the vlan, qos and protocol of a vf for the link.
+// proto is either VLAN_PROTOCOL_8021Q, the default when 0, or
+// VLAN_PROTOCOL_8021AD for QinQ. It is set through IFLA_VF_VLAN_LIST, and
+// drivers that only support the legacy 802.1Q form fail with an error
+// wrapping EOPNOTSUPP or EPROTONOSUPPORT.
 // Equivalent to: `ip link set $link vf $vf vlan $vlan qos $qos proto $proto`
 func (h *Handle) LinkSetVfVlanQosProto(link Link, vf, vlan, qos, proto int) error {
+	switch VlanProtocol(proto) {
+	case VLAN_PROTOCOL_UNKNOWN:
+		proto = int(VLAN_PROTOCOL_8021Q)
+	case VLAN_PROTOCOL_8021Q, VLAN_PROTOCOL_8021AD:
+	default:
+		return fmt.Errorf("invalid vf vlan protocol %#x", proto)
+	}
 	base := link.Attrs()
 	h.ensureIndex(base)
 	req := h.newNetlinkRequest(unix.RTM_SETLINK, unix.NLM_F_ACK)
@@ -692,13 +763,16 @@ func (h *Handle) LinkSetVfVlanQosProto(link Link, vf, vlan, qos, proto int) erro
 			Vlan: uint32(vlan),
 			Qos:  uint32(qos),
 		},
-		VlanProto: (uint16(proto)>>8)&0xFF | (uint16(proto)&0xFF)<<8,
+		VlanProto: uint16(proto),
 	}
 
 	vfVlanList.AddRtAttr(nl.IFLA_VF_VLAN_INFO, vfmsg.Serialize())
 	req.AddData(data)
 
 	_, err := req.Execute(unix.NETLINK_ROUTE, 0)
+	if errors.Is(err, unix.EOPNOTSUPP) || errors.Is(err, unix.EPROTONOSUPPORT) {
+		return fmt.Errorf("the driver of %s does not support setting the vlan protocol of vf %d: %w", base.Name, vf, err)
+	}
 	return err
 }
 
@@ -1127,6 +1201,76 @@ func (h *Handle) LinkSetGROMaxSize(link Link, maxSize int) error {
 	return err
 }
 
+// VethSetGSOMaxSize sets the IPv6 GSO maximum size of the veth link and,
+// if both is set, of its peer. If the peer can't be updated the link is
+// restored to its previous size.
+// Equivalent to: `ip link set $link gso_max_size $maxSize && ip link set $peer gso_max_size $maxSize`
+func VethSetGSOMaxSize(link *Veth, maxSize int, both bool) error {
+	return pkgHandle.VethSetGSOMaxSize(link, maxSize, both)
+}
+
+// VethSetGSOMaxSize sets the IPv6 GSO maximum size of the veth link and,
+// if both is set, of its peer. If the peer can't be updated the link is
+// restored to its previous size.
+// Equivalent to: `ip link set $link gso_max_size $maxSize && ip link set $peer gso_max_size $maxSize`
+func (h *Handle) VethSetGSOMaxSize(link *Veth, maxSize int, both bool) error {
+	return h.vethSetMaxSize(link, maxSize, both, func(attrs *LinkAttrs) uint32 { return attrs.GSOMaxSize }, h.LinkSetGSOMaxSize)
+}
+
+// VethSetGROMaxSize sets the IPv6 GRO maximum size of the veth link and,
+// if both is set, of its peer. If the peer can't be updated the link is
+// restored to its previous size.
+// Equivalent to: `ip link set $link gro_max_size $maxSize && ip link set $peer gro_max_size $maxSize`
+func VethSetGROMaxSize(link *Veth, maxSize int, both bool) error {
+	return pkgHandle.VethSetGROMaxSize(link, maxSize, both)
+}
+
+// VethSetGROMaxSize sets the IPv6 GRO maximum size of the veth link and,
+// if both is set, of its peer. If the peer can't be updated the link is
+// restored to its previous size.
+// Equivalent to: `ip link set $link gro_max_size $maxSize && ip link set $peer gro_max_size $maxSize`
+func (h *Handle) VethSetGROMaxSize(link *Veth, maxSize int, both bool) error {
+	return h.vethSetMaxSize(link, maxSize, both, func(attrs *LinkAttrs) uint32 { return attrs.GROMaxSize }, h.LinkSetGROMaxSize)
+}
+
+func (h *Handle) vethSetMaxSize(link *Veth, maxSize int, both bool, get func(*LinkAttrs) uint32, set func(Link, int) error) error {
+	if !both {
+		return set(link, maxSize)
+	}
+	base := link.Attrs()
+	h.ensureIndex(base)
+	current, err := h.LinkByIndex(base.Index)
+	if err != nil {
+		return err
+	}
+	// Use the peer index read through h, the one of link may be unset and
+	// VethPeerIndex would then query the namespace of the caller.
+	veth, ok := current.(*Veth)
+	if !ok {
+		return fmt.Errorf("%q is not a veth", base.Name)
+	}
+	peer, err := h.LinkByIndex(veth.PeerIndex)
+	if err != nil {
+		return fmt.Errorf("failed to get peer of %q: %w", base.Name, err)
+	}
+	// PeerIndex is an index of the peer's namespace, make sure it names
+	// the actual peer.
+	if peer.Attrs().ParentIndex != base.Index {
+		return fmt.Errorf("peer of %q is not in the same namespace", base.Name)
+	}
+
+	if err := set(link, maxSize); err != nil {
+		return err
+	}
+	if err := set(peer, maxSize); err != nil {
+		if rollbackErr := set(link, int(get(current.Attrs()))); rollbackErr != nil {
+			return fmt.Errorf("failed to set peer %q: %w, and to restore %q: %v", peer.Attrs().Name, err, base.Name, rollbackErr)
+		}
+		return fmt.Errorf("failed to set peer %q: %w", peer.Attrs().Name, err)
+	}
+	return nil
+}
+
 // LinkSetGSOIPv4MaxSize sets the IPv4 GSO maximum size of the link device.
 // Equivalent to: `ip link set $link gso_ipv4_max_size $maxSize`
 func LinkSetGSOIPv4MaxSize(link Link, maxSize int) error {
@@ -1193,7 +1337,11 @@ type vxlanPortRange struct {
 	Lo, Hi uint16
 }
 
-func addVxlanAttrs(vxlan *Vxlan, linkInfo *nl.RtAttr) {
+// addVxlanAttrs encodes vxlan. When the link is modified rather than
+// created, only the attributes the kernel's changelink accepts are sent,
+// it refuses the others even when their value is unchanged.
+func addVxlanAttrs(vxlan *Vxlan, linkInfo *nl.RtAttr, flags int) {
+	create := flags&unix.NLM_F_CREATE != 0
 	data := linkInfo.AddRtAttr(nl.IFLA_INFO_DATA, nil)
 
 	if vxlan.FlowBased {
@@ -1231,6 +1379,18 @@ func addVxlanAttrs(vxlan *Vxlan, linkInfo *nl.RtAttr) {
 	data.AddRtAttr(nl.IFLA_VXLAN_TTL, nl.Uint8Attr(uint8(vxlan.TTL)))
 	data.AddRtAttr(nl.IFLA_VXLAN_TOS, nl.Uint8Attr(uint8(vxlan.TOS)))
 	data.AddRtAttr(nl.IFLA_VXLAN_LEARNING, boolAttr(vxlan.Learning))
+	if vxlan.NoAge {
+		data.AddRtAttr(nl.IFLA_VXLAN_AGEING, nl.Uint32Attr(0))
+	} else if vxlan.Age > 0 {
+		data.AddRtAttr(nl.IFLA_VXLAN_AGEING, nl.Uint32Attr(uint32(vxlan.Age)))
+	}
+	if vxlan.DF != VXLAN_DF_UNSET {
+		data.AddRtAttr(nl.IFLA_VXLAN_DF, nl.Uint8Attr(uint8(vxlan.DF)))
+	}
+	if !create {
+		return
+	}
+
 	data.AddRtAttr(nl.IFLA_VXLAN_PROXY, boolAttr(vxlan.Proxy))
 	data.AddRtAttr(nl.IFLA_VXLAN_RSC, boolAttr(vxlan.RSC))
 	data.AddRtAttr(nl.IFLA_VXLAN_L2MISS, boolAttr(vxlan.L2miss))
@@ -1247,11 +1407,6 @@ func addVxlanAttrs(vxlan *Vxlan, linkInfo *nl.RtAttr) {
 	if vxlan.FlowBased {
 		data.AddRtAttr(nl.IFLA_VXLAN_FLOWBASED, boolAttr(vxlan.FlowBased))
 	}
-	if vxlan.NoAge {
-		data.AddRtAttr(nl.IFLA_VXLAN_AGEING, nl.Uint32Attr(0))
-	} else if vxlan.Age > 0 {
-		data.AddRtAttr(nl.IFLA_VXLAN_AGEING, nl.Uint32Attr(uint32(vxlan.Age)))
-	}
 	if vxlan.Limit > 0 {
 		data.AddRtAttr(nl.IFLA_VXLAN_LIMIT, nl.Uint32Attr(uint32(vxlan.Limit)))
 	}
@@ -1266,6 +1421,9 @@ func addVxlanAttrs(vxlan *Vxlan, linkInfo *nl.RtAttr) {
 
 		data.AddRtAttr(nl.IFLA_VXLAN_PORT_RANGE, buf.Bytes())
 	}
+	if vxlan.VniFilter {
+		data.AddRtAttr(nl.IFLA_VXLAN_VNIFILTER, boolAttr(vxlan.VniFilter))
+	}
 }
 
 func addBondAttrs(bond *Bond, linkInfo *nl.RtAttr) {
@@ -1305,6 +1463,14 @@ func addBondAttrs(bond *Bond, linkInfo *nl.RtAttr) {
 			}
 		}
 	}
+	if bond.NsIP6Targets != nil {
+		msg := data.AddRtAttr(nl.IFLA_BOND_NS_IP6_TARGET, nil)
+		for i := range bond.NsIP6Targets {
+			if ip := bond.NsIP6Targets[i].To16(); ip != nil {
+				msg.AddRtAttr(i, []byte(ip))
+			}
+		}
+	}
 	if bond.ArpValidate >= 0 {
 		data.AddRtAttr(nl.IFLA_BOND_ARP_VALIDATE, nl.Uint32Attr(uint32(bond.ArpValidate)))
 	}
@@ -1390,7 +1556,8 @@ func (h *Handle) LinkModify(link Link) error {
 }
 
 func (h *Handle) linkModify(link Link, flags int) error {
-	// TODO: support extra data for macvlan
+	// TODO: support sending Macvlan.MACAddrs, MacvlanMACAddrSet sets them
+	// meanwhile
 	base := link.Attrs()
 
 	// if tuntap, then the name can be empty, OS will provide a name
@@ -1798,7 +1965,7 @@ func (h *Handle) linkModify(link Link, flags int) error {
 			}
 		}
 	case *Vxlan:
-		addVxlanAttrs(link, linkInfo)
+		addVxlanAttrs(link, linkInfo, flags)
 	case *Bond:
 		addBondAttrs(link, linkInfo)
 	case *IPVlan:
@@ -1834,14 +2001,34 @@ func (h *Handle) linkModify(link Link, flags int) error {
 	case *GTP:
 		addGTPAttrs(link, linkInfo)
 	case *Xfrmi:
-		addXfrmiAttrs(link, linkInfo)
+		if err := addXfrmiAttrs(link, linkInfo); err != nil {
+			return err
+		}
 	case *IPoIB:
 		addIPoIBAttrs(link, linkInfo)
 	case *BareUDP:
+		if err := validateBareUDP(link); err != nil {
+			return err
+		}
 		addBareUDPAttrs(link, linkInfo)
+	case *GenericLink:
+		if codec := lookupLinkKind(link.Type()); codec != nil {
+			if err := addRegisteredLinkKindAttrs(codec, link, linkInfo); err != nil {
+				return err
+			}
+		} else if len(link.Data) > 0 {
+			linkInfo.AddRtAttr(nl.IFLA_INFO_DATA, link.Data)
+		}
+	default:
+		if codec := lookupLinkKind(link.Type()); codec != nil {
+			if err := addRegisteredLinkKindAttrs(codec, link, linkInfo); err != nil {
+				return err
+			}
+		}
 	}
 
 	req.AddData(linkInfo)
+	addExtraAttrs(req, base.Extras)
 
 	_, err := req.Execute(unix.NETLINK_ROUTE, 0)
 	if err != nil {
@@ -1860,6 +2047,8 @@ func (h *Handle) linkModify(link Link, flags int) error {
 
 // LinkDel deletes link device. Either Index or Name must be set in
 // the link object for it to be deleted. The other values are ignored.
+// If the link does not exist a LinkNotFoundError wrapping unix.ENODEV is
+// returned.
 // Equivalent to: `ip link del $link`
 func LinkDel(link Link) error {
 	return pkgHandle.LinkDel(link)
@@ -1867,6 +2056,8 @@ func LinkDel(link Link) error {
 
 // LinkDel deletes link device. Either Index or Name must be set in
 // the link object for it to be deleted. The other values are ignored.
+// If the link does not exist a LinkNotFoundError wrapping unix.ENODEV is
+// returned.
 // Equivalent to: `ip link del $link`
 func (h *Handle) LinkDel(link Link) error {
 	base := link.Attrs()
@@ -1878,44 +2069,179 @@ func (h *Handle) LinkDel(link Link) error {
 	msg := nl.NewIfInfomsg(unix.AF_UNSPEC)
 	msg.Index = int32(base.Index)
 	req.AddData(msg)
+	if base.Index == 0 && base.Name != "" {
+		// not found by name, let the kernel report it
+		req.AddData(nl.NewRtAttr(unix.IFLA_IFNAME, nl.ZeroTerminated(base.Name)))
+	}
 
 	_, err := req.Execute(unix.NETLINK_ROUTE, 0)
+	if errors.Is(err, unix.ENODEV) {
+		if base.Index != 0 {
+			return LinkNotFoundError{fmt.Errorf("Link with index %d not found: %w", base.Index, err)}
+		}
+		return LinkNotFoundError{fmt.Errorf("Link %s not found: %w", base.Name, err)}
+	}
 	return err
 }
 
-func (h *Handle) linkByNameDump(name string) (Link, error) {
-	links, executeErr := h.LinkList()
-	if executeErr != nil && !errors.Is(executeErr, ErrDumpInterrupted) {
-		return nil, executeErr
+// LinkDelIdempotent deletes link device like LinkDel, but succeeds when
+// the link does not exist, e.g. when reconciling to a state without it.
+func LinkDelIdempotent(link Link) error {
+	return pkgHandle.LinkDelIdempotent(link)
+}
+
+// LinkDelIdempotent deletes link device like LinkDel, but succeeds when
+// the link does not exist, e.g. when reconciling to a state without it.
+func (h *Handle) LinkDelIdempotent(link Link) error {
+	err := h.LinkDel(link)
+	var notFound LinkNotFoundError
+	if errors.As(err, &notFound) {
+		return nil
 	}
+	return err
+}
 
-	for _, link := range links {
-		if link.Attrs().Name == name {
-			return link, executeErr
+// LinkDelByIndex deletes the link device with the given index.
+// If the link does not exist a LinkNotFoundError is returned.
+// Equivalent to: `ip link del dev $index`
+func LinkDelByIndex(index int) error {
+	return pkgHandle.LinkDelByIndex(index)
+}
+
+// LinkDelByIndex deletes the link device with the given index.
+// If the link does not exist a LinkNotFoundError is returned.
+// Equivalent to: `ip link del dev $index`
+func (h *Handle) LinkDelByIndex(index int) error {
+	req := h.newNetlinkRequest(unix.RTM_DELLINK, unix.NLM_F_ACK)
+
+	msg := nl.NewIfInfomsg(unix.AF_UNSPEC)
+	msg.Index = int32(index)
+	req.AddData(msg)
+
+	_, err := req.Execute(unix.NETLINK_ROUTE, 0)
+	if errors.Is(err, unix.ENODEV) {
+		return LinkNotFoundError{fmt.Errorf("Link with index %d not found", index)}
+	}
+	return err
+}
+
+// LinkDelByName deletes the link device with the given name.
+// If the link does not exist a LinkNotFoundError is returned.
+// Equivalent to: `ip link del $name`
+func LinkDelByName(name string) error {
+	return pkgHandle.LinkDelByName(name)
+}
+
+// LinkDelByName deletes the link device with the given name.
+// If the link does not exist a LinkNotFoundError is returned.
+// Equivalent to: `ip link del $name`
+func (h *Handle) LinkDelByName(name string) error {
+	req := h.newNetlinkRequest(unix.RTM_DELLINK, unix.NLM_F_ACK)
+
+	msg := nl.NewIfInfomsg(unix.AF_UNSPEC)
+	req.AddData(msg)
+
+	nameData := nl.NewRtAttr(unix.IFLA_IFNAME, nl.ZeroTerminated(name))
+	req.AddData(nameData)
+
+	_, err := req.Execute(unix.NETLINK_ROUTE, 0)
+	if errors.Is(err, unix.ENODEV) {
+		return LinkNotFoundError{fmt.Errorf("Link %s not found", name)}
+	}
+	return err
+}
+
+// linkFindDump returns the first link of a dump for which match returns
+// true, without decoding the rest of the dump. A nil link is returned if
+// there is none, along with ErrDumpInterrupted if the dump was still
+// interrupted after retrying.
+func (h *Handle) linkFindDump(match func(Link) bool) (Link, error) {
+	for i := 0; ; i++ {
+		var (
+			found    Link
+			parseErr error
+		)
+		executeErr := h.linkDumpRequest().ExecuteIter(unix.NETLINK_ROUTE, unix.RTM_NEWLINK, func(m []byte) bool {
+			link, err := linkDeserialize(nil, m, h.options.keepExtras)
+			if err != nil {
+				parseErr = err
+				return false
+			}
+			if match(link) {
+				found = link
+				return false
+			}
+			return true
+		})
+		if parseErr != nil {
+			return nil, parseErr
 		}
+		if executeErr != nil && !errors.Is(executeErr, ErrDumpInterrupted) {
+			return nil, executeErr
+		}
+		if found != nil || executeErr == nil || i >= h.options.dumpRetries {
+			return found, executeErr
+		}
+	}
+}
 
+func (h *Handle) linkByNameDump(name string) (Link, error) {
+	link, executeErr := h.linkFindDump(func(link Link) bool {
+		if link.Attrs().Name == name {
+			return true
+		}
 		// support finding interfaces also via altnames
 		for _, altName := range link.Attrs().AltNames {
 			if altName == name {
-				return link, executeErr
+				return true
 			}
 		}
+		return false
+	})
+	if link == nil && (executeErr == nil || errors.Is(executeErr, ErrDumpInterrupted)) {
+		return nil, LinkNotFoundError{fmt.Errorf("Link %s not found", name)}
 	}
-	return nil, LinkNotFoundError{fmt.Errorf("Link %s not found", name)}
+	return link, executeErr
 }
 
 func (h *Handle) linkByAliasDump(alias string) (Link, error) {
-	links, executeErr := h.LinkList()
-	if executeErr != nil && !errors.Is(executeErr, ErrDumpInterrupted) {
-		return nil, executeErr
+	link, executeErr := h.linkFindDump(func(link Link) bool {
+		return link.Attrs().Alias == alias
+	})
+	if link == nil && (executeErr == nil || errors.Is(executeErr, ErrDumpInterrupted)) {
+		return nil, LinkNotFoundError{fmt.Errorf("Link alias %s not found", alias)}
 	}
+	return link, executeErr
+}
 
-	for _, link := range links {
-		if link.Attrs().Alias == alias {
-			return link, executeErr
-		}
+// LinkByHardwareAddr finds a link by its hardware address and returns a
+// pointer to the object. The kernel cannot look links up by address, so
+// this scans a dump, stopping at the first match. When several links share
+// the address, as VLANs and bond members do, the first one reported is
+// returned.
+//
+// If the returned error is [ErrDumpInterrupted] the result may be missing
+// or outdated.
+func LinkByHardwareAddr(addr net.HardwareAddr) (Link, error) {
+	return pkgHandle.LinkByHardwareAddr(addr)
+}
+
+// LinkByHardwareAddr finds a link by its hardware address and returns a
+// pointer to the object. The kernel cannot look links up by address, so
+// this scans a dump, stopping at the first match. When several links share
+// the address, as VLANs and bond members do, the first one reported is
+// returned.
+//
+// If the returned error is [ErrDumpInterrupted] the result may be missing
+// or outdated.
+func (h *Handle) LinkByHardwareAddr(addr net.HardwareAddr) (Link, error) {
+	link, executeErr := h.linkFindDump(func(link Link) bool {
+		return bytes.Equal(link.Attrs().HardwareAddr, addr)
+	})
+	if link == nil && (executeErr == nil || errors.Is(executeErr, ErrDumpInterrupted)) {
+		return nil, LinkNotFoundError{fmt.Errorf("Link with address %s not found", addr)}
 	}
-	return nil, LinkNotFoundError{fmt.Errorf("Link alias %s not found", alias)}
+	return link, executeErr
 }
 
 // LinkByName finds a link by name and returns a pointer to the object.
@@ -1953,7 +2279,7 @@ func (h *Handle) LinkByName(name string) (Link, error) {
 	}
 	req.AddData(nameData)
 
-	link, err := execGetLink(req)
+	link, err := execGetLink(req, h.options.keepExtras)
 	if err == unix.EINVAL {
 		// older kernels don't support looking up via IFLA_IFNAME
 		// so fall back to dumping all links
@@ -1998,7 +2324,7 @@ func (h *Handle) LinkByAlias(alias string) (Link, error) {
 	nameData := nl.NewRtAttr(unix.IFLA_IFALIAS, nl.ZeroTerminated(alias))
 	req.AddData(nameData)
 
-	link, err := execGetLink(req)
+	link, err := execGetLink(req, h.options.keepExtras)
 	if err == unix.EINVAL {
 		// older kernels don't support looking up via IFLA_IFALIAS
 		// so fall back to dumping all links
@@ -2009,6 +2335,91 @@ func (h *Handle) LinkByAlias(alias string) (Link, error) {
 	return link, err
 }
 
+// LinkStatsPoll reads the statistics of link every interval and sends the
+// counters, along with their change since the previous reading, down ch.
+// Counters are treated as 32 bit wide, see LinkStatsDelta, until one of
+// them exceeds 2^32-1. Close the 'done' chan to stop polling; ch is
+// closed once polling stops, which also happens when the link can no
+// longer be read, e.g. because it was deleted. interval must be positive.
+func LinkStatsPoll(link Link, interval time.Duration, ch chan<- LinkStatsSample, done <-chan struct{}) error {
+	return pkgHandle.LinkStatsPoll(link, interval, ch, done)
+}
+
+// LinkStatsPoll reads the statistics of link every interval and sends the
+// counters, along with their change since the previous reading, down ch.
+// Counters are treated as 32 bit wide, see LinkStatsDelta, until one of
+// them exceeds 2^32-1. Close the 'done' chan to stop polling; ch is
+// closed once polling stops, which also happens when the link can no
+// longer be read, e.g. because it was deleted. interval must be positive.
+func (h *Handle) LinkStatsPoll(link Link, interval time.Duration, ch chan<- LinkStatsSample, done <-chan struct{}) error {
+	if interval <= 0 {
+		return fmt.Errorf("invalid polling interval %v", interval)
+	}
+	// Polling happens on another goroutine, which may run on a thread
+	// in another namespace, so bind a socket to the current one.
+	ph := h
+	if h.sockets == nil {
+		var err error
+		if ph, err = NewHandle(unix.NETLINK_ROUTE); err != nil {
+			return err
+		}
+	}
+	closeHandle := func() {
+		if ph != h {
+			ph.Close()
+		}
+	}
+	index := link.Attrs().Index
+	readStats := func() (*LinkStatistics, error) {
+		l, err := ph.LinkByIndex(index)
+		if err != nil {
+			return nil, err
+		}
+		if l.Attrs().Statistics == nil {
+			return nil, fmt.Errorf("link %d reported no statistics", index)
+		}
+		return l.Attrs().Statistics, nil
+	}
+	prev, err := readStats()
+	if err != nil {
+		closeHandle()
+		return err
+	}
+	go func() {
+		defer close(ch)
+		defer closeHandle()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		is64bit := prev.is64bit()
+		last := time.Now()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				cur, err := readStats()
+				if err != nil {
+					return
+				}
+				is64bit = is64bit || cur.is64bit()
+				sample := LinkStatsSample{
+					Time:       now,
+					Interval:   now.Sub(last),
+					Statistics: cur,
+					Delta:      LinkStatsDelta(prev, cur, is64bit),
+				}
+				select {
+				case ch <- sample:
+				case <-done:
+					return
+				}
+				prev, last = cur, now
+			}
+		}
+	}()
+	return nil
+}
+
 // LinkByIndex finds a link by index and returns a pointer to the object.
 func LinkByIndex(index int) (Link, error) {
 	return pkgHandle.LinkByIndex(index)
@@ -2026,11 +2437,56 @@ func (h *Handle) LinkByIndex(index int) (Link, error) {
 		req.AddData(attr)
 	}
 
-	return execGetLink(req)
+	return execGetLink(req, h.options.keepExtras)
+}
+
+func execGetLink(req *nl.NetlinkRequest, keepExtras bool) (Link, error) {
+	link, err := execGetLinkOnce(req, keepExtras)
+	if err != nil || len(link.Attrs().Vfs) >= link.Attrs().NumVfs || !skipVfStats(req) {
+		return link, err
+	}
+	// The VF list of devices with hundreds of VFs overflows the 16 bit
+	// length of IFLA_VFINFO_LIST, get it again without the VF statistics
+	// to make it smaller.
+	if retry, err := execGetLinkOnce(req, keepExtras); err == nil && len(retry.Attrs().Vfs) > len(link.Attrs().Vfs) {
+		return retry, nil
+	}
+	return link, nil
 }
 
-func execGetLink(req *nl.NetlinkRequest) (Link, error) {
-	msgs, err := req.Execute(unix.NETLINK_ROUTE, 0)
+// skipVfStats adds RTEXT_FILTER_SKIP_STATS to the IFLA_EXT_MASK of req, it
+// returns false if req has no IFLA_EXT_MASK or it already has the flag.
+func skipVfStats(req *nl.NetlinkRequest) bool {
+	for _, data := range req.Data {
+		attr, ok := data.(*nl.RtAttr)
+		if !ok || attr.Type != unix.IFLA_EXT_MASK {
+			continue
+		}
+		mask := native.Uint32(attr.Data)
+		if mask&nl.RTEXT_FILTER_SKIP_STATS != 0 {
+			return false
+		}
+		attr.Data = nl.Uint32Attr(mask | nl.RTEXT_FILTER_SKIP_STATS)
+		return true
+	}
+	return false
+}
+
+// executeLinkRequest executes req, again with a larger receive buffer as
+// long as the response doesn't fit, as happens with the VF information of
+// devices with many VFs.
+func executeLinkRequest(req *nl.NetlinkRequest, resType uint16) ([][]byte, error) {
+	msgs, err := req.Execute(unix.NETLINK_ROUTE, resType)
+	var truncErr *nl.MessageTruncatedError
+	for errors.As(err, &truncErr) && truncErr.Len > req.ReceiveBufferSize {
+		req.ReceiveBufferSize = truncErr.Len
+		msgs, err = req.Execute(unix.NETLINK_ROUTE, resType)
+	}
+	return msgs, err
+}
+
+func execGetLinkOnce(req *nl.NetlinkRequest, keepExtras bool) (Link, error) {
+	msgs, err := executeLinkRequest(req, 0)
 	if err != nil {
 		if errno, ok := err.(syscall.Errno); ok {
 			if errno == unix.ENODEV {
@@ -2045,16 +2501,93 @@ func execGetLink(req *nl.NetlinkRequest) (Link, error) {
 		return nil, LinkNotFoundError{fmt.Errorf("Link not found")}
 
 	case len(msgs) == 1:
-		return LinkDeserialize(nil, msgs[0])
+		return linkDeserialize(nil, msgs[0], keepExtras)
 
 	default:
 		return nil, fmt.Errorf("More than one link found")
 	}
 }
 
+// linkAttrKnown holds the top-level link attributes LinkDeserialize
+// decodes or deliberately ignores. Only the other ones go to
+// LinkAttrs.Extras, the ignored ones are reported by the kernel and must
+// not be sent back.
+var linkAttrKnown = map[uint16]bool{
+	unix.IFLA_UNSPEC:              true,
+	unix.IFLA_ADDRESS:             true,
+	unix.IFLA_BROADCAST:           true,
+	unix.IFLA_IFNAME:              true,
+	unix.IFLA_MTU:                 true,
+	unix.IFLA_LINK:                true,
+	unix.IFLA_QDISC:               true,
+	unix.IFLA_STATS:               true,
+	unix.IFLA_COST:                true,
+	unix.IFLA_PRIORITY:            true,
+	unix.IFLA_MASTER:              true,
+	unix.IFLA_WIRELESS:            true,
+	unix.IFLA_PROTINFO:            true,
+	unix.IFLA_TXQLEN:              true,
+	unix.IFLA_MAP:                 true,
+	unix.IFLA_WEIGHT:              true,
+	unix.IFLA_OPERSTATE:           true,
+	unix.IFLA_LINKMODE:            true,
+	unix.IFLA_LINKINFO:            true,
+	unix.IFLA_NET_NS_PID:          true,
+	unix.IFLA_IFALIAS:             true,
+	unix.IFLA_NUM_VF:              true,
+	unix.IFLA_VFINFO_LIST:         true,
+	unix.IFLA_STATS64:             true,
+	unix.IFLA_VF_PORTS:            true,
+	unix.IFLA_PORT_SELF:           true,
+	unix.IFLA_AF_SPEC:             true,
+	unix.IFLA_GROUP:               true,
+	unix.IFLA_NET_NS_FD:           true,
+	unix.IFLA_EXT_MASK:            true,
+	unix.IFLA_PROMISCUITY:         true,
+	unix.IFLA_NUM_TX_QUEUES:       true,
+	unix.IFLA_NUM_RX_QUEUES:       true,
+	unix.IFLA_CARRIER:             true,
+	unix.IFLA_PHYS_PORT_ID:        true,
+	unix.IFLA_CARRIER_CHANGES:     true,
+	unix.IFLA_PHYS_SWITCH_ID:      true,
+	unix.IFLA_LINK_NETNSID:        true,
+	unix.IFLA_PHYS_PORT_NAME:      true,
+	unix.IFLA_PROTO_DOWN:          true,
+	unix.IFLA_GSO_MAX_SEGS:        true,
+	unix.IFLA_GSO_MAX_SIZE:        true,
+	unix.IFLA_PAD:                 true,
+	unix.IFLA_XDP:                 true,
+	unix.IFLA_EVENT:               true,
+	unix.IFLA_NEW_NETNSID:         true,
+	unix.IFLA_IF_NETNSID:          true,
+	unix.IFLA_CARRIER_UP_COUNT:    true,
+	unix.IFLA_CARRIER_DOWN_COUNT:  true,
+	unix.IFLA_NEW_IFINDEX:         true,
+	unix.IFLA_MIN_MTU:             true,
+	unix.IFLA_MAX_MTU:             true,
+	unix.IFLA_PROP_LIST:           true,
+	unix.IFLA_ALT_IFNAME:          true,
+	unix.IFLA_PERM_ADDRESS:        true,
+	unix.IFLA_PROTO_DOWN_REASON:   true,
+	unix.IFLA_PARENT_DEV_NAME:     true,
+	unix.IFLA_PARENT_DEV_BUS_NAME: true,
+	unix.IFLA_GRO_MAX_SIZE:        true,
+	unix.IFLA_TSO_MAX_SIZE:        true,
+	unix.IFLA_TSO_MAX_SEGS:        true,
+	unix.IFLA_ALLMULTI:            true,
+	unix.IFLA_DEVLINK_PORT:        true,
+	unix.IFLA_GSO_IPV4_MAX_SIZE:   true,
+	unix.IFLA_GRO_IPV4_MAX_SIZE:   true,
+	nl.IFLA_NETNS_IMMUTABLE:       true,
+}
+
 // LinkDeserialize deserializes a raw message received from netlink into
 // a link object.
 func LinkDeserialize(hdr *unix.NlMsghdr, m []byte) (Link, error) {
+	return linkDeserialize(hdr, m, false)
+}
+
+func linkDeserialize(hdr *unix.NlMsghdr, m []byte, keepExtras bool) (Link, error) {
 	msg := nl.DeserializeIfInfomsg(m)
 
 	attrs, err := nl.ParseRouteAttr(m[msg.Len():])
@@ -2064,16 +2597,9 @@ func LinkDeserialize(hdr *unix.NlMsghdr, m []byte) (Link, error) {
 
 	base := NewLinkAttrs()
 	base.Index = int(msg.Index)
-	base.RawFlags = msg.Flags
-	base.Flags = linkFlags(msg.Flags)
+	setLinkFlags(&base, msg.Flags)
 	base.EncapType = msg.EncapType()
 	base.NetNsID = -1
-	if msg.Flags&unix.IFF_ALLMULTI != 0 {
-		base.Allmulti = 1
-	}
-	if msg.Flags&unix.IFF_MULTICAST != 0 {
-		base.Multi = 1
-	}
 
 	var (
 		link      Link
@@ -2082,6 +2608,9 @@ func LinkDeserialize(hdr *unix.NlMsghdr, m []byte) (Link, error) {
 		linkType  string
 		linkSlave LinkSlave
 		slaveType string
+		codec     LinkCodec
+		codecData []syscall.NetlinkRouteAttr
+		hasParent bool
 	)
 	for _, attr := range attrs {
 		switch attr.Attr.Type {
@@ -2099,6 +2628,8 @@ func LinkDeserialize(hdr *unix.NlMsghdr, m []byte) (Link, error) {
 						link = &Dummy{}
 					case "ifb":
 						link = &Ifb{}
+					case "team":
+						link = &Team{}
 					case "bridge":
 						link = &Bridge{}
 					case "vlan":
@@ -2155,6 +2686,7 @@ func LinkDeserialize(hdr *unix.NlMsghdr, m []byte) (Link, error) {
 						link = &BareUDP{}
 					default:
 						link = &GenericLink{LinkType: linkType}
+						codec = lookupLinkKind(linkType)
 					}
 				case nl.IFLA_INFO_DATA:
 					data, err := nl.ParseRouteAttr(info.Value)
@@ -2212,6 +2744,11 @@ func LinkDeserialize(hdr *unix.NlMsghdr, m []byte) (Link, error) {
 						parseCanData(link, data)
 					case "bareudp":
 						parseBareUDPData(link, data)
+					default:
+						codecData = data
+						if generic, ok := link.(*GenericLink); ok {
+							generic.Data = info.Value
+						}
 					}
 
 				case nl.IFLA_INFO_SLAVE_KIND:
@@ -2258,6 +2795,7 @@ func LinkDeserialize(hdr *unix.NlMsghdr, m []byte) (Link, error) {
 			base.Promisc = int(native.Uint32(attr.Value[0:4]))
 		case unix.IFLA_LINK:
 			base.ParentIndex = int(native.Uint32(attr.Value[0:4]))
+			hasParent = true
 		case unix.IFLA_MASTER:
 			base.MasterIndex = int(native.Uint32(attr.Value[0:4]))
 		case unix.IFLA_TXQLEN:
@@ -2332,12 +2870,26 @@ func LinkDeserialize(hdr *unix.NlMsghdr, m []byte) (Link, error) {
 				return nil, err
 			}
 			base.Vfs = vfs
+		case unix.IFLA_NUM_VF:
+			base.NumVfs = int(native.Uint32(attr.Value[0:4]))
 		case unix.IFLA_NUM_TX_QUEUES:
 			base.NumTxQueues = int(native.Uint32(attr.Value[0:4]))
 		case unix.IFLA_NUM_RX_QUEUES:
 			base.NumRxQueues = int(native.Uint32(attr.Value[0:4]))
 		case unix.IFLA_GROUP:
 			base.Group = native.Uint32(attr.Value[0:4])
+		case unix.IFLA_MAP:
+			if len(attr.Value) >= nl.SizeofRtnlLinkIfmap {
+				m := nl.DeserializeRtnlLinkIfmap(attr.Value)
+				base.Map = &LinkMap{
+					MemStart: m.MemStart,
+					MemEnd:   m.MemEnd,
+					BaseAddr: m.BaseAddr,
+					Irq:      m.Irq,
+					Dma:      m.Dma,
+					Port:     m.Port,
+				}
+			}
 		case unix.IFLA_PERM_ADDRESS:
 			for _, b := range attr.Value {
 				if b != 0 {
@@ -2349,6 +2901,15 @@ func LinkDeserialize(hdr *unix.NlMsghdr, m []byte) (Link, error) {
 			base.ParentDev = string(attr.Value[:len(attr.Value)-1])
 		case unix.IFLA_PARENT_DEV_BUS_NAME:
 			base.ParentDevBus = string(attr.Value[:len(attr.Value)-1])
+		case nl.IFLA_NETNS_IMMUTABLE:
+			base.NetNsImmutable = attr.Value[0] != 0
+		default:
+			if keepExtras && !linkAttrKnown[attr.Attr.Type&nl.NLA_TYPE_MASK] {
+				if base.Extras == nil {
+					base.Extras = make(map[uint16][]byte)
+				}
+				base.Extras[attr.Attr.Type] = attr.Value
+			}
 		}
 	}
 
@@ -2358,6 +2919,13 @@ func LinkDeserialize(hdr *unix.NlMsghdr, m []byte) (Link, error) {
 		base.Statistics = (*LinkStatistics)(stats32.to64())
 	}
 
+	if codec != nil {
+		link, err = decodeRegisteredLinkKind(codec, linkType, codecData)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Links that don't have IFLA_INFO_KIND are hardware devices
 	if link == nil {
 		link = &Device{}
@@ -2365,40 +2933,64 @@ func LinkDeserialize(hdr *unix.NlMsghdr, m []byte) (Link, error) {
 	*link.Attrs() = base
 	link.Attrs().Slave = linkSlave
 
-	// If the tuntap attributes are not updated by netlink due to
-	// an older driver, use sysfs
-	if link != nil && linkType == "tun" {
-		tuntap := link.(*Tuntap)
-
-		if tuntap.Mode == 0 {
-			ifname := tuntap.Attrs().Name
-			if flags, err := readSysPropAsInt64(ifname, "tun_flags"); err == nil {
+	if veth, ok := link.(*Veth); ok {
+		setVethPeer(veth, hasParent)
+	}
 
-				if flags&unix.IFF_TUN != 0 {
-					tuntap.Mode = unix.IFF_TUN
-				} else if flags&unix.IFF_TAP != 0 {
-					tuntap.Mode = unix.IFF_TAP
-				}
+	// Kernels before 4.18 don't report the tuntap attributes over netlink,
+	// IFLA_TUN_TYPE is always there otherwise.
+	if tuntap, ok := link.(*Tuntap); ok && tuntap.Mode == 0 {
+		readTuntapSysfs(tuntap)
+	}
 
-				tuntap.NonPersist = false
-				if flags&unix.IFF_PERSIST == 0 {
-					tuntap.NonPersist = true
-				}
-			}
+	return link, nil
+}
 
-			// The sysfs interface for owner/group returns -1 for root user, instead of returning 0.
-			// So explicitly check for negative value, before assigning the owner uid/gid.
-			if owner, err := readSysPropAsInt64(ifname, "owner"); err == nil && owner > 0 {
-				tuntap.Owner = uint32(owner)
-			}
+// setVethPeer sets the peer of veth from its IFLA_LINK and
+// IFLA_LINK_NETNSID. The kernel leaves IFLA_LINK out when it is the index
+// of the link itself, which for a veth means that the peer has the same
+// index in another namespace.
+func setVethPeer(veth *Veth, hasParent bool) {
+	base := veth.Attrs()
+	veth.PeerIndex = base.ParentIndex
+	if !hasParent {
+		veth.PeerIndex = base.Index
+	}
+	if base.NetNsID >= 0 {
+		nsid := int32(base.NetNsID)
+		veth.PeerNetNsID = &nsid
+	}
+}
+
+// readTuntapSysfs fills the mode, flags, owner and group of tuntap from
+// sysfs. This only works when /sys was mounted from the network namespace
+// of the device, so it is a fallback for old kernels.
+func readTuntapSysfs(tuntap *Tuntap) {
+	ifname := tuntap.Attrs().Name
+	if flags, err := readSysPropAsInt64(ifname, "tun_flags"); err == nil {
+		if flags&unix.IFF_TUN != 0 {
+			tuntap.Mode = unix.IFF_TUN
+		} else if flags&unix.IFF_TAP != 0 {
+			tuntap.Mode = unix.IFF_TAP
+		}
 
-			if group, err := readSysPropAsInt64(ifname, "group"); err == nil && group > 0 {
-				tuntap.Group = uint32(group)
+		tuntap.NonPersist = flags&unix.IFF_PERSIST == 0
+		for _, f := range []TuntapFlag{TUNTAP_NO_PI, TUNTAP_VNET_HDR, TUNTAP_MULTI_QUEUE} {
+			if flags&int64(f) != 0 {
+				tuntap.Flags |= f
 			}
 		}
 	}
 
-	return link, nil
+	// The sysfs interface for owner/group returns -1 for root user, instead of returning 0.
+	// So explicitly check for negative value, before assigning the owner uid/gid.
+	if owner, err := readSysPropAsInt64(ifname, "owner"); err == nil && owner > 0 {
+		tuntap.Owner = uint32(owner)
+	}
+
+	if group, err := readSysPropAsInt64(ifname, "group"); err == nil && group > 0 {
+		tuntap.Group = uint32(group)
+	}
 }
 
 func readSysPropAsInt64(ifname, prop string) (int64, error) {
@@ -2428,30 +3020,44 @@ func LinkList() ([]Link, error) {
 // If the returned error is [ErrDumpInterrupted], results may be inconsistent
 // or incomplete.
 func (h *Handle) LinkList() ([]Link, error) {
-	// NOTE(vish): This duplicates functionality in net/iface_linux.go, but we need
-	//             to get the message ourselves to parse link type.
-	req := h.newNetlinkRequest(unix.RTM_GETLINK, unix.NLM_F_DUMP)
-
-	msg := nl.NewIfInfomsg(unix.AF_UNSPEC)
-	req.AddData(msg)
-	attr := nl.NewRtAttr(unix.IFLA_EXT_MASK, nl.Uint32Attr(nl.RTEXT_FILTER_VF))
-	req.AddData(attr)
-
-	msgs, executeErr := req.Execute(unix.NETLINK_ROUTE, unix.RTM_NEWLINK)
+	msgs, executeErr := executeLinkRequest(h.linkDumpRequest(), unix.RTM_NEWLINK)
 	if executeErr != nil && !errors.Is(executeErr, ErrDumpInterrupted) {
 		return nil, executeErr
 	}
 
+	res, decodeErrs, err := h.linkListDeserialize(msgs)
+	if err != nil {
+		return nil, err
+	}
+	return res, withDecodeErrors(executeErr, decodeErrs)
+}
+
+func (h *Handle) linkListDeserialize(msgs [][]byte) ([]Link, *MultiDecodeError, error) {
 	var res []Link
+	var decodeErrs *MultiDecodeError
 	for _, m := range msgs {
-		link, err := LinkDeserialize(nil, m)
+		link, err := linkDeserialize(nil, m, h.options.keepExtras)
 		if err != nil {
-			return nil, err
+			if h.decodeFailed(&decodeErrs, m, err) {
+				continue
+			}
+			return nil, nil, err
 		}
 		res = append(res, link)
 	}
+	return res, decodeErrs, nil
+}
 
-	return res, executeErr
+func (h *Handle) linkDumpRequest() *nl.NetlinkRequest {
+	// NOTE(vish): This duplicates functionality in net/iface_linux.go, but we need
+	//             to get the message ourselves to parse link type.
+	req := h.newNetlinkRequest(unix.RTM_GETLINK, unix.NLM_F_DUMP)
+
+	msg := nl.NewIfInfomsg(unix.AF_UNSPEC)
+	req.AddData(msg)
+	attr := nl.NewRtAttr(unix.IFLA_EXT_MASK, nl.Uint32Attr(nl.RTEXT_FILTER_VF))
+	req.AddData(attr)
+	return req
 }
 
 // LinkUpdate is used to pass information back from LinkSubscribe()
@@ -2459,18 +3065,23 @@ type LinkUpdate struct {
 	nl.IfInfomsg
 	Header unix.NlMsghdr
 	Link
+	// NsID is the id, in the subscribing namespace, of the network
+	// namespace the update comes from. It is -1 for updates from the
+	// subscription's own namespace. Other namespaces are only heard from
+	// when subscribing with ListenAllNsid.
+	NsID int
 }
 
 // LinkSubscribe takes a chan down which notifications will be sent
 // when links change.  Close the 'done' chan to stop subscription.
 func LinkSubscribe(ch chan<- LinkUpdate, done <-chan struct{}) error {
-	return linkSubscribeAt(netns.None(), netns.None(), ch, done, nil, false, 0, nil, false)
+	return linkSubscribeAt(netns.None(), netns.None(), ch, done, nil, false, 0, nil, false, false, false, nil)
 }
 
 // LinkSubscribeAt works like LinkSubscribe plus it allows the caller
 // to choose the network namespace in which to subscribe (ns).
 func LinkSubscribeAt(ns netns.NsHandle, ch chan<- LinkUpdate, done <-chan struct{}) error {
-	return linkSubscribeAt(ns, netns.None(), ch, done, nil, false, 0, nil, false)
+	return linkSubscribeAt(ns, netns.None(), ch, done, nil, false, 0, nil, false, false, false, nil)
 }
 
 // LinkSubscribeOptions contains a set of options to use with
@@ -2482,8 +3093,80 @@ type LinkSubscribeOptions struct {
 	ReceiveBufferSize      int
 	ReceiveBufferForceSize bool
 	ReceiveTimeout         *unix.Timeval
+	// ResyncOnOverflow keeps the subscription going when the socket
+	// overflows and notifications are lost (ENOBUFS). The error callback
+	// is still called, then an update with Header.Type LinkUpdateResync is
+	// sent on the channel, followed by a fresh dump of all the links as
+	// with ListExisting so that the consumer can reconcile its state.
+	ResyncOnOverflow bool
+	// ListenAllNsid sets NETLINK_LISTEN_ALL_NSID on the subscription
+	// socket so that updates from every namespace that has an id in the
+	// subscribing namespace are received too, tagged with their NsID.
+	ListenAllNsid bool
+	// IfIndexFilter, when not empty, restricts the updates sent on the
+	// channel to the links with one of these indexes. Updates for other
+	// links are dropped before their attributes are parsed.
+	IfIndexFilter []int
+	// NamePrefixFilter, when not empty, restricts the updates sent on
+	// the channel to the links whose name starts with it. When both
+	// filters are set, an update has to pass both.
+	NamePrefixFilter string
+}
+
+// linkUpdateFilter is the userspace filtering applied to the updates
+// of a link subscription, see LinkSubscribeOptions.IfIndexFilter and
+// NamePrefixFilter.
+type linkUpdateFilter struct {
+	indexes    map[int32]struct{}
+	namePrefix string
+}
+
+func newLinkUpdateFilter(indexes []int, namePrefix string) *linkUpdateFilter {
+	if len(indexes) == 0 && namePrefix == "" {
+		return nil
+	}
+	f := &linkUpdateFilter{namePrefix: namePrefix}
+	if len(indexes) > 0 {
+		f.indexes = make(map[int32]struct{}, len(indexes))
+		for _, index := range indexes {
+			f.indexes[int32(index)] = struct{}{}
+		}
+	}
+	return f
+}
+
+// match reports whether the RTM_*LINK message data is for a link the
+// filter lets through. It only looks at the ifinfomsg and IFLA_IFNAME.
+func (f *linkUpdateFilter) match(ifmsg *nl.IfInfomsg, data []byte) bool {
+	if f == nil {
+		return true
+	}
+	if f.indexes != nil {
+		if _, ok := f.indexes[ifmsg.Index]; !ok {
+			return false
+		}
+	}
+	if f.namePrefix == "" {
+		return true
+	}
+	attrs, err := nl.ParseRouteAttr(data[ifmsg.Len():])
+	if err != nil {
+		// let LinkDeserialize report the error
+		return true
+	}
+	for _, attr := range attrs {
+		if attr.Attr.Type == unix.IFLA_IFNAME {
+			return strings.HasPrefix(string(attr.Value[:len(attr.Value)-1]), f.namePrefix)
+		}
+	}
+	return false
 }
 
+// LinkUpdateResync is the Header.Type of the LinkUpdate, without a Link,
+// marking that notifications were lost and a dump of all the links
+// follows. See LinkSubscribeOptions.ResyncOnOverflow.
+const LinkUpdateResync = unix.NLMSG_OVERRUN
+
 // LinkSubscribeWithOptions work like LinkSubscribe but enable to
 // provide additional options to modify the behavior. Currently, the
 // namespace can be provided as well as an error callback.
@@ -2497,15 +3180,22 @@ func LinkSubscribeWithOptions(ch chan<- LinkUpdate, done <-chan struct{}, option
 		options.Namespace = &none
 	}
 	return linkSubscribeAt(*options.Namespace, netns.None(), ch, done, options.ErrorCallback, options.ListExisting,
-		options.ReceiveBufferSize, options.ReceiveTimeout, options.ReceiveBufferForceSize)
+		options.ReceiveBufferSize, options.ReceiveTimeout, options.ReceiveBufferForceSize, options.ResyncOnOverflow,
+		options.ListenAllNsid, newLinkUpdateFilter(options.IfIndexFilter, options.NamePrefixFilter))
 }
 
 func linkSubscribeAt(newNs, curNs netns.NsHandle, ch chan<- LinkUpdate, done <-chan struct{}, cberr func(error), listExisting bool,
-	rcvbuf int, rcvTimeout *unix.Timeval, rcvbufForce bool) error {
+	rcvbuf int, rcvTimeout *unix.Timeval, rcvbufForce bool, resync bool, listenAllNsid bool, filter *linkUpdateFilter) error {
 	s, err := nl.SubscribeAt(newNs, curNs, unix.NETLINK_ROUTE, unix.RTNLGRP_LINK)
 	if err != nil {
 		return err
 	}
+	if listenAllNsid {
+		if err := s.SetListenAllNsid(true); err != nil {
+			s.Close()
+			return err
+		}
+	}
 	if rcvTimeout != nil {
 		if err := s.SetReceiveTimeout(rcvTimeout); err != nil {
 			return err
@@ -2523,24 +3213,48 @@ func linkSubscribeAt(newNs, curNs netns.NsHandle, ch chan<- LinkUpdate, done <-c
 			s.Close()
 		}()
 	}
-	if listExisting {
+	// A dump can't be started while another one is running on the
+	// socket, a resync requested meanwhile waits for its end.
+	dumping, resyncPending := false, false
+	dump := func() error {
 		req := pkgHandle.newNetlinkRequest(unix.RTM_GETLINK,
 			unix.NLM_F_DUMP)
 		msg := nl.NewIfInfomsg(unix.AF_UNSPEC)
 		req.AddData(msg)
-		if err := s.Send(req); err != nil {
+		dumping = true
+		return s.Send(req)
+	}
+	startResync := func() error {
+		resyncPending = false
+		ch <- LinkUpdate{Header: unix.NlMsghdr{Type: LinkUpdateResync}, NsID: -1}
+		return dump()
+	}
+	if listExisting {
+		if err := dump(); err != nil {
 			return err
 		}
 	}
 	go func() {
 		defer close(ch)
 		for {
-			msgs, from, err := s.Receive()
+			msgs, from, nsid, err := s.ReceiveNsid()
 			if err != nil {
 				if cberr != nil {
-					cberr(fmt.Errorf("Receive failed: %v",
+					cberr(fmt.Errorf("Receive failed: %w",
 						err))
 				}
+				if resync && errors.Is(err, unix.ENOBUFS) {
+					resyncPending = true
+					if !dumping {
+						if err := startResync(); err != nil {
+							if cberr != nil {
+								cberr(err)
+							}
+							return
+						}
+					}
+					continue
+				}
 				return
 			}
 			if from.Pid != nl.PidKernel {
@@ -2553,21 +3267,29 @@ func linkSubscribeAt(newNs, curNs netns.NsHandle, ch chan<- LinkUpdate, done <-c
 				if m.Header.Flags&unix.NLM_F_DUMP_INTR != 0 && cberr != nil {
 					cberr(ErrDumpInterrupted)
 				}
-				if m.Header.Type == unix.NLMSG_DONE {
-					continue
-				}
-				if m.Header.Type == unix.NLMSG_ERROR {
-					error := int32(native.Uint32(m.Data[0:4]))
-					if error == 0 {
-						continue
+				if m.Header.Type == unix.NLMSG_DONE || m.Header.Type == unix.NLMSG_ERROR {
+					// both only come in response to our dump requests
+					dumping = false
+					if m.Header.Type == unix.NLMSG_ERROR {
+						if error := int32(native.Uint32(m.Data[0:4])); error != 0 && cberr != nil {
+							cberr(fmt.Errorf("error message: %v",
+								syscall.Errno(-error)))
+						}
 					}
-					if cberr != nil {
-						cberr(fmt.Errorf("error message: %v",
-							syscall.Errno(-error)))
+					if resyncPending {
+						if err := startResync(); err != nil {
+							if cberr != nil {
+								cberr(err)
+							}
+							return
+						}
 					}
 					continue
 				}
 				ifmsg := nl.DeserializeIfInfomsg(m.Data)
+				if !filter.match(ifmsg, m.Data) {
+					continue
+				}
 				header := unix.NlMsghdr(m.Header)
 				link, err := LinkDeserialize(&header, m.Data)
 				if err != nil {
@@ -2576,7 +3298,7 @@ func linkSubscribeAt(newNs, curNs netns.NsHandle, ch chan<- LinkUpdate, done <-c
 					}
 					continue
 				}
-				ch <- LinkUpdate{IfInfomsg: *ifmsg, Header: header, Link: link}
+				ch <- LinkUpdate{IfInfomsg: *ifmsg, Header: header, Link: link, NsID: nsid}
 			}
 		}
 	}()
@@ -2618,6 +3340,48 @@ func (h *Handle) LinkSetFastLeave(link Link, mode bool) error {
 	return h.setProtinfoAttr(link, mode, nl.IFLA_BRPORT_FAST_LEAVE)
 }
 
+// LinkSetBrPortMcastRouter sets the multicast router mode of a bridge
+// port to one of the MDB_RTR_TYPE_* modes.
+// Equivalent to: `bridge link set dev $link mcast_router $mode`
+func LinkSetBrPortMcastRouter(link Link, mode int) error {
+	return pkgHandle.LinkSetBrPortMcastRouter(link, mode)
+}
+
+// LinkSetBrPortMcastRouter sets the multicast router mode of a bridge
+// port to one of the MDB_RTR_TYPE_* modes.
+// Equivalent to: `bridge link set dev $link mcast_router $mode`
+func (h *Handle) LinkSetBrPortMcastRouter(link Link, mode int) error {
+	return h.setProtinfoAttrRawVal(link, nl.Uint8Attr(uint8(mode)), nl.IFLA_BRPORT_MULTICAST_ROUTER)
+}
+
+// LinkSetBrPortBackup sets backup as the backup port of the bridge port
+// link, taking over its traffic when link loses carrier.
+// Equivalent to: `bridge link set dev $link backup_port $backup`
+func LinkSetBrPortBackup(link Link, backup Link) error {
+	return pkgHandle.LinkSetBrPortBackup(link, backup)
+}
+
+// LinkSetBrPortBackup sets backup as the backup port of the bridge port
+// link, taking over its traffic when link loses carrier.
+// Equivalent to: `bridge link set dev $link backup_port $backup`
+func (h *Handle) LinkSetBrPortBackup(link Link, backup Link) error {
+	base := backup.Attrs()
+	h.ensureIndex(base)
+	return h.setProtinfoAttrRawVal(link, nl.Uint32Attr(uint32(base.Index)), nl.IFLA_BRPORT_BACKUP_PORT)
+}
+
+// LinkClearBrPortBackup removes the backup port of the bridge port link.
+// Equivalent to: `bridge link set dev $link nobackup_port`
+func LinkClearBrPortBackup(link Link) error {
+	return pkgHandle.LinkClearBrPortBackup(link)
+}
+
+// LinkClearBrPortBackup removes the backup port of the bridge port link.
+// Equivalent to: `bridge link set dev $link nobackup_port`
+func (h *Handle) LinkClearBrPortBackup(link Link) error {
+	return h.setProtinfoAttrRawVal(link, nl.Uint32Attr(0), nl.IFLA_BRPORT_BACKUP_PORT)
+}
+
 func LinkSetLearning(link Link, mode bool) error {
 	return pkgHandle.LinkSetLearning(link, mode)
 }
@@ -2682,7 +3446,48 @@ func (h *Handle) LinkSetBrNeighSuppress(link Link, mode bool) error {
 	return h.setProtinfoAttr(link, mode, nl.IFLA_BRPORT_NEIGH_SUPPRESS)
 }
 
+// EnableEVPNArpSuppression configures the vxlan port of bridge for EVPN
+// ARP/ND suppression: vlan_tunnel and neigh_suppress are turned on and
+// learning is turned off, since the control plane installs the remote
+// entries. The three flags are changed by a single request so the port is
+// never left half configured. vxlan must already be enslaved to bridge.
+// Equivalent to: `bridge link set dev $vxlan vlan_tunnel on neigh_suppress on learning off`
+func EnableEVPNArpSuppression(bridge, vxlan Link) error {
+	return pkgHandle.EnableEVPNArpSuppression(bridge, vxlan)
+}
+
+// EnableEVPNArpSuppression configures the vxlan port of bridge for EVPN
+// ARP/ND suppression: vlan_tunnel and neigh_suppress are turned on and
+// learning is turned off, since the control plane installs the remote
+// entries. The three flags are changed by a single request so the port is
+// never left half configured. vxlan must already be enslaved to bridge.
+// Equivalent to: `bridge link set dev $vxlan vlan_tunnel on neigh_suppress on learning off`
+func (h *Handle) EnableEVPNArpSuppression(bridge, vxlan Link) error {
+	if vxlan.Type() != "vxlan" {
+		return fmt.Errorf("%s is not a vxlan link", vxlan.Attrs().Name)
+	}
+	brBase := bridge.Attrs()
+	h.ensureIndex(brBase)
+	vxBase := vxlan.Attrs()
+	h.ensureIndex(vxBase)
+	current, err := h.LinkByIndex(vxBase.Index)
+	if err != nil {
+		return err
+	}
+	if current.Attrs().MasterIndex != brBase.Index {
+		return fmt.Errorf("%s is not a port of bridge %s", vxBase.Name, brBase.Name)
+	}
+	return h.setProtinfoAttrs(vxlan,
+		nl.NewRtAttr(nl.IFLA_BRPORT_VLAN_TUNNEL, boolToByte(true)),
+		nl.NewRtAttr(nl.IFLA_BRPORT_NEIGH_SUPPRESS, boolToByte(true)),
+		nl.NewRtAttr(nl.IFLA_BRPORT_LEARNING, boolToByte(false)))
+}
+
 func (h *Handle) setProtinfoAttrRawVal(link Link, val []byte, attr int) error {
+	return h.setProtinfoAttrs(link, nl.NewRtAttr(attr, val))
+}
+
+func (h *Handle) setProtinfoAttrs(link Link, attrs ...*nl.RtAttr) error {
 	base := link.Attrs()
 	h.ensureIndex(base)
 	req := h.newNetlinkRequest(unix.RTM_SETLINK, unix.NLM_F_ACK)
@@ -2692,7 +3497,9 @@ func (h *Handle) setProtinfoAttrRawVal(link Link, val []byte, attr int) error {
 	req.AddData(msg)
 
 	br := nl.NewRtAttr(unix.IFLA_PROTINFO|unix.NLA_F_NESTED, nil)
-	br.AddRtAttr(attr, val)
+	for _, attr := range attrs {
+		br.AddChild(attr)
+	}
 	req.AddData(br)
 	_, err := req.Execute(unix.NETLINK_ROUTE, 0)
 	if err != nil {
@@ -3000,6 +3807,10 @@ func parseVxlanData(link Link, data []syscall.NetlinkRouteAttr) {
 				vxlan.PortLow = int(pr.Lo)
 				vxlan.PortHigh = int(pr.Hi)
 			}
+		case nl.IFLA_VXLAN_DF:
+			vxlan.DF = VxlanDF(datum.Value[0])
+		case nl.IFLA_VXLAN_VNIFILTER:
+			vxlan.VniFilter = int8(datum.Value[0]) != 0
 		}
 	}
 }
@@ -3024,6 +3835,8 @@ func parseBondData(link Link, data []syscall.NetlinkRouteAttr) {
 			bond.ArpInterval = int(native.Uint32(data[i].Value[0:4]))
 		case nl.IFLA_BOND_ARP_IP_TARGET:
 			bond.ArpIpTargets = parseBondArpIpTargets(data[i].Value)
+		case nl.IFLA_BOND_NS_IP6_TARGET:
+			bond.NsIP6Targets = parseBondArpIpTargets(data[i].Value)
 		case nl.IFLA_BOND_ARP_VALIDATE:
 			bond.ArpValidate = BondArpValidate(native.Uint32(data[i].Value[0:4]))
 		case nl.IFLA_BOND_ARP_ALL_TARGETS:
@@ -3123,6 +3936,8 @@ func parseBondSlaveData(slave LinkSlave, data []syscall.NetlinkRouteAttr) {
 			bondSlave.AdActorOperPortState = uint8(data[i].Value[0])
 		case nl.IFLA_BOND_SLAVE_AD_PARTNER_OPER_PORT_STATE:
 			bondSlave.AdPartnerOperPortState = native.Uint16(data[i].Value[0:2])
+		case nl.IFLA_BOND_SLAVE_PRIO:
+			bondSlave.Prio = int32(native.Uint32(data[i].Value[0:4]))
 		}
 	}
 }
@@ -3131,7 +3946,7 @@ func parseVrfSlaveData(slave LinkSlave, data []syscall.NetlinkRouteAttr) {
 	vrfSlave := slave.(*VrfSlave)
 	for i := range data {
 		switch data[i].Attr.Type {
-		case nl.IFLA_BOND_SLAVE_STATE:
+		case nl.IFLA_VRF_PORT_TABLE:
 			vrfSlave.Table = native.Uint32(data[i].Value[0:4])
 		}
 	}
@@ -3220,6 +4035,25 @@ func parseMacvlanData(link Link, data []syscall.NetlinkRouteAttr) {
 	}
 }
 
+// setLinkFlags stores rawFlags in base along with the fields derived from
+// them. Promisc is only raised to 1 when IFF_PROMISC is set, as it otherwise
+// carries the kernel promiscuity counter from IFLA_PROMISCUITY.
+func setLinkFlags(base *LinkAttrs, rawFlags uint32) {
+	base.RawFlags = rawFlags
+	base.Flags = linkFlags(rawFlags)
+	base.Allmulti = 0
+	if rawFlags&unix.IFF_ALLMULTI != 0 {
+		base.Allmulti = 1
+	}
+	base.Multi = 0
+	if rawFlags&unix.IFF_MULTICAST != 0 {
+		base.Multi = 1
+	}
+	if rawFlags&unix.IFF_PROMISC != 0 && base.Promisc == 0 {
+		base.Promisc = 1
+	}
+}
+
 func linkFlags(rawFlags uint32) net.Flags {
 	var f net.Flags
 	if rawFlags&unix.IFF_UP != 0 {
@@ -3371,6 +4205,15 @@ func addGretapAttrs(gretap *Gretap, linkInfo *nl.RtAttr) {
 	data.AddRtAttr(nl.IFLA_GRE_ENCAP_FLAGS, nl.Uint16Attr(gretap.EncapFlags))
 	data.AddRtAttr(nl.IFLA_GRE_ENCAP_SPORT, htons(gretap.EncapSport))
 	data.AddRtAttr(nl.IFLA_GRE_ENCAP_DPORT, htons(gretap.EncapDport))
+
+	if gretap.Local.To4() == nil {
+		if gretap.EncapLimit != 0 {
+			data.AddRtAttr(nl.IFLA_GRE_ENCAP_LIMIT, nl.Uint8Attr(gretap.EncapLimit))
+		}
+		if gretap.FlowInfo != 0 {
+			data.AddRtAttr(nl.IFLA_GRE_FLOWINFO, htonl(gretap.FlowInfo))
+		}
+	}
 }
 
 func parseGretapData(link Link, data []syscall.NetlinkRouteAttr) {
@@ -3403,6 +4246,10 @@ func parseGretapData(link Link, data []syscall.NetlinkRouteAttr) {
 			gre.EncapType = native.Uint16(datum.Value[0:2])
 		case nl.IFLA_GRE_ENCAP_FLAGS:
 			gre.EncapFlags = native.Uint16(datum.Value[0:2])
+		case nl.IFLA_GRE_ENCAP_LIMIT:
+			gre.EncapLimit = uint8(datum.Value[0])
+		case nl.IFLA_GRE_FLOWINFO:
+			gre.FlowInfo = ntohl(datum.Value[0:4])
 		case nl.IFLA_GRE_COLLECT_METADATA:
 			gre.FlowBased = true
 		}
@@ -3456,6 +4303,15 @@ func addGretunAttrs(gre *Gretun, linkInfo *nl.RtAttr) {
 	data.AddRtAttr(nl.IFLA_GRE_ENCAP_FLAGS, nl.Uint16Attr(gre.EncapFlags))
 	data.AddRtAttr(nl.IFLA_GRE_ENCAP_SPORT, htons(gre.EncapSport))
 	data.AddRtAttr(nl.IFLA_GRE_ENCAP_DPORT, htons(gre.EncapDport))
+
+	if gre.Local.To4() == nil {
+		if gre.EncapLimit != 0 {
+			data.AddRtAttr(nl.IFLA_GRE_ENCAP_LIMIT, nl.Uint8Attr(gre.EncapLimit))
+		}
+		if gre.FlowInfo != 0 {
+			data.AddRtAttr(nl.IFLA_GRE_FLOWINFO, htonl(gre.FlowInfo))
+		}
+	}
 }
 
 func parseGretunData(link Link, data []syscall.NetlinkRouteAttr) {
@@ -3488,6 +4344,10 @@ func parseGretunData(link Link, data []syscall.NetlinkRouteAttr) {
 			gre.EncapSport = ntohs(datum.Value[0:2])
 		case nl.IFLA_GRE_ENCAP_DPORT:
 			gre.EncapDport = ntohs(datum.Value[0:2])
+		case nl.IFLA_GRE_ENCAP_LIMIT:
+			gre.EncapLimit = uint8(datum.Value[0])
+		case nl.IFLA_GRE_FLOWINFO:
+			gre.FlowInfo = ntohl(datum.Value[0:4])
 		case nl.IFLA_GRE_COLLECT_METADATA:
 			gre.FlowBased = true
 		}
@@ -3591,6 +4451,14 @@ func parseIptunData(link Link, data []syscall.NetlinkRouteAttr) {
 	}
 }
 
+const (
+	// ip6FlowLabelMask is IPV6_FLOWLABEL_MASK in host byte order.
+	ip6FlowLabelMask = 0x000FFFFF
+	// ip6DefaultTnlEncapLimit is IPV6_DEFAULT_TNL_ENCAP_LIMIT, the kernel
+	// uses 0 when IFLA_IPTUN_ENCAP_LIMIT is missing.
+	ip6DefaultTnlEncapLimit = 4
+)
+
 func addIp6tnlAttrs(ip6tnl *Ip6tnl, linkInfo *nl.RtAttr) {
 	data := linkInfo.AddRtAttr(nl.IFLA_INFO_DATA, nil)
 
@@ -3614,12 +4482,30 @@ func addIp6tnlAttrs(ip6tnl *Ip6tnl, linkInfo *nl.RtAttr) {
 		data.AddRtAttr(nl.IFLA_IPTUN_REMOTE, []byte(ip))
 	}
 
+	flags := ip6tnl.Flags
+	if ip6tnl.TClassInherit {
+		flags |= IP6_TNL_F_USE_ORIG_TCLASS
+	}
+	// the flow info is a big endian field of the ipv6 header
+	flowInfo := nl.Uint32Attr(ip6tnl.FlowInfo)
+	if ip6tnl.FlowLabel != 0 {
+		label := make([]byte, 4)
+		binary.BigEndian.PutUint32(label, ip6tnl.FlowLabel&ip6FlowLabelMask)
+		for i := range flowInfo {
+			flowInfo[i] |= label[i]
+		}
+	}
+
 	data.AddRtAttr(nl.IFLA_IPTUN_TTL, nl.Uint8Attr(ip6tnl.Ttl))
 	data.AddRtAttr(nl.IFLA_IPTUN_TOS, nl.Uint8Attr(ip6tnl.Tos))
-	data.AddRtAttr(nl.IFLA_IPTUN_FLAGS, nl.Uint32Attr(ip6tnl.Flags))
+	data.AddRtAttr(nl.IFLA_IPTUN_FLAGS, nl.Uint32Attr(flags))
 	data.AddRtAttr(nl.IFLA_IPTUN_PROTO, nl.Uint8Attr(ip6tnl.Proto))
-	data.AddRtAttr(nl.IFLA_IPTUN_FLOWINFO, nl.Uint32Attr(ip6tnl.FlowInfo))
-	data.AddRtAttr(nl.IFLA_IPTUN_ENCAP_LIMIT, nl.Uint8Attr(ip6tnl.EncapLimit))
+	data.AddRtAttr(nl.IFLA_IPTUN_FLOWINFO, flowInfo)
+	encapLimit := uint8(ip6DefaultTnlEncapLimit)
+	if ip6tnl.EncapLimit != nil {
+		encapLimit = *ip6tnl.EncapLimit
+	}
+	data.AddRtAttr(nl.IFLA_IPTUN_ENCAP_LIMIT, nl.Uint8Attr(encapLimit))
 	data.AddRtAttr(nl.IFLA_IPTUN_ENCAP_TYPE, nl.Uint16Attr(ip6tnl.EncapType))
 	data.AddRtAttr(nl.IFLA_IPTUN_ENCAP_FLAGS, nl.Uint16Attr(ip6tnl.EncapFlags))
 	data.AddRtAttr(nl.IFLA_IPTUN_ENCAP_SPORT, htons(ip6tnl.EncapSport))
@@ -3640,12 +4526,15 @@ func parseIp6tnlData(link Link, data []syscall.NetlinkRouteAttr) {
 			ip6tnl.Tos = datum.Value[0]
 		case nl.IFLA_IPTUN_FLAGS:
 			ip6tnl.Flags = native.Uint32(datum.Value[:4])
+			ip6tnl.TClassInherit = ip6tnl.Flags&IP6_TNL_F_USE_ORIG_TCLASS != 0
 		case nl.IFLA_IPTUN_PROTO:
 			ip6tnl.Proto = datum.Value[0]
 		case nl.IFLA_IPTUN_FLOWINFO:
 			ip6tnl.FlowInfo = native.Uint32(datum.Value[:4])
+			ip6tnl.FlowLabel = binary.BigEndian.Uint32(datum.Value[:4]) & ip6FlowLabelMask
 		case nl.IFLA_IPTUN_ENCAP_LIMIT:
-			ip6tnl.EncapLimit = datum.Value[0]
+			encapLimit := datum.Value[0]
+			ip6tnl.EncapLimit = &encapLimit
 		case nl.IFLA_IPTUN_ENCAP_TYPE:
 			ip6tnl.EncapType = native.Uint16(datum.Value[0:2])
 		case nl.IFLA_IPTUN_ENCAP_FLAGS:
@@ -3690,10 +4579,23 @@ func addSittunAttrs(sittun *Sittun, linkInfo *nl.RtAttr) {
 	data.AddRtAttr(nl.IFLA_IPTUN_ENCAP_FLAGS, nl.Uint16Attr(sittun.EncapFlags))
 	data.AddRtAttr(nl.IFLA_IPTUN_ENCAP_SPORT, htons(sittun.EncapSport))
 	data.AddRtAttr(nl.IFLA_IPTUN_ENCAP_DPORT, htons(sittun.EncapDport))
+
+	if p := sittun.SixRdPrefix; p != nil {
+		ones, _ := p.Mask.Size()
+		data.AddRtAttr(nl.IFLA_IPTUN_6RD_PREFIX, []byte(p.IP.To16()))
+		data.AddRtAttr(nl.IFLA_IPTUN_6RD_PREFIXLEN, nl.Uint16Attr(uint16(ones)))
+	}
+	if p := sittun.SixRdRelayPrefix; p != nil {
+		ones, _ := p.Mask.Size()
+		data.AddRtAttr(nl.IFLA_IPTUN_6RD_RELAY_PREFIX, []byte(p.IP.To4()))
+		data.AddRtAttr(nl.IFLA_IPTUN_6RD_RELAY_PREFIXLEN, nl.Uint16Attr(uint16(ones)))
+	}
 }
 
 func parseSittunData(link Link, data []syscall.NetlinkRouteAttr) {
 	sittun := link.(*Sittun)
+	var sixRdPrefix, sixRdRelayPrefix net.IP
+	var sixRdPrefixLen, sixRdRelayPrefixLen int
 	for _, datum := range data {
 		switch datum.Attr.Type {
 		case nl.IFLA_IPTUN_LOCAL:
@@ -3716,8 +4618,22 @@ func parseSittunData(link Link, data []syscall.NetlinkRouteAttr) {
 			sittun.EncapSport = ntohs(datum.Value[0:2])
 		case nl.IFLA_IPTUN_ENCAP_DPORT:
 			sittun.EncapDport = ntohs(datum.Value[0:2])
+		case nl.IFLA_IPTUN_6RD_PREFIX:
+			sixRdPrefix = net.IP(datum.Value[0:16])
+		case nl.IFLA_IPTUN_6RD_PREFIXLEN:
+			sixRdPrefixLen = int(native.Uint16(datum.Value[0:2]))
+		case nl.IFLA_IPTUN_6RD_RELAY_PREFIX:
+			sixRdRelayPrefix = net.IP(datum.Value[0:4])
+		case nl.IFLA_IPTUN_6RD_RELAY_PREFIXLEN:
+			sixRdRelayPrefixLen = int(native.Uint16(datum.Value[0:2]))
 		}
 	}
+	if sixRdPrefix != nil {
+		sittun.SixRdPrefix = &net.IPNet{IP: sixRdPrefix, Mask: net.CIDRMask(sixRdPrefixLen, 128)}
+	}
+	if sixRdRelayPrefix != nil {
+		sittun.SixRdRelayPrefix = &net.IPNet{IP: sixRdRelayPrefix, Mask: net.CIDRMask(sixRdRelayPrefixLen, 32)}
+	}
 }
 
 func addVtiAttrs(vti *Vti, linkInfo *nl.RtAttr) {
@@ -3754,6 +4670,10 @@ func addVtiAttrs(vti *Vti, linkInfo *nl.RtAttr) {
 
 	data.AddRtAttr(nl.IFLA_VTI_IKEY, htonl(vti.IKey))
 	data.AddRtAttr(nl.IFLA_VTI_OKEY, htonl(vti.OKey))
+
+	if vti.Fwmark != 0 {
+		data.AddRtAttr(nl.IFLA_VTI_FWMARK, nl.Uint32Attr(vti.Fwmark))
+	}
 }
 
 func parseVtiData(link Link, data []syscall.NetlinkRouteAttr) {
@@ -3768,6 +4688,10 @@ func parseVtiData(link Link, data []syscall.NetlinkRouteAttr) {
 			vti.IKey = ntohl(datum.Value[0:4])
 		case nl.IFLA_VTI_OKEY:
 			vti.OKey = ntohl(datum.Value[0:4])
+		case nl.IFLA_VTI_LINK:
+			vti.Link = native.Uint32(datum.Value[0:4])
+		case nl.IFLA_VTI_FWMARK:
+			vti.Fwmark = native.Uint32(datum.Value[0:4])
 		}
 	}
 }
@@ -3937,12 +4861,21 @@ func parseVfInfo(data []syscall.NetlinkRouteAttr, id int) (VfInfo, error) {
 	return vf, nil
 }
 
-func addXfrmiAttrs(xfrmi *Xfrmi, linkInfo *nl.RtAttr) {
+func addXfrmiAttrs(xfrmi *Xfrmi, linkInfo *nl.RtAttr) error {
+	if xfrmi.FlowBased && (xfrmi.Ifid != 0 || xfrmi.ParentIndex != 0) {
+		return fmt.Errorf("xfrmi in flow based mode can't have an Ifid or a ParentIndex")
+	}
 	data := linkInfo.AddRtAttr(nl.IFLA_INFO_DATA, nil)
+	if xfrmi.FlowBased {
+		// In flow based mode, no other attributes need to be configured
+		data.AddRtAttr(nl.IFLA_XFRM_COLLECT_METADATA, []byte{})
+		return nil
+	}
 	data.AddRtAttr(nl.IFLA_XFRM_LINK, nl.Uint32Attr(uint32(xfrmi.ParentIndex)))
 	if xfrmi.Ifid != 0 {
 		data.AddRtAttr(nl.IFLA_XFRM_IF_ID, nl.Uint32Attr(xfrmi.Ifid))
 	}
+	return nil
 }
 
 func parseXfrmiData(link Link, data []syscall.NetlinkRouteAttr) {
@@ -3953,6 +4886,8 @@ func parseXfrmiData(link Link, data []syscall.NetlinkRouteAttr) {
 			xfrmi.ParentIndex = int(native.Uint32(datum.Value))
 		case nl.IFLA_XFRM_IF_ID:
 			xfrmi.Ifid = native.Uint32(datum.Value)
+		case nl.IFLA_XFRM_COLLECT_METADATA:
+			xfrmi.FlowBased = true
 		}
 	}
 }
@@ -4025,6 +4960,34 @@ func LinkSetBondSlaveQueueId(link Link, queueId uint16) error {
 	return pkgHandle.LinkSetBondSlaveQueueId(link, queueId)
 }
 
+// LinkSetBondSlavePrio sets the priority of a bond slave, used to select
+// the active slave in active-backup, balance-tlb and balance-alb modes.
+// Equivalent to: `ip link set $link type bond_slave prio $prio`
+func (h *Handle) LinkSetBondSlavePrio(link Link, prio int32) error {
+	base := link.Attrs()
+	h.ensureIndex(base)
+	req := h.newNetlinkRequest(unix.RTM_SETLINK, unix.NLM_F_ACK)
+
+	msg := nl.NewIfInfomsg(unix.AF_UNSPEC)
+	msg.Index = int32(base.Index)
+	req.AddData(msg)
+
+	linkInfo := nl.NewRtAttr(unix.IFLA_LINKINFO, nil)
+	data := linkInfo.AddRtAttr(nl.IFLA_INFO_SLAVE_DATA, nil)
+	data.AddRtAttr(nl.IFLA_BOND_SLAVE_PRIO, nl.Uint32Attr(uint32(prio)))
+
+	req.AddData(linkInfo)
+	_, err := req.Execute(unix.NETLINK_ROUTE, 0)
+	return err
+}
+
+// LinkSetBondSlavePrio sets the priority of a bond slave, used to select
+// the active slave in active-backup, balance-tlb and balance-alb modes.
+// Equivalent to: `ip link set $link type bond_slave prio $prio`
+func LinkSetBondSlavePrio(link Link, prio int32) error {
+	return pkgHandle.LinkSetBondSlavePrio(link, prio)
+}
+
 func vethStatsSerialize(stats ethtoolStats) ([]byte, error) {
 	statsSize := int(unsafe.Sizeof(stats)) + int(stats.nStats)*int(unsafe.Sizeof(uint64(0)))
 	b := make([]byte, 0, statsSize)
@@ -4048,7 +5011,12 @@ func vethStatsDeserialize(b []byte) (vethEthtoolStats, error) {
 }
 
 // VethPeerIndex get veth peer index.
+// The PeerIndex of veths returned by LinkByName or LinkList already holds
+// it, the ethtool statistics are only queried when PeerIndex is not set.
 func VethPeerIndex(link *Veth) (int, error) {
+	if link.PeerIndex != 0 {
+		return link.PeerIndex, nil
+	}
 	fd, err := getSocketUDP()
 	if err != nil {
 		return -1, err
@@ -4189,10 +5157,30 @@ func addIPoIBAttrs(ipoib *IPoIB, linkInfo *nl.RtAttr) {
 	data.AddRtAttr(nl.IFLA_IPOIB_UMCAST, nl.Uint16Attr(uint16(ipoib.Umcast)))
 }
 
+// bareUDPDefaultPort is the port the kernel documents for bareudp; some
+// kernels reject an explicit port of 0 instead of applying it.
+const bareUDPDefaultPort = 6635
+
+// validateBareUDP checks the invariants the kernel enforces for bareudp
+// devices so that callers get a descriptive error instead of EINVAL.
+func validateBareUDP(bareudp *BareUDP) error {
+	if bareudp.EtherType == 0 {
+		return fmt.Errorf("BareUDP.EtherType must be set")
+	}
+	if bareudp.MultiProto && bareudp.EtherType != unix.ETH_P_IP && bareudp.EtherType != unix.ETH_P_MPLS_UC {
+		return fmt.Errorf("BareUDP.MultiProto requires EtherType ETH_P_IP or ETH_P_MPLS_UC, got 0x%04x", bareudp.EtherType)
+	}
+	return nil
+}
+
 func addBareUDPAttrs(bareudp *BareUDP, linkInfo *nl.RtAttr) {
 	data := linkInfo.AddRtAttr(nl.IFLA_INFO_DATA, nil)
 
-	data.AddRtAttr(nl.IFLA_BAREUDP_PORT, nl.Uint16Attr(nl.Swap16(bareudp.Port)))
+	port := bareudp.Port
+	if port == 0 {
+		port = bareUDPDefaultPort
+	}
+	data.AddRtAttr(nl.IFLA_BAREUDP_PORT, nl.Uint16Attr(nl.Swap16(port)))
 	data.AddRtAttr(nl.IFLA_BAREUDP_ETHERTYPE, nl.Uint16Attr(nl.Swap16(bareudp.EtherType)))
 	if bareudp.SrcPortMin != 0 {
 		data.AddRtAttr(nl.IFLA_BAREUDP_SRCPORT_MIN, nl.Uint16Attr(bareudp.SrcPortMin))
@@ -4200,6 +5188,9 @@ func addBareUDPAttrs(bareudp *BareUDP, linkInfo *nl.RtAttr) {
 	if bareudp.MultiProto {
 		data.AddRtAttr(nl.IFLA_BAREUDP_MULTIPROTO_MODE, []byte{})
 	}
+	if bareudp.RxCollectMetadata {
+		data.AddRtAttr(nl.IFLA_BAREUDP_RX_COLLECT_METADATA, []byte{})
+	}
 }
 
 func parseBareUDPData(link Link, data []syscall.NetlinkRouteAttr) {
@@ -4214,6 +5205,8 @@ func parseBareUDPData(link Link, data []syscall.NetlinkRouteAttr) {
 			bareudp.SrcPortMin = native.Uint16(attr.Value)
 		case nl.IFLA_BAREUDP_MULTIPROTO_MODE:
 			bareudp.MultiProto = true
+		case nl.IFLA_BAREUDP_RX_COLLECT_METADATA:
+			bareudp.RxCollectMetadata = true
 		}
 	}
 }
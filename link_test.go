@@ -5,8 +5,10 @@ package netlink
 
 import (
 	"bytes"
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"math"
 	"net"
 	"os"
 	"os/exec"
@@ -16,6 +18,7 @@ import (
 	"syscall"
 	"testing"
 	"time"
+	"unsafe"
 
 	"github.com/vishvananda/netlink/nl"
 	"github.com/vishvananda/netns"
@@ -259,11 +262,20 @@ func testLinkAddDel(t *testing.T, link Link) {
 		}
 	}
 
-	if _, ok := link.(*Vti); ok {
-		_, ok := result.(*Vti)
+	if vti, ok := link.(*Vti); ok {
+		other, ok := result.(*Vti)
 		if !ok {
 			t.Fatal("Result of create is not a vti")
 		}
+		if vti.IKey != other.IKey || vti.OKey != other.OKey {
+			t.Fatalf("Got unexpected keys: %#x/%#x, expected: %#x/%#x", other.IKey, other.OKey, vti.IKey, vti.OKey)
+		}
+		if vti.Fwmark != other.Fwmark {
+			t.Fatalf("Got unexpected fwmark: %#x, expected: %#x", other.Fwmark, vti.Fwmark)
+		}
+		if !vti.Local.Equal(other.Local) || !vti.Remote.Equal(other.Remote) {
+			t.Fatalf("Got unexpected endpoints: %s -> %s, expected: %s -> %s", other.Local, other.Remote, vti.Local, vti.Remote)
+		}
 	}
 
 	if bond, ok := link.(*Bond); ok {
@@ -292,6 +304,20 @@ func testLinkAddDel(t *testing.T, link Link) {
 			}
 		}
 
+		if bond.NsIP6Targets != nil {
+			if len(bond.NsIP6Targets) != len(other.NsIP6Targets) {
+				t.Fatalf("Got unexpected NsIP6Targets len: %d, expected: %d",
+					len(other.NsIP6Targets), len(bond.NsIP6Targets))
+			}
+
+			for i := range bond.NsIP6Targets {
+				if !bond.NsIP6Targets[i].Equal(other.NsIP6Targets[i]) {
+					t.Fatalf("Got unexpected NsIP6Targets: %s, expected: %s",
+						other.NsIP6Targets[i], bond.NsIP6Targets[i])
+				}
+			}
+		}
+
 		switch mode := bondModeToString[bond.Mode]; mode {
 		case "802.3ad":
 			if bond.AdSelect != other.AdSelect {
@@ -331,14 +357,29 @@ func testLinkAddDel(t *testing.T, link Link) {
 		if ip6tnl.FlowBased != other.FlowBased {
 			t.Fatal("Ip6tnl.FlowBased doesn't match")
 		}
+		if ip6tnl.EncapLimit != nil && (other.EncapLimit == nil || *ip6tnl.EncapLimit != *other.EncapLimit) {
+			t.Fatal("Ip6tnl.EncapLimit doesn't match")
+		}
+		if ip6tnl.FlowLabel != other.FlowLabel {
+			t.Fatalf("Ip6tnl.FlowLabel doesn't match: %#x != %#x", ip6tnl.FlowLabel, other.FlowLabel)
+		}
+		if ip6tnl.TClassInherit != other.TClassInherit {
+			t.Fatal("Ip6tnl.TClassInherit doesn't match")
+		}
 
 	}
 
-	if _, ok := link.(*Sittun); ok {
-		_, ok := result.(*Sittun)
+	if sittun, ok := link.(*Sittun); ok {
+		other, ok := result.(*Sittun)
 		if !ok {
 			t.Fatal("Result of create is not a sittun")
 		}
+		if sittun.SixRdPrefix != nil && !ipNetEqual(sittun.SixRdPrefix, other.SixRdPrefix) {
+			t.Fatalf("Sittun.SixRdPrefix doesn't match: %s %s", sittun.SixRdPrefix, other.SixRdPrefix)
+		}
+		if sittun.SixRdRelayPrefix != nil && !ipNetEqual(sittun.SixRdRelayPrefix, other.SixRdRelayPrefix) {
+			t.Fatalf("Sittun.SixRdRelayPrefix doesn't match: %s %s", sittun.SixRdRelayPrefix, other.SixRdRelayPrefix)
+		}
 	}
 
 	if geneve, ok := link.(*Geneve); ok {
@@ -511,6 +552,14 @@ func compareGretap(t *testing.T, expected, actual *Gretap) {
 	if actual.FlowBased != expected.FlowBased {
 		t.Fatal("Gretap.FlowBased doesn't match")
 	}
+
+	if expected.EncapLimit != 0 && actual.EncapLimit != expected.EncapLimit {
+		t.Fatal("Gretap.EncapLimit doesn't match")
+	}
+
+	if actual.FlowInfo != expected.FlowInfo {
+		t.Fatal("Gretap.FlowInfo doesn't match")
+	}
 }
 
 func compareGretun(t *testing.T, expected, actual *Gretun) {
@@ -572,6 +621,14 @@ func compareGretun(t *testing.T, expected, actual *Gretun) {
 	if actual.FlowBased != expected.FlowBased {
 		t.Fatal("Gretun.FlowBased doesn't match")
 	}
+
+	if expected.EncapLimit != 0 && actual.EncapLimit != expected.EncapLimit {
+		t.Fatal("Gretun.EncapLimit doesn't match")
+	}
+
+	if actual.FlowInfo != expected.FlowInfo {
+		t.Fatal("Gretun.FlowInfo doesn't match")
+	}
 }
 
 func compareVxlan(t *testing.T, expected, actual *Vxlan) {
@@ -639,12 +696,21 @@ func compareVxlan(t *testing.T, expected, actual *Vxlan) {
 			t.Fatal("Vxlan.PortHigh doesn't match")
 		}
 	}
+	if expected.DF != VXLAN_DF_UNSET && actual.DF != expected.DF {
+		t.Fatalf("Vxlan.DF doesn't match: expected %s, got %s", expected.DF, actual.DF)
+	}
+	if actual.VniFilter != expected.VniFilter {
+		t.Fatal("Vxlan.VniFilter doesn't match")
+	}
 }
 
 func compareXfrmi(t *testing.T, expected, actual *Xfrmi) {
 	if expected.Ifid != actual.Ifid {
 		t.Fatal("Xfrmi.Ifid doesn't match")
 	}
+	if expected.FlowBased != actual.FlowBased {
+		t.Fatal("Xfrmi.FlowBased doesn't match")
+	}
 }
 
 func compareTuntap(t *testing.T, expected, actual *Tuntap) {
@@ -705,6 +771,10 @@ func compareBareUDP(t *testing.T, expected, actual *BareUDP) {
 	if actual.MultiProto != expected.MultiProto {
 		t.Fatal("BareUDP.MultiProto doesn't match")
 	}
+
+	if actual.RxCollectMetadata != expected.RxCollectMetadata {
+		t.Fatal("BareUDP.RxCollectMetadata doesn't match")
+	}
 }
 
 func TestLinkAddDelWithIndex(t *testing.T) {
@@ -755,6 +825,191 @@ func TestLinkModify(t *testing.T) {
 	}
 }
 
+func TestLinkExtras(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+
+	h, err := NewHandle(unix.NETLINK_ROUTE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	h.RetainUnknownAttributes()
+
+	var reply, sent []byte
+	h.SetTraceFunc(func(dir nl.Direction, proto int, msg []byte) {
+		if (*unix.NlMsghdr)(unsafe.Pointer(&msg[0])).Type != unix.RTM_NEWLINK {
+			return
+		}
+		if dir == nl.DirectionReceive {
+			reply = append([]byte(nil), msg...)
+		} else {
+			sent = append([]byte(nil), msg...)
+		}
+	})
+
+	if err := h.LinkAdd(&Ifb{LinkAttrs{Name: "foo"}}); err != nil {
+		t.Fatal(err)
+	}
+	link, err := h.LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for typ := range link.Attrs().Extras {
+		if linkAttrKnown[typ&nl.NLA_TYPE_MASK] {
+			t.Fatalf("known attribute %d retained in Extras", typ)
+		}
+	}
+
+	// pretend the kernel sent an attribute newer than the library
+	const fakeType = 1000
+	fakeValue := []byte{1, 2, 3, 4}
+	m := append(reply[unix.SizeofNlMsghdr:], nl.NewRtAttr(fakeType, fakeValue).Serialize()...)
+	link, err = linkDeserialize(nil, m, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(link.Attrs().Extras[fakeType], fakeValue) {
+		t.Fatalf("fake attribute not retained: %v", link.Attrs().Extras)
+	}
+	if plain, err := LinkDeserialize(nil, m); err != nil || plain.Attrs().Extras != nil {
+		t.Fatalf("Extras retained without opting in: %v, %v", plain, err)
+	}
+
+	// the kernel refuses the attributes it only reports, only send the
+	// fake one, which it ignores
+	modified := &Ifb{LinkAttrs{Name: "foo", MTU: 1400, Extras: map[uint16][]byte{fakeType: fakeValue}}}
+	if err := h.LinkModify(modified); err != nil {
+		t.Fatal(err)
+	}
+	attrs, err := nl.ParseRouteAttr(sent[unix.SizeofNlMsghdr+unix.SizeofIfInfomsg:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, attr := range attrs {
+		if attr.Attr.Type == fakeType && bytes.Equal(attr.Value, fakeValue) {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("LinkModify did not send the retained attribute")
+	}
+	if link, err = h.LinkByName("foo"); err != nil || link.Attrs().MTU != 1400 {
+		t.Fatalf("LinkModify was not applied: %v", err)
+	}
+}
+
+func TestLinkVfInfoDeserialize(t *testing.T) {
+	const numVfs = 300
+	encode := func(vfs int) []byte {
+		msg := nl.NewIfInfomsg(unix.AF_UNSPEC)
+		msg.Index = 7
+		m := msg.Serialize()
+		m = append(m, nl.NewRtAttr(unix.IFLA_IFNAME, nl.ZeroTerminated("foo")).Serialize()...)
+		m = append(m, nl.NewRtAttr(unix.IFLA_NUM_VF, nl.Uint32Attr(numVfs)).Serialize()...)
+		list := nl.NewRtAttr(unix.IFLA_VFINFO_LIST, nil)
+		for i := 0; i < vfs; i++ {
+			info := list.AddRtAttr(nl.IFLA_VF_INFO, nil)
+			mac := nl.VfMac{Vf: uint32(i)}
+			copy(mac.Mac[:], []byte{0x02, 0, 0, 0, byte(i >> 8), byte(i)})
+			info.AddRtAttr(nl.IFLA_VF_MAC, mac.Serialize())
+			vlan := nl.VfVlan{Vf: uint32(i), Vlan: uint32(100 + i)}
+			info.AddRtAttr(nl.IFLA_VF_VLAN, vlan.Serialize())
+		}
+		return append(m, list.Serialize()...)
+	}
+
+	link, err := LinkDeserialize(nil, encode(numVfs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	attrs := link.Attrs()
+	if attrs.NumVfs != numVfs || len(attrs.Vfs) != numVfs {
+		t.Fatalf("expected %d VFs, got NumVfs %d and %d entries", numVfs, attrs.NumVfs, len(attrs.Vfs))
+	}
+	last := attrs.Vfs[numVfs-1]
+	if last.ID != numVfs-1 || last.Vlan != 100+numVfs-1 || last.Mac.String() != "02:00:00:00:01:2b" {
+		t.Fatalf("unexpected last VF: %+v", last)
+	}
+
+	// a list cut short is reported by NumVfs
+	link, err = LinkDeserialize(nil, encode(256))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.Attrs().NumVfs != numVfs || len(link.Attrs().Vfs) != 256 {
+		t.Fatalf("expected 256 of %d VFs, got %d of %d", numVfs, len(link.Attrs().Vfs), link.Attrs().NumVfs)
+	}
+
+	// the VF list is then requested again without the VF statistics
+	req := nl.NewNetlinkRequest(unix.RTM_GETLINK, 0)
+	req.AddData(nl.NewIfInfomsg(unix.AF_UNSPEC))
+	ext := nl.NewRtAttr(unix.IFLA_EXT_MASK, nl.Uint32Attr(nl.RTEXT_FILTER_VF))
+	req.AddData(ext)
+	if !skipVfStats(req) || native.Uint32(ext.Data) != nl.RTEXT_FILTER_VF|nl.RTEXT_FILTER_SKIP_STATS {
+		t.Fatalf("RTEXT_FILTER_SKIP_STATS not added: %#x", native.Uint32(ext.Data))
+	}
+	if skipVfStats(req) {
+		t.Fatal("RTEXT_FILTER_SKIP_STATS added twice")
+	}
+}
+
+func TestLinkMapDeserialize(t *testing.T) {
+	ifmap := nl.RtnlLinkIfmap{
+		MemStart: 0xd0000,
+		MemEnd:   0xd3fff,
+		BaseAddr: 0x300,
+		Irq:      10,
+		Dma:      3,
+		Port:     2,
+	}
+	msg := nl.NewIfInfomsg(unix.AF_UNSPEC)
+	msg.Index = 7
+	m := msg.Serialize()
+	m = append(m, nl.NewRtAttr(unix.IFLA_IFNAME, nl.ZeroTerminated("foo")).Serialize()...)
+	m = append(m, nl.NewRtAttr(unix.IFLA_MAP, ifmap.Serialize()).Serialize()...)
+
+	link, err := LinkDeserialize(nil, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := LinkMap{MemStart: 0xd0000, MemEnd: 0xd3fff, BaseAddr: 0x300, Irq: 10, Dma: 3, Port: 2}
+	if got := link.Attrs().Map; got == nil || *got != expected {
+		t.Fatalf("Map is %+v, should be %+v", got, expected)
+	}
+
+	// a truncated attribute is ignored
+	m = append(msg.Serialize(), nl.NewRtAttr(unix.IFLA_MAP, ifmap.Serialize()[:16]).Serialize()...)
+	link, err = LinkDeserialize(nil, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.Attrs().Map != nil {
+		t.Fatalf("Map decoded from a truncated attribute: %+v", link.Attrs().Map)
+	}
+}
+
+func TestLinkSetMap(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+
+	if err := LinkAdd(&Dummy{LinkAttrs{Name: "foo"}}); err != nil {
+		t.Fatal(err)
+	}
+	link, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := link.Attrs().Map; m == nil || *m != (LinkMap{}) {
+		t.Fatalf("Map is %+v, should be all zeros", m)
+	}
+
+	// dummy has no legacy resources to configure
+	err = LinkSetMap(link, &LinkMap{BaseAddr: 0x300, Irq: 10})
+	if !errors.Is(err, unix.EOPNOTSUPP) {
+		t.Fatalf("LinkSetMap returned %v, should be EOPNOTSUPP", err)
+	}
+}
+
 func TestLinkAddDelIfb(t *testing.T) {
 	t.Cleanup(setUpNetlinkTest(t))
 
@@ -841,11 +1096,13 @@ func TestLinkAddDelGretap(t *testing.T) {
 		Remote:    net.IPv4(127, 0, 0, 1)})
 
 	testLinkAddDel(t, &Gretap{
-		LinkAttrs: LinkAttrs{Name: "foo6"},
-		IKey:      0x101,
-		OKey:      0x101,
-		Local:     net.ParseIP("2001:db8:abcd::1"),
-		Remote:    net.ParseIP("2001:db8:ef33::2")})
+		LinkAttrs:  LinkAttrs{Name: "foo6"},
+		IKey:       0x101,
+		OKey:       0x101,
+		Local:      net.ParseIP("2001:db8:abcd::1"),
+		Remote:     net.ParseIP("2001:db8:ef33::2"),
+		EncapLimit: 4,
+		FlowInfo:   0x12345})
 }
 
 func TestLinkAddDelGretun(t *testing.T) {
@@ -1161,6 +1418,54 @@ func TestLinkMacvBCQueueLen(t *testing.T) {
 		},
 	})
 
+	macvlan := &Macvlan{
+		LinkAttrs:  LinkAttrs{Name: "bar", ParentIndex: parent.Attrs().Index},
+		Mode:       MACVLAN_MODE_PRIVATE,
+		BCQueueLen: 10000,
+	}
+	macvtap := &Macvtap{
+		Macvlan: Macvlan{
+			LinkAttrs:  LinkAttrs{Name: "baz", ParentIndex: parent.Attrs().Index},
+			Mode:       MACVLAN_MODE_PRIVATE,
+			BCQueueLen: 10000,
+		},
+	}
+	for _, link := range []Link{macvlan, macvtap} {
+		if err := LinkAdd(link); err != nil {
+			t.Fatal(err)
+		}
+	}
+	macvlan.BCQueueLen = 20000
+	if err := LinkModify(macvlan); err != nil {
+		t.Fatal(err)
+	}
+	macvtap.BCQueueLen = 20000
+	if err := LinkModify(macvtap); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"bar", "baz"} {
+		link, err := LinkByName(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var macv *Macvlan
+		switch link := link.(type) {
+		case *Macvlan:
+			macv = link
+		case *Macvtap:
+			macv = &link.Macvlan
+		default:
+			t.Fatalf("unexpected link type %T", link)
+		}
+		if macv.BCQueueLen != 20000 || macv.UsedBCQueueLen != 20000 {
+			t.Fatalf("%s: BCQueueLen %d, UsedBCQueueLen %d, expected 20000",
+				name, macv.BCQueueLen, macv.UsedBCQueueLen)
+		}
+		if err := LinkDel(link); err != nil {
+			t.Fatal(err)
+		}
+	}
+
 	if err := LinkDel(parent); err != nil {
 		t.Fatal(err)
 	}
@@ -1285,6 +1590,9 @@ func TestLinkAddDelBond(t *testing.T) {
 	t.Cleanup(setUpNetlinkTest(t))
 
 	modes := []string{"802.3ad", "balance-tlb"}
+	if k, m, err := KernelVersion(); err == nil && (k > 5 || k == 5 && m >= 19) {
+		modes = append(modes, "active-backup")
+	}
 	for _, mode := range modes {
 		bond := NewLinkBond(LinkAttrs{Name: "foo"})
 		bond.Mode = StringToBondModeMap[mode]
@@ -1298,6 +1606,9 @@ func TestLinkAddDelBond(t *testing.T) {
 		case "balance-tlb":
 			bond.TlbDynamicLb = 1
 			bond.ArpIpTargets = []net.IP{net.ParseIP("1.1.1.2"), net.ParseIP("1.1.1.1")}
+		case "active-backup":
+			bond.ArpInterval = 100
+			bond.NsIP6Targets = []net.IP{net.ParseIP("2001:db8::1"), net.ParseIP("2001:db8::2")}
 		}
 		testLinkAddDel(t, bond)
 	}
@@ -1783,7 +2094,7 @@ func TestVethPeerNs(t *testing.T) {
 		t.Fatal("Failed to set basens")
 	}
 
-	_, err = LinkByName("bar")
+	peer, err := LinkByName("bar")
 	if err != nil {
 		t.Fatal("Link bar is not in basens")
 	}
@@ -1793,10 +2104,25 @@ func TestVethPeerNs(t *testing.T) {
 		t.Fatal("Failed to set newns")
 	}
 
-	_, err = LinkByName("foo")
+	primary, err := LinkByName("foo")
 	if err != nil {
 		t.Fatal("Link foo is not in newns")
 	}
+
+	// the parent of foo is bar, in basens
+	nsid, err := GetNetNsIdByFd(int(basens))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nsid == -1 {
+		t.Fatal("basens has no id in newns")
+	}
+	if primary.Attrs().NetNsID != nsid {
+		t.Fatalf("NetNsID is %d, should be %d", primary.Attrs().NetNsID, nsid)
+	}
+	if primary.Attrs().ParentIndex != peer.Attrs().Index {
+		t.Fatalf("ParentIndex is %d, should be %d", primary.Attrs().ParentIndex, peer.Attrs().Index)
+	}
 }
 
 func TestVethPeerNs2(t *testing.T) {
@@ -1883,6 +2209,42 @@ func TestLinkAddDelVxlan(t *testing.T) {
 	}
 }
 
+func TestLinkAddDelVxlanDF(t *testing.T) {
+	minKernelRequired(t, 5, 0)
+	t.Cleanup(setUpNetlinkTest(t))
+
+	vxlan := &Vxlan{
+		LinkAttrs: LinkAttrs{
+			Name: "bar",
+		},
+		VxlanId: 10,
+		Port:    4789,
+		DF:      VXLAN_DF_INHERIT,
+	}
+	if err := LinkAdd(vxlan); err != nil {
+		t.Fatal(err)
+	}
+	link, err := LinkByName("bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareVxlan(t, vxlan, link.(*Vxlan))
+
+	vxlan.DF = VXLAN_DF_SET
+	if err := LinkModify(vxlan); err != nil {
+		t.Fatal(err)
+	}
+	link, err = LinkByName("bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareVxlan(t, vxlan, link.(*Vxlan))
+
+	if err := LinkDel(link); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestLinkAddDelVxlanUdpCSum6(t *testing.T) {
 	minKernelRequired(t, 3, 16)
 	t.Cleanup(setUpNetlinkTest(t))
@@ -1981,6 +2343,32 @@ func TestLinkAddDelBareUDP(t *testing.T) {
 		SrcPortMin: 12345,
 		MultiProto: true,
 	})
+
+	// Port and SrcPortMin left unset fall back to the defaults
+	testLinkAddDel(t, &BareUDP{
+		LinkAttrs: LinkAttrs{Name: "foo101"},
+		EtherType: syscall.ETH_P_IPV6,
+	})
+}
+
+func TestBareUDPValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		link    *BareUDP
+		wantErr bool
+	}{
+		{"mpls multiproto", &BareUDP{EtherType: syscall.ETH_P_MPLS_UC, MultiProto: true}, false},
+		{"ip multiproto", &BareUDP{EtherType: syscall.ETH_P_IP, MultiProto: true}, false},
+		{"ipv6 single proto", &BareUDP{EtherType: syscall.ETH_P_IPV6}, false},
+		{"ipv6 multiproto", &BareUDP{EtherType: syscall.ETH_P_IPV6, MultiProto: true}, true},
+		{"missing ethertype", &BareUDP{SrcPortMin: 12345}, true},
+	}
+	for _, tt := range tests {
+		err := validateBareUDP(tt.link)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: validateBareUDP() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
 }
 
 func TestBareUDPCompareToIP(t *testing.T) {
@@ -2345,7 +2733,10 @@ func expectLinkUpdate(ch <-chan LinkUpdate, ifaceName string, up bool) bool {
 	for {
 		timeout := time.After(time.Minute)
 		select {
-		case update := <-ch:
+		case update, ok := <-ch:
+			if !ok {
+				return false
+			}
 			if ifaceName == update.Link.Attrs().Name && (update.IfInfomsg.Flags&unix.IFF_UP != 0) == up {
 				return true
 			}
@@ -2391,95 +2782,463 @@ func TestLinkSubscribe(t *testing.T) {
 	}
 }
 
-func TestLinkSubscribeWithOptions(t *testing.T) {
+func TestLinkSubscribeShutdown(t *testing.T) {
 	t.Cleanup(setUpNetlinkTest(t))
 
 	ch := make(chan LinkUpdate)
 	done := make(chan struct{})
-	defer close(done)
-	var lastError error
-	defer func() {
-		if lastError != nil {
-			t.Fatalf("Fatal error received during subscription: %v", lastError)
-		}
-	}()
-	if err := LinkSubscribeWithOptions(ch, done, LinkSubscribeOptions{
-		ErrorCallback: func(err error) {
-			lastError = err
-		},
-	}); err != nil {
+	if err := LinkSubscribe(ch, done); err != nil {
 		t.Fatal(err)
 	}
 
-	link := &Veth{LinkAttrs: LinkAttrs{Name: "foo", TxQLen: testTxQLen, MTU: 1400}, PeerName: "bar"}
+	link := &Veth{LinkAttrs: LinkAttrs{Name: "foo"}, PeerName: "bar"}
 	if err := LinkAdd(link); err != nil {
 		t.Fatal(err)
 	}
-
 	if !expectLinkUpdate(ch, "foo", false) {
 		t.Fatal("Add update not received as expected")
 	}
-}
 
-func TestLinkSubscribeAt(t *testing.T) {
-	skipUnlessRoot(t)
+	close(done)
 
-	// Create an handle on a custom netns
-	newNs, err := netns.New()
-	if err != nil {
-		t.Fatal(err)
+	timeout := time.After(time.Minute)
+	for {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("update channel not closed after done")
+		}
 	}
-	defer newNs.Close()
+}
 
-	nh, err := NewHandleAt(newNs)
-	if err != nil {
-		t.Fatal(err)
-	}
-	defer nh.Close()
+func TestLinkDelByIndexFromUpdate(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
 
-	// Subscribe for Link events on the custom netns
 	ch := make(chan LinkUpdate)
 	done := make(chan struct{})
 	defer close(done)
-	if err := LinkSubscribeAt(newNs, ch, done); err != nil {
+	if err := LinkSubscribe(ch, done); err != nil {
 		t.Fatal(err)
 	}
 
-	link := &Veth{LinkAttrs: LinkAttrs{Name: "test", TxQLen: testTxQLen, MTU: 1400}, PeerName: "bar"}
-	if err := nh.LinkAdd(link); err != nil {
+	if err := LinkAdd(&Veth{LinkAttrs: LinkAttrs{Name: "foo"}, PeerName: "bar"}); err != nil {
 		t.Fatal(err)
 	}
 
-	if !expectLinkUpdate(ch, "test", false) {
-		t.Fatal("Add update not received as expected")
+	var index int
+	timeout := time.After(time.Minute)
+	for index == 0 {
+		select {
+		case update := <-ch:
+			if update.Link.Attrs().Name == "foo" && update.Header.Type == unix.RTM_NEWLINK {
+				index = int(update.Index)
+			}
+		case <-timeout:
+			t.Fatal("Add update not received as expected")
+		}
 	}
 
-	if err := nh.LinkSetUp(link); err != nil {
+	if err := LinkDelByIndex(index); err != nil {
 		t.Fatal(err)
 	}
-
-	if !expectLinkUpdate(ch, "test", true) {
-		t.Fatal("Link Up update not received as expected")
-	}
-
-	if err := nh.LinkDel(link); err != nil {
-		t.Fatal(err)
+	if _, err := LinkByIndex(index); err == nil {
+		t.Fatal("Link not removed properly")
 	}
 
-	if !expectLinkUpdate(ch, "test", false) {
-		t.Fatal("Del update not received as expected")
+	err := LinkDelByIndex(index)
+	if _, ok := err.(LinkNotFoundError); !ok {
+		t.Fatalf("expected LinkNotFoundError, got %v", err)
 	}
 }
 
-func TestLinkSubscribeListExisting(t *testing.T) {
-	skipUnlessRoot(t)
+func TestLinkDelIdempotent(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
 
-	// Create an handle on a custom netns
-	newNs, err := netns.New()
-	if err != nil {
+	link := &Veth{LinkAttrs: LinkAttrs{Name: "foo"}, PeerName: "bar"}
+	if err := LinkAdd(link); err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkDel(link); err != nil {
 		t.Fatal(err)
 	}
-	defer newNs.Close()
+
+	// by index, as the link was added
+	err := LinkDel(link)
+	if _, ok := err.(LinkNotFoundError); !ok || !errors.Is(err, unix.ENODEV) {
+		t.Fatalf("expected LinkNotFoundError wrapping ENODEV, got %v", err)
+	}
+	// by name, as built by a reconciliation loop
+	err = LinkDel(&Veth{LinkAttrs: LinkAttrs{Name: "foo"}})
+	if _, ok := err.(LinkNotFoundError); !ok {
+		t.Fatalf("expected LinkNotFoundError, got %v", err)
+	}
+
+	if err := LinkDelIdempotent(link); err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkDelIdempotent(&Veth{LinkAttrs: LinkAttrs{Name: "foo"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// an existing link is still deleted
+	if err := LinkAdd(&Veth{LinkAttrs: LinkAttrs{Name: "foo"}, PeerName: "bar"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkDelIdempotent(&Veth{LinkAttrs: LinkAttrs{Name: "foo"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LinkByName("foo"); err == nil {
+		t.Fatal("Link not removed properly")
+	}
+}
+
+func TestLinkDelByName(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+
+	if err := LinkAdd(&Veth{LinkAttrs: LinkAttrs{Name: "foo"}, PeerName: "bar"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkDelByName("foo"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LinkByName("foo"); err == nil {
+		t.Fatal("Link not removed properly")
+	}
+
+	err := LinkDelByName("foo")
+	if _, ok := err.(LinkNotFoundError); !ok {
+		t.Fatalf("expected LinkNotFoundError, got %v", err)
+	}
+}
+
+func TestLinkListDumpInterrupted(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+
+	h, err := NewHandle()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	h.RetryInterruptedDumps(5)
+
+	stop := make(chan struct{})
+	churnDone := make(chan struct{})
+	go func() {
+		defer close(churnDone)
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			name := fmt.Sprintf("churn%d", i%8)
+			link := &Veth{LinkAttrs: LinkAttrs{Name: name}, PeerName: name + "p"}
+			if err := h.LinkAdd(link); err != nil {
+				continue
+			}
+			h.LinkDel(link)
+		}
+	}()
+
+	var interrupted int
+	for i := 0; i < 200; i++ {
+		links, err := h.LinkList()
+		if errors.Is(err, ErrDumpInterrupted) {
+			interrupted++
+			continue
+		}
+		if err != nil {
+			close(stop)
+			<-churnDone
+			t.Fatal(err)
+		}
+		seen := make(map[int]bool)
+		for _, l := range links {
+			if seen[l.Attrs().Index] {
+				close(stop)
+				<-churnDone
+				t.Fatalf("link index %d listed twice in a consistent dump", l.Attrs().Index)
+			}
+			seen[l.Attrs().Index] = true
+		}
+	}
+	close(stop)
+	<-churnDone
+	t.Logf("%d of 200 dumps still interrupted after retries", interrupted)
+}
+
+func TestLinkSubscribeWithOptions(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+
+	ch := make(chan LinkUpdate)
+	done := make(chan struct{})
+	defer close(done)
+	var lastError error
+	defer func() {
+		if lastError != nil {
+			t.Fatalf("Fatal error received during subscription: %v", lastError)
+		}
+	}()
+	if err := LinkSubscribeWithOptions(ch, done, LinkSubscribeOptions{
+		ErrorCallback: func(err error) {
+			lastError = err
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	link := &Veth{LinkAttrs: LinkAttrs{Name: "foo", TxQLen: testTxQLen, MTU: 1400}, PeerName: "bar"}
+	if err := LinkAdd(link); err != nil {
+		t.Fatal(err)
+	}
+
+	if !expectLinkUpdate(ch, "foo", false) {
+		t.Fatal("Add update not received as expected")
+	}
+}
+
+func TestLinkSubscribeFilter(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+
+	for _, veth := range []*Veth{
+		{LinkAttrs: LinkAttrs{Name: "foo"}, PeerName: "bar"},
+		{LinkAttrs: LinkAttrs{Name: "baz"}, PeerName: "qux"},
+	} {
+		if err := LinkAdd(veth); err != nil {
+			t.Fatal(err)
+		}
+	}
+	foo, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	baz, err := LinkByName("baz")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	byIndex := make(chan LinkUpdate, 16)
+	if err := LinkSubscribeWithOptions(byIndex, done, LinkSubscribeOptions{
+		IfIndexFilter: []int{foo.Attrs().Index},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	byName := make(chan LinkUpdate, 16)
+	if err := LinkSubscribeWithOptions(byName, done, LinkSubscribeOptions{
+		NamePrefixFilter: "fo",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// baz changes first, so that its update would be read before the
+	// one of foo if it wasn't filtered out.
+	if err := LinkSetUp(baz); err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkSetUp(foo); err != nil {
+		t.Fatal(err)
+	}
+	for _, ch := range []chan LinkUpdate{byIndex, byName} {
+		update := <-ch
+		if name := update.Link.Attrs().Name; name != "foo" {
+			t.Fatalf("received update for %s, expected only foo", name)
+		}
+		if update.IfInfomsg.Flags&unix.IFF_UP == 0 && !expectLinkUpdate(ch, "foo", true) {
+			t.Fatal("Up update for foo not received as expected")
+		}
+	}
+}
+
+func TestLinkSubscribeResyncOnOverflow(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+
+	ch := make(chan LinkUpdate)
+	done := make(chan struct{})
+	defer close(done)
+	overflowed := make(chan struct{}, 1)
+	if err := LinkSubscribeWithOptions(ch, done, LinkSubscribeOptions{
+		ReceiveBufferSize: 4096,
+		ResyncOnOverflow:  true,
+		ErrorCallback: func(err error) {
+			if errors.Is(err, unix.ENOBUFS) {
+				select {
+				case overflowed <- struct{}{}:
+				default:
+				}
+			}
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nobody reads ch, so the notifications pile up in the socket until
+	// they overflow its small receive buffer.
+	for i := 0; i < 100; i++ {
+		link := &Veth{LinkAttrs: LinkAttrs{Name: "foo"}, PeerName: "bar"}
+		if err := LinkAdd(link); err != nil {
+			t.Fatal(err)
+		}
+		if err := LinkDel(link); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := LinkAdd(&Veth{LinkAttrs: LinkAttrs{Name: "foo"}, PeerName: "bar"}); err != nil {
+		t.Fatal(err)
+	}
+
+	timeout := time.After(time.Minute)
+	resynced := false
+	for {
+		select {
+		case update, ok := <-ch:
+			if !ok {
+				t.Fatal("subscription stopped")
+			}
+			if update.Header.Type == LinkUpdateResync {
+				if update.Link != nil {
+					t.Fatalf("resync marker carries a link: %v", update.Link)
+				}
+				resynced = true
+				continue
+			}
+			// the dump following the marker reports foo as it is now
+			if resynced && update.Header.Flags&unix.NLM_F_MULTI != 0 && update.Link.Attrs().Name == "foo" {
+				select {
+				case <-overflowed:
+				default:
+					t.Fatal("error callback not called with ENOBUFS")
+				}
+				return
+			}
+		case <-timeout:
+			t.Fatal("resync not received")
+		}
+	}
+}
+
+func TestLinkSubscribeAt(t *testing.T) {
+	skipUnlessRoot(t)
+
+	// Create an handle on a custom netns
+	newNs, err := netns.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer newNs.Close()
+
+	nh, err := NewHandleAt(newNs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer nh.Close()
+
+	// Subscribe for Link events on the custom netns
+	ch := make(chan LinkUpdate)
+	done := make(chan struct{})
+	defer close(done)
+	if err := LinkSubscribeAt(newNs, ch, done); err != nil {
+		t.Fatal(err)
+	}
+
+	link := &Veth{LinkAttrs: LinkAttrs{Name: "test", TxQLen: testTxQLen, MTU: 1400}, PeerName: "bar"}
+	if err := nh.LinkAdd(link); err != nil {
+		t.Fatal(err)
+	}
+
+	if !expectLinkUpdate(ch, "test", false) {
+		t.Fatal("Add update not received as expected")
+	}
+
+	if err := nh.LinkSetUp(link); err != nil {
+		t.Fatal(err)
+	}
+
+	if !expectLinkUpdate(ch, "test", true) {
+		t.Fatal("Link Up update not received as expected")
+	}
+
+	if err := nh.LinkDel(link); err != nil {
+		t.Fatal(err)
+	}
+
+	if !expectLinkUpdate(ch, "test", false) {
+		t.Fatal("Del update not received as expected")
+	}
+}
+
+func TestLinkSubscribeListenAllNsid(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+
+	hostNs, err := netns.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hostNs.Close()
+	newNs, err := netns.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer newNs.Close()
+	if err := netns.Set(hostNs); err != nil {
+		t.Fatal(err)
+	}
+	// Only namespaces with an id are heard from.
+	const nsid = 42
+	if err := SetNetNsIdByFd(int(newNs), nsid); err != nil {
+		t.Fatal(err)
+	}
+
+	ch := make(chan LinkUpdate)
+	done := make(chan struct{})
+	defer close(done)
+	if err := LinkSubscribeWithOptions(ch, done, LinkSubscribeOptions{ListenAllNsid: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	nh, err := NewHandleAt(newNs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer nh.Close()
+	if err := nh.LinkAdd(&Ifb{LinkAttrs: LinkAttrs{Name: "remote"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkAdd(&Ifb{LinkAttrs: LinkAttrs{Name: "local"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	seen := map[string]int{}
+	timeout := time.After(time.Minute)
+	for len(seen) < 2 {
+		select {
+		case update := <-ch:
+			seen[update.Link.Attrs().Name] = update.NsID
+		case <-timeout:
+			t.Fatalf("timed out waiting for updates, got %v", seen)
+		}
+	}
+	if id, ok := seen["remote"]; !ok || id != nsid {
+		t.Fatalf("expected the remote link with nsid %d, got %v", nsid, seen)
+	}
+	if id, ok := seen["local"]; !ok || id != -1 {
+		t.Fatalf("expected the local link with nsid -1, got %v", seen)
+	}
+}
+
+func TestLinkSubscribeListExisting(t *testing.T) {
+	skipUnlessRoot(t)
+
+	// Create an handle on a custom netns
+	newNs, err := netns.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer newNs.Close()
 
 	nh, err := NewHandleAt(newNs)
 	if err != nil {
@@ -2572,6 +3331,104 @@ func TestLinkStats(t *testing.T) {
 	}
 }
 
+func TestLinkStatsDelta(t *testing.T) {
+	tests := []struct {
+		name      string
+		prev, cur uint64
+		is64bit   bool
+		want      uint64
+	}{
+		{"increase", 100, 250, false, 150},
+		{"unchanged", 7, 7, true, 0},
+		{"32bit wrap", math.MaxUint32 - 9, 5, false, 15},
+		{"32bit wrap to zero", math.MaxUint32, 0, false, 1},
+		{"64bit wrap", math.MaxUint64 - 1, 3, true, 5},
+		{"64bit above 32bit", math.MaxUint32 - 1, math.MaxUint32 + 10, true, 11},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev := &LinkStatistics{RxBytes: tt.prev, TxCompressed: tt.prev}
+			cur := &LinkStatistics{RxBytes: tt.cur, TxCompressed: tt.cur}
+			delta := LinkStatsDelta(prev, cur, tt.is64bit)
+			if delta.RxBytes != tt.want || delta.TxCompressed != tt.want {
+				t.Fatalf("got delta %d/%d, want %d", delta.RxBytes, delta.TxCompressed, tt.want)
+			}
+			if delta.TxBytes != 0 || delta.RxPackets != 0 {
+				t.Fatalf("untouched counters changed: %+v", delta)
+			}
+		})
+	}
+}
+
+func TestLinkStatsPoll(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+
+	if err := LinkAdd(&Veth{LinkAttrs: LinkAttrs{Name: "v0"}, PeerName: "v1"}); err != nil {
+		t.Fatal(err)
+	}
+	veth0, err := LinkByName("v0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	veth1, err := LinkByName("v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkSetUp(veth0); err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkSetUp(veth1); err != nil {
+		t.Fatal(err)
+	}
+
+	ch := make(chan LinkStatsSample)
+	done := make(chan struct{})
+	defer close(done)
+	if err := LinkStatsPoll(veth0, 0, ch, done); err == nil {
+		t.Fatal("polling with a zero interval was accepted")
+	}
+	if err := LinkStatsPoll(veth0, 50*time.Millisecond, ch, done); err != nil {
+		t.Fatal(err)
+	}
+
+	frame := make([]byte, 60)
+	copy(frame, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+	copy(frame[6:], veth0.Attrs().HardwareAddr)
+	frame[12], frame[13] = 0x88, 0xb5
+	sent := sendRawFrames(t, veth0, frame, 10)
+
+	var first, last LinkStatsSample
+	var txPackets uint64
+	timeout := time.After(10 * time.Second)
+	for txPackets < sent {
+		select {
+		case sample, ok := <-ch:
+			if !ok {
+				t.Fatal("polling stopped unexpectedly")
+			}
+			if first.Statistics == nil {
+				first = sample
+			}
+			if sample.Interval <= 0 {
+				t.Fatalf("invalid sample interval %v", sample.Interval)
+			}
+			last = sample
+			txPackets += sample.Delta.TxPackets
+		case <-timeout:
+			t.Fatalf("only %d of %d packets accounted for", txPackets, sent)
+		}
+	}
+	if base := first.Statistics.TxPackets - first.Delta.TxPackets; last.Statistics.TxPackets-base != txPackets {
+		t.Fatalf("deltas add up to %d packets, counters moved by %d", txPackets, last.Statistics.TxPackets-base)
+	}
+
+	if err := LinkDel(veth0); err != nil {
+		t.Fatal(err)
+	}
+	for range ch {
+	}
+}
+
 func TestLinkXdp(t *testing.T) {
 	links, err := LinkList()
 	if err != nil {
@@ -2631,6 +3488,36 @@ func TestLinkAddDelIp6tnl(t *testing.T) {
 		Local:     net.ParseIP("2001:db8::100"),
 		Remote:    net.ParseIP("2001:db8::200"),
 	})
+
+	// an explicit zero encap limit differs from the default of 4
+	encapLimit := uint8(0)
+	testLinkAddDel(t, &Ip6tnl{
+		LinkAttrs:     LinkAttrs{Name: "ip6tnltest"},
+		Local:         net.ParseIP("2001:db8::100"),
+		Remote:        net.ParseIP("2001:db8::200"),
+		EncapLimit:    &encapLimit,
+		FlowLabel:     0x12345,
+		TClassInherit: true,
+	})
+
+	if err := LinkAdd(&Ip6tnl{
+		LinkAttrs: LinkAttrs{Name: "ip6tnltest"},
+		Local:     net.ParseIP("2001:db8::100"),
+		Remote:    net.ParseIP("2001:db8::200"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	link, err := LinkByName("ip6tnltest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ip6tnl := link.(*Ip6tnl)
+	if ip6tnl.EncapLimit == nil || *ip6tnl.EncapLimit != 4 {
+		t.Fatalf("expected the default encap limit of 4, got %v", ip6tnl.EncapLimit)
+	}
+	if ip6tnl.TClassInherit || ip6tnl.FlowLabel != 0 {
+		t.Fatalf("unexpected inherit %v or flow label %#x", ip6tnl.TClassInherit, ip6tnl.FlowLabel)
+	}
 }
 
 func TestLinkAddDelIp6tnlFlowbased(t *testing.T) {
@@ -2652,6 +3539,19 @@ func TestLinkAddDelSittun(t *testing.T) {
 		Remote:    net.IPv4(127, 0, 0, 1)})
 }
 
+func TestLinkAddDelSittun6rd(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+
+	_, prefix, _ := net.ParseCIDR("2001:db8::/32")
+	_, relay, _ := net.ParseCIDR("192.0.2.0/24")
+	testLinkAddDel(t, &Sittun{
+		LinkAttrs:        LinkAttrs{Name: "sit6rd"},
+		Local:            net.IPv4(192, 0, 2, 1),
+		Ttl:              64,
+		SixRdPrefix:      prefix,
+		SixRdRelayPrefix: relay})
+}
+
 func TestLinkAddDelVti(t *testing.T) {
 	t.Cleanup(setUpNetlinkTest(t))
 
@@ -2668,6 +3568,20 @@ func TestLinkAddDelVti(t *testing.T) {
 		OKey:      0x101,
 		Local:     net.IPv6loopback,
 		Remote:    net.IPv6loopback})
+
+	testLinkAddDel(t, &Vti{
+		LinkAttrs: LinkAttrs{Name: "vtimark"},
+		IKey:      0x102,
+		OKey:      0x103,
+		Fwmark:    0x42,
+		Local:     net.IPv4(127, 0, 0, 2),
+		Remote:    net.IPv4(127, 0, 0, 3)})
+
+	// a keyless tunnel reads back with zero keys
+	testLinkAddDel(t, &Vti{
+		LinkAttrs: LinkAttrs{Name: "vtinokey"},
+		Local:     net.ParseIP("2001:db8::1"),
+		Remote:    net.ParseIP("2001:db8::2")})
 }
 
 func TestLinkSetGSOMaxSize(t *testing.T) {
@@ -2679,23 +3593,93 @@ func TestLinkSetGSOMaxSize(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	link, err := LinkByName("foo")
+	link, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = LinkSetGSOMaxSize(link, 32768)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	link, err = LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if link.Attrs().GSOMaxSize != 32768 {
+		t.Fatalf("GSO max size was not modified")
+	}
+}
+
+func TestVethSetGSOGROMaxSizeBoth(t *testing.T) {
+	minKernelRequired(t, 5, 19)
+	t.Cleanup(setUpNetlinkTest(t))
+
+	veth := &Veth{LinkAttrs: LinkAttrs{Name: "foo", TxQLen: testTxQLen, MTU: 1500}, PeerName: "bar"}
+	if err := LinkAdd(veth); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := VethSetGSOMaxSize(veth, 32768, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := VethSetGROMaxSize(veth, 16384, true); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"foo", "bar"} {
+		link, err := LinkByName(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if link.Attrs().GSOMaxSize != 32768 {
+			t.Fatalf("GSO max size of %s was not modified: %d", name, link.Attrs().GSOMaxSize)
+		}
+		if link.Attrs().GROMaxSize != 16384 {
+			t.Fatalf("GRO max size of %s was not modified: %d", name, link.Attrs().GROMaxSize)
+		}
+	}
+
+	// only the given end is updated when both is false
+	if err := VethSetGSOMaxSize(veth, 8192, false); err != nil {
+		t.Fatal(err)
+	}
+	peer, err := LinkByName("bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if peer.Attrs().GSOMaxSize != 32768 {
+		t.Fatalf("GSO max size of the peer was modified: %d", peer.Attrs().GSOMaxSize)
+	}
+
+	// a peer in another namespace can't be resolved, leave the link alone
+	basens, err := netns.Get()
 	if err != nil {
 		t.Fatal(err)
 	}
-
-	err = LinkSetGSOMaxSize(link, 32768)
+	defer basens.Close()
+	newns, err := netns.New()
 	if err != nil {
 		t.Fatal(err)
 	}
-
-	link, err = LinkByName("foo")
+	defer newns.Close()
+	// netns.New switched the thread to the new namespace
+	if err := netns.Set(basens); err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkSetNsFd(peer, int(newns)); err != nil {
+		t.Fatal(err)
+	}
+	if err := VethSetGSOMaxSize(veth, 4096, true); err == nil {
+		t.Fatal("expected an error for a peer in another namespace")
+	}
+	link, err := LinkByName("foo")
 	if err != nil {
 		t.Fatal(err)
 	}
-
-	if link.Attrs().GSOMaxSize != 32768 {
-		t.Fatalf("GSO max size was not modified")
+	if link.Attrs().GSOMaxSize != 8192 {
+		t.Fatalf("GSO max size was modified: %d", link.Attrs().GSOMaxSize)
 	}
 }
 
@@ -3215,6 +4199,27 @@ func TestLinkAddDelXfrmi(t *testing.T) {
 		Ifid:      123})
 }
 
+func TestLinkAddDelXfrmiFlowBased(t *testing.T) {
+	minKernelRequired(t, 6, 0)
+	t.Cleanup(setUpNetlinkTest(t))
+
+	testLinkAddDel(t, &Xfrmi{
+		LinkAttrs: LinkAttrs{Name: "xfrm0"},
+		FlowBased: true})
+}
+
+func TestLinkAddXfrmiFlowBasedWithIfid(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+
+	err := LinkAdd(&Xfrmi{
+		LinkAttrs: LinkAttrs{Name: "xfrm0"},
+		Ifid:      42,
+		FlowBased: true})
+	if err == nil {
+		t.Fatal("flow based xfrmi with an Ifid should fail")
+	}
+}
+
 func TestLinkAddDelXfrmiNoId(t *testing.T) {
 	minKernelRequired(t, 4, 19)
 	t.Cleanup(setUpNetlinkTest(t))
@@ -3253,20 +4258,31 @@ func TestLinkByAliasWhenLinkIsNotFound(t *testing.T) {
 	}
 }
 
-func TestLinkAddDelTuntap(t *testing.T) {
+func TestLinkByHardwareAddr(t *testing.T) {
 	t.Cleanup(setUpNetlinkTest(t))
 
-	// Mount sysfs so that sysfs gets the namespace tag of the current network namespace
-	// This is necessary so that /sys shows the network interfaces of the current namespace.
-	if err := syscall.Mount("sysfs", "/sys", "sysfs", syscall.MS_RDONLY, ""); err != nil {
-		t.Fatal("Cannot mount sysfs")
+	addr, _ := net.ParseMAC("02:00:00:00:21:86")
+	if err := LinkAdd(&Dummy{LinkAttrs{Name: "foo", HardwareAddr: addr}}); err != nil {
+		t.Fatal(err)
+	}
+	link, err := LinkByHardwareAddr(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.Attrs().Name != "foo" || !bytes.Equal(link.Attrs().HardwareAddr, addr) {
+		t.Fatalf("unexpected link %s with address %s", link.Attrs().Name, link.Attrs().HardwareAddr)
 	}
 
-	defer func() {
-		if err := syscall.Unmount("/sys", 0); err != nil {
-			t.Fatal("Cannot umount /sys")
-		}
-	}()
+	missing, _ := net.ParseMAC("02:00:00:00:21:87")
+	_, err = LinkByHardwareAddr(missing)
+	if _, ok := err.(LinkNotFoundError); !ok {
+		t.Fatalf("expected LinkNotFoundError, got %v", err)
+	}
+}
+
+func TestLinkAddDelTuntap(t *testing.T) {
+	minKernelRequired(t, 4, 18)
+	t.Cleanup(setUpNetlinkTest(t))
 
 	testLinkAddDel(t, &Tuntap{
 		LinkAttrs: LinkAttrs{Name: "foo"},
@@ -3274,18 +4290,9 @@ func TestLinkAddDelTuntap(t *testing.T) {
 }
 
 func TestLinkAddDelTuntapMq(t *testing.T) {
+	minKernelRequired(t, 4, 18)
 	t.Cleanup(setUpNetlinkTest(t))
 
-	if err := syscall.Mount("sysfs", "/sys", "sysfs", syscall.MS_RDONLY, ""); err != nil {
-		t.Fatal("Cannot mount sysfs")
-	}
-
-	defer func() {
-		if err := syscall.Unmount("/sys", 0); err != nil {
-			t.Fatal("Cannot umount /sys")
-		}
-	}()
-
 	testLinkAddDel(t, &Tuntap{
 		LinkAttrs: LinkAttrs{Name: "foo"},
 		Mode:      TUNTAP_MODE_TAP,
@@ -3306,18 +4313,9 @@ func TestLinkAddDelTuntapMq(t *testing.T) {
 }
 
 func TestTuntapPartialQueues(t *testing.T) {
+	minKernelRequired(t, 4, 18)
 	t.Cleanup(setUpNetlinkTest(t))
 
-	if err := syscall.Mount("sysfs", "/sys", "sysfs", syscall.MS_RDONLY, ""); err != nil {
-		t.Fatal("Cannot mount sysfs")
-	}
-
-	defer func() {
-		if err := syscall.Unmount("/sys", 0); err != nil {
-			t.Fatal("Cannot umount /sys")
-		}
-	}()
-
 	compare := func(expected *Tuntap) {
 		result, err := LinkByName(expected.Name)
 		if err != nil {
@@ -3363,24 +4361,23 @@ func TestTuntapPartialQueues(t *testing.T) {
 }
 
 func TestLinkAddDelTuntapOwnerGroup(t *testing.T) {
+	minKernelRequired(t, 4, 18)
 	t.Cleanup(setUpNetlinkTest(t))
 
-	if err := syscall.Mount("sysfs", "/sys", "sysfs", syscall.MS_RDONLY, ""); err != nil {
-		t.Fatal("Cannot mount sysfs")
-	}
-
-	defer func() {
-		if err := syscall.Unmount("/sys", 0); err != nil {
-			t.Fatal("Cannot umount /sys")
-		}
-	}()
-
 	testLinkAddDel(t, &Tuntap{
 		LinkAttrs: LinkAttrs{Name: "foo"},
 		Mode:      TUNTAP_MODE_TAP,
 		Owner:     0,
 		Group:     0,
 	})
+
+	// owned by someone else than the caller
+	testLinkAddDel(t, &Tuntap{
+		LinkAttrs: LinkAttrs{Name: "foo"},
+		Mode:      TUNTAP_MODE_TAP,
+		Owner:     1000,
+		Group:     1001,
+	})
 }
 
 func TestVethPeerIndex(t *testing.T) {
@@ -3431,6 +4428,62 @@ func TestVethPeerIndex(t *testing.T) {
 	if peerIndexTwo != linkOne.Attrs().Index {
 		t.Errorf("VethPeerIndex(%s) mismatch %d != %d", linkTwo.Attrs().Name, peerIndexTwo, linkOne.Attrs().Index)
 	}
+
+	vethOne := linkOne.(*Veth)
+	if vethOne.PeerIndex != linkTwo.Attrs().Index || vethOne.PeerNetNsID != nil {
+		t.Errorf("decoded peer of %s is %d in %v, expected %d", vethOne.Name, vethOne.PeerIndex, vethOne.PeerNetNsID, linkTwo.Attrs().Index)
+	}
+}
+
+func TestVethPeerNetNsID(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+
+	ns, err := netns.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ns.Close()
+	peerNs, err := netns.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer peerNs.Close()
+	if err := netns.Set(ns); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := LinkAdd(&Veth{LinkAttrs: LinkAttrs{Name: "foo"}, PeerName: "bar", PeerNamespace: NsFd(peerNs)}); err != nil {
+		t.Fatal(err)
+	}
+	link, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	peerHandle, err := NewHandleAt(peerNs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer peerHandle.Close()
+	peer, err := peerHandle.LinkByName("bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	veth := link.(*Veth)
+	if veth.PeerIndex != peer.Attrs().Index {
+		t.Errorf("PeerIndex %d, expected %d", veth.PeerIndex, peer.Attrs().Index)
+	}
+	if veth.PeerNetNsID == nil || int(*veth.PeerNetNsID) != veth.NetNsID {
+		t.Errorf("PeerNetNsID %v, expected %d", veth.PeerNetNsID, veth.NetNsID)
+	}
+
+	// the kernel omits IFLA_LINK when the peer in the other namespace
+	// has the same index
+	self := &Veth{LinkAttrs: LinkAttrs{Index: 7, NetNsID: 1}}
+	setVethPeer(self, false)
+	if self.PeerIndex != 7 || self.PeerNetNsID == nil || *self.PeerNetNsID != 1 {
+		t.Errorf("peer with the same index decoded as %d in %v", self.PeerIndex, self.PeerNetNsID)
+	}
 }
 
 func TestLinkSlaveBond(t *testing.T) {
@@ -3506,6 +4559,74 @@ func TestLinkSetBondSlaveQueueId(t *testing.T) {
 	}
 }
 
+func TestLinkSetBondSlavePrio(t *testing.T) {
+	minKernelRequired(t, 6, 0)
+
+	t.Cleanup(setUpNetlinkTest(t))
+
+	bond := NewLinkBond(LinkAttrs{Name: "foo"})
+	bond.Mode = BOND_MODE_ACTIVE_BACKUP
+	if err := LinkAdd(bond); err != nil {
+		t.Fatal(err)
+	}
+
+	slave := &Dummy{LinkAttrs{Name: "fooFoo"}}
+	if err := LinkAdd(slave); err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkSetBondSlave(slave, bond); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := LinkSetBondSlavePrio(slave, 10); err != nil {
+		t.Fatal(err)
+	}
+
+	link, err := LinkByName("fooFoo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bondSlave, ok := link.Attrs().Slave.(*BondSlave)
+	if !ok {
+		t.Fatalf("expected slave to be *BondSlave, got %T", link.Attrs().Slave)
+	}
+	if bondSlave.Prio != 10 {
+		t.Fatalf("expected bond slave prio 10, got %d", bondSlave.Prio)
+	}
+}
+
+func TestLinkVrfSlaveTable(t *testing.T) {
+	minKernelRequired(t, 4, 4)
+	t.Cleanup(setUpNetlinkTest(t))
+
+	vrf := &Vrf{LinkAttrs: LinkAttrs{Name: "vrf100"}, Table: 100}
+	if err := LinkAdd(vrf); err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkAdd(&Dummy{LinkAttrs{Name: "foo"}}); err != nil {
+		t.Fatal(err)
+	}
+	slave, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkSetMaster(slave, vrf); err != nil {
+		t.Fatal(err)
+	}
+
+	slave, err = LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	vrfSlave, ok := slave.Attrs().Slave.(*VrfSlave)
+	if !ok {
+		t.Fatalf("expected slave to be *VrfSlave, got %T", slave.Attrs().Slave)
+	}
+	if vrfSlave.Table != 100 {
+		t.Fatalf("expected VrfSlave.Table 100, got %d", vrfSlave.Table)
+	}
+}
+
 func TestLinkSetBondSlave(t *testing.T) {
 	minKernelRequired(t, 3, 13)
 
@@ -3731,6 +4852,82 @@ func TestLinkSetAllmulticast(t *testing.T) {
 	}
 }
 
+func TestLinkSetPromisc(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+
+	iface := &Veth{LinkAttrs: LinkAttrs{Name: "foo"}, PeerName: "bar"}
+	if err := LinkAdd(iface); err != nil {
+		t.Fatal(err)
+	}
+
+	link, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := LinkSetPromiscOn(link); err != nil {
+		t.Fatal(err)
+	}
+	if link.Attrs().Promisc != 1 || link.Attrs().RawFlags&unix.IFF_PROMISC == 0 {
+		t.Fatalf("link attributes not updated after LinkSetPromiscOn: %+v", link.Attrs())
+	}
+
+	link, err = LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if link.Attrs().Promisc != 1 {
+		t.Fatal("IFF_PROMISC was not set")
+	}
+	if link.Attrs().RawFlags&unix.IFF_PROMISC == 0 {
+		t.Fatal("IFF_PROMISC missing from RawFlags")
+	}
+
+	if err := LinkSetPromiscOff(link); err != nil {
+		t.Fatal(err)
+	}
+	if link.Attrs().Promisc != 0 || link.Attrs().RawFlags&unix.IFF_PROMISC != 0 {
+		t.Fatalf("link attributes not updated after LinkSetPromiscOff: %+v", link.Attrs())
+	}
+
+	link, err = LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if link.Attrs().Promisc != 0 {
+		t.Fatal("IFF_PROMISC is still set")
+	}
+	if link.Attrs().RawFlags&unix.IFF_PROMISC != 0 {
+		t.Fatal("IFF_PROMISC still in RawFlags")
+	}
+}
+
+func TestLinkNetNsImmutable(t *testing.T) {
+	minKernelRequired(t, 6, 14)
+	t.Cleanup(setUpNetlinkTest(t))
+
+	lo, err := LinkByName("lo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !lo.Attrs().NetNsImmutable {
+		t.Fatal("lo is not reported as netns immutable")
+	}
+
+	if err := LinkAdd(&Veth{LinkAttrs: LinkAttrs{Name: "foo"}, PeerName: "bar"}); err != nil {
+		t.Fatal(err)
+	}
+	link, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.Attrs().NetNsImmutable {
+		t.Fatal("veth is reported as netns immutable")
+	}
+}
+
 func TestLinkSetMulticast(t *testing.T) {
 	t.Cleanup(setUpNetlinkTest(t))
 
@@ -3849,3 +5046,142 @@ func TestLinkSetMacvlanMode(t *testing.T) {
 	testMacvlanMode(macvtap, MACVLAN_MODE_SOURCE)
 	testMacvlanMode(macvtap, MACVLAN_MODE_BRIDGE)
 }
+
+func TestValidateHardwareAddr(t *testing.T) {
+	mac := net.HardwareAddr{0x00, 0x12, 0x34, 0x56, 0x78, 0xab}
+	eui64 := net.HardwareAddr{0x00, 0x12, 0x34, 0xff, 0xfe, 0x56, 0x78, 0xab}
+	ib := make(net.HardwareAddr, 20)
+	for _, tc := range []struct {
+		name   string
+		base   LinkAttrs
+		hwaddr net.HardwareAddr
+		valid  bool
+	}{
+		{"mac on ether", LinkAttrs{HardwareAddr: mac, EncapType: "ether"}, mac, true},
+		{"eui64 on ether", LinkAttrs{HardwareAddr: mac, EncapType: "ether"}, eui64, false},
+		{"mac on infiniband", LinkAttrs{HardwareAddr: ib, EncapType: "infiniband"}, mac, false},
+		{"infiniband on infiniband", LinkAttrs{HardwareAddr: ib, EncapType: "infiniband"}, ib, true},
+		{"mac on unread ether", LinkAttrs{EncapType: "ether"}, mac, true},
+		{"eui64 on unread ether", LinkAttrs{EncapType: "ether"}, eui64, false},
+		{"mac on unread infiniband", LinkAttrs{EncapType: "infiniband"}, mac, false},
+		{"eui64 on 8 byte device", LinkAttrs{HardwareAddr: eui64, EncapType: "ieee802.15.4"}, eui64, true},
+		{"mac on 8 byte device", LinkAttrs{HardwareAddr: eui64, EncapType: "ieee802.15.4"}, mac, false},
+		{"anything on unknown device", LinkAttrs{}, eui64, true},
+	} {
+		err := validateHardwareAddr(&tc.base, tc.hwaddr)
+		if tc.valid && err != nil {
+			t.Errorf("%s: unexpected error %v", tc.name, err)
+		}
+		if !tc.valid && !errors.Is(err, ErrBadHardwareAddrLen) {
+			t.Errorf("%s: error is %v, should be ErrBadHardwareAddrLen", tc.name, err)
+		}
+	}
+}
+
+func TestLinkSetHardwareAddrLen(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+
+	if err := LinkAdd(&Veth{LinkAttrs: LinkAttrs{Name: "foo"}, PeerName: "bar"}); err != nil {
+		t.Fatal(err)
+	}
+	link, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	eui64 := net.HardwareAddr{0x02, 0x12, 0x34, 0xff, 0xfe, 0x56, 0x78, 0xab}
+	err = LinkSetHardwareAddr(link, eui64)
+	if !errors.Is(err, ErrBadHardwareAddrLen) || !strings.Contains(err.Error(), "expects 6") {
+		t.Fatalf("LinkSetHardwareAddr returned %v, should be ErrBadHardwareAddrLen expecting 6", err)
+	}
+
+	// the kernel takes the first addr_len bytes of a longer address
+	if err := LinkSetHardwareAddrWithOptions(link, eui64, &LinkSetHardwareAddrOptions{Force: true}); err != nil {
+		t.Fatal(err)
+	}
+	link, err = LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(link.Attrs().HardwareAddr, eui64[:6]) {
+		t.Fatalf("hardware address is %s, should be %s", link.Attrs().HardwareAddr, eui64[:6])
+	}
+}
+
+func TestLinkListContinueOnDecodeError(t *testing.T) {
+	linkMsg := func(index int32, name string) []byte {
+		msg := nl.NewIfInfomsg(unix.AF_UNSPEC)
+		msg.Index = index
+		return append(msg.Serialize(), nl.NewRtAttr(unix.IFLA_IFNAME, nl.ZeroTerminated(name)).Serialize()...)
+	}
+	// An attribute claiming to be longer than the message.
+	corrupt := nl.NewIfInfomsg(unix.AF_UNSPEC).Serialize()
+	corrupt = append(corrupt, 0xff, 0x00, byte(unix.IFLA_IFNAME), 0x00)
+	msgs := [][]byte{linkMsg(1, "good0"), corrupt, linkMsg(2, "good1")}
+
+	h := &Handle{}
+	if _, _, err := h.linkListDeserialize(msgs); err == nil {
+		t.Fatal("expected the corrupt message to fail the dump")
+	}
+
+	h.ContinueOnDecodeError()
+	links, decodeErrs, err := h.linkListDeserialize(msgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(links) != 2 || links[0].Attrs().Name != "good0" || links[1].Attrs().Name != "good1" {
+		t.Fatalf("expected the two good links, got %v", links)
+	}
+	if decodeErrs == nil || len(decodeErrs.Errors) != 1 {
+		t.Fatalf("expected one decode error, got %v", decodeErrs)
+	}
+	if !bytes.Equal(decodeErrs.Errors[0].Raw, corrupt) {
+		t.Fatal("the decode error does not carry the raw message")
+	}
+
+	err = withDecodeErrors(ErrDumpInterrupted, decodeErrs)
+	if !errors.Is(err, ErrDumpInterrupted) {
+		t.Fatalf("expected ErrDumpInterrupted to be kept, got %v", err)
+	}
+	var multi *MultiDecodeError
+	if !errors.As(err, &multi) || multi != decodeErrs {
+		t.Fatalf("expected the MultiDecodeError, got %v", err)
+	}
+}
+
+func TestParseVfInfoVlanProto(t *testing.T) {
+	if nl.NativeEndian() != binary.LittleEndian {
+		t.Skip("fixture captured on a little endian host")
+	}
+	// IFLA_VF_INFO of an 802.1ad VF, vlan 100 qos 3, from an x86_64 dump.
+	data := []byte{
+		0x28, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x11, 0x22, 0x33, 0x44, 0x55, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x10, 0x00, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x64, 0x00, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00, 0x18, 0x00, 0x0c, 0x00, 0x14, 0x00, 0x01, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x64, 0x00, 0x00, 0x00, 0x03, 0x00, 0x00, 0x00, 0x88, 0xa8, 0x00, 0x00,
+	}
+	attrs, err := nl.ParseRouteAttr(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vf, err := parseVfInfo(attrs, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vf.Vlan != 100 || vf.Qos != 3 {
+		t.Fatalf("vlan %d qos %d, expected 100 and 3", vf.Vlan, vf.Qos)
+	}
+	if VlanProtocol(vf.VlanProto) != VLAN_PROTOCOL_8021AD {
+		t.Fatalf("vlan protocol %#x, expected 802.1ad", vf.VlanProto)
+	}
+
+	// The setter encodes the protocol the way the kernel reports it.
+	info := nl.VfVlanInfo{
+		VfVlan:    nl.VfVlan{Vf: 0, Vlan: 100, Qos: 3},
+		VlanProto: uint16(VLAN_PROTOCOL_8021AD),
+	}
+	if !bytes.Equal(info.Serialize(), data[len(data)-nl.SizeofVfVlanInfo:]) {
+		t.Fatalf("serialized vlan info % x does not match the dump", info.Serialize())
+	}
+}
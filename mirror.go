@@ -0,0 +1,39 @@
+package netlink
+
+import "fmt"
+
+// Direction selects which side of a link traffic is intercepted on.
+type Direction uint8
+
+const (
+	DIRECTION_INGRESS Direction = iota
+	DIRECTION_EGRESS
+)
+
+func (d Direction) String() string {
+	switch d {
+	case DIRECTION_INGRESS:
+		return "ingress"
+	case DIRECTION_EGRESS:
+		return "egress"
+	}
+	return fmt.Sprintf("unknown(%d)", uint8(d))
+}
+
+// TrafficMirror represents traffic of one link being mirrored to another
+// one. It is returned by TrafficMirrorAdd and owns the tc objects that
+// were created for it.
+type TrafficMirror struct {
+	SrcIndex  int
+	DstIndex  int
+	Direction Direction
+	Filter    *MatchAll
+
+	handle        *Handle
+	createdClsact bool
+}
+
+func (m *TrafficMirror) String() string {
+	return fmt.Sprintf("{SrcIndex: %d, DstIndex: %d, Direction: %s, Priority: %d}",
+		m.SrcIndex, m.DstIndex, m.Direction, m.Filter.Priority)
+}
@@ -0,0 +1,150 @@
+package netlink
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// trafficMirrorPriority is the first tc priority used for mirror filters.
+// It is high enough to stay out of the way of filters installed by hand.
+const trafficMirrorPriority = 0xc000
+
+// TrafficMirrorAdd mirrors all traffic seen on src in the given direction
+// to the egress of dst. A clsact qdisc is attached to src if needed, and a
+// matchall filter with a mirred action is installed on the first free
+// priority at or above trafficMirrorPriority.
+// Equivalent to:
+//
+//	tc qdisc add dev $src clsact
+//	tc filter add dev $src $direction matchall action mirred egress mirror dev $dst
+func TrafficMirrorAdd(src, dst Link, direction Direction) (*TrafficMirror, error) {
+	return pkgHandle.TrafficMirrorAdd(src, dst, direction)
+}
+
+// TrafficMirrorAdd mirrors all traffic seen on src in the given direction
+// to the egress of dst. A clsact qdisc is attached to src if needed, and a
+// matchall filter with a mirred action is installed on the first free
+// priority at or above trafficMirrorPriority.
+// Equivalent to:
+//
+//	tc qdisc add dev $src clsact
+//	tc filter add dev $src $direction matchall action mirred egress mirror dev $dst
+func (h *Handle) TrafficMirrorAdd(src, dst Link, direction Direction) (*TrafficMirror, error) {
+	var parent uint32
+	switch direction {
+	case DIRECTION_INGRESS:
+		parent = HANDLE_MIN_INGRESS
+	case DIRECTION_EGRESS:
+		parent = HANDLE_MIN_EGRESS
+	default:
+		return nil, fmt.Errorf("invalid traffic mirror direction %s", direction)
+	}
+
+	srcBase := src.Attrs()
+	h.ensureIndex(srcBase)
+	dstBase := dst.Attrs()
+	h.ensureIndex(dstBase)
+
+	qdiscs, err := h.QdiscList(src)
+	if err != nil && !errors.Is(err, ErrDumpInterrupted) {
+		return nil, err
+	}
+	hasClsact := false
+	for _, q := range qdiscs {
+		if _, ok := q.(*Clsact); ok {
+			hasClsact = true
+			break
+		}
+	}
+	mirror := &TrafficMirror{
+		SrcIndex:  srcBase.Index,
+		DstIndex:  dstBase.Index,
+		Direction: direction,
+		handle:    h,
+	}
+	if !hasClsact {
+		clsact := &Clsact{
+			QdiscAttrs: QdiscAttrs{
+				LinkIndex: srcBase.Index,
+				Handle:    MakeHandle(0xffff, 0),
+				Parent:    HANDLE_CLSACT,
+			},
+		}
+		if err := h.QdiscAdd(clsact); err != nil {
+			return nil, err
+		}
+		mirror.createdClsact = true
+	}
+
+	filters, err := h.FilterList(src, parent)
+	if err != nil && !errors.Is(err, ErrDumpInterrupted) {
+		mirror.cleanupClsact()
+		return nil, err
+	}
+	used := make(map[uint16]bool, len(filters))
+	for _, f := range filters {
+		used[f.Attrs().Priority] = true
+	}
+	prio := uint16(trafficMirrorPriority)
+	for used[prio] {
+		prio++
+	}
+
+	mirred := NewMirredAction(dstBase.Index)
+	mirred.Action = TC_ACT_PIPE
+	mirred.MirredAction = TCA_EGRESS_MIRROR
+	mirror.Filter = &MatchAll{
+		FilterAttrs: FilterAttrs{
+			LinkIndex: srcBase.Index,
+			Parent:    parent,
+			Handle:    1,
+			Priority:  prio,
+			Protocol:  unix.ETH_P_ALL,
+		},
+		Actions: []Action{mirred},
+	}
+	if err := h.FilterAdd(mirror.Filter); err != nil {
+		mirror.cleanupClsact()
+		return nil, err
+	}
+	return mirror, nil
+}
+
+// Remove tears down the filter installed by TrafficMirrorAdd. The clsact
+// qdisc is deleted as well if it was created for this mirror and no other
+// filters are left on it.
+func (m *TrafficMirror) Remove() error {
+	if err := m.handle.FilterDel(m.Filter); err != nil {
+		return err
+	}
+	if !m.createdClsact {
+		return nil
+	}
+	link := &Device{LinkAttrs{Index: m.SrcIndex}}
+	for _, parent := range []uint32{HANDLE_MIN_INGRESS, HANDLE_MIN_EGRESS} {
+		filters, err := m.handle.FilterList(link, parent)
+		if err != nil && !errors.Is(err, ErrDumpInterrupted) {
+			return err
+		}
+		if len(filters) > 0 {
+			return nil
+		}
+	}
+	return m.cleanupClsact()
+}
+
+func (m *TrafficMirror) cleanupClsact() error {
+	if !m.createdClsact {
+		return nil
+	}
+	m.createdClsact = false
+	return m.handle.QdiscDel(&Clsact{
+		QdiscAttrs: QdiscAttrs{
+			LinkIndex: m.SrcIndex,
+			Handle:    MakeHandle(0xffff, 0),
+			Parent:    HANDLE_CLSACT,
+		},
+	})
+}
@@ -0,0 +1,81 @@
+//go:build linux
+// +build linux
+
+package netlink
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrafficMirrorAddRemove(t *testing.T) {
+	minKernelRequired(t, 4, 7)
+	t.Cleanup(setUpNetlinkTest(t))
+
+	// v0/v1 generate traffic once up (ICMPv6 DAD and router
+	// solicitations), which is mirrored from v0 to the m0/m1 pair.
+	links := map[string]Link{}
+	for _, pair := range [][2]string{{"v0", "v1"}, {"m0", "m1"}} {
+		if err := LinkAdd(&Veth{LinkAttrs: LinkAttrs{Name: pair[0]}, PeerName: pair[1]}); err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range pair {
+			link, err := LinkByName(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			links[name] = link
+		}
+	}
+
+	mirror, err := TrafficMirrorAdd(links["v0"], links["m0"], DIRECTION_EGRESS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filters, err := FilterList(links["v0"], HANDLE_MIN_EGRESS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filters) != 1 {
+		t.Fatalf("expected 1 mirror filter, got %d", len(filters))
+	}
+	if _, ok := filters[0].(*MatchAll); !ok {
+		t.Fatalf("expected matchall filter, got %s", filters[0].Type())
+	}
+
+	for _, name := range []string{"m1", "m0", "v1", "v0"} {
+		if err := LinkSetUp(links[name]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(2 * time.Second)
+
+	v0, err := LinkByName("v0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m1, err := LinkByName("m1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v0.Attrs().Statistics.TxPackets == 0 {
+		t.Fatal("no traffic was sent on v0")
+	}
+	if m1.Attrs().Statistics.RxPackets < v0.Attrs().Statistics.TxPackets {
+		t.Fatalf("mirrored traffic missing: v0 sent %d packets, m1 received %d",
+			v0.Attrs().Statistics.TxPackets, m1.Attrs().Statistics.RxPackets)
+	}
+
+	if err := mirror.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	qdiscs, err := SafeQdiscList(links["v0"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, q := range qdiscs {
+		if _, ok := q.(*Clsact); ok {
+			t.Fatal("clsact qdisc was not removed")
+		}
+	}
+}
@@ -0,0 +1,354 @@
+package netlink
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/vishvananda/netlink/nl"
+	"github.com/vishvananda/netns"
+	"golang.org/x/sys/unix"
+)
+
+// MonitorPolicy selects what a Monitor does when a consumer is not
+// keeping up with its updates.
+type MonitorPolicy int
+
+const (
+	// MONITOR_BLOCK waits until the consumer accepts the update. A slow
+	// consumer stalls every other consumer of the same Monitor.
+	MONITOR_BLOCK MonitorPolicy = iota
+	// MONITOR_DROP discards the update if the consumer's channel is full.
+	MONITOR_DROP
+)
+
+func (p MonitorPolicy) String() string {
+	switch p {
+	case MONITOR_BLOCK:
+		return "block"
+	case MONITOR_DROP:
+		return "drop"
+	}
+	return fmt.Sprintf("MonitorPolicy(%d)", int(p))
+}
+
+// MonitorOptions configures the socket used by a Monitor.
+type MonitorOptions struct {
+	Namespace     *netns.NsHandle
+	ErrorCallback func(error)
+
+	// max size is based on value of /proc/sys/net/core/rmem_max
+	ReceiveBufferSize      int
+	ReceiveBufferForceSize bool
+	ReceiveTimeout         *unix.Timeval
+}
+
+// ErrMonitorStarted is returned when a consumer is registered on a
+// Monitor that has already been started.
+var ErrMonitorStarted = errors.New("monitor already started")
+
+type monitorLinkConsumer struct {
+	ch     chan<- LinkUpdate
+	policy MonitorPolicy
+}
+
+type monitorAddrConsumer struct {
+	ch     chan<- AddrUpdate
+	policy MonitorPolicy
+}
+
+type monitorRouteConsumer struct {
+	ch     chan<- RouteUpdate
+	policy MonitorPolicy
+}
+
+type monitorNeighConsumer struct {
+	ch     chan<- NeighUpdate
+	policy MonitorPolicy
+}
+
+// Monitor multiplexes link, address, route and neighbor notifications
+// over a single NETLINK_ROUTE socket. Consumers are registered with the
+// Subscribe* methods before Start is called; the socket only joins the
+// multicast groups that have at least one consumer. All consumer channels
+// are closed once the Monitor stops.
+type Monitor struct {
+	options MonitorOptions
+
+	mu      sync.Mutex
+	started bool
+	links   []monitorLinkConsumer
+	addrs   []monitorAddrConsumer
+	routes  []monitorRouteConsumer
+	neighs  []monitorNeighConsumer
+
+	dropped uint64
+}
+
+// NewMonitor returns a Monitor that has not been started yet.
+func NewMonitor(options MonitorOptions) *Monitor {
+	return &Monitor{options: options}
+}
+
+// SubscribeLinks registers ch to receive link updates.
+func (m *Monitor) SubscribeLinks(ch chan<- LinkUpdate, policy MonitorPolicy) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.started {
+		return ErrMonitorStarted
+	}
+	m.links = append(m.links, monitorLinkConsumer{ch: ch, policy: policy})
+	return nil
+}
+
+// SubscribeAddrs registers ch to receive IPv4 and IPv6 address updates.
+func (m *Monitor) SubscribeAddrs(ch chan<- AddrUpdate, policy MonitorPolicy) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.started {
+		return ErrMonitorStarted
+	}
+	m.addrs = append(m.addrs, monitorAddrConsumer{ch: ch, policy: policy})
+	return nil
+}
+
+// SubscribeRoutes registers ch to receive IPv4 and IPv6 route updates.
+func (m *Monitor) SubscribeRoutes(ch chan<- RouteUpdate, policy MonitorPolicy) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.started {
+		return ErrMonitorStarted
+	}
+	m.routes = append(m.routes, monitorRouteConsumer{ch: ch, policy: policy})
+	return nil
+}
+
+// SubscribeNeighs registers ch to receive neighbor updates.
+func (m *Monitor) SubscribeNeighs(ch chan<- NeighUpdate, policy MonitorPolicy) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.started {
+		return ErrMonitorStarted
+	}
+	m.neighs = append(m.neighs, monitorNeighConsumer{ch: ch, policy: policy})
+	return nil
+}
+
+// Dropped returns the number of updates discarded for MONITOR_DROP
+// consumers whose channel was full.
+func (m *Monitor) Dropped() uint64 {
+	return atomic.LoadUint64(&m.dropped)
+}
+
+// Start opens the socket and starts delivering updates. Close the 'done'
+// chan to stop the Monitor.
+func (m *Monitor) Start(done <-chan struct{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.started {
+		return ErrMonitorStarted
+	}
+
+	var groups []uint
+	if len(m.links) > 0 {
+		groups = append(groups, unix.RTNLGRP_LINK)
+	}
+	if len(m.addrs) > 0 {
+		groups = append(groups, unix.RTNLGRP_IPV4_IFADDR, unix.RTNLGRP_IPV6_IFADDR)
+	}
+	if len(m.routes) > 0 {
+		groups = append(groups, unix.RTNLGRP_IPV4_ROUTE, unix.RTNLGRP_IPV6_ROUTE)
+	}
+	if len(m.neighs) > 0 {
+		groups = append(groups, unix.RTNLGRP_NEIGH)
+	}
+	if len(groups) == 0 {
+		return fmt.Errorf("monitor has no consumers")
+	}
+
+	newNs := netns.None()
+	if m.options.Namespace != nil {
+		newNs = *m.options.Namespace
+	}
+	s, err := nl.SubscribeAt(newNs, netns.None(), unix.NETLINK_ROUTE, groups...)
+	if err != nil {
+		return err
+	}
+	if m.options.ReceiveTimeout != nil {
+		if err := s.SetReceiveTimeout(m.options.ReceiveTimeout); err != nil {
+			s.Close()
+			return err
+		}
+	}
+	if m.options.ReceiveBufferSize != 0 {
+		if err := s.SetReceiveBufferSize(m.options.ReceiveBufferSize, m.options.ReceiveBufferForceSize); err != nil {
+			s.Close()
+			return err
+		}
+	}
+	m.started = true
+
+	if done != nil {
+		go func() {
+			<-done
+			s.Close()
+		}()
+	}
+	go m.run(s)
+	return nil
+}
+
+func (m *Monitor) run(s *nl.NetlinkSocket) {
+	defer m.closeConsumers()
+	cberr := m.options.ErrorCallback
+	for {
+		msgs, from, err := s.Receive()
+		if err != nil {
+			if cberr != nil {
+				cberr(fmt.Errorf("Receive failed: %w", err))
+			}
+			return
+		}
+		if from.Pid != nl.PidKernel {
+			if cberr != nil {
+				cberr(fmt.Errorf("Wrong sender portid %d, expected %d", from.Pid, nl.PidKernel))
+			}
+			continue
+		}
+		for _, msg := range msgs {
+			if err := m.dispatch(msg); err != nil && cberr != nil {
+				cberr(err)
+			}
+		}
+	}
+}
+
+func (m *Monitor) dispatch(msg syscall.NetlinkMessage) error {
+	switch msg.Header.Type {
+	case unix.NLMSG_DONE:
+		return nil
+	case unix.NLMSG_ERROR:
+		nError := int32(native.Uint32(msg.Data[0:4]))
+		if nError == 0 {
+			return nil
+		}
+		return fmt.Errorf("error message: %w", syscall.Errno(-nError))
+	case unix.RTM_NEWLINK, unix.RTM_DELLINK:
+		ifmsg := nl.DeserializeIfInfomsg(msg.Data)
+		header := unix.NlMsghdr(msg.Header)
+		link, err := LinkDeserialize(&header, msg.Data)
+		if err != nil {
+			return err
+		}
+		update := LinkUpdate{IfInfomsg: *ifmsg, Header: header, Link: link}
+		for _, c := range m.links {
+			if c.policy == MONITOR_DROP {
+				select {
+				case c.ch <- update:
+				default:
+					atomic.AddUint64(&m.dropped, 1)
+				}
+				continue
+			}
+			c.ch <- update
+		}
+	case unix.RTM_NEWADDR, unix.RTM_DELADDR:
+		addr, _, err := parseAddr(msg.Data)
+		if err != nil {
+			return fmt.Errorf("could not parse address: %w", err)
+		}
+		update := AddrUpdate{LinkAddress: *addr.IPNet,
+			LinkIndex:   addr.LinkIndex,
+			NewAddr:     msg.Header.Type == unix.RTM_NEWADDR,
+			Flags:       addr.Flags,
+			Scope:       addr.Scope,
+			PreferedLft: addr.PreferedLft,
+			ValidLft:    addr.ValidLft,
+			Deprecated:  addr.Flags&unix.IFA_F_DEPRECATED != 0}
+		for _, c := range m.addrs {
+			if c.policy == MONITOR_DROP {
+				select {
+				case c.ch <- update:
+				default:
+					atomic.AddUint64(&m.dropped, 1)
+				}
+				continue
+			}
+			c.ch <- update
+		}
+	case unix.RTM_NEWROUTE, unix.RTM_DELROUTE:
+		route, err := deserializeRoute(msg.Data, false)
+		if err != nil {
+			return err
+		}
+		update := RouteUpdate{
+			Type:    msg.Header.Type,
+			NlFlags: msg.Header.Flags & (unix.NLM_F_REPLACE | unix.NLM_F_EXCL | unix.NLM_F_CREATE | unix.NLM_F_APPEND),
+			Route:   route,
+		}
+		for _, c := range m.routes {
+			if c.policy == MONITOR_DROP {
+				select {
+				case c.ch <- update:
+				default:
+					atomic.AddUint64(&m.dropped, 1)
+				}
+				continue
+			}
+			c.ch <- update
+		}
+	case unix.RTM_NEWNEIGH, unix.RTM_DELNEIGH:
+		neigh, err := NeighDeserialize(msg.Data)
+		if err != nil {
+			return err
+		}
+		update := NeighUpdate{Type: msg.Header.Type, Neigh: *neigh}
+		for _, c := range m.neighs {
+			if c.policy == MONITOR_DROP {
+				select {
+				case c.ch <- update:
+				default:
+					atomic.AddUint64(&m.dropped, 1)
+				}
+				continue
+			}
+			c.ch <- update
+		}
+	}
+	return nil
+}
+
+// closeConsumers closes every consumer channel once, a channel may have
+// been subscribed more than once.
+func (m *Monitor) closeConsumers() {
+	links := make(map[chan<- LinkUpdate]bool)
+	for _, c := range m.links {
+		if !links[c.ch] {
+			links[c.ch] = true
+			close(c.ch)
+		}
+	}
+	addrs := make(map[chan<- AddrUpdate]bool)
+	for _, c := range m.addrs {
+		if !addrs[c.ch] {
+			addrs[c.ch] = true
+			close(c.ch)
+		}
+	}
+	routes := make(map[chan<- RouteUpdate]bool)
+	for _, c := range m.routes {
+		if !routes[c.ch] {
+			routes[c.ch] = true
+			close(c.ch)
+		}
+	}
+	neighs := make(map[chan<- NeighUpdate]bool)
+	for _, c := range m.neighs {
+		if !neighs[c.ch] {
+			neighs[c.ch] = true
+			close(c.ch)
+		}
+	}
+}
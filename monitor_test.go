@@ -0,0 +1,287 @@
+//go:build linux
+// +build linux
+
+package netlink
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMonitorFanOut(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+
+	m := NewMonitor(MonitorOptions{})
+	linkCh1 := make(chan LinkUpdate)
+	linkCh2 := make(chan LinkUpdate)
+	addrCh := make(chan AddrUpdate)
+	dropCh := make(chan RouteUpdate)
+	if err := m.SubscribeLinks(linkCh1, MONITOR_BLOCK); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SubscribeLinks(linkCh2, MONITOR_BLOCK); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SubscribeAddrs(addrCh, MONITOR_BLOCK); err != nil {
+		t.Fatal(err)
+	}
+	// Never read, so every route update must be dropped rather than
+	// stalling the other consumers.
+	if err := m.SubscribeRoutes(dropCh, MONITOR_DROP); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	if err := m.Start(done); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SubscribeNeighs(make(chan NeighUpdate), MONITOR_BLOCK); err != ErrMonitorStarted {
+		t.Fatalf("expected ErrMonitorStarted, got %v", err)
+	}
+
+	link, err := LinkByName("lo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkSetUp(link); err != nil {
+		t.Fatal(err)
+	}
+	if !expectLinkUpdate(linkCh1, "lo", true) {
+		t.Fatal("first link consumer did not receive update")
+	}
+	if !expectLinkUpdate(linkCh2, "lo", true) {
+		t.Fatal("second link consumer did not receive update")
+	}
+
+	ip := net.IPv4(127, 1, 1, 1)
+	addr := &Addr{IPNet: &net.IPNet{IP: ip, Mask: net.CIDRMask(32, 32)}}
+	if err := AddrAdd(link, addr); err != nil {
+		t.Fatal(err)
+	}
+	if !expectAddrUpdate(addrCh, true, ip) {
+		t.Fatal("addr consumer did not receive update")
+	}
+	deadline := time.Now().Add(time.Minute)
+	for m.Dropped() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected route updates to be dropped")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	close(done)
+	for _, ch := range []<-chan LinkUpdate{linkCh1, linkCh2} {
+		select {
+		case <-drainLinkUpdates(ch):
+		case <-time.After(time.Minute):
+			t.Fatal("link channel not closed after done")
+		}
+	}
+}
+
+func TestMonitorSameChannelTwice(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+
+	m := NewMonitor(MonitorOptions{})
+	ch := make(chan LinkUpdate, 16)
+	for i := 0; i < 2; i++ {
+		if err := m.SubscribeLinks(ch, MONITOR_DROP); err != nil {
+			t.Fatal(err)
+		}
+	}
+	done := make(chan struct{})
+	if err := m.Start(done); err != nil {
+		t.Fatal(err)
+	}
+	close(done)
+	select {
+	case <-drainLinkUpdates(ch):
+	case <-time.After(time.Minute):
+		t.Fatal("link channel not closed after done")
+	}
+}
+
+func drainLinkUpdates(ch <-chan LinkUpdate) <-chan struct{} {
+	closed := make(chan struct{})
+	go func() {
+		for range ch {
+		}
+		close(closed)
+	}()
+	return closed
+}
+
+func countOpenFds(b *testing.B) int {
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		b.Fatal(err)
+	}
+	return len(entries)
+}
+
+// countContextSwitches sums the voluntary context switches of every thread
+// of the process, which approximates how often readers were woken up.
+func countContextSwitches(b *testing.B) int {
+	paths, err := filepath.Glob("/proc/self/task/*/status")
+	if err != nil {
+		b.Fatal(err)
+	}
+	total := 0
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if v, ok := strings.CutPrefix(line, "voluntary_ctxt_switches:"); ok {
+				n, _ := strconv.Atoi(strings.TrimSpace(v))
+				total += n
+			}
+		}
+	}
+	return total
+}
+
+func benchmarkSubscriptions(b *testing.B, subscribe func(done <-chan struct{}) (drained <-chan struct{})) {
+	b.Cleanup(setUpNetlinkTest(b))
+
+	lo, err := LinkByName("lo")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	fds := countOpenFds(b)
+	done := make(chan struct{})
+	drained := subscribe(done)
+	fds = countOpenFds(b) - fds
+
+	switches := countContextSwitches(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := LinkSetUp(lo); err != nil {
+			b.Fatal(err)
+		}
+		addr := &Addr{IPNet: &net.IPNet{IP: net.IPv4(127, 2, byte(i>>8), byte(i)), Mask: net.CIDRMask(32, 32)}}
+		if err := AddrAdd(lo, addr); err != nil {
+			b.Fatal(err)
+		}
+		if err := AddrDel(lo, addr); err != nil {
+			b.Fatal(err)
+		}
+		if err := LinkSetDown(lo); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.StopTimer()
+	switches = countContextSwitches(b) - switches
+
+	close(done)
+	<-drained
+	b.ReportMetric(float64(fds), "fds")
+	b.ReportMetric(float64(switches)/float64(b.N), "ctxsw/op")
+}
+
+func drainAll(chans ...func()) <-chan struct{} {
+	drained := make(chan struct{})
+	go func() {
+		finished := make(chan struct{}, len(chans))
+		for _, c := range chans {
+			go func(c func()) {
+				c()
+				finished <- struct{}{}
+			}(c)
+		}
+		for range chans {
+			<-finished
+		}
+		close(drained)
+	}()
+	return drained
+}
+
+func BenchmarkSubscribeSeparate(b *testing.B) {
+	benchmarkSubscriptions(b, func(done <-chan struct{}) <-chan struct{} {
+		linkCh := make(chan LinkUpdate, 64)
+		addrCh := make(chan AddrUpdate, 64)
+		routeCh := make(chan RouteUpdate, 64)
+		neighCh := make(chan NeighUpdate, 64)
+		if err := LinkSubscribe(linkCh, done); err != nil {
+			b.Fatal(err)
+		}
+		if err := AddrSubscribe(addrCh, done); err != nil {
+			b.Fatal(err)
+		}
+		if err := RouteSubscribe(routeCh, done); err != nil {
+			b.Fatal(err)
+		}
+		if err := NeighSubscribe(neighCh, done); err != nil {
+			b.Fatal(err)
+		}
+		return drainAll(
+			func() {
+				for range linkCh {
+				}
+			},
+			func() {
+				for range addrCh {
+				}
+			},
+			func() {
+				for range routeCh {
+				}
+			},
+			func() {
+				for range neighCh {
+				}
+			},
+		)
+	})
+}
+
+func BenchmarkSubscribeMonitor(b *testing.B) {
+	benchmarkSubscriptions(b, func(done <-chan struct{}) <-chan struct{} {
+		linkCh := make(chan LinkUpdate, 64)
+		addrCh := make(chan AddrUpdate, 64)
+		routeCh := make(chan RouteUpdate, 64)
+		neighCh := make(chan NeighUpdate, 64)
+		m := NewMonitor(MonitorOptions{})
+		if err := m.SubscribeLinks(linkCh, MONITOR_BLOCK); err != nil {
+			b.Fatal(err)
+		}
+		if err := m.SubscribeAddrs(addrCh, MONITOR_BLOCK); err != nil {
+			b.Fatal(err)
+		}
+		if err := m.SubscribeRoutes(routeCh, MONITOR_BLOCK); err != nil {
+			b.Fatal(err)
+		}
+		if err := m.SubscribeNeighs(neighCh, MONITOR_BLOCK); err != nil {
+			b.Fatal(err)
+		}
+		if err := m.Start(done); err != nil {
+			b.Fatal(err)
+		}
+		return drainAll(
+			func() {
+				for range linkCh {
+				}
+			},
+			func() {
+				for range addrCh {
+				}
+			},
+			func() {
+				for range routeCh {
+				}
+			},
+			func() {
+				for range neighCh {
+				}
+			},
+		)
+	})
+}
@@ -0,0 +1,86 @@
+package netlink
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// ErrMPLSUnavailable is returned by MPLSPlatformLabels and
+// SetMPLSPlatformLabels when the net.mpls.platform_labels sysctl can't be
+// found, because /proc is not mounted or the mpls_router module is not
+// loaded.
+var ErrMPLSUnavailable = errors.New("net.mpls.platform_labels is not available")
+
+// mplsPlatformLabelsPath is the sysctl holding the size of the MPLS label
+// table. The kernel has no netlink attribute for it.
+var mplsPlatformLabelsPath = "/proc/sys/net/mpls/platform_labels"
+
+// MPLSPlatformLabels returns the size of the MPLS label table of the
+// network namespace of the calling thread: only routes for labels below it
+// can be installed. It is 0, disabling MPLS forwarding, until set.
+// Equivalent to: `sysctl net.mpls.platform_labels`
+func MPLSPlatformLabels() (int, error) {
+	b, err := os.ReadFile(mplsPlatformLabelsPath)
+	if err != nil {
+		return 0, mplsSysctlError(err)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(string(b)))
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", mplsPlatformLabelsPath, err)
+	}
+	return n, nil
+}
+
+// SetMPLSPlatformLabels sets the size of the MPLS label table of the
+// network namespace of the calling thread to n. Shrinking the table
+// removes the routes of the labels above the new size.
+// Equivalent to: `sysctl -w net.mpls.platform_labels=$n`
+func SetMPLSPlatformLabels(n int) error {
+	if n < 0 {
+		return fmt.Errorf("invalid MPLS platform labels %d", n)
+	}
+	f, err := os.OpenFile(mplsPlatformLabelsPath, os.O_WRONLY, 0)
+	if err != nil {
+		return mplsSysctlError(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(strconv.Itoa(n)); err != nil {
+		return fmt.Errorf("failed to set %s: %w", mplsPlatformLabelsPath, err)
+	}
+	return nil
+}
+
+func mplsSysctlError(err error) error {
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w: %v", ErrMPLSUnavailable, err)
+	}
+	return err
+}
+
+// mplsRouteError explains err, returned by the kernel when installing a
+// route for the MPLS label dst, if the label is out of the label table.
+func mplsRouteError(dst int, err error) error {
+	if !errors.Is(err, unix.EINVAL) && !errors.Is(err, unix.ENETUNREACH) {
+		return err
+	}
+	limit, lerr := MPLSPlatformLabels()
+	if lerr != nil {
+		return err
+	}
+	return mplsLabelError(dst, limit, err)
+}
+
+func mplsLabelError(dst, limit int, err error) error {
+	if dst < limit {
+		return err
+	}
+	if limit == 0 {
+		return fmt.Errorf("%w: MPLS label %d can't be installed, net.mpls.platform_labels is 0, see SetMPLSPlatformLabels", err, dst)
+	}
+	return fmt.Errorf("%w: MPLS label %d is not below net.mpls.platform_labels (%d), see SetMPLSPlatformLabels", err, dst, limit)
+}
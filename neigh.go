@@ -3,6 +3,7 @@ package netlink
 import (
 	"fmt"
 	"net"
+	"time"
 )
 
 // Neigh represents a link layer neighbor from netlink.
@@ -27,6 +28,18 @@ type Neigh struct {
 	Confirmed uint32 // The last time ARP/ND succeeded OR higher layer confirmation was received
 	Used      uint32 // The last time ARP/ND took place for this neighbor
 	Updated   uint32 // The time when the current NUD state was entered
+
+	// CacheInfo is the NDA_CACHEINFO of the entry with the ages above
+	// converted to durations, nil if the kernel didn't report it.
+	CacheInfo *NeighCacheInfo
+}
+
+// NeighCacheInfo contains the ages and reference count of a neighbor entry.
+type NeighCacheInfo struct {
+	Confirmed time.Duration // since ARP/ND succeeded or a higher layer confirmed reachability
+	Used      time.Duration // since the entry was last used
+	Updated   time.Duration // since the current NUD state was entered
+	Refcnt    uint32
 }
 
 // String returns $ip/$hwaddr $label
@@ -4,7 +4,10 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"os"
+	"sync"
 	"syscall"
+	"time"
 	"unsafe"
 
 	"github.com/vishvananda/netlink/nl"
@@ -108,6 +111,36 @@ func (h *Handle) NeighSet(neigh *Neigh) error {
 	return h.neighAdd(neigh, unix.NLM_F_CREATE|unix.NLM_F_REPLACE)
 }
 
+// NeighSetBatch adds or replaces the entries like NeighSet, sending many
+// of them per write instead of waiting for each one to be acknowledged.
+// opts.Flags can turn individual entries into appends or exclusive adds.
+// results holds the error of each entry, nil on success; err is only set
+// when the batch itself failed.
+func NeighSetBatch(entries []*Neigh, opts BatchOptions) (results []error, err error) {
+	return pkgHandle.NeighSetBatch(entries, opts)
+}
+
+// NeighSetBatch adds or replaces the entries like NeighSet, sending many
+// of them per write instead of waiting for each one to be acknowledged.
+// opts.Flags can turn individual entries into appends or exclusive adds.
+// results holds the error of each entry, nil on success; err is only set
+// when the batch itself failed.
+func (h *Handle) NeighSetBatch(entries []*Neigh, opts BatchOptions) (results []error, err error) {
+	reqs := make([]*nl.NetlinkRequest, len(entries))
+	for i, neigh := range entries {
+		flags := unix.NLM_F_CREATE | unix.NLM_F_REPLACE
+		if i < len(opts.Flags) && opts.Flags[i] != 0 {
+			flags = opts.Flags[i]
+		}
+		reqs[i] = h.newNetlinkRequest(unix.RTM_NEWNEIGH, flags)
+		neighPrepare(neigh, reqs[i])
+	}
+	if opts.SingleAck {
+		return nl.ExecuteBatchSingleAck(unix.NETLINK_ROUTE, reqs)
+	}
+	return nl.ExecuteBatch(unix.NETLINK_ROUTE, reqs)
+}
+
 // NeighAppend will append an entry to FDB
 // Equivalent to: `bridge fdb append...`
 func NeighAppend(neigh *Neigh) error {
@@ -147,6 +180,12 @@ func (h *Handle) NeighDel(neigh *Neigh) error {
 }
 
 func neighHandle(neigh *Neigh, req *nl.NetlinkRequest) error {
+	neighPrepare(neigh, req)
+	_, err := req.Execute(unix.NETLINK_ROUTE, 0)
+	return err
+}
+
+func neighPrepare(neigh *Neigh, req *nl.NetlinkRequest) {
 	var family int
 
 	if neigh.Family > 0 {
@@ -199,9 +238,78 @@ func neighHandle(neigh *Neigh, req *nl.NetlinkRequest) error {
 		masterData := nl.NewRtAttr(NDA_MASTER, nl.Uint32Attr(uint32(neigh.MasterIndex)))
 		req.AddData(masterData)
 	}
+}
 
-	_, err := req.Execute(unix.NETLINK_ROUTE, 0)
-	return err
+// NeighFlush deletes the neighbor entries of link, or of all links if link
+// is nil, in family whose state is in stateMask and returns how many were
+// deleted. A stateMask of 0 selects every state but NUD_PERMANENT and
+// NUD_NOARP; permanent entries are only deleted when stateMask includes
+// NUD_PERMANENT. The deletes are batched, several per write, rather than
+// sent one round trip each.
+// Equivalent to: `ip neigh flush dev $link nud $state`.
+//
+// If the returned error is [ErrDumpInterrupted], some matching entries
+// may have been missed.
+func NeighFlush(link Link, family int, stateMask uint16) (int, error) {
+	return pkgHandle.NeighFlush(link, family, stateMask)
+}
+
+// NeighFlush deletes the neighbor entries of link, or of all links if link
+// is nil, in family whose state is in stateMask and returns how many were
+// deleted. A stateMask of 0 selects every state but NUD_PERMANENT and
+// NUD_NOARP; permanent entries are only deleted when stateMask includes
+// NUD_PERMANENT. The deletes are batched, several per write, rather than
+// sent one round trip each.
+// Equivalent to: `ip neigh flush dev $link nud $state`.
+//
+// If the returned error is [ErrDumpInterrupted], some matching entries
+// may have been missed.
+func (h *Handle) NeighFlush(link Link, family int, stateMask uint16) (int, error) {
+	linkIndex := 0
+	if link != nil {
+		base := link.Attrs()
+		h.ensureIndex(base)
+		linkIndex = base.Index
+	}
+	if stateMask == 0 {
+		stateMask = ^uint16(NUD_PERMANENT | NUD_NOARP)
+	}
+
+	neighs, executeErr := h.NeighList(linkIndex, family)
+	if executeErr != nil && !errors.Is(executeErr, ErrDumpInterrupted) {
+		return 0, executeErr
+	}
+
+	var reqs []*nl.NetlinkRequest
+	for _, neigh := range neighs {
+		if neigh.IP == nil || uint16(neigh.State)&stateMask == 0 {
+			continue
+		}
+		req := h.newNetlinkRequest(unix.RTM_DELNEIGH, unix.NLM_F_ACK)
+		neighPrepare(&Neigh{
+			LinkIndex: neigh.LinkIndex,
+			Family:    neigh.Family,
+			IP:        neigh.IP,
+		}, req)
+		reqs = append(reqs, req)
+	}
+
+	results, err := nl.ExecuteBatch(unix.NETLINK_ROUTE, reqs)
+	deleted := 0
+	for _, result := range results {
+		switch {
+		case result == nil:
+			deleted++
+		case errors.Is(result, unix.ENOENT):
+			// already gone, e.g. garbage collected since the dump
+		case err == nil:
+			err = result
+		}
+	}
+	if err != nil {
+		return deleted, err
+	}
+	return deleted, executeErr
 }
 
 // NeighList returns a list of IP-MAC mappings in the system (ARP table).
@@ -353,6 +461,14 @@ func NeighDeserialize(m []byte) (*Neigh, error) {
 			neigh.Confirmed = native.Uint32(attr.Value[0:4])
 			neigh.Used = native.Uint32(attr.Value[4:8])
 			neigh.Updated = native.Uint32(attr.Value[8:12])
+			neigh.CacheInfo = &NeighCacheInfo{
+				Confirmed: clockTicksToDuration(neigh.Confirmed),
+				Used:      clockTicksToDuration(neigh.Used),
+				Updated:   clockTicksToDuration(neigh.Updated),
+			}
+			if len(attr.Value) >= 16 {
+				neigh.CacheInfo.Refcnt = native.Uint32(attr.Value[12:16])
+			}
 		}
 	}
 
@@ -497,3 +613,38 @@ func neighSubscribeAt(newNs, curNs netns.NsHandle, ch chan<- NeighUpdate, done <
 
 	return nil
 }
+
+// userHZ returns the clock ticks per second (USER_HZ, sysconf(_SC_CLK_TCK))
+// the kernel uses for clock_t values, as found in the auxiliary vector.
+var userHZ = sync.OnceValue(func() uint64 {
+	if hz := auxvClockTicks(); hz > 0 {
+		return hz
+	}
+	return 100
+})
+
+// auxvClockTicks reads AT_CLKTCK from /proc/self/auxv, 0 if unavailable.
+func auxvClockTicks() uint64 {
+	const atClkTck = 17
+	auxv, err := os.ReadFile("/proc/self/auxv")
+	if err != nil {
+		return 0
+	}
+	size := int(unsafe.Sizeof(uintptr(0)))
+	for i := 0; i+2*size <= len(auxv); i += 2 * size {
+		var tag, val uint64
+		if size == 8 {
+			tag, val = native.Uint64(auxv[i:]), native.Uint64(auxv[i+size:])
+		} else {
+			tag, val = uint64(native.Uint32(auxv[i:])), uint64(native.Uint32(auxv[i+size:]))
+		}
+		if tag == atClkTck {
+			return val
+		}
+	}
+	return 0
+}
+
+func clockTicksToDuration(ticks uint32) time.Duration {
+	return time.Duration(uint64(ticks) * uint64(time.Second) / userHZ())
+}
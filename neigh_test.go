@@ -4,6 +4,7 @@
 package netlink
 
 import (
+	"errors"
 	"net"
 	"syscall"
 	"testing"
@@ -157,6 +158,18 @@ func TestNeighAddDel(t *testing.T) {
 		}
 	}
 
+	// Freshly added entries have near-zero ages
+	for _, n := range dump {
+		if n.CacheInfo == nil {
+			t.Fatalf("Neigh %v has no cache info", n.IP)
+		}
+		for _, age := range []time.Duration{n.CacheInfo.Confirmed, n.CacheInfo.Used, n.CacheInfo.Updated} {
+			if age < 0 || age > 10*time.Second {
+				t.Errorf("Neigh %v has unexpected ages: %+v", n.IP, *n.CacheInfo)
+			}
+		}
+	}
+
 	// Delete the arpTable
 	for _, entry := range arpTable {
 		err := NeighDel(&Neigh{
@@ -188,6 +201,179 @@ func TestNeighAddDel(t *testing.T) {
 	}
 }
 
+func TestNeighFlush(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+
+	veth := &Veth{LinkAttrs: LinkAttrs{Name: "neigh0"}, PeerName: "neigh1"}
+	if err := LinkAdd(veth); err != nil {
+		t.Fatal(err)
+	}
+	peer, err := LinkByName("neigh1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ensureIndex(veth.Attrs())
+
+	entries := []*Neigh{
+		{LinkIndex: veth.Index, State: NUD_STALE, IP: net.ParseIP("10.99.0.1"), HardwareAddr: parseMAC("aa:bb:cc:dd:00:01")},
+		{LinkIndex: veth.Index, State: NUD_STALE, IP: net.ParseIP("10.99.0.2"), HardwareAddr: parseMAC("aa:bb:cc:dd:00:02")},
+		{LinkIndex: veth.Index, State: NUD_STALE, IP: net.ParseIP("10.99.0.3"), HardwareAddr: parseMAC("aa:bb:cc:dd:00:03")},
+		{LinkIndex: veth.Index, State: NUD_PERMANENT, IP: net.ParseIP("10.99.0.4"), HardwareAddr: parseMAC("aa:bb:cc:dd:00:04")},
+		{LinkIndex: peer.Attrs().Index, State: NUD_STALE, IP: net.ParseIP("10.99.0.5"), HardwareAddr: parseMAC("aa:bb:cc:dd:00:05")},
+	}
+	for _, entry := range entries {
+		if err := NeighAdd(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// only the stale entries of neigh0 go
+	deleted, err := NeighFlush(veth, FAMILY_V4, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 3 {
+		t.Fatalf("expected 3 entries deleted, got %d", deleted)
+	}
+	neighs, err := NeighList(veth.Index, FAMILY_V4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(neighs) != 1 || neighs[0].State != NUD_PERMANENT {
+		t.Fatalf("expected only the permanent entry left, got %v", neighs)
+	}
+	neighs, err = NeighList(peer.Attrs().Index, FAMILY_V4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(neighs) != 1 {
+		t.Fatalf("expected the entry of neigh1 left, got %v", neighs)
+	}
+
+	// permanent entries go when asked for
+	deleted, err = NeighFlush(veth, FAMILY_V4, NUD_PERMANENT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 1 {
+		t.Fatalf("expected 1 entry deleted, got %d", deleted)
+	}
+	if deleted, err = NeighFlush(veth, FAMILY_V4, 0); err != nil || deleted != 0 {
+		t.Fatalf("expected nothing left to flush, got %d: %v", deleted, err)
+	}
+}
+
+func TestNeighSetBatch(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+
+	vxlan := &Vxlan{LinkAttrs: LinkAttrs{Name: "foo"}, VxlanId: 1}
+	if err := LinkAdd(vxlan); err != nil {
+		t.Fatal(err)
+	}
+	fdb := func(mac string, dst net.IP) *Neigh {
+		return &Neigh{
+			Family:       unix.AF_BRIDGE,
+			LinkIndex:    vxlan.Index,
+			State:        NUD_PERMANENT,
+			Flags:        NTF_SELF,
+			IP:           dst,
+			HardwareAddr: parseMAC(mac),
+		}
+	}
+	entries := []*Neigh{
+		fdb("00:00:00:00:00:00", net.IPv4(198, 51, 100, 1)),
+		fdb("00:00:00:00:00:00", net.IPv4(198, 51, 100, 2)),
+		fdb("aa:bb:cc:dd:00:01", net.IPv4(198, 51, 100, 3)),
+		fdb("aa:bb:cc:dd:00:01", net.IPv4(198, 51, 100, 4)),
+		{LinkIndex: 0x7fffffff, IP: net.IPv4(198, 51, 100, 5), HardwareAddr: parseMAC("aa:bb:cc:dd:00:05")},
+	}
+	appendFlags := unix.NLM_F_CREATE | unix.NLM_F_APPEND
+	flags := []int{appendFlags, appendFlags, 0, unix.NLM_F_CREATE | unix.NLM_F_EXCL}
+
+	for _, singleAck := range []bool{false, true} {
+		results, err := NeighSetBatch(entries, BatchOptions{SingleAck: singleAck, Flags: flags})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, result := range results[:3] {
+			if result != nil {
+				t.Fatalf("entry %d failed: %v", i, result)
+			}
+		}
+		if !errors.Is(results[3], unix.EEXIST) {
+			t.Fatalf("expected EEXIST for the exclusive add, got %v", results[3])
+		}
+		if !errors.Is(results[4], unix.ENODEV) {
+			t.Fatalf("expected ENODEV for the missing link, got %v", results[4])
+		}
+	}
+
+	neighs, err := NeighList(vxlan.Index, unix.AF_BRIDGE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dsts := make(map[string][]string)
+	for _, neigh := range neighs {
+		dsts[neigh.HardwareAddr.String()] = append(dsts[neigh.HardwareAddr.String()], neigh.IP.String())
+	}
+	if len(dsts["00:00:00:00:00:00"]) != 2 {
+		t.Fatalf("expected 2 appended destinations, got %v", dsts)
+	}
+	if got := dsts["aa:bb:cc:dd:00:01"]; len(got) != 1 || got[0] != "198.51.100.3" {
+		t.Fatalf("expected a single replaced destination, got %v", dsts)
+	}
+}
+
+func BenchmarkNeighSetBatch(b *testing.B) {
+	const n = 50000
+	b.Cleanup(setUpNetlinkTest(b))
+
+	vxlan := &Vxlan{LinkAttrs: LinkAttrs{Name: "foo"}, VxlanId: 1}
+	if err := LinkAdd(vxlan); err != nil {
+		b.Fatal(err)
+	}
+	entries := make([]*Neigh, n)
+	for i := range entries {
+		entries[i] = &Neigh{
+			Family:       unix.AF_BRIDGE,
+			LinkIndex:    vxlan.Index,
+			State:        NUD_PERMANENT,
+			Flags:        NTF_SELF,
+			IP:           net.IPv4(198, 51, byte(i>>8), byte(i)),
+			HardwareAddr: net.HardwareAddr{0xaa, 0xbb, 0xcc, 0, byte(i >> 8), byte(i)},
+		}
+	}
+
+	b.Run("Loop", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, neigh := range entries {
+				if err := NeighSet(neigh); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	for _, singleAck := range []bool{false, true} {
+		name := "Batch"
+		if singleAck {
+			name = "BatchSingleAck"
+		}
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				results, err := NeighSetBatch(entries, BatchOptions{SingleAck: singleAck})
+				if err != nil {
+					b.Fatal(err)
+				}
+				for _, result := range results {
+					if result != nil {
+						b.Fatal(result)
+					}
+				}
+			}
+		})
+	}
+}
+
 func TestNeighAddDelProxy(t *testing.T) {
 	t.Cleanup(setUpNetlinkTest(t))
 
@@ -266,7 +452,10 @@ func expectNeighUpdate(ch <-chan NeighUpdate, expected []NeighUpdate) bool {
 	for {
 		timeout := time.After(time.Second)
 		select {
-		case update := <-ch:
+		case update, ok := <-ch:
+			if !ok {
+				return false
+			}
 			var toDelete []int
 			for index, elem := range expected {
 				if update.Type == elem.Type &&
@@ -633,3 +822,16 @@ func TestNeighListExecuteStateFilter(t *testing.T) {
 		}
 	}
 }
+
+func TestClockTicksToDuration(t *testing.T) {
+	hz := userHZ()
+	if hz == 0 {
+		t.Fatal("USER_HZ must not be zero")
+	}
+	if d := clockTicksToDuration(uint32(hz)); d != time.Second {
+		t.Fatalf("expected %d ticks to be 1s, got %v", hz, d)
+	}
+	if d := clockTicksToDuration(uint32(hz / 2)); d != 500*time.Millisecond {
+		t.Fatalf("expected %d ticks to be 500ms, got %v", hz/2, d)
+	}
+}
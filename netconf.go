@@ -0,0 +1,74 @@
+package netlink
+
+import "fmt"
+
+// Pseudo device indexes of Netconf, for the "all" and "default" entries
+// of /proc/sys/net/ipv{4,6}/conf.
+const (
+	NETCONF_IFINDEX_ALL     = -1
+	NETCONF_IFINDEX_DEFAULT = -2
+)
+
+// RpFilterMode is the IPv4 reverse path filtering mode, see rp_filter in
+// ip-sysctl.rst.
+type RpFilterMode uint32
+
+const (
+	RP_FILTER_OFF RpFilterMode = iota
+	RP_FILTER_STRICT
+	RP_FILTER_LOOSE
+)
+
+func (m RpFilterMode) String() string {
+	switch m {
+	case RP_FILTER_OFF:
+		return "off"
+	case RP_FILTER_STRICT:
+		return "strict"
+	case RP_FILTER_LOOSE:
+		return "loose"
+	}
+	return fmt.Sprintf("unknown(%d)", uint32(m))
+}
+
+// Netconf holds the per family settings of a device, or of the "all" and
+// "default" pseudo devices, as reported in RTM_NEWNETCONF messages. A nil
+// field was not reported: notifications only carry the setting which
+// changed, and RpFilter only exists for IPv4.
+type Netconf struct {
+	Family                   int
+	Ifindex                  int
+	Forwarding               *bool
+	RpFilter                 *RpFilterMode
+	McForwarding             *bool
+	ProxyNeigh               *bool
+	IgnoreRoutesWithLinkdown *bool
+}
+
+func (n Netconf) String() string {
+	s := fmt.Sprintf("{Family: %d Ifindex: %d", n.Family, n.Ifindex)
+	for _, f := range []struct {
+		name  string
+		value *bool
+	}{
+		{"Forwarding", n.Forwarding},
+		{"McForwarding", n.McForwarding},
+		{"ProxyNeigh", n.ProxyNeigh},
+		{"IgnoreRoutesWithLinkdown", n.IgnoreRoutesWithLinkdown},
+	} {
+		if f.value != nil {
+			s += fmt.Sprintf(" %s: %t", f.name, *f.value)
+		}
+	}
+	if n.RpFilter != nil {
+		s += fmt.Sprintf(" RpFilter: %s", *n.RpFilter)
+	}
+	return s + "}"
+}
+
+// NetconfUpdate is sent when the settings of a device change - type is
+// RTM_NEWNETCONF or RTM_DELNETCONF.
+type NetconfUpdate struct {
+	Type uint16
+	Netconf
+}
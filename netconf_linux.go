@@ -0,0 +1,207 @@
+package netlink
+
+import (
+	"fmt"
+	"syscall"
+
+	"github.com/vishvananda/netlink/nl"
+	"github.com/vishvananda/netns"
+	"golang.org/x/sys/unix"
+)
+
+// NetconfGet returns the family settings of the device ifindex, which
+// may also be NETCONF_IFINDEX_ALL or NETCONF_IFINDEX_DEFAULT.
+// Equivalent to: `ip netconf show dev $ifindex`.
+func NetconfGet(family int, ifindex int) (*Netconf, error) {
+	return pkgHandle.NetconfGet(family, ifindex)
+}
+
+// NetconfGet returns the family settings of the device ifindex, which
+// may also be NETCONF_IFINDEX_ALL or NETCONF_IFINDEX_DEFAULT.
+// Equivalent to: `ip netconf show dev $ifindex`.
+func (h *Handle) NetconfGet(family int, ifindex int) (*Netconf, error) {
+	req := h.newNetlinkRequest(unix.RTM_GETNETCONF, 0)
+	msg := nl.NewRtGenMsg()
+	msg.Family = uint8(family)
+	req.AddData(msg)
+	req.AddData(nl.NewRtAttr(nl.NETCONFA_IFINDEX, nl.Uint32Attr(uint32(int32(ifindex)))))
+
+	msgs, err := req.Execute(unix.NETLINK_ROUTE, unix.RTM_NEWNETCONF)
+	if err != nil {
+		return nil, err
+	}
+	if len(msgs) != 1 {
+		return nil, fmt.Errorf("expected 1 netconf message, got %d", len(msgs))
+	}
+	return parseNetconf(msgs[0])
+}
+
+func parseNetconf(m []byte) (*Netconf, error) {
+	msg := nl.DeserializeRtGenMsg(m)
+	attrs, err := nl.ParseRouteAttr(m[msg.Len():])
+	if err != nil {
+		return nil, err
+	}
+	nc := &Netconf{Family: int(msg.Family)}
+	for _, attr := range attrs {
+		if attr.Attr.Type == nl.NETCONFA_IFINDEX {
+			nc.Ifindex = int(int32(native.Uint32(attr.Value[0:4])))
+			continue
+		}
+		if len(attr.Value) < 4 {
+			continue
+		}
+		value := native.Uint32(attr.Value[0:4])
+		enabled := value != 0
+		switch attr.Attr.Type {
+		case nl.NETCONFA_FORWARDING:
+			nc.Forwarding = &enabled
+		case nl.NETCONFA_RP_FILTER:
+			mode := RpFilterMode(value)
+			nc.RpFilter = &mode
+		case nl.NETCONFA_MC_FORWARDING:
+			nc.McForwarding = &enabled
+		case nl.NETCONFA_PROXY_NEIGH:
+			nc.ProxyNeigh = &enabled
+		case nl.NETCONFA_IGNORE_ROUTES_WITH_LINKDOWN:
+			nc.IgnoreRoutesWithLinkdown = &enabled
+		}
+	}
+	return nc, nil
+}
+
+// NetconfSubscribe takes a chan down which notifications will be sent
+// when the IPv4 or IPv6 settings of a device change, e.g. when something
+// flips forwarding. Close the 'done' chan to stop subscription.
+func NetconfSubscribe(ch chan<- NetconfUpdate, done <-chan struct{}) error {
+	return netconfSubscribeAt(netns.None(), netns.None(), ch, done, nil, false, 0, nil, false)
+}
+
+// NetconfSubscribeAt works like NetconfSubscribe plus it allows the caller
+// to choose the network namespace in which to subscribe (ns).
+func NetconfSubscribeAt(ns netns.NsHandle, ch chan<- NetconfUpdate, done <-chan struct{}) error {
+	return netconfSubscribeAt(ns, netns.None(), ch, done, nil, false, 0, nil, false)
+}
+
+// NetconfSubscribeOptions contains a set of options to use with
+// NetconfSubscribeWithOptions.
+type NetconfSubscribeOptions struct {
+	Namespace     *netns.NsHandle
+	ErrorCallback func(error)
+	// ListExisting sends the current IPv4 and IPv6 settings of every
+	// device before any notification.
+	ListExisting bool
+
+	// max size is based on value of /proc/sys/net/core/rmem_max
+	ReceiveBufferSize      int
+	ReceiveBufferForceSize bool
+	ReceiveTimeout         *unix.Timeval
+}
+
+// NetconfSubscribeWithOptions work like NetconfSubscribe but enable to
+// provide additional options to modify the behavior.
+//
+// When options.ListExisting is true, options.ErrorCallback may be
+// called with [ErrDumpInterrupted] to indicate that results from
+// the initial dump may be inconsistent or incomplete.
+func NetconfSubscribeWithOptions(ch chan<- NetconfUpdate, done <-chan struct{}, options NetconfSubscribeOptions) error {
+	if options.Namespace == nil {
+		none := netns.None()
+		options.Namespace = &none
+	}
+	return netconfSubscribeAt(*options.Namespace, netns.None(), ch, done, options.ErrorCallback, options.ListExisting,
+		options.ReceiveBufferSize, options.ReceiveTimeout, options.ReceiveBufferForceSize)
+}
+
+func netconfSubscribeAt(newNs, curNs netns.NsHandle, ch chan<- NetconfUpdate, done <-chan struct{}, cberr func(error), listExisting bool,
+	rcvbuf int, rcvTimeout *unix.Timeval, rcvbufForce bool) error {
+	s, err := nl.SubscribeAt(newNs, curNs, unix.NETLINK_ROUTE, unix.RTNLGRP_IPV4_NETCONF, unix.RTNLGRP_IPV6_NETCONF)
+	if err != nil {
+		return err
+	}
+	makeRequest := func(family int) error {
+		req := pkgHandle.newNetlinkRequest(unix.RTM_GETNETCONF, unix.NLM_F_DUMP)
+		msg := nl.NewRtGenMsg()
+		msg.Family = uint8(family)
+		req.AddData(msg)
+		return s.Send(req)
+	}
+	if rcvTimeout != nil {
+		if err := s.SetReceiveTimeout(rcvTimeout); err != nil {
+			return err
+		}
+	}
+	if rcvbuf != 0 {
+		err = s.SetReceiveBufferSize(rcvbuf, rcvbufForce)
+		if err != nil {
+			return err
+		}
+	}
+	if done != nil {
+		go func() {
+			<-done
+			s.Close()
+		}()
+	}
+	if listExisting {
+		if err := makeRequest(unix.AF_INET); err != nil {
+			return err
+		}
+		// The IPv6 dump is requested once the IPv4 one is done
+	}
+	go func() {
+		defer close(ch)
+		for {
+			msgs, from, err := s.Receive()
+			if err != nil {
+				if cberr != nil {
+					cberr(err)
+				}
+				return
+			}
+			if from.Pid != nl.PidKernel {
+				if cberr != nil {
+					cberr(fmt.Errorf("Wrong sender portid %d, expected %d", from.Pid, nl.PidKernel))
+				}
+				continue
+			}
+			for _, m := range msgs {
+				if m.Header.Flags&unix.NLM_F_DUMP_INTR != 0 && cberr != nil {
+					cberr(ErrDumpInterrupted)
+				}
+				if m.Header.Type == unix.NLMSG_DONE {
+					if listExisting {
+						if err := makeRequest(unix.AF_INET6); err != nil {
+							if cberr != nil {
+								cberr(err)
+							}
+							return
+						}
+						listExisting = false
+					}
+					continue
+				}
+				if m.Header.Type == unix.NLMSG_ERROR {
+					nError := int32(native.Uint32(m.Data[0:4]))
+					if nError == 0 {
+						continue
+					}
+					if cberr != nil {
+						cberr(syscall.Errno(-nError))
+					}
+					return
+				}
+				nc, err := parseNetconf(m.Data)
+				if err != nil {
+					if cberr != nil {
+						cberr(err)
+					}
+					return
+				}
+				ch <- NetconfUpdate{Type: m.Header.Type, Netconf: *nc}
+			}
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,115 @@
+//go:build linux
+// +build linux
+
+package netlink
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+func readSysctlBool(t *testing.T, path string) bool {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimSpace(string(b)) != "0"
+}
+
+func TestNetconfGet(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+
+	nc, err := NetconfGet(FAMILY_V4, NETCONF_IFINDEX_ALL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nc.Family != FAMILY_V4 || nc.Ifindex != NETCONF_IFINDEX_ALL {
+		t.Fatalf("unexpected netconf %v", nc)
+	}
+	if nc.Forwarding == nil || nc.RpFilter == nil || nc.McForwarding == nil ||
+		nc.ProxyNeigh == nil || nc.IgnoreRoutesWithLinkdown == nil {
+		t.Fatalf("missing IPv4 settings in %v", nc)
+	}
+	if *nc.Forwarding != readSysctlBool(t, "/proc/sys/net/ipv4/conf/all/forwarding") {
+		t.Fatalf("Forwarding %t does not match sysctl", *nc.Forwarding)
+	}
+	if *nc.RpFilter > RP_FILTER_LOOSE {
+		t.Fatalf("invalid rp_filter %v", *nc.RpFilter)
+	}
+
+	nc, err = NetconfGet(FAMILY_V6, NETCONF_IFINDEX_DEFAULT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nc.Family != FAMILY_V6 || nc.Ifindex != NETCONF_IFINDEX_DEFAULT || nc.Forwarding == nil {
+		t.Fatalf("unexpected netconf %v", nc)
+	}
+	if nc.RpFilter != nil {
+		t.Fatal("IPv6 has no rp_filter")
+	}
+	if *nc.Forwarding != readSysctlBool(t, "/proc/sys/net/ipv6/conf/default/forwarding") {
+		t.Fatalf("Forwarding %t does not match sysctl", *nc.Forwarding)
+	}
+
+	lo, err := LinkByName("lo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nc, err = NetconfGet(FAMILY_V4, lo.Attrs().Index); err != nil || nc.Ifindex != lo.Attrs().Index {
+		t.Fatalf("unexpected netconf %v for lo: %v", nc, err)
+	}
+	if _, err := NetconfGet(FAMILY_V4, 12345); err != unix.ENODEV {
+		t.Fatalf("expected ENODEV for a missing device, got %v", err)
+	}
+}
+
+func TestNetconfSubscribe(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+
+	ch := make(chan NetconfUpdate)
+	done := make(chan struct{})
+	defer close(done)
+	if err := NetconfSubscribeWithOptions(ch, done, NetconfSubscribeOptions{
+		ListExisting: true,
+		ErrorCallback: func(err error) {
+			t.Errorf("subscription error: %v", err)
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the initial dump covers both families
+	families := map[int]bool{}
+	timeout := time.After(time.Minute)
+	for !families[FAMILY_V4] || !families[FAMILY_V6] {
+		select {
+		case update := <-ch:
+			if update.Type != unix.RTM_NEWNETCONF {
+				t.Fatalf("unexpected update %v", update)
+			}
+			families[update.Family] = true
+		case <-timeout:
+			t.Fatal("initial netconf dump not received")
+		}
+	}
+
+	if err := os.WriteFile("/proc/sys/net/ipv4/conf/all/forwarding", []byte("1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		select {
+		case update := <-ch:
+			if update.Family == FAMILY_V4 && update.Ifindex == NETCONF_IFINDEX_ALL &&
+				update.Forwarding != nil && *update.Forwarding {
+				return
+			}
+		case <-timeout:
+			t.Fatal("forwarding change not notified")
+		}
+	}
+}
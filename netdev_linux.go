@@ -0,0 +1,224 @@
+package netlink
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/vishvananda/netlink/nl"
+)
+
+// NetdevDev contains the XDP and AF_XDP capabilities of a device as
+// reported by the netdev generic netlink family.
+type NetdevDev struct {
+	Ifindex               int
+	XDPFeatures           uint64 // nl.NETDEV_XDP_ACT_*
+	XDPZCMaxSegs          uint32 // max fragments of a zero-copy AF_XDP frame
+	XDPRxMetadataFeatures uint64 // nl.NETDEV_XDP_RX_METADATA_*
+	XSKFeatures           uint64 // nl.NETDEV_XSK_FLAGS_*
+}
+
+// XSKZeroCopy reports whether the device supports zero-copy AF_XDP sockets.
+func (d *NetdevDev) XSKZeroCopy() bool {
+	return d.XDPFeatures&nl.NETDEV_XDP_ACT_XSK_ZEROCOPY != 0
+}
+
+// NetdevNapi is a NAPI instance of a device.
+type NetdevNapi struct {
+	Ifindex int
+	ID      uint32
+	IRQ     uint32 // 0 if the instance has no irq
+	PID     uint32 // pid of the threaded NAPI kthread, 0 if not threaded
+}
+
+// NetdevQueue is a rx or tx queue of a device.
+type NetdevQueue struct {
+	Ifindex int
+	ID      uint32
+	Type    uint32 // nl.NETDEV_QUEUE_TYPE_*
+	NapiID  uint32 // 0 if the queue is not bound to a NAPI instance
+}
+
+type netdevNetlinkMessage []syscall.NetlinkRouteAttr
+
+// netdevUint decodes a variable length unsigned attribute, the kernel
+// sends 4 or 8 bytes depending on the value.
+func netdevUint(value []byte) uint64 {
+	if len(value) >= 8 {
+		return native.Uint64(value)
+	}
+	return uint64(native.Uint32(value))
+}
+
+func (d *NetdevDev) parseAttributes(attrs netdevNetlinkMessage) {
+	for _, a := range attrs {
+		switch a.Attr.Type {
+		case nl.NETDEV_A_DEV_IFINDEX:
+			d.Ifindex = int(native.Uint32(a.Value))
+		case nl.NETDEV_A_DEV_XDP_FEATURES:
+			d.XDPFeatures = netdevUint(a.Value)
+		case nl.NETDEV_A_DEV_XDP_ZC_MAX_SEGS:
+			d.XDPZCMaxSegs = native.Uint32(a.Value)
+		case nl.NETDEV_A_DEV_XDP_RX_METADATA_FEATURES:
+			d.XDPRxMetadataFeatures = netdevUint(a.Value)
+		case nl.NETDEV_A_DEV_XSK_FEATURES:
+			d.XSKFeatures = netdevUint(a.Value)
+		}
+	}
+}
+
+func (n *NetdevNapi) parseAttributes(attrs netdevNetlinkMessage) {
+	for _, a := range attrs {
+		switch a.Attr.Type {
+		case nl.NETDEV_A_NAPI_IFINDEX:
+			n.Ifindex = int(native.Uint32(a.Value))
+		case nl.NETDEV_A_NAPI_ID:
+			n.ID = native.Uint32(a.Value)
+		case nl.NETDEV_A_NAPI_IRQ:
+			n.IRQ = native.Uint32(a.Value)
+		case nl.NETDEV_A_NAPI_PID:
+			n.PID = native.Uint32(a.Value)
+		}
+	}
+}
+
+func (q *NetdevQueue) parseAttributes(attrs netdevNetlinkMessage) {
+	for _, a := range attrs {
+		switch a.Attr.Type {
+		case nl.NETDEV_A_QUEUE_ID:
+			q.ID = native.Uint32(a.Value)
+		case nl.NETDEV_A_QUEUE_IFINDEX:
+			q.Ifindex = int(native.Uint32(a.Value))
+		case nl.NETDEV_A_QUEUE_TYPE:
+			q.Type = native.Uint32(a.Value)
+		case nl.NETDEV_A_QUEUE_NAPI_ID:
+			q.NapiID = native.Uint32(a.Value)
+		}
+	}
+}
+
+func (h *Handle) netdevRequest(command uint8, extraFlags int, attrs []*nl.RtAttr) ([]netdevNetlinkMessage, error) {
+	f, err := h.GenlFamilyGet(nl.NETDEV_GENL_NAME)
+	if err != nil {
+		return nil, err
+	}
+	req := h.newNetlinkRequest(int(f.ID), unix.NLM_F_ACK|extraFlags)
+	req.AddData(&nl.Genlmsg{
+		Command: command,
+		Version: nl.NETDEV_GENL_VERSION,
+	})
+	for _, a := range attrs {
+		req.AddData(a)
+	}
+
+	resp, executeErr := req.Execute(unix.NETLINK_GENERIC, 0)
+	if executeErr != nil && !errors.Is(executeErr, ErrDumpInterrupted) {
+		return nil, executeErr
+	}
+	messages := make([]netdevNetlinkMessage, 0, len(resp))
+	for _, m := range resp {
+		attrs, err := nl.ParseRouteAttr(m[nl.SizeofGenlmsg:])
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, attrs)
+	}
+	return messages, executeErr
+}
+
+// netdevLinkAttrs returns the ifindex filter of a netdev request, the dump
+// covers every device if link is nil.
+func (h *Handle) netdevLinkAttrs(link Link, attrType int) []*nl.RtAttr {
+	if link == nil {
+		return nil
+	}
+	base := link.Attrs()
+	h.ensureIndex(base)
+	return []*nl.RtAttr{nl.NewRtAttr(attrType, nl.Uint32Attr(uint32(base.Index)))}
+}
+
+// NetdevGetDev returns the XDP and AF_XDP capabilities of link.
+// Equivalent to: `ynl --family netdev --do dev-get --json '{"ifindex": IFINDEX}'`
+func NetdevGetDev(link Link) (*NetdevDev, error) {
+	return pkgHandle.NetdevGetDev(link)
+}
+
+// NetdevGetDev returns the XDP and AF_XDP capabilities of link.
+// Equivalent to: `ynl --family netdev --do dev-get --json '{"ifindex": IFINDEX}'`
+func (h *Handle) NetdevGetDev(link Link) (*NetdevDev, error) {
+	if link == nil {
+		return nil, fmt.Errorf("link is required")
+	}
+	messages, err := h.netdevRequest(nl.NETDEV_CMD_DEV_GET, 0, h.netdevLinkAttrs(link, nl.NETDEV_A_DEV_IFINDEX))
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("device not found")
+	}
+	d := &NetdevDev{}
+	d.parseAttributes(messages[0])
+	return d, nil
+}
+
+// NetdevNapiList returns the NAPI instances of link, or of all devices if
+// link is nil.
+// Equivalent to: `ynl --family netdev --dump napi-get`
+//
+// If the returned error is [ErrDumpInterrupted], results may be inconsistent
+// or incomplete.
+func NetdevNapiList(link Link) ([]*NetdevNapi, error) {
+	return pkgHandle.NetdevNapiList(link)
+}
+
+// NetdevNapiList returns the NAPI instances of link, or of all devices if
+// link is nil.
+// Equivalent to: `ynl --family netdev --dump napi-get`
+//
+// If the returned error is [ErrDumpInterrupted], results may be inconsistent
+// or incomplete.
+func (h *Handle) NetdevNapiList(link Link) ([]*NetdevNapi, error) {
+	messages, executeErr := h.netdevRequest(nl.NETDEV_CMD_NAPI_GET, unix.NLM_F_DUMP, h.netdevLinkAttrs(link, nl.NETDEV_A_NAPI_IFINDEX))
+	if executeErr != nil && !errors.Is(executeErr, ErrDumpInterrupted) {
+		return nil, executeErr
+	}
+	napis := make([]*NetdevNapi, 0, len(messages))
+	for _, m := range messages {
+		n := &NetdevNapi{}
+		n.parseAttributes(m)
+		napis = append(napis, n)
+	}
+	return napis, executeErr
+}
+
+// NetdevQueueList returns the rx and tx queues of link, or of all devices
+// if link is nil.
+// Equivalent to: `ynl --family netdev --dump queue-get`
+//
+// If the returned error is [ErrDumpInterrupted], results may be inconsistent
+// or incomplete.
+func NetdevQueueList(link Link) ([]*NetdevQueue, error) {
+	return pkgHandle.NetdevQueueList(link)
+}
+
+// NetdevQueueList returns the rx and tx queues of link, or of all devices
+// if link is nil.
+// Equivalent to: `ynl --family netdev --dump queue-get`
+//
+// If the returned error is [ErrDumpInterrupted], results may be inconsistent
+// or incomplete.
+func (h *Handle) NetdevQueueList(link Link) ([]*NetdevQueue, error) {
+	messages, executeErr := h.netdevRequest(nl.NETDEV_CMD_QUEUE_GET, unix.NLM_F_DUMP, h.netdevLinkAttrs(link, nl.NETDEV_A_QUEUE_IFINDEX))
+	if executeErr != nil && !errors.Is(executeErr, ErrDumpInterrupted) {
+		return nil, executeErr
+	}
+	queues := make([]*NetdevQueue, 0, len(messages))
+	for _, m := range messages {
+		q := &NetdevQueue{}
+		q.parseAttributes(m)
+		queues = append(queues, q)
+	}
+	return queues, executeErr
+}
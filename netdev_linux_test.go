@@ -0,0 +1,111 @@
+package netlink
+
+import (
+	"testing"
+
+	"github.com/vishvananda/netlink/nl"
+)
+
+func netdevTestMessage(t *testing.T, attrs ...*nl.RtAttr) netdevNetlinkMessage {
+	t.Helper()
+	var b []byte
+	for _, a := range attrs {
+		b = append(b, a.Serialize()...)
+	}
+	msg, err := nl.ParseRouteAttr(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return netdevNetlinkMessage(msg)
+}
+
+func TestNetdevDevParseAttributes(t *testing.T) {
+	features := uint64(nl.NETDEV_XDP_ACT_BASIC | nl.NETDEV_XDP_ACT_REDIRECT | nl.NETDEV_XDP_ACT_XSK_ZEROCOPY)
+	msg := netdevTestMessage(t,
+		nl.NewRtAttr(nl.NETDEV_A_DEV_IFINDEX, nl.Uint32Attr(7)),
+		nl.NewRtAttr(nl.NETDEV_A_DEV_XDP_FEATURES, nl.Uint64Attr(features)),
+		nl.NewRtAttr(nl.NETDEV_A_DEV_XDP_ZC_MAX_SEGS, nl.Uint32Attr(1)),
+		// variable length attributes may be sent as 4 bytes
+		nl.NewRtAttr(nl.NETDEV_A_DEV_XDP_RX_METADATA_FEATURES, nl.Uint32Attr(nl.NETDEV_XDP_RX_METADATA_HASH)),
+		nl.NewRtAttr(nl.NETDEV_A_DEV_XSK_FEATURES, nl.Uint64Attr(nl.NETDEV_XSK_FLAGS_TX_CHECKSUM)),
+	)
+	d := &NetdevDev{}
+	d.parseAttributes(msg)
+	expected := NetdevDev{
+		Ifindex:               7,
+		XDPFeatures:           features,
+		XDPZCMaxSegs:          1,
+		XDPRxMetadataFeatures: nl.NETDEV_XDP_RX_METADATA_HASH,
+		XSKFeatures:           nl.NETDEV_XSK_FLAGS_TX_CHECKSUM,
+	}
+	if *d != expected {
+		t.Fatalf("expected %+v, got %+v", expected, *d)
+	}
+	if !d.XSKZeroCopy() {
+		t.Fatal("expected zero-copy support")
+	}
+}
+
+func TestNetdevNapiQueueParseAttributes(t *testing.T) {
+	n := &NetdevNapi{}
+	n.parseAttributes(netdevTestMessage(t,
+		nl.NewRtAttr(nl.NETDEV_A_NAPI_IFINDEX, nl.Uint32Attr(3)),
+		nl.NewRtAttr(nl.NETDEV_A_NAPI_ID, nl.Uint32Attr(8193)),
+		nl.NewRtAttr(nl.NETDEV_A_NAPI_IRQ, nl.Uint32Attr(42)),
+	))
+	if expected := (NetdevNapi{Ifindex: 3, ID: 8193, IRQ: 42}); *n != expected {
+		t.Fatalf("expected %+v, got %+v", expected, *n)
+	}
+
+	q := &NetdevQueue{}
+	q.parseAttributes(netdevTestMessage(t,
+		nl.NewRtAttr(nl.NETDEV_A_QUEUE_ID, nl.Uint32Attr(1)),
+		nl.NewRtAttr(nl.NETDEV_A_QUEUE_IFINDEX, nl.Uint32Attr(3)),
+		nl.NewRtAttr(nl.NETDEV_A_QUEUE_TYPE, nl.Uint32Attr(nl.NETDEV_QUEUE_TYPE_TX)),
+		nl.NewRtAttr(nl.NETDEV_A_QUEUE_NAPI_ID, nl.Uint32Attr(8193)),
+	))
+	if expected := (NetdevQueue{Ifindex: 3, ID: 1, Type: nl.NETDEV_QUEUE_TYPE_TX, NapiID: 8193}); *q != expected {
+		t.Fatalf("expected %+v, got %+v", expected, *q)
+	}
+}
+
+func TestNetdevGetDev(t *testing.T) {
+	minKernelRequired(t, 6, 3)
+	t.Cleanup(setUpNetlinkTest(t))
+	if _, err := GenlFamilyGet(nl.NETDEV_GENL_NAME); err != nil {
+		t.Skipf("netdev genetlink family not available: %v", err)
+	}
+
+	lo, err := LinkByName("lo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dev, err := NetdevGetDev(lo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dev.Ifindex != lo.Attrs().Index {
+		t.Fatalf("expected ifindex %d, got %d", lo.Attrs().Index, dev.Ifindex)
+	}
+	// loopback has no driver XDP support, only the generic features
+	if dev.XSKZeroCopy() {
+		t.Fatal("loopback should not support zero-copy AF_XDP")
+	}
+
+	if _, err := NetdevGetDev(&Dummy{LinkAttrs{Index: 0x7fff}}); err == nil {
+		t.Fatal("expected an error for a missing device")
+	}
+
+	queues, err := NetdevQueueList(lo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, q := range queues {
+		if q.Ifindex != lo.Attrs().Index {
+			t.Fatalf("unexpected queue of ifindex %d: %+v", q.Ifindex, q)
+		}
+	}
+	if _, err := NetdevNapiList(lo); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -6,6 +6,15 @@
 // be run as root. The low level primitives for netlink are contained
 // in the nl subpackage. This package attempts to provide a high-level
 // interface that is loosly modeled on the iproute2 cli.
+//
+// # Subscriptions
+//
+// AddrSubscribe, LinkSubscribe, NeighSubscribe, NetconfSubscribe,
+// PrefixSubscribe and RouteSubscribe, along with their At and WithOptions
+// variants, close the channel passed to them once the subscription stops,
+// because 'done' was closed or receiving from the socket failed. The
+// updates already read from the socket are delivered first, in the order
+// the kernel sent them, so ranging over the channel sees all of them.
 package netlink
 
 import (
@@ -16,8 +25,28 @@ import (
 var (
 	// ErrNotImplemented is returned when a requested feature is not implemented.
 	ErrNotImplemented = errors.New("not implemented")
+	// ErrNamespaceGone is returned by Handle.CheckNamespace when the
+	// network namespace of the handle was deleted.
+	ErrNamespaceGone = errors.New("network namespace of the handle is gone")
+	// ErrNamespaceUnchecked is returned by Handle.CheckNamespace when the
+	// network namespace of the handle could not be found but some entries
+	// of /proc, those of another user for instance, could not be read.
+	ErrNamespaceUnchecked = errors.New("network namespace of the handle could not be checked")
 )
 
+// BatchOptions controls how a batch of requests is sent to the kernel.
+type BatchOptions struct {
+	// SingleAck only asks the kernel to acknowledge the last request of
+	// each write instead of every request. Failed requests are still
+	// reported individually.
+	SingleAck bool
+	// Flags holds the NLM_F_* creation flags of each entry of the batch,
+	// e.g. NLM_F_CREATE|NLM_F_APPEND to add another destination to a
+	// FDB entry. A missing or zero entry takes the default of the batch
+	// operation.
+	Flags []int
+}
+
 // ParseIPNet parses a string in ip/net format and returns a net.IPNet.
 // This is valuable because addresses in netlink are often IPNets and
 // ParseCIDR returns an IPNet with the IP part set to the base IP of the
@@ -1,6 +1,13 @@
 package netlink
 
-import "github.com/vishvananda/netlink/nl"
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/vishvananda/netlink/nl"
+)
 
 // Family type definitions
 const (
@@ -12,3 +19,80 @@ const (
 
 // ErrDumpInterrupted is an alias for [nl.ErrDumpInterrupted].
 var ErrDumpInterrupted = nl.ErrDumpInterrupted
+
+// DecodeError describes a message of a dump that could not be decoded.
+type DecodeError struct {
+	Err error
+	// Raw is a copy of the message payload, without the netlink header.
+	Raw []byte
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("decoding %d byte message: %v", len(e.Raw), e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// MultiDecodeError is returned, along with the entries that could be
+// decoded, by the list functions of a handle configured with
+// ContinueOnDecodeError when some messages of the dump were skipped.
+type MultiDecodeError struct {
+	Errors []*DecodeError
+}
+
+func (e *MultiDecodeError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d messages could not be decoded: %s", len(e.Errors), strings.Join(msgs, "; "))
+}
+
+func (e *MultiDecodeError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+// decodeFailed records the message m that failed to decode with err and
+// reports whether the dump can go on, which is the case when the handle
+// was configured with ContinueOnDecodeError.
+func (h *Handle) decodeFailed(errs **MultiDecodeError, m []byte, err error) bool {
+	if !h.options.skipBadMsgs {
+		return false
+	}
+	if *errs == nil {
+		*errs = &MultiDecodeError{}
+	}
+	(*errs).Errors = append((*errs).Errors, &DecodeError{Err: err, Raw: append([]byte(nil), m...)})
+	return true
+}
+
+// withDecodeErrors joins the error of a dump with the decode errors
+// collected by decodeFailed, if any.
+func withDecodeErrors(err error, errs *MultiDecodeError) error {
+	if errs == nil {
+		return err
+	}
+	if err == nil {
+		return errs
+	}
+	return errors.Join(err, errs)
+}
+
+// addExtraAttrs adds the attributes retained in extras to req, ordered
+// by type.
+func addExtraAttrs(req *nl.NetlinkRequest, extras map[uint16][]byte) {
+	types := make([]int, 0, len(extras))
+	for t := range extras {
+		types = append(types, int(t))
+	}
+	sort.Ints(types)
+	for _, t := range types {
+		req.AddData(nl.NewRtAttr(t, extras[uint16(t)]))
+	}
+}
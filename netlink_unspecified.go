@@ -33,6 +33,10 @@ func LinkSetName(link Link, name string) error {
 	return ErrNotImplemented
 }
 
+func LinkSetMap(link Link, m *LinkMap) error {
+	return ErrNotImplemented
+}
+
 func LinkSetAlias(link Link, name string) error {
 	return ErrNotImplemented
 }
@@ -81,6 +85,14 @@ func LinkSetXdpFdWithFlags(link Link, fd, flags int) error {
 	return ErrNotImplemented
 }
 
+func LinkSetPromiscOn(link Link) error {
+	return ErrNotImplemented
+}
+
+func LinkSetPromiscOff(link Link) error {
+	return ErrNotImplemented
+}
+
 func LinkSetARPOff(link Link) error {
 	return ErrNotImplemented
 }
@@ -97,6 +109,10 @@ func LinkByAlias(alias string) (Link, error) {
 	return nil, ErrNotImplemented
 }
 
+func LinkByHardwareAddr(addr net.HardwareAddr) (Link, error) {
+	return nil, ErrNotImplemented
+}
+
 func LinkByIndex(index int) (Link, error) {
 	return nil, ErrNotImplemented
 }
@@ -109,6 +125,18 @@ func LinkSetGuard(link Link, mode bool) error {
 	return ErrNotImplemented
 }
 
+func LinkSetBrPortBackup(link Link, backup Link) error {
+	return ErrNotImplemented
+}
+
+func LinkClearBrPortBackup(link Link) error {
+	return ErrNotImplemented
+}
+
+func LinkSetBrPortMcastRouter(link Link, mode int) error {
+	return ErrNotImplemented
+}
+
 func LinkSetFastLeave(link Link, mode bool) error {
 	return ErrNotImplemented
 }
@@ -137,6 +165,14 @@ func LinkSetGROMaxSize(link Link, maxSize int) error {
 	return ErrNotImplemented
 }
 
+func VethSetGSOMaxSize(link *Veth, maxSize int, both bool) error {
+	return ErrNotImplemented
+}
+
+func VethSetGROMaxSize(link *Veth, maxSize int, both bool) error {
+	return ErrNotImplemented
+}
+
 func LinkSetGSOIPv4MaxSize(link Link, maxSize int) error {
 	return ErrNotImplemented
 }
@@ -157,6 +193,18 @@ func LinkDel(link Link) error {
 	return ErrNotImplemented
 }
 
+func LinkDelIdempotent(link Link) error {
+	return ErrNotImplemented
+}
+
+func LinkDelByIndex(index int) error {
+	return ErrNotImplemented
+}
+
+func LinkDelByName(name string) error {
+	return ErrNotImplemented
+}
+
 func SetHairpin(link Link, mode bool) error {
 	return ErrNotImplemented
 }
@@ -197,6 +245,10 @@ func AddrDel(link Link, addr *Addr) error {
 	return ErrNotImplemented
 }
 
+func AddrFlush(link Link, family int, opts *AddrFlushOptions) (int, error) {
+	return 0, ErrNotImplemented
+}
+
 func AddrList(link Link, family int) ([]Addr, error) {
 	return nil, ErrNotImplemented
 }
@@ -205,6 +257,14 @@ func RouteAdd(route *Route) error {
 	return ErrNotImplemented
 }
 
+func MPLSPlatformLabels() (int, error) {
+	return 0, ErrNotImplemented
+}
+
+func SetMPLSPlatformLabels(n int) error {
+	return ErrNotImplemented
+}
+
 func RouteAppend(route *Route) error {
 	return ErrNotImplemented
 }
@@ -233,6 +293,30 @@ func RouteReplace(route *Route) error {
 	return ErrNotImplemented
 }
 
+func NexthopAdd(nh *Nexthop) error {
+	return ErrNotImplemented
+}
+
+func NexthopReplace(nh *Nexthop) error {
+	return ErrNotImplemented
+}
+
+func NexthopDel(nh *Nexthop) error {
+	return ErrNotImplemented
+}
+
+func NexthopGet(id uint32) (*Nexthop, error) {
+	return nil, ErrNotImplemented
+}
+
+func NexthopList() ([]Nexthop, error) {
+	return nil, ErrNotImplemented
+}
+
+func NexthopBucketList(groupID uint32) ([]NexthopBucket, error) {
+	return nil, ErrNotImplemented
+}
+
 func XfrmPolicyAdd(policy *XfrmPolicy) error {
 	return ErrNotImplemented
 }
@@ -269,6 +353,10 @@ func NeighSet(neigh *Neigh) error {
 	return ErrNotImplemented
 }
 
+func NeighSetBatch(entries []*Neigh, opts BatchOptions) ([]error, error) {
+	return nil, ErrNotImplemented
+}
+
 func NeighAppend(neigh *Neigh) error {
 	return ErrNotImplemented
 }
@@ -277,10 +365,22 @@ func NeighDel(neigh *Neigh) error {
 	return ErrNotImplemented
 }
 
+func NeighFlush(link Link, family int, stateMask uint16) (int, error) {
+	return 0, ErrNotImplemented
+}
+
 func NeighList(linkIndex, family int) ([]Neigh, error) {
 	return nil, ErrNotImplemented
 }
 
+func NetconfGet(family int, ifindex int) (*Netconf, error) {
+	return nil, ErrNotImplemented
+}
+
 func NeighDeserialize(m []byte) (*Neigh, error) {
 	return nil, ErrNotImplemented
 }
+
+func (action *MirredAction) Validate(h *Handle) error {
+	return ErrNotImplemented
+}
@@ -0,0 +1,87 @@
+package netlink
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// Nexthop represents a nexthop object, either a single nexthop or a
+// group of nexthops which routes can refer to by ID.
+type Nexthop struct {
+	ID        uint32
+	Family    int
+	Protocol  RouteProtocol
+	Flags     int
+	LinkIndex int
+	Gw        net.IP
+	Blackhole bool
+	FDB       bool
+	// Group lists the members of a nexthop group. A group has no link or
+	// gateway of its own.
+	Group []NexthopGroupMember
+	// Resilient makes Group a resilient group, which maps flows to a
+	// fixed number of buckets so that membership changes only move the
+	// flows of the buckets that have to change hands. It is nil for a
+	// plain hash-threshold group.
+	Resilient *NexthopResilient
+}
+
+// NexthopGroupMember is a member of a nexthop group.
+type NexthopGroupMember struct {
+	ID uint32
+	// Weight is between 1 and 256, 0 is taken as 1.
+	Weight int
+}
+
+// NexthopResilient holds the parameters of a resilient nexthop group.
+type NexthopResilient struct {
+	// Buckets is the number of hash buckets, it can't be changed once
+	// the group is created.
+	Buckets uint16
+	// IdleTimer is how long a bucket must be idle before it may be
+	// migrated to another nexthop, 0 leaves the kernel default of 120s.
+	IdleTimer time.Duration
+	// UnbalancedTimer bounds how long the group may stay unbalanced
+	// before busy buckets are migrated too, 0 disables it.
+	UnbalancedTimer time.Duration
+	// UnbalancedTime is how long the group has been unbalanced. It is
+	// only reported by the kernel.
+	UnbalancedTime time.Duration
+}
+
+// NexthopBucket is a hash bucket of a resilient nexthop group.
+type NexthopBucket struct {
+	GroupID   uint32
+	Index     uint16
+	IdleTime  time.Duration
+	NexthopID uint32
+}
+
+func (nh Nexthop) String() string {
+	elems := []string{fmt.Sprintf("Id: %d", nh.ID)}
+	if len(nh.Group) > 0 {
+		members := make([]string, len(nh.Group))
+		for i, m := range nh.Group {
+			members[i] = fmt.Sprintf("%d,%d", m.ID, m.Weight)
+		}
+		elems = append(elems, fmt.Sprintf("Group: %s", strings.Join(members, "/")))
+		if nh.Resilient != nil {
+			elems = append(elems, fmt.Sprintf("Buckets: %d", nh.Resilient.Buckets))
+		}
+	}
+	if nh.Blackhole {
+		elems = append(elems, "Blackhole")
+	}
+	if nh.Gw != nil {
+		elems = append(elems, fmt.Sprintf("Gw: %s", nh.Gw))
+	}
+	if nh.LinkIndex != 0 {
+		elems = append(elems, fmt.Sprintf("Ifindex: %d", nh.LinkIndex))
+	}
+	if nh.FDB {
+		elems = append(elems, "FDB")
+	}
+	return fmt.Sprintf("{%s}", strings.Join(elems, " "))
+}
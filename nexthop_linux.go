@@ -0,0 +1,322 @@
+package netlink
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+// NexthopAdd will add a nexthop object to the system.
+// Equivalent to: `ip nexthop add $nh`
+func NexthopAdd(nh *Nexthop) error {
+	return pkgHandle.NexthopAdd(nh)
+}
+
+// NexthopAdd will add a nexthop object to the system.
+// Equivalent to: `ip nexthop add $nh`
+func (h *Handle) NexthopAdd(nh *Nexthop) error {
+	req := h.newNetlinkRequest(unix.RTM_NEWNEXTHOP, unix.NLM_F_CREATE|unix.NLM_F_EXCL|unix.NLM_F_ACK)
+	return h.nexthopHandle(nh, req)
+}
+
+// NexthopReplace will add a nexthop object to the system, or replace the
+// one with the same ID.
+// Equivalent to: `ip nexthop replace $nh`
+func NexthopReplace(nh *Nexthop) error {
+	return pkgHandle.NexthopReplace(nh)
+}
+
+// NexthopReplace will add a nexthop object to the system, or replace the
+// one with the same ID.
+// Equivalent to: `ip nexthop replace $nh`
+func (h *Handle) NexthopReplace(nh *Nexthop) error {
+	req := h.newNetlinkRequest(unix.RTM_NEWNEXTHOP, unix.NLM_F_CREATE|unix.NLM_F_REPLACE|unix.NLM_F_ACK)
+	return h.nexthopHandle(nh, req)
+}
+
+// NexthopDel will delete the nexthop object with the ID of nh.
+// Equivalent to: `ip nexthop del id $id`
+func NexthopDel(nh *Nexthop) error {
+	return pkgHandle.NexthopDel(nh)
+}
+
+// NexthopDel will delete the nexthop object with the ID of nh.
+// Equivalent to: `ip nexthop del id $id`
+func (h *Handle) NexthopDel(nh *Nexthop) error {
+	req := h.newNetlinkRequest(unix.RTM_DELNEXTHOP, unix.NLM_F_ACK)
+	req.AddData(nl.NewNhmsg(unix.AF_UNSPEC))
+	req.AddData(nl.NewRtAttr(nl.NHA_ID, nl.Uint32Attr(nh.ID)))
+	_, err := req.Execute(unix.NETLINK_ROUTE, 0)
+	return err
+}
+
+func (h *Handle) nexthopHandle(nh *Nexthop, req *nl.NetlinkRequest) error {
+	msg := nl.NewNhmsg(nh.Family)
+	msg.Protocol = uint8(nh.Protocol)
+	msg.Flags = uint32(nh.Flags)
+	req.AddData(msg)
+
+	if nh.ID != 0 {
+		req.AddData(nl.NewRtAttr(nl.NHA_ID, nl.Uint32Attr(nh.ID)))
+	}
+	if len(nh.Group) > 0 {
+		if nh.Gw != nil || nh.LinkIndex != 0 || nh.Blackhole {
+			return fmt.Errorf("nexthop group %d can't have a gateway, link or blackhole", nh.ID)
+		}
+		msg.Family = unix.AF_UNSPEC
+		var grp []byte
+		for _, m := range nh.Group {
+			weight := m.Weight
+			if weight == 0 {
+				weight = 1
+			}
+			if weight < 1 || weight > 256 {
+				return fmt.Errorf("invalid weight %d of nexthop %d in group %d", m.Weight, m.ID, nh.ID)
+			}
+			member := nl.NexthopGrp{Id: m.ID, Weight: uint8(weight - 1)}
+			grp = append(grp, member.Serialize()...)
+		}
+		req.AddData(nl.NewRtAttr(nl.NHA_GROUP, grp))
+		if nh.Resilient != nil {
+			req.AddData(nl.NewRtAttr(nl.NHA_GROUP_TYPE, nl.Uint16Attr(nl.NEXTHOP_GRP_TYPE_RES)))
+			res := nl.NewRtAttr(nl.NHA_RES_GROUP|unix.NLA_F_NESTED, nil)
+			if nh.Resilient.Buckets != 0 {
+				res.AddRtAttr(nl.NHA_RES_GROUP_BUCKETS, nl.Uint16Attr(nh.Resilient.Buckets))
+			}
+			if nh.Resilient.IdleTimer != 0 {
+				res.AddRtAttr(nl.NHA_RES_GROUP_IDLE_TIMER, nl.Uint32Attr(durationToClockTicks(nh.Resilient.IdleTimer)))
+			}
+			res.AddRtAttr(nl.NHA_RES_GROUP_UNBALANCED_TIMER, nl.Uint32Attr(durationToClockTicks(nh.Resilient.UnbalancedTimer)))
+			req.AddData(res)
+		}
+	} else if nh.Blackhole {
+		req.AddData(nl.NewRtAttr(nl.NHA_BLACKHOLE, nil))
+	} else {
+		if nh.LinkIndex != 0 {
+			req.AddData(nl.NewRtAttr(nl.NHA_OIF, nl.Uint32Attr(uint32(nh.LinkIndex))))
+		}
+		if nh.Gw != nil {
+			gw := nh.Gw.To4()
+			family := unix.AF_INET
+			if gw == nil {
+				gw = nh.Gw.To16()
+				family = unix.AF_INET6
+			}
+			if msg.Family == unix.AF_UNSPEC {
+				msg.Family = uint8(family)
+			}
+			req.AddData(nl.NewRtAttr(nl.NHA_GATEWAY, gw))
+		}
+	}
+	if nh.FDB {
+		req.AddData(nl.NewRtAttr(nl.NHA_FDB, nil))
+	}
+
+	_, err := req.Execute(unix.NETLINK_ROUTE, 0)
+	return err
+}
+
+// NexthopGet gets the nexthop object with the given ID.
+// Equivalent to: `ip nexthop get id $id`
+func NexthopGet(id uint32) (*Nexthop, error) {
+	return pkgHandle.NexthopGet(id)
+}
+
+// NexthopGet gets the nexthop object with the given ID.
+// Equivalent to: `ip nexthop get id $id`
+func (h *Handle) NexthopGet(id uint32) (*Nexthop, error) {
+	req := h.newNetlinkRequest(unix.RTM_GETNEXTHOP, unix.NLM_F_ACK)
+	req.AddData(nl.NewNhmsg(unix.AF_UNSPEC))
+	req.AddData(nl.NewRtAttr(nl.NHA_ID, nl.Uint32Attr(id)))
+
+	msgs, err := req.Execute(unix.NETLINK_ROUTE, unix.RTM_NEWNEXTHOP)
+	if err != nil {
+		return nil, err
+	}
+	if len(msgs) == 0 {
+		return nil, errors.New("no message nor error from netlink")
+	}
+	return deserializeNexthop(msgs[0])
+}
+
+// NexthopList gets all the nexthop objects of the system.
+// Equivalent to: `ip nexthop show`
+//
+// If the returned error is [ErrDumpInterrupted], results may be inconsistent
+// or incomplete.
+func NexthopList() ([]Nexthop, error) {
+	return pkgHandle.NexthopList()
+}
+
+// NexthopList gets all the nexthop objects of the system.
+// Equivalent to: `ip nexthop show`
+//
+// If the returned error is [ErrDumpInterrupted], results may be inconsistent
+// or incomplete.
+func (h *Handle) NexthopList() ([]Nexthop, error) {
+	req := h.newNetlinkRequest(unix.RTM_GETNEXTHOP, unix.NLM_F_DUMP)
+	req.AddData(nl.NewNhmsg(unix.AF_UNSPEC))
+
+	msgs, executeErr := req.Execute(unix.NETLINK_ROUTE, unix.RTM_NEWNEXTHOP)
+	if executeErr != nil && !errors.Is(executeErr, ErrDumpInterrupted) {
+		return nil, executeErr
+	}
+	var res []Nexthop
+	for _, m := range msgs {
+		nh, err := deserializeNexthop(m)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *nh)
+	}
+	return res, executeErr
+}
+
+func deserializeNexthop(m []byte) (*Nexthop, error) {
+	msg := nl.DeserializeNhmsg(m)
+	attrs, err := nl.ParseRouteAttr(m[msg.Len():])
+	if err != nil {
+		return nil, err
+	}
+	nh := &Nexthop{
+		Family:   int(msg.Family),
+		Protocol: RouteProtocol(msg.Protocol),
+		Flags:    int(msg.Flags),
+	}
+	groupType := nl.NEXTHOP_GRP_TYPE_MPATH
+	for _, attr := range attrs {
+		switch attr.Attr.Type &^ unix.NLA_F_NESTED {
+		case nl.NHA_ID:
+			nh.ID = native.Uint32(attr.Value[0:4])
+		case nl.NHA_GROUP:
+			for b := attr.Value; len(b) >= nl.SizeofNexthopGrp; b = b[nl.SizeofNexthopGrp:] {
+				member := nl.DeserializeNexthopGrp(b)
+				nh.Group = append(nh.Group, NexthopGroupMember{
+					ID:     member.Id,
+					Weight: int(member.Weight) + 1,
+				})
+			}
+		case nl.NHA_GROUP_TYPE:
+			groupType = int(native.Uint16(attr.Value[0:2]))
+		case nl.NHA_BLACKHOLE:
+			nh.Blackhole = true
+		case nl.NHA_OIF:
+			nh.LinkIndex = int(native.Uint32(attr.Value[0:4]))
+		case nl.NHA_GATEWAY:
+			nh.Gw = net.IP(attr.Value)
+		case nl.NHA_FDB:
+			nh.FDB = true
+		case nl.NHA_RES_GROUP:
+			res, err := parseNexthopResilient(attr.Value)
+			if err != nil {
+				return nil, err
+			}
+			nh.Resilient = res
+		}
+	}
+	if groupType == nl.NEXTHOP_GRP_TYPE_RES && nh.Resilient == nil {
+		nh.Resilient = &NexthopResilient{}
+	}
+	return nh, nil
+}
+
+func parseNexthopResilient(b []byte) (*NexthopResilient, error) {
+	attrs, err := nl.ParseRouteAttr(b)
+	if err != nil {
+		return nil, err
+	}
+	res := &NexthopResilient{}
+	for _, attr := range attrs {
+		switch attr.Attr.Type {
+		case nl.NHA_RES_GROUP_BUCKETS:
+			res.Buckets = native.Uint16(attr.Value[0:2])
+		case nl.NHA_RES_GROUP_IDLE_TIMER:
+			res.IdleTimer = clockTicksToDuration(native.Uint32(attr.Value[0:4]))
+		case nl.NHA_RES_GROUP_UNBALANCED_TIMER:
+			res.UnbalancedTimer = clockTicksToDuration(native.Uint32(attr.Value[0:4]))
+		case nl.NHA_RES_GROUP_UNBALANCED_TIME:
+			res.UnbalancedTime = clockTicks64ToDuration(native.Uint64(attr.Value[0:8]))
+		}
+	}
+	return res, nil
+}
+
+// NexthopBucketList gets the hash buckets of the resilient nexthop group
+// with the given ID.
+// Equivalent to: `ip nexthop bucket show id $id`
+//
+// If the returned error is [ErrDumpInterrupted], results may be inconsistent
+// or incomplete.
+func NexthopBucketList(groupID uint32) ([]NexthopBucket, error) {
+	return pkgHandle.NexthopBucketList(groupID)
+}
+
+// NexthopBucketList gets the hash buckets of the resilient nexthop group
+// with the given ID.
+// Equivalent to: `ip nexthop bucket show id $id`
+//
+// If the returned error is [ErrDumpInterrupted], results may be inconsistent
+// or incomplete.
+func (h *Handle) NexthopBucketList(groupID uint32) ([]NexthopBucket, error) {
+	req := h.newNetlinkRequest(nl.RTM_GETNEXTHOPBUCKET, unix.NLM_F_DUMP)
+	req.AddData(nl.NewNhmsg(unix.AF_UNSPEC))
+	req.AddData(nl.NewRtAttr(nl.NHA_ID, nl.Uint32Attr(groupID)))
+
+	msgs, executeErr := req.Execute(unix.NETLINK_ROUTE, nl.RTM_NEWNEXTHOPBUCKET)
+	if executeErr != nil && !errors.Is(executeErr, ErrDumpInterrupted) {
+		return nil, executeErr
+	}
+	var res []NexthopBucket
+	for _, m := range msgs {
+		bucket, err := parseNexthopBucket(m)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, bucket)
+	}
+	return res, executeErr
+}
+
+func parseNexthopBucket(m []byte) (NexthopBucket, error) {
+	var bucket NexthopBucket
+	msg := nl.DeserializeNhmsg(m)
+	attrs, err := nl.ParseRouteAttr(m[msg.Len():])
+	if err != nil {
+		return bucket, err
+	}
+	for _, attr := range attrs {
+		switch attr.Attr.Type &^ unix.NLA_F_NESTED {
+		case nl.NHA_ID:
+			bucket.GroupID = native.Uint32(attr.Value[0:4])
+		case nl.NHA_RES_BUCKET:
+			nested, err := nl.ParseRouteAttr(attr.Value)
+			if err != nil {
+				return bucket, err
+			}
+			for _, a := range nested {
+				switch a.Attr.Type {
+				case nl.NHA_RES_BUCKET_INDEX:
+					bucket.Index = native.Uint16(a.Value[0:2])
+				case nl.NHA_RES_BUCKET_IDLE_TIME:
+					bucket.IdleTime = clockTicks64ToDuration(native.Uint64(a.Value[0:8]))
+				case nl.NHA_RES_BUCKET_NH_ID:
+					bucket.NexthopID = native.Uint32(a.Value[0:4])
+				}
+			}
+		}
+	}
+	return bucket, nil
+}
+
+func clockTicks64ToDuration(ticks uint64) time.Duration {
+	hz := userHZ()
+	return time.Duration(ticks/hz*uint64(time.Second) + ticks%hz*uint64(time.Second)/hz)
+}
+
+func durationToClockTicks(d time.Duration) uint32 {
+	return uint32(uint64(d) * userHZ() / uint64(time.Second))
+}
@@ -0,0 +1,112 @@
+//go:build linux
+// +build linux
+
+package netlink
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestNexthopResilientGroup(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+
+	if err := LinkAdd(&Veth{LinkAttrs: LinkAttrs{Name: "foo"}, PeerName: "bar"}); err != nil {
+		t.Fatal(err)
+	}
+	link, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkSetUp(link); err != nil {
+		t.Fatal(err)
+	}
+	peer, err := LinkByName("bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkSetUp(peer); err != nil {
+		t.Fatal(err)
+	}
+	addr, _ := ParseAddr("192.168.0.1/24")
+	if err := AddrAdd(link, addr); err != nil {
+		t.Fatal(err)
+	}
+
+	members := []*Nexthop{
+		{ID: 1, LinkIndex: link.Attrs().Index, Gw: net.ParseIP("192.168.0.2")},
+		{ID: 2, LinkIndex: link.Attrs().Index, Gw: net.ParseIP("192.168.0.3")},
+	}
+	for _, nh := range members {
+		if err := NexthopAdd(nh); err != nil {
+			t.Fatal(err)
+		}
+	}
+	group := &Nexthop{
+		ID:    10,
+		Group: []NexthopGroupMember{{ID: 1}, {ID: 2, Weight: 3}},
+		// IdleTimer is left to the kernel default
+		Resilient: &NexthopResilient{
+			Buckets: 64,
+		},
+	}
+	if err := NexthopAdd(group); err != nil {
+		t.Fatal(err)
+	}
+
+	nh, err := NexthopGet(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nh.Group) != 2 || nh.Group[0] != (NexthopGroupMember{ID: 1, Weight: 1}) || nh.Group[1] != (NexthopGroupMember{ID: 2, Weight: 3}) {
+		t.Fatalf("unexpected group members %v", nh.Group)
+	}
+	if nh.Resilient == nil {
+		t.Fatal("group is not resilient")
+	}
+	if nh.Resilient.Buckets != 64 || nh.Resilient.IdleTimer != 120*time.Second {
+		t.Fatalf("unexpected resilient parameters %+v", *nh.Resilient)
+	}
+
+	buckets, err := NexthopBucketList(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(buckets) != 64 {
+		t.Fatalf("got %d buckets, expected 64", len(buckets))
+	}
+	seen := map[uint16]bool{}
+	for _, b := range buckets {
+		if b.GroupID != 10 {
+			t.Fatalf("bucket %d belongs to group %d", b.Index, b.GroupID)
+		}
+		if b.NexthopID != 1 && b.NexthopID != 2 {
+			t.Fatalf("bucket %d maps to nexthop %d which is not a member", b.Index, b.NexthopID)
+		}
+		seen[b.Index] = true
+	}
+	if len(seen) != 64 {
+		t.Fatalf("bucket indexes are not unique: %d", len(seen))
+	}
+
+	nhs, err := NexthopList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nhs) != 3 {
+		t.Fatalf("expected 3 nexthops, got %v", nhs)
+	}
+	for _, nh := range nhs {
+		if nh.ID == 1 && (!nh.Gw.Equal(members[0].Gw) || nh.LinkIndex != link.Attrs().Index) {
+			t.Fatalf("unexpected nexthop %v", nh)
+		}
+	}
+
+	if err := NexthopDel(group); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NexthopGet(10); err == nil {
+		t.Fatal("group still exists")
+	}
+}
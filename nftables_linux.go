@@ -0,0 +1,174 @@
+package netlink
+
+import (
+	"errors"
+
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+// NftTable is an nftables table as reported by NFT_MSG_GETTABLE.
+type NftTable struct {
+	Family uint8
+	Name   string
+	Handle uint64
+	Flags  uint32
+	Use    uint32
+}
+
+// NftChainHook describes where a base chain is attached.
+type NftChainHook struct {
+	Hooknum  uint32
+	Priority int32
+	Device   string
+}
+
+// NftChain is an nftables chain as reported by NFT_MSG_GETCHAIN.
+// Hook and Policy are only set for base chains.
+type NftChain struct {
+	Family uint8
+	Table  string
+	Name   string
+	Handle uint64
+	Type   string
+	Hook   *NftChainHook
+	Policy *uint32
+	Use    uint32
+	Flags  uint32
+}
+
+// NftListTables lists the nftables tables of the given family
+// (unix.NFPROTO_*, or unix.NFPROTO_UNSPEC for all families).
+// Equivalent to: `nft list tables $family`
+//
+// Only listing is supported, rule management is out of scope.
+//
+// If the returned error is [ErrDumpInterrupted], results may be inconsistent
+// or incomplete.
+func NftListTables(family uint8) ([]NftTable, error) {
+	return pkgHandle.NftListTables(family)
+}
+
+// NftListTables lists the nftables tables of the given family
+// (unix.NFPROTO_*, or unix.NFPROTO_UNSPEC for all families).
+// Equivalent to: `nft list tables $family`
+//
+// Only listing is supported, rule management is out of scope.
+//
+// If the returned error is [ErrDumpInterrupted], results may be inconsistent
+// or incomplete.
+func (h *Handle) NftListTables(family uint8) ([]NftTable, error) {
+	req := h.newNftDumpRequest(unix.NFT_MSG_GETTABLE, family)
+	msgs, executeErr := req.Execute(unix.NETLINK_NETFILTER, 0)
+	if executeErr != nil && !errors.Is(executeErr, ErrDumpInterrupted) {
+		return nil, executeErr
+	}
+
+	res := make([]NftTable, 0, len(msgs))
+	for _, m := range msgs {
+		res = append(res, parseNftTable(m))
+	}
+	return res, executeErr
+}
+
+// NftListChains lists the nftables chains of the given family. If table is
+// not empty only the chains of that table are returned.
+// Equivalent to: `nft list chains $family`
+//
+// If the returned error is [ErrDumpInterrupted], results may be inconsistent
+// or incomplete.
+func NftListChains(family uint8, table string) ([]NftChain, error) {
+	return pkgHandle.NftListChains(family, table)
+}
+
+// NftListChains lists the nftables chains of the given family. If table is
+// not empty only the chains of that table are returned.
+// Equivalent to: `nft list chains $family`
+//
+// If the returned error is [ErrDumpInterrupted], results may be inconsistent
+// or incomplete.
+func (h *Handle) NftListChains(family uint8, table string) ([]NftChain, error) {
+	req := h.newNftDumpRequest(unix.NFT_MSG_GETCHAIN, family)
+	if table != "" {
+		req.AddData(nl.NewRtAttr(unix.NFTA_CHAIN_TABLE, nl.ZeroTerminated(table)))
+	}
+	msgs, executeErr := req.Execute(unix.NETLINK_NETFILTER, 0)
+	if executeErr != nil && !errors.Is(executeErr, ErrDumpInterrupted) {
+		return nil, executeErr
+	}
+
+	res := make([]NftChain, 0, len(msgs))
+	for _, m := range msgs {
+		chain := parseNftChain(m)
+		// older kernels ignore the table attribute on dumps
+		if table != "" && chain.Table != table {
+			continue
+		}
+		res = append(res, chain)
+	}
+	return res, executeErr
+}
+
+func (h *Handle) newNftDumpRequest(cmd int, family uint8) *nl.NetlinkRequest {
+	req := h.newNetlinkRequest(cmd|(unix.NFNL_SUBSYS_NFTABLES<<8), unix.NLM_F_DUMP)
+	req.AddData(&nl.Nfgenmsg{
+		NfgenFamily: family,
+		Version:     nl.NFNETLINK_V0,
+		ResId:       0,
+	})
+	return req
+}
+
+func parseNftTable(msg []byte) NftTable {
+	table := NftTable{Family: nl.DeserializeNfgenmsg(msg).NfgenFamily}
+	for attr := range nl.ParseAttributes(msg[nl.SizeofNfgenmsg:]) {
+		switch attr.Type & nl.NLA_TYPE_MASK {
+		case unix.NFTA_TABLE_NAME:
+			table.Name = nl.BytesToString(attr.Value)
+		case unix.NFTA_TABLE_FLAGS:
+			table.Flags = networkOrder.Uint32(attr.Value)
+		case unix.NFTA_TABLE_USE:
+			table.Use = networkOrder.Uint32(attr.Value)
+		case nl.NFTA_TABLE_HANDLE:
+			table.Handle = networkOrder.Uint64(attr.Value)
+		}
+	}
+	return table
+}
+
+func parseNftChain(msg []byte) NftChain {
+	chain := NftChain{Family: nl.DeserializeNfgenmsg(msg).NfgenFamily}
+	for attr := range nl.ParseAttributes(msg[nl.SizeofNfgenmsg:]) {
+		switch attr.Type & nl.NLA_TYPE_MASK {
+		case unix.NFTA_CHAIN_TABLE:
+			chain.Table = nl.BytesToString(attr.Value)
+		case unix.NFTA_CHAIN_NAME:
+			chain.Name = nl.BytesToString(attr.Value)
+		case unix.NFTA_CHAIN_HANDLE:
+			chain.Handle = networkOrder.Uint64(attr.Value)
+		case unix.NFTA_CHAIN_TYPE:
+			chain.Type = nl.BytesToString(attr.Value)
+		case unix.NFTA_CHAIN_POLICY:
+			policy := networkOrder.Uint32(attr.Value)
+			chain.Policy = &policy
+		case unix.NFTA_CHAIN_USE:
+			chain.Use = networkOrder.Uint32(attr.Value)
+		case nl.NFTA_CHAIN_FLAGS:
+			chain.Flags = networkOrder.Uint32(attr.Value)
+		case unix.NFTA_CHAIN_HOOK:
+			hook := &NftChainHook{}
+			for attr := range nl.ParseAttributes(attr.Value) {
+				switch attr.Type & nl.NLA_TYPE_MASK {
+				case unix.NFTA_HOOK_HOOKNUM:
+					hook.Hooknum = networkOrder.Uint32(attr.Value)
+				case unix.NFTA_HOOK_PRIORITY:
+					hook.Priority = int32(networkOrder.Uint32(attr.Value))
+				case unix.NFTA_HOOK_DEV:
+					hook.Device = nl.BytesToString(attr.Value)
+				}
+			}
+			chain.Hook = hook
+		}
+	}
+	return chain
+}
@@ -0,0 +1,51 @@
+package netlink
+
+import (
+	"os"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestParseNftTableResult(t *testing.T) {
+	msgBytes, err := os.ReadFile("testdata/nft_table_result")
+	if err != nil {
+		t.Fatalf("reading test fixture failed: %v", err)
+	}
+
+	table := parseNftTable(msgBytes)
+	expected := NftTable{Family: unix.NFPROTO_IPV4, Name: "filter", Handle: 7, Use: 2}
+	if table != expected {
+		t.Errorf("expected table %+v, got %+v", expected, table)
+	}
+}
+
+func TestParseNftChainResult(t *testing.T) {
+	msgBytes, err := os.ReadFile("testdata/nft_chain_result")
+	if err != nil {
+		t.Fatalf("reading test fixture failed: %v", err)
+	}
+
+	chain := parseNftChain(msgBytes)
+	if chain.Family != unix.NFPROTO_IPV4 {
+		t.Errorf("expected Family to equal %d, got %d", unix.NFPROTO_IPV4, chain.Family)
+	}
+	if chain.Table != "filter" || chain.Name != "input" || chain.Type != "filter" {
+		t.Errorf(`expected filter/input of type "filter", got %s/%s of type %q`, chain.Table, chain.Name, chain.Type)
+	}
+	if chain.Handle != 1 {
+		t.Errorf("expected Handle to equal 1, got %d", chain.Handle)
+	}
+	if chain.Flags != 1 {
+		t.Errorf("expected Flags to equal 1, got %d", chain.Flags)
+	}
+	if chain.Policy == nil || *chain.Policy != 1 {
+		t.Errorf("expected accept policy, got %v", chain.Policy)
+	}
+	if chain.Hook == nil {
+		t.Fatal("expected base chain hook to be decoded")
+	}
+	if chain.Hook.Hooknum != unix.NF_INET_LOCAL_IN || chain.Hook.Priority != -150 {
+		t.Errorf("expected hook input priority -150, got %+v", *chain.Hook)
+	}
+}
@@ -15,6 +15,15 @@ const (
 	BRIDGE_FLAGS_SELF              /* Bridge command to/from lowerdev */
 )
 
+/* Bridge port STP states, IFLA_BRPORT_STATE */
+const (
+	BR_STATE_DISABLED = iota
+	BR_STATE_LISTENING
+	BR_STATE_LEARNING
+	BR_STATE_FORWARDING
+	BR_STATE_BLOCKING
+)
+
 /* Bridge management nested attributes
  * [IFLA_AF_SPEC] = {
  *     [IFLA_BRIDGE_FLAGS]
@@ -42,6 +51,8 @@ const (
 	BRIDGE_VLAN_INFO_UNTAGGED
 	BRIDGE_VLAN_INFO_RANGE_BEGIN
 	BRIDGE_VLAN_INFO_RANGE_END
+	BRIDGE_VLAN_INFO_BRENTRY
+	BRIDGE_VLAN_INFO_ONLY_OPTS
 )
 
 // struct bridge_vlan_info {
@@ -84,4 +95,111 @@ const (
 	RTEXT_FILTER_VF = 1 << iota
 	RTEXT_FILTER_BRVLAN
 	RTEXT_FILTER_BRVLAN_COMPRESSED
+	RTEXT_FILTER_SKIP_STATS
+)
+
+// RTM_NEWVLAN, RTM_DELVLAN and RTM_GETVLAN manage the bridge vlan db.
+const (
+	RTM_NEWVLAN = 0x70
+	RTM_DELVLAN = 0x71
+	RTM_GETVLAN = 0x72
+)
+
+const (
+	SizeofBrVlanMsg = 0x08
+)
+
+/* Bridge vlan db dump attributes */
+const (
+	BRIDGE_VLANDB_DUMP_UNSPEC = iota
+	BRIDGE_VLANDB_DUMP_FLAGS
+)
+
+const (
+	BRIDGE_VLANDB_DUMPF_STATS = 1 << iota
+	BRIDGE_VLANDB_DUMPF_GLOBAL
 )
+
+/* Bridge vlan db attributes
+ * [BRIDGE_VLANDB_ENTRY] = {
+ *     [BRIDGE_VLANDB_ENTRY_INFO]
+ *     ...
+ * }
+ * [BRIDGE_VLANDB_GLOBAL_OPTIONS] = {
+ *     [BRIDGE_VLANDB_GOPTS_ID]
+ *     ...
+ * }
+ */
+const (
+	BRIDGE_VLANDB_UNSPEC = iota
+	BRIDGE_VLANDB_ENTRY
+	BRIDGE_VLANDB_GLOBAL_OPTIONS
+)
+
+const (
+	BRIDGE_VLANDB_ENTRY_UNSPEC = iota
+	BRIDGE_VLANDB_ENTRY_INFO
+	BRIDGE_VLANDB_ENTRY_RANGE
+	BRIDGE_VLANDB_ENTRY_STATE
+	BRIDGE_VLANDB_ENTRY_TUNNEL_INFO
+	BRIDGE_VLANDB_ENTRY_STATS
+	BRIDGE_VLANDB_ENTRY_MCAST_ROUTER
+	BRIDGE_VLANDB_ENTRY_MCAST_N_GROUPS
+	BRIDGE_VLANDB_ENTRY_MCAST_MAX_GROUPS
+	BRIDGE_VLANDB_ENTRY_NEIGH_SUPPRESS
+)
+
+const (
+	BRIDGE_VLANDB_GOPTS_UNSPEC = iota
+	BRIDGE_VLANDB_GOPTS_ID
+	BRIDGE_VLANDB_GOPTS_RANGE
+	BRIDGE_VLANDB_GOPTS_MCAST_SNOOPING
+	BRIDGE_VLANDB_GOPTS_MCAST_IGMP_VERSION
+	BRIDGE_VLANDB_GOPTS_MCAST_MLD_VERSION
+	BRIDGE_VLANDB_GOPTS_MCAST_LAST_MEMBER_CNT
+	BRIDGE_VLANDB_GOPTS_MCAST_STARTUP_QUERY_CNT
+	BRIDGE_VLANDB_GOPTS_MCAST_LAST_MEMBER_INTVL
+	BRIDGE_VLANDB_GOPTS_PAD
+	BRIDGE_VLANDB_GOPTS_MCAST_MEMBERSHIP_INTVL
+	BRIDGE_VLANDB_GOPTS_MCAST_QUERIER_INTVL
+	BRIDGE_VLANDB_GOPTS_MCAST_QUERY_INTVL
+	BRIDGE_VLANDB_GOPTS_MCAST_QUERY_RESPONSE_INTVL
+	BRIDGE_VLANDB_GOPTS_MCAST_STARTUP_QUERY_INTVL
+	BRIDGE_VLANDB_GOPTS_MCAST_QUERIER
+	BRIDGE_VLANDB_GOPTS_MCAST_ROUTER_PORTS
+	BRIDGE_VLANDB_GOPTS_MCAST_QUERIER_STATE
+	BRIDGE_VLANDB_GOPTS_MSTI
+)
+
+// struct br_vlan_msg {
+//   __u8 family;
+//   __u8 reserved1;
+//   __u16 reserved2;
+//   __u32 ifindex;
+// };
+
+type BrVlanMsg struct {
+	Family    uint8
+	Reserved1 uint8
+	Reserved2 uint16
+	Ifindex   uint32
+}
+
+func NewBrVlanMsg(family int, ifindex int) *BrVlanMsg {
+	return &BrVlanMsg{
+		Family:  uint8(family),
+		Ifindex: uint32(ifindex),
+	}
+}
+
+func (msg *BrVlanMsg) Len() int {
+	return SizeofBrVlanMsg
+}
+
+func (msg *BrVlanMsg) Serialize() []byte {
+	return (*(*[SizeofBrVlanMsg]byte)(unsafe.Pointer(msg)))[:]
+}
+
+func DeserializeBrVlanMsg(b []byte) *BrVlanMsg {
+	return (*BrVlanMsg)(unsafe.Pointer(&b[0:SizeofBrVlanMsg][0]))
+}
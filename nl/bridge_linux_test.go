@@ -33,3 +33,32 @@ func TestBridgeVlanInfoDeserializeSerialize(t *testing.T) {
 	msg := DeserializeBridgeVlanInfo(orig)
 	testDeserializeSerialize(t, orig, safemsg, msg)
 }
+
+func (msg *BrVlanMsg) write(b []byte) {
+	native := NativeEndian()
+	b[0] = msg.Family
+	b[1] = msg.Reserved1
+	native.PutUint16(b[2:4], msg.Reserved2)
+	native.PutUint32(b[4:8], msg.Ifindex)
+}
+
+func (msg *BrVlanMsg) serializeSafe() []byte {
+	length := SizeofBrVlanMsg
+	b := make([]byte, length)
+	msg.write(b)
+	return b
+}
+
+func deserializeBrVlanMsgSafe(b []byte) *BrVlanMsg {
+	var msg = BrVlanMsg{}
+	binary.Read(bytes.NewReader(b[0:SizeofBrVlanMsg]), NativeEndian(), &msg)
+	return &msg
+}
+
+func TestBrVlanMsgDeserializeSerialize(t *testing.T) {
+	var orig = make([]byte, SizeofBrVlanMsg)
+	rand.Read(orig)
+	safemsg := deserializeBrVlanMsgSafe(orig)
+	msg := DeserializeBrVlanMsg(orig)
+	testDeserializeSerialize(t, orig, safemsg, msg)
+}
@@ -71,9 +71,16 @@ const (
 	GENL_GTP_ATTR_PAD
 )
 
+// struct genlmsghdr {
+//   __u8 cmd;
+//   __u8 version;
+//   __u16 reserved;
+// };
+
 type Genlmsg struct {
-	Command uint8
-	Version uint8
+	Command  uint8
+	Version  uint8
+	reserved uint16 // must be zero, strictly validated families reject it otherwise
 }
 
 func (msg *Genlmsg) Len() int {
@@ -0,0 +1,31 @@
+package nl
+
+// ioam6 lwtunnel modes
+// from include/uapi/linux/ioam6_iptunnel.h
+const (
+	IOAM6_IPTUNNEL_MODE_INLINE = iota + 1
+	IOAM6_IPTUNNEL_MODE_ENCAP
+	IOAM6_IPTUNNEL_MODE_AUTO
+)
+
+// ioam6 lwtunnel attributes
+const (
+	IOAM6_IPTUNNEL_UNSPEC = iota
+	IOAM6_IPTUNNEL_MODE   // u8
+	IOAM6_IPTUNNEL_DST    // struct in6_addr
+	IOAM6_IPTUNNEL_TRACE  // struct ioam6_trace_hdr
+	IOAM6_IPTUNNEL_FREQ_K // u32
+	IOAM6_IPTUNNEL_FREQ_N // u32
+	__IOAM6_IPTUNNEL_MAX
+)
+
+const (
+	IOAM6_IPTUNNEL_MAX = __IOAM6_IPTUNNEL_MAX - 1
+)
+
+const (
+	// size of struct ioam6_trace_hdr without the node data
+	SizeofIoam6TraceHdr = 8
+	// IOAM6_TRACE_DATA_SIZE_MAX is the largest pre-allocated trace size
+	IOAM6_TRACE_DATA_SIZE_MAX = 244
+)
@@ -3,6 +3,29 @@ package nl
 // id's of route attribute from https://elixir.bootlin.com/linux/v5.17.3/source/include/uapi/linux/lwtunnel.h#L38
 // the value's size are specified in https://elixir.bootlin.com/linux/v5.17.3/source/net/ipv4/ip_tunnel_core.c#L928
 
+const (
+	LWTUNNEL_IP_UNSPEC = iota
+	LWTUNNEL_IP_ID
+	LWTUNNEL_IP_DST
+	LWTUNNEL_IP_SRC
+	LWTUNNEL_IP_TTL
+	LWTUNNEL_IP_TOS
+	LWTUNNEL_IP_FLAGS
+	LWTUNNEL_IP_PAD
+	LWTUNNEL_IP_OPTS // not implemented
+	__LWTUNNEL_IP_MAX
+)
+
+// Flags of the tunnel metadata, LWTUNNEL_IP_FLAGS and LWTUNNEL_IP6_FLAGS,
+// in host order
+const (
+	TUNNEL_CSUM          = 0x01
+	TUNNEL_KEY           = 0x04
+	TUNNEL_SEQ           = 0x08
+	TUNNEL_DONT_FRAGMENT = 0x0100
+	TUNNEL_OAM           = 0x0200
+)
+
 const (
 	LWTUNNEL_IP6_UNSPEC = iota
 	LWTUNNEL_IP6_ID
@@ -11,11 +34,7 @@ const (
 	LWTUNNEL_IP6_HOPLIMIT
 	LWTUNNEL_IP6_TC
 	LWTUNNEL_IP6_FLAGS
-	LWTUNNEL_IP6_PAD // not implemented
+	LWTUNNEL_IP6_PAD
 	LWTUNNEL_IP6_OPTS // not implemented
 	__LWTUNNEL_IP6_MAX
 )
-
-
-
-
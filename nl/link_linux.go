@@ -11,6 +11,11 @@ const (
 	DEFAULT_CHANGE = 0xFFFFFFFF
 )
 
+// IFLA_NETNS_IMMUTABLE is not yet defined in golang.org/x/sys/unix.
+const (
+	IFLA_NETNS_IMMUTABLE = 0x43
+)
+
 const (
 	IFLA_INFO_UNSPEC = iota
 	IFLA_INFO_KIND
@@ -90,7 +95,12 @@ const (
 	IFLA_VXLAN_GBP
 	IFLA_VXLAN_REMCSUM_NOPARTIAL
 	IFLA_VXLAN_FLOWBASED
-	IFLA_VXLAN_MAX = IFLA_VXLAN_FLOWBASED
+	IFLA_VXLAN_LABEL
+	IFLA_VXLAN_GPE
+	IFLA_VXLAN_TTL_INHERIT
+	IFLA_VXLAN_DF
+	IFLA_VXLAN_VNIFILTER
+	IFLA_VXLAN_MAX = IFLA_VXLAN_VNIFILTER
 )
 
 const (
@@ -209,6 +219,10 @@ const (
 	IFLA_BOND_AD_USER_PORT_KEY
 	IFLA_BOND_AD_ACTOR_SYSTEM
 	IFLA_BOND_TLB_DYNAMIC_LB
+	IFLA_BOND_PEER_NOTIF_DELAY
+	IFLA_BOND_AD_LACP_ACTIVE
+	IFLA_BOND_MISSED_MAX
+	IFLA_BOND_NS_IP6_TARGET
 )
 
 const (
@@ -230,6 +244,7 @@ const (
 	IFLA_BOND_SLAVE_AD_AGGREGATOR_ID
 	IFLA_BOND_SLAVE_AD_ACTOR_OPER_PORT_STATE
 	IFLA_BOND_SLAVE_AD_PARTNER_OPER_PORT_STATE
+	IFLA_BOND_SLAVE_PRIO
 )
 
 const (
@@ -350,6 +365,39 @@ const (
 	SizeofVfGUID       = 0x10
 )
 
+const SizeofRtnlLinkIfmap = 0x20
+
+// struct rtnl_link_ifmap {
+//   __u64 mem_start;
+//   __u64 mem_end;
+//   __u64 base_addr;
+//   __u16 irq;
+//   __u8 dma;
+//   __u8 port;
+// };
+
+type RtnlLinkIfmap struct {
+	MemStart uint64
+	MemEnd   uint64
+	BaseAddr uint64
+	Irq      uint16
+	Dma      uint8
+	Port     uint8
+	_        [4]byte
+}
+
+func (msg *RtnlLinkIfmap) Len() int {
+	return SizeofRtnlLinkIfmap
+}
+
+func DeserializeRtnlLinkIfmap(b []byte) *RtnlLinkIfmap {
+	return (*RtnlLinkIfmap)(unsafe.Pointer(&b[0:SizeofRtnlLinkIfmap][0]))
+}
+
+func (msg *RtnlLinkIfmap) Serialize() []byte {
+	return (*(*[SizeofRtnlLinkIfmap]byte)(unsafe.Pointer(msg)))[:]
+}
+
 // struct ifla_vf_mac {
 //   __u32 vf;
 //   __u8 mac[32]; /* MAX_ADDR_LEN */
@@ -435,8 +483,13 @@ func DeserializeVfVlanInfo(b []byte) *VfVlanInfo {
 	}
 }
 
+// Serialize encodes the message, with VlanProto in network byte order as
+// the kernel expects it.
 func (msg *VfVlanInfo) Serialize() []byte {
-	return (*(*[SizeofVfVlanInfo]byte)(unsafe.Pointer(msg)))[:]
+	b := make([]byte, SizeofVfVlanInfo)
+	copy(b, msg.VfVlan.Serialize())
+	binary.BigEndian.PutUint16(b[SizeofVfVlan:], msg.VlanProto)
+	return b
 }
 
 // struct ifla_vf_tx_rate {
@@ -705,7 +758,8 @@ const (
 	IFLA_VTI_OKEY
 	IFLA_VTI_LOCAL
 	IFLA_VTI_REMOTE
-	IFLA_VTI_MAX = IFLA_VTI_REMOTE
+	IFLA_VTI_FWMARK
+	IFLA_VTI_MAX = IFLA_VTI_FWMARK
 )
 
 const (
@@ -713,6 +767,11 @@ const (
 	IFLA_VRF_TABLE
 )
 
+const (
+	IFLA_VRF_PORT_UNSPEC = iota
+	IFLA_VRF_PORT_TABLE
+)
+
 const (
 	IFLA_BR_UNSPEC = iota
 	IFLA_BR_FORWARD_DELAY
@@ -779,6 +838,7 @@ const (
 	IFLA_XFRM_UNSPEC = iota
 	IFLA_XFRM_LINK
 	IFLA_XFRM_IF_ID
+	IFLA_XFRM_COLLECT_METADATA
 
 	IFLA_XFRM_MAX = iota - 1
 )
@@ -831,7 +891,8 @@ const (
 	IFLA_BAREUDP_ETHERTYPE
 	IFLA_BAREUDP_SRCPORT_MIN
 	IFLA_BAREUDP_MULTIPROTO_MODE
-	IFLA_BAREUDP_MAX = IFLA_BAREUDP_MULTIPROTO_MODE
+	IFLA_BAREUDP_RX_COLLECT_METADATA
+	IFLA_BAREUDP_MAX = IFLA_BAREUDP_RX_COLLECT_METADATA
 )
 
 const (
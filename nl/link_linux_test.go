@@ -34,6 +34,37 @@ func TestVfMacDeserializeSerialize(t *testing.T) {
 	testDeserializeSerialize(t, orig, safemsg, msg)
 }
 
+func (msg *RtnlLinkIfmap) write(b []byte) {
+	native := NativeEndian()
+	native.PutUint64(b[0:8], msg.MemStart)
+	native.PutUint64(b[8:16], msg.MemEnd)
+	native.PutUint64(b[16:24], msg.BaseAddr)
+	native.PutUint16(b[24:26], msg.Irq)
+	b[26] = msg.Dma
+	b[27] = msg.Port
+}
+
+func (msg *RtnlLinkIfmap) serializeSafe() []byte {
+	length := SizeofRtnlLinkIfmap
+	b := make([]byte, length)
+	msg.write(b)
+	return b
+}
+
+func deserializeRtnlLinkIfmapSafe(b []byte) *RtnlLinkIfmap {
+	var msg = RtnlLinkIfmap{}
+	binary.Read(bytes.NewReader(b[0:SizeofRtnlLinkIfmap]), NativeEndian(), &msg)
+	return &msg
+}
+
+func TestRtnlLinkIfmapDeserializeSerialize(t *testing.T) {
+	var orig = make([]byte, SizeofRtnlLinkIfmap)
+	rand.Read(orig[:SizeofRtnlLinkIfmap-4])
+	safemsg := deserializeRtnlLinkIfmapSafe(orig)
+	msg := DeserializeRtnlLinkIfmap(orig)
+	testDeserializeSerialize(t, orig, safemsg, msg)
+}
+
 func (msg *VfVlan) write(b []byte) {
 	native := NativeEndian()
 	native.PutUint32(b[0:4], uint32(msg.Vf))
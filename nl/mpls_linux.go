@@ -7,6 +7,25 @@ const (
 	MPLS_LS_S_SHIFT     = 8
 )
 
+// Reserved label values, RFC 3032 and RFC 7274
+const (
+	MPLS_LABEL_IPV4NULL         = 0 // IPv4 explicit null
+	MPLS_LABEL_RTALERT          = 1 // router alert
+	MPLS_LABEL_IPV6NULL         = 2 // IPv6 explicit null
+	MPLS_LABEL_IMPLNULL         = 3 // implicit null
+	MPLS_LABEL_ENTROPY          = 7 // entropy label indicator
+	MPLS_LABEL_GAL              = 13
+	MPLS_LABEL_OAMALERT         = 14
+	MPLS_LABEL_EXTENSION        = 15
+	MPLS_LABEL_FIRST_UNRESERVED = 16
+)
+
+// IsReservedMPLSLabel returns whether label is one of the special purpose
+// values below MPLS_LABEL_FIRST_UNRESERVED.
+func IsReservedMPLSLabel(label int) bool {
+	return label >= 0 && label < MPLS_LABEL_FIRST_UNRESERVED
+}
+
 func EncodeMPLSStack(labels ...int) []byte {
 	b := make([]byte, 4*len(labels))
 	for idx, label := range labels {
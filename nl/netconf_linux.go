@@ -0,0 +1,20 @@
+package nl
+
+// Ref: include/uapi/linux/netconf.h
+const (
+	NETCONFA_UNSPEC = iota
+	NETCONFA_IFINDEX
+	NETCONFA_FORWARDING
+	NETCONFA_RP_FILTER
+	NETCONFA_MC_FORWARDING
+	NETCONFA_PROXY_NEIGH
+	NETCONFA_IGNORE_ROUTES_WITH_LINKDOWN
+	NETCONFA_INPUT
+	NETCONFA_BC_FORWARDING
+	NETCONFA_MAX = NETCONFA_BC_FORWARDING
+)
+
+const (
+	NETCONFA_IFINDEX_ALL     = -1
+	NETCONFA_IFINDEX_DEFAULT = -2
+)
@@ -0,0 +1,78 @@
+package nl
+
+const (
+	NETDEV_GENL_NAME    = "netdev"
+	NETDEV_GENL_VERSION = 0x1
+)
+
+const (
+	NETDEV_CMD_UNSPEC  = iota
+	NETDEV_CMD_DEV_GET /* can dump */
+	NETDEV_CMD_DEV_ADD_NTF
+	NETDEV_CMD_DEV_DEL_NTF
+	NETDEV_CMD_DEV_CHANGE_NTF
+	NETDEV_CMD_PAGE_POOL_GET /* can dump */
+	NETDEV_CMD_PAGE_POOL_ADD_NTF
+	NETDEV_CMD_PAGE_POOL_DEL_NTF
+	NETDEV_CMD_PAGE_POOL_CHANGE_NTF
+	NETDEV_CMD_PAGE_POOL_STATS_GET /* can dump */
+	NETDEV_CMD_QUEUE_GET           /* can dump */
+	NETDEV_CMD_NAPI_GET            /* can dump */
+	NETDEV_CMD_QSTATS_GET          /* can dump */
+)
+
+const (
+	NETDEV_A_DEV_UNSPEC = iota
+	NETDEV_A_DEV_IFINDEX
+	NETDEV_A_DEV_PAD
+	NETDEV_A_DEV_XDP_FEATURES
+	NETDEV_A_DEV_XDP_ZC_MAX_SEGS
+	NETDEV_A_DEV_XDP_RX_METADATA_FEATURES
+	NETDEV_A_DEV_XSK_FEATURES
+)
+
+const (
+	NETDEV_A_NAPI_UNSPEC = iota
+	NETDEV_A_NAPI_IFINDEX
+	NETDEV_A_NAPI_ID
+	NETDEV_A_NAPI_IRQ
+	NETDEV_A_NAPI_PID
+)
+
+const (
+	NETDEV_A_QUEUE_UNSPEC = iota
+	NETDEV_A_QUEUE_ID
+	NETDEV_A_QUEUE_IFINDEX
+	NETDEV_A_QUEUE_TYPE
+	NETDEV_A_QUEUE_NAPI_ID
+)
+
+/* XDP features, NETDEV_A_DEV_XDP_FEATURES */
+const (
+	NETDEV_XDP_ACT_BASIC = 1 << iota
+	NETDEV_XDP_ACT_REDIRECT
+	NETDEV_XDP_ACT_NDO_XMIT
+	NETDEV_XDP_ACT_XSK_ZEROCOPY
+	NETDEV_XDP_ACT_HW_OFFLOAD
+	NETDEV_XDP_ACT_RX_SG
+	NETDEV_XDP_ACT_NDO_XMIT_SG
+)
+
+/* XDP rx metadata, NETDEV_A_DEV_XDP_RX_METADATA_FEATURES */
+const (
+	NETDEV_XDP_RX_METADATA_TIMESTAMP = 1 << iota
+	NETDEV_XDP_RX_METADATA_HASH
+	NETDEV_XDP_RX_METADATA_VLAN_TAG
+)
+
+/* AF_XDP features, NETDEV_A_DEV_XSK_FEATURES */
+const (
+	NETDEV_XSK_FLAGS_TX_TIMESTAMP = 1 << iota
+	NETDEV_XSK_FLAGS_TX_CHECKSUM
+)
+
+/* Queue types, NETDEV_A_QUEUE_TYPE */
+const (
+	NETDEV_QUEUE_TYPE_RX = iota
+	NETDEV_QUEUE_TYPE_TX
+)
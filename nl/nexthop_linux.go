@@ -0,0 +1,113 @@
+package nl
+
+import (
+	"unsafe"
+)
+
+const (
+	SizeofNhmsg          = 0x08
+	SizeofNexthopGrp     = 0x08
+	RTM_NEWNEXTHOPBUCKET = 0x74
+	RTM_DELNEXTHOPBUCKET = 0x75
+	RTM_GETNEXTHOPBUCKET = 0x76
+)
+
+/* Nexthop object attributes */
+const (
+	NHA_UNSPEC = iota
+	NHA_ID
+	NHA_GROUP
+	NHA_GROUP_TYPE
+	NHA_BLACKHOLE
+	NHA_OIF
+	NHA_GATEWAY
+	NHA_ENCAP_TYPE
+	NHA_ENCAP
+	NHA_GROUPS
+	NHA_MASTER
+	NHA_FDB
+	NHA_RES_GROUP
+	NHA_RES_BUCKET
+)
+
+const (
+	NEXTHOP_GRP_TYPE_MPATH = iota /* hash-threshold nexthop group */
+	NEXTHOP_GRP_TYPE_RES          /* resilient nexthop group */
+)
+
+/* Nested in NHA_RES_GROUP */
+const (
+	NHA_RES_GROUP_PAD = iota
+	NHA_RES_GROUP_BUCKETS
+	NHA_RES_GROUP_IDLE_TIMER
+	NHA_RES_GROUP_UNBALANCED_TIMER
+	NHA_RES_GROUP_UNBALANCED_TIME
+)
+
+/* Nested in NHA_RES_BUCKET */
+const (
+	NHA_RES_BUCKET_PAD = iota
+	NHA_RES_BUCKET_INDEX
+	NHA_RES_BUCKET_IDLE_TIME
+	NHA_RES_BUCKET_NH_ID
+)
+
+// struct nhmsg {
+//   unsigned char nh_family;
+//   unsigned char nh_scope;
+//   unsigned char nh_protocol;
+//   unsigned char resvd;
+//   unsigned int  nh_flags;
+// };
+
+type Nhmsg struct {
+	Family   uint8
+	Scope    uint8
+	Protocol uint8
+	Resvd    uint8
+	Flags    uint32
+}
+
+func NewNhmsg(family int) *Nhmsg {
+	return &Nhmsg{
+		Family: uint8(family),
+	}
+}
+
+func (msg *Nhmsg) Len() int {
+	return SizeofNhmsg
+}
+
+func (msg *Nhmsg) Serialize() []byte {
+	return (*(*[SizeofNhmsg]byte)(unsafe.Pointer(msg)))[:]
+}
+
+func DeserializeNhmsg(b []byte) *Nhmsg {
+	return (*Nhmsg)(unsafe.Pointer(&b[0:SizeofNhmsg][0]))
+}
+
+// struct nexthop_grp {
+//   __u32 id;
+//   __u8  weight;      /* weight of this nexthop minus 1 */
+//   __u8  weight_high;
+//   __u16 resvd2;
+// };
+
+type NexthopGrp struct {
+	Id         uint32
+	Weight     uint8
+	WeightHigh uint8
+	Resvd2     uint16
+}
+
+func (msg *NexthopGrp) Len() int {
+	return SizeofNexthopGrp
+}
+
+func (msg *NexthopGrp) Serialize() []byte {
+	return (*(*[SizeofNexthopGrp]byte)(unsafe.Pointer(msg)))[:]
+}
+
+func DeserializeNexthopGrp(b []byte) *NexthopGrp {
+	return (*NexthopGrp)(unsafe.Pointer(&b[0:SizeofNexthopGrp][0]))
+}
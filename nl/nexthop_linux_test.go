@@ -0,0 +1,67 @@
+package nl
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"testing"
+)
+
+func (msg *Nhmsg) write(b []byte) {
+	native := NativeEndian()
+	b[0] = msg.Family
+	b[1] = msg.Scope
+	b[2] = msg.Protocol
+	b[3] = msg.Resvd
+	native.PutUint32(b[4:8], msg.Flags)
+}
+
+func (msg *Nhmsg) serializeSafe() []byte {
+	length := SizeofNhmsg
+	b := make([]byte, length)
+	msg.write(b)
+	return b
+}
+
+func deserializeNhmsgSafe(b []byte) *Nhmsg {
+	var msg = Nhmsg{}
+	binary.Read(bytes.NewReader(b[0:SizeofNhmsg]), NativeEndian(), &msg)
+	return &msg
+}
+
+func TestNhmsgDeserializeSerialize(t *testing.T) {
+	var orig = make([]byte, SizeofNhmsg)
+	rand.Read(orig)
+	safemsg := deserializeNhmsgSafe(orig)
+	msg := DeserializeNhmsg(orig)
+	testDeserializeSerialize(t, orig, safemsg, msg)
+}
+
+func (msg *NexthopGrp) write(b []byte) {
+	native := NativeEndian()
+	native.PutUint32(b[0:4], msg.Id)
+	b[4] = msg.Weight
+	b[5] = msg.WeightHigh
+	native.PutUint16(b[6:8], msg.Resvd2)
+}
+
+func (msg *NexthopGrp) serializeSafe() []byte {
+	length := SizeofNexthopGrp
+	b := make([]byte, length)
+	msg.write(b)
+	return b
+}
+
+func deserializeNexthopGrpSafe(b []byte) *NexthopGrp {
+	var msg = NexthopGrp{}
+	binary.Read(bytes.NewReader(b[0:SizeofNexthopGrp]), NativeEndian(), &msg)
+	return &msg
+}
+
+func TestNexthopGrpDeserializeSerialize(t *testing.T) {
+	var orig = make([]byte, SizeofNexthopGrp)
+	rand.Read(orig)
+	safemsg := deserializeNexthopGrpSafe(orig)
+	msg := DeserializeNexthopGrp(orig)
+	testDeserializeSerialize(t, orig, safemsg, msg)
+}
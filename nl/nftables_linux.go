@@ -0,0 +1,16 @@
+package nl
+
+// Attributes from include/uapi/linux/netfilter/nf_tables.h that are not
+// exported by golang.org/x/sys/unix.
+const (
+	NFTA_TABLE_HANDLE   = 0x4
+	NFTA_TABLE_PAD      = 0x5
+	NFTA_TABLE_USERDATA = 0x6
+	NFTA_TABLE_OWNER    = 0x7
+)
+
+const (
+	NFTA_CHAIN_FLAGS    = 0xa
+	NFTA_CHAIN_ID       = 0xb
+	NFTA_CHAIN_USERDATA = 0xc
+)
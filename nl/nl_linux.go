@@ -67,6 +67,19 @@ func (e errDumpInterrupted) Is(target error) bool {
 	return target == unix.EINTR
 }
 
+// MessageTruncatedError is returned when a datagram of Len bytes received
+// from the kernel did not fit in the receive buffer of BufferSize bytes.
+// The datagram is lost, the request can be executed again with a
+// NetlinkRequest.ReceiveBufferSize of at least Len.
+type MessageTruncatedError struct {
+	Len        int
+	BufferSize int
+}
+
+func (e *MessageTruncatedError) Error() string {
+	return fmt.Sprintf("netlink message of %d bytes truncated to the receive buffer of %d bytes", e.Len, e.BufferSize)
+}
+
 // GetIPFamily returns the family type of a net.IP.
 func GetIPFamily(ip net.IP) int {
 	if len(ip) <= net.IPv4len {
@@ -474,6 +487,20 @@ type NetlinkRequest struct {
 	Data    []NetlinkRequestData
 	RawData []byte
 	Sockets map[int]*SocketHandle
+	// DumpRetries is the number of times Execute re-issues a dump
+	// request whose response was interrupted (NLM_F_DUMP_INTR) before
+	// giving up and returning [ErrDumpInterrupted].
+	DumpRetries int
+	// Trace, when set, is called with the request and every message
+	// received in response to it.
+	Trace TraceFunc
+	// Stats, when set, accumulates counters about the request and its
+	// response.
+	Stats *Stats
+	// ReceiveBufferSize, when larger than RECEIVE_BUFFER_SIZE, is the size
+	// of the buffer the response is read into, for messages which don't
+	// fit in the default one, see MessageTruncatedError.
+	ReceiveBufferSize int
 }
 
 // Serialize the Netlink Request into a byte array
@@ -517,13 +544,21 @@ func (req *NetlinkRequest) AddRawData(data []byte) {
 // Returns a list of netlink messages in serialized format, optionally filtered
 // by resType.
 // If the returned error is [ErrDumpInterrupted], results may be inconsistent
-// or incomplete.
+// or incomplete. An interrupted dump is retried up to req.DumpRetries times
+// before that error is returned.
 func (req *NetlinkRequest) Execute(sockType int, resType uint16) ([][]byte, error) {
 	var res [][]byte
-	err := req.ExecuteIter(sockType, resType, func(msg []byte) bool {
-		res = append(res, msg)
-		return true
-	})
+	var err error
+	for i := 0; ; i++ {
+		res = nil
+		err = req.ExecuteIter(sockType, resType, func(msg []byte) bool {
+			res = append(res, msg)
+			return true
+		})
+		if !errors.Is(err, ErrDumpInterrupted) || i >= req.DumpRetries {
+			break
+		}
+	}
 	if err != nil && !errors.Is(err, ErrDumpInterrupted) {
 		return nil, err
 	}
@@ -541,46 +576,25 @@ func (req *NetlinkRequest) Execute(sockType int, resType uint16) ([][]byte, erro
 // it finishes iteration so the callback must not call back into
 // the netlink API.
 func (req *NetlinkRequest) ExecuteIter(sockType int, resType uint16, f func(msg []byte) bool) error {
-	var (
-		s   *NetlinkSocket
-		err error
-	)
-
-	if req.Sockets != nil {
-		if sh, ok := req.Sockets[sockType]; ok {
-			s = sh.Socket
-			req.Seq = atomic.AddUint32(&sh.Seq, 1)
-		}
+	s, sh, release, err := acquireSocket(req.Sockets, sockType)
+	if err != nil {
+		return err
 	}
-	sharedSocket := s != nil
-
-	if s == nil {
-		s, err = getNetlinkSocket(sockType)
-		if err != nil {
-			return err
-		}
-
-		if err := s.SetSendTimeout(&SocketTimeoutTv); err != nil {
-			return err
-		}
-		if err := s.SetReceiveTimeout(&SocketTimeoutTv); err != nil {
-			return err
-		}
-		if EnableErrorMessageReporting {
-			if err := s.SetExtAck(true); err != nil {
-				return err
-			}
-		}
-
-		defer s.Close()
-	} else {
-		s.Lock()
-		defer s.Unlock()
+	defer release()
+	sharedSocket := sh != nil
+	if sharedSocket {
+		req.Seq = atomic.AddUint32(&sh.Seq, 1)
 	}
 
-	if err := s.Send(req); err != nil {
+	serializedReq := req.Serialize()
+	if req.Trace != nil {
+		req.Trace(DirectionSend, sockType, serializedReq)
+	}
+	if err := s.sendBytes(serializedReq); err != nil {
 		return err
 	}
+	req.Stats.requestSent(req.Type, len(serializedReq))
+	dump := req.Flags&unix.NLM_F_DUMP == unix.NLM_F_DUMP
 
 	pid, err := s.GetPid()
 	if err != nil {
@@ -591,7 +605,7 @@ func (req *NetlinkRequest) ExecuteIter(sockType int, resType uint16, f func(msg
 
 done:
 	for {
-		msgs, from, err := s.Receive()
+		msgs, from, _, err := s.receive(nil, req.ReceiveBufferSize)
 		if err != nil {
 			return err
 		}
@@ -608,6 +622,10 @@ done:
 			if m.Header.Pid != pid {
 				continue
 			}
+			if req.Trace != nil {
+				traceMessage(req, sockType, &m)
+			}
+			req.Stats.messageReceived(&m, dump)
 
 			if m.Header.Flags&unix.NLM_F_DUMP_INTR != 0 {
 				dumpIntr = true
@@ -616,40 +634,15 @@ done:
 			if m.Header.Type == unix.NLMSG_DONE || m.Header.Type == unix.NLMSG_ERROR {
 				// NLMSG_DONE might have no payload, if so assume no error.
 				if m.Header.Type == unix.NLMSG_DONE && len(m.Data) == 0 {
+					req.Stats.ackReceived(nil)
 					break done
 				}
-
-				native := NativeEndian()
-				errno := int32(native.Uint32(m.Data[0:4]))
-				if errno == 0 {
-					break done
-				}
-				var err error
-				err = syscall.Errno(-errno)
-
-				unreadData := m.Data[4:]
-				if m.Header.Flags&unix.NLM_F_ACK_TLVS != 0 && len(unreadData) > syscall.SizeofNlMsghdr {
-					// Skip the echoed request message.
-					echoReqH := (*syscall.NlMsghdr)(unsafe.Pointer(&unreadData[0]))
-					unreadData = unreadData[nlmAlignOf(int(echoReqH.Len)):]
-
-					// Annotate `err` using nlmsgerr attributes.
-					for len(unreadData) >= syscall.SizeofRtAttr {
-						attr := (*syscall.RtAttr)(unsafe.Pointer(&unreadData[0]))
-						attrData := unreadData[syscall.SizeofRtAttr:attr.Len]
-
-						switch attr.Type {
-						case NLMSGERR_ATTR_MSG:
-							err = fmt.Errorf("%w: %s", err, unix.ByteSliceToString(attrData))
-						default:
-							// TODO: handle other NLMSGERR_ATTR types
-						}
-
-						unreadData = unreadData[rtaAlignOf(int(attr.Len)):]
-					}
+				err := ackError(&m)
+				req.Stats.ackReceived(err)
+				if err != nil {
+					return err
 				}
-
-				return err
+				break done
 			}
 			if resType != 0 && m.Header.Type != resType {
 				continue
@@ -674,6 +667,216 @@ func dummyMsgIterFunc(msg []byte) bool {
 	return true
 }
 
+// acquireSocket returns the socket of sockets for sockType, locked, or a new
+// one if there is none. sh is nil for a new socket. release unlocks or
+// closes the socket.
+func acquireSocket(sockets map[int]*SocketHandle, sockType int) (s *NetlinkSocket, sh *SocketHandle, release func(), err error) {
+	if sh, ok := sockets[sockType]; ok {
+		sh.Socket.Lock()
+		return sh.Socket, sh, sh.Socket.Unlock, nil
+	}
+
+	s, err = getNetlinkSocket(sockType)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if err := s.SetSendTimeout(&SocketTimeoutTv); err != nil {
+		s.Close()
+		return nil, nil, nil, err
+	}
+	if err := s.SetReceiveTimeout(&SocketTimeoutTv); err != nil {
+		s.Close()
+		return nil, nil, nil, err
+	}
+	if EnableErrorMessageReporting {
+		if err := s.SetExtAck(true); err != nil {
+			s.Close()
+			return nil, nil, nil, err
+		}
+	}
+	return s, nil, func() { s.Close() }, nil
+}
+
+// ackError returns the error carried by an NLMSG_ERROR or NLMSG_DONE
+// message, annotated with the extended ack message if any, or nil if the
+// message acknowledges success.
+func ackError(m *syscall.NetlinkMessage) error {
+	native := NativeEndian()
+	errno := int32(native.Uint32(m.Data[0:4]))
+	if errno == 0 {
+		return nil
+	}
+	var err error
+	err = syscall.Errno(-errno)
+
+	unreadData := m.Data[4:]
+	if m.Header.Flags&unix.NLM_F_ACK_TLVS != 0 && len(unreadData) > syscall.SizeofNlMsghdr {
+		// Skip the echoed request message.
+		echoReqH := (*syscall.NlMsghdr)(unsafe.Pointer(&unreadData[0]))
+		unreadData = unreadData[nlmAlignOf(int(echoReqH.Len)):]
+
+		// Annotate `err` using nlmsgerr attributes.
+		for len(unreadData) >= syscall.SizeofRtAttr {
+			attr := (*syscall.RtAttr)(unsafe.Pointer(&unreadData[0]))
+			attrData := unreadData[syscall.SizeofRtAttr:attr.Len]
+
+			switch attr.Type {
+			case NLMSGERR_ATTR_MSG:
+				err = fmt.Errorf("%w: %s", err, unix.ByteSliceToString(attrData))
+			default:
+				// TODO: handle other NLMSGERR_ATTR types
+			}
+
+			unreadData = unreadData[rtaAlignOf(int(attr.Len)):]
+		}
+	}
+	return err
+}
+
+// A single batch write is bounded well below the default socket send
+// buffer, and so that the acks, each taking around a kilobyte of the
+// receive buffer, do not overflow it before they are read.
+const (
+	maxBatchSize     = 32 * 1024
+	maxBatchMessages = 64
+)
+
+// ExecuteBatch sends reqs, which must not be dumps, against the given
+// sockType with as few writes as possible, instead of one round trip
+// each, and waits for the kernel to acknowledge every one of them. The
+// kernel processes the requests in order and carries on after a failed
+// one. results holds the error of each request, nil on success. err is
+// only set when the batch itself failed, in which case it is also the
+// result of every request not acknowledged yet, whether or not the kernel
+// processed it.
+//
+// The socket and trace function are taken from the first request.
+func ExecuteBatch(sockType int, reqs []*NetlinkRequest) (results []error, err error) {
+	return executeBatch(sockType, reqs, false)
+}
+
+// ExecuteBatchSingleAck works like ExecuteBatch but only asks the kernel
+// to acknowledge the last request of each write. The kernel still
+// reports the requests that failed, the others are known to have
+// succeeded once the final acknowledgement arrives. This halves the
+// messages to read for batches that mostly succeed and lets more
+// requests go in a single write.
+func ExecuteBatchSingleAck(sockType int, reqs []*NetlinkRequest) (results []error, err error) {
+	return executeBatch(sockType, reqs, true)
+}
+
+func executeBatch(sockType int, reqs []*NetlinkRequest, singleAck bool) (results []error, err error) {
+	results = make([]error, len(reqs))
+	acked := make([]bool, len(reqs))
+	defer func() {
+		if err == nil {
+			return
+		}
+		for i := range results {
+			if !acked[i] {
+				results[i] = err
+			}
+		}
+	}()
+	if len(reqs) == 0 {
+		return results, nil
+	}
+	s, sh, release, err := acquireSocket(reqs[0].Sockets, sockType)
+	if err != nil {
+		return results, err
+	}
+	defer release()
+	trace := reqs[0].Trace
+
+	pid, err := s.GetPid()
+	if err != nil {
+		return results, err
+	}
+
+	for len(reqs) > 0 {
+		var bufs [][]byte
+		size := 0
+		pending := make(map[uint32]int)
+		n := 0
+		for ; n < len(reqs); n++ {
+			req := reqs[n]
+			if singleAck {
+				req.Flags &^= unix.NLM_F_ACK
+			} else {
+				req.Flags |= unix.NLM_F_ACK
+			}
+			if sh != nil {
+				req.Seq = atomic.AddUint32(&sh.Seq, 1)
+			}
+			b := req.Serialize()
+			if !singleAck && n == maxBatchMessages || n > 0 && size+len(b) > maxBatchSize {
+				break
+			}
+			bufs = append(bufs, b)
+			size += len(b)
+			pending[req.Seq] = len(results) - len(reqs) + n
+		}
+		// only the last request of the write is acknowledged
+		lastSeq := reqs[n-1].Seq
+		if singleAck {
+			reqs[n-1].Flags |= unix.NLM_F_ACK
+			bufs[n-1] = reqs[n-1].Serialize()
+		}
+		buf := make([]byte, 0, size)
+		for i, b := range bufs {
+			if trace != nil {
+				trace(DirectionSend, sockType, b)
+			}
+			reqs[i].Stats.requestSent(reqs[i].Type, len(b))
+			buf = append(buf, b...)
+		}
+		if err := s.sendBytes(buf); err != nil {
+			return results, err
+		}
+
+		for len(pending) > 0 {
+			msgs, from, err := s.Receive()
+			if err != nil {
+				return results, err
+			}
+			if from.Pid != PidKernel {
+				return results, fmt.Errorf("Wrong sender portid %d, expected %d", from.Pid, PidKernel)
+			}
+			for _, m := range msgs {
+				i, ok := pending[m.Header.Seq]
+				if !ok {
+					if sh != nil {
+						continue
+					}
+					return results, fmt.Errorf("Wrong Seq nr %d, no such request in the batch", m.Header.Seq)
+				}
+				if m.Header.Pid != pid || m.Header.Type != unix.NLMSG_ERROR {
+					continue
+				}
+				if trace != nil {
+					trace(DirectionReceive, sockType, serializeMessage(&m))
+				}
+				stats := reqs[i-len(results)+len(reqs)].Stats
+				stats.messageReceived(&m, false)
+				results[i] = ackError(&m)
+				stats.ackReceived(results[i])
+				acked[i] = true
+				delete(pending, m.Header.Seq)
+				if singleAck && m.Header.Seq == lastSeq {
+					// the requests before were processed first, and
+					// reported only if they failed
+					for _, i := range pending {
+						acked[i] = true
+					}
+					pending = nil
+				}
+			}
+		}
+		reqs = reqs[n:]
+	}
+	return results, nil
+}
+
 // Create a new netlink request from proto and flags
 // Note the Len value will be inaccurate once data is added until
 // the message is serialized
@@ -843,6 +1046,10 @@ func (s *NetlinkSocket) GetTimeouts() (send, receive time.Duration) {
 }
 
 func (s *NetlinkSocket) Send(request *NetlinkRequest) error {
+	return s.sendBytes(request.Serialize())
+}
+
+func (s *NetlinkSocket) sendBytes(serializedReq []byte) error {
 	rawConn, err := s.file.SyscallConn()
 	if err != nil {
 		return err
@@ -858,7 +1065,6 @@ func (s *NetlinkSocket) Send(request *NetlinkRequest) error {
 	if err := s.file.SetWriteDeadline(deadline); err != nil {
 		return err
 	}
-	serializedReq := request.Serialize()
 	err = rawConn.Write(func(fd uintptr) (done bool) {
 		innerErr = unix.Sendto(int(s.fd), serializedReq, 0, &s.lsa)
 		return innerErr != unix.EWOULDBLOCK
@@ -878,55 +1084,101 @@ func (s *NetlinkSocket) Send(request *NetlinkRequest) error {
 }
 
 func (s *NetlinkSocket) Receive() ([]syscall.NetlinkMessage, *unix.SockaddrNetlink, error) {
+	msgs, from, _, err := s.receive(nil, 0)
+	return msgs, from, err
+}
+
+// ReceiveNsid works like Receive and also returns the id of the network
+// namespace the messages were sent from, as reported on sockets listening
+// to all namespaces (see SetListenAllNsid). The id is -1 when the kernel
+// did not tag the messages, which is the case for messages from the
+// socket's own namespace.
+func (s *NetlinkSocket) ReceiveNsid() ([]syscall.NetlinkMessage, *unix.SockaddrNetlink, int, error) {
+	return s.receive(make([]byte, unix.CmsgSpace(4)), 0)
+}
+
+// receive reads a datagram into a buffer of RECEIVE_BUFFER_SIZE bytes, or
+// of size bytes if it is larger.
+func (s *NetlinkSocket) receive(oob []byte, size int) ([]syscall.NetlinkMessage, *unix.SockaddrNetlink, int, error) {
 	rawConn, err := s.file.SyscallConn()
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, -1, err
 	}
 	var (
 		deadline time.Time
 		fromAddr *unix.SockaddrNetlink
 		rb       [RECEIVE_BUFFER_SIZE]byte
 		nr       int
+		oobn     int
 		from     unix.Sockaddr
 		innerErr error
 	)
+	buf := rb[:]
+	if size > len(buf) {
+		buf = make([]byte, nlmAlignOf(size))
+	}
 	receiveTimeout := atomic.LoadInt64(&s.receiveTimeout)
 	if receiveTimeout != 0 {
 		deadline = time.Now().Add(time.Duration(receiveTimeout))
 	}
 	if err := s.file.SetReadDeadline(deadline); err != nil {
-		return nil, nil, err
+		return nil, nil, -1, err
 	}
 	err = rawConn.Read(func(fd uintptr) (done bool) {
-		nr, from, innerErr = unix.Recvfrom(int(fd), rb[:], 0)
+		// with MSG_TRUNC, nr is the length of the datagram even if it
+		// did not fit in buf
+		if oob == nil {
+			nr, from, innerErr = unix.Recvfrom(int(fd), buf, unix.MSG_TRUNC)
+		} else {
+			nr, oobn, _, from, innerErr = unix.Recvmsg(int(fd), buf, oob, unix.MSG_TRUNC)
+		}
 		return innerErr != unix.EWOULDBLOCK
 	})
 	if innerErr != nil {
-		return nil, nil, innerErr
+		return nil, nil, -1, innerErr
 	}
 	if err != nil {
 		// The timeout was previously implemented using SO_RCVTIMEO on a blocking
 		// socket. So, continue to return EAGAIN when the timeout is reached.
 		if errors.Is(err, os.ErrDeadlineExceeded) {
-			return nil, nil, unix.EAGAIN
+			return nil, nil, -1, unix.EAGAIN
 		}
-		return nil, nil, err
+		return nil, nil, -1, err
 	}
 	fromAddr, ok := from.(*unix.SockaddrNetlink)
 	if !ok {
-		return nil, nil, fmt.Errorf("Error converting to netlink sockaddr")
+		return nil, nil, -1, fmt.Errorf("Error converting to netlink sockaddr")
 	}
 	if nr < unix.NLMSG_HDRLEN {
-		return nil, nil, fmt.Errorf("Got short response from netlink")
+		return nil, nil, -1, fmt.Errorf("Got short response from netlink")
+	}
+	if nr > len(buf) {
+		return nil, nil, -1, &MessageTruncatedError{Len: nr, BufferSize: len(buf)}
 	}
 	msgLen := nlmAlignOf(nr)
 	rb2 := make([]byte, msgLen)
-	copy(rb2, rb[:msgLen])
+	copy(rb2, buf[:msgLen])
 	nl, err := syscall.ParseNetlinkMessage(rb2)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, -1, err
 	}
-	return nl, fromAddr, nil
+	return nl, fromAddr, parseNsidCmsg(oob[:oobn]), nil
+}
+
+func parseNsidCmsg(oob []byte) int {
+	if len(oob) == 0 {
+		return -1
+	}
+	cmsgs, err := unix.ParseSocketControlMessage(oob)
+	if err != nil {
+		return -1
+	}
+	for _, c := range cmsgs {
+		if c.Header.Level == unix.SOL_NETLINK && c.Header.Type == unix.NETLINK_LISTEN_ALL_NSID && len(c.Data) >= 4 {
+			return int(int32(NativeEndian().Uint32(c.Data)))
+		}
+	}
+	return -1
 }
 
 // SetSendTimeout allows to set a send timeout on the socket
@@ -950,6 +1202,18 @@ func (s *NetlinkSocket) SetReceiveBufferSize(size int, force bool) error {
 	return unix.SetsockoptInt(int(s.fd), unix.SOL_SOCKET, opt, size)
 }
 
+// SetListenAllNsid makes the socket receive the notifications of the groups
+// it joined from every network namespace that has an id in the socket's
+// namespace. Use ReceiveNsid to learn where a notification comes from.
+func (s *NetlinkSocket) SetListenAllNsid(enable bool) error {
+	var enableN int
+	if enable {
+		enableN = 1
+	}
+
+	return unix.SetsockoptInt(int(s.fd), unix.SOL_NETLINK, unix.NETLINK_LISTEN_ALL_NSID, enableN)
+}
+
 // SetExtAck requests error messages to be reported on the socket
 func (s *NetlinkSocket) SetExtAck(enable bool) error {
 	var enableN int
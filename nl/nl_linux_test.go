@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"crypto/rand"
 	"encoding/binary"
+	"errors"
 	"reflect"
 	"testing"
 	"time"
@@ -97,6 +98,46 @@ func TestIfSocketCloses(t *testing.T) {
 	}
 }
 
+func TestExecuteBatch(t *testing.T) {
+	testExecuteBatch(t, ExecuteBatch)
+}
+
+func TestExecuteBatchSingleAck(t *testing.T) {
+	testExecuteBatch(t, ExecuteBatchSingleAck)
+}
+
+func testExecuteBatch(t *testing.T, execute func(int, []*NetlinkRequest) ([]error, error)) {
+	// enough requests to need several writes
+	n := 3*maxBatchMessages + 1
+	reqs := make([]*NetlinkRequest, n)
+	for i := range reqs {
+		reqs[i] = NewNetlinkRequest(unix.RTM_GETLINK, 0)
+		msg := NewIfInfomsg(unix.AF_UNSPEC)
+		// every 10th request asks for lo, the others for a missing link
+		msg.Index = 0x7fffffff
+		if i%10 == 0 {
+			msg.Index = 1
+		}
+		reqs[i].AddData(msg)
+	}
+	results, err := execute(unix.NETLINK_ROUTE, reqs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != n {
+		t.Fatalf("expected %d results, got %d", n, len(results))
+	}
+	for i, result := range results {
+		if i%10 == 0 {
+			if result != nil {
+				t.Fatalf("request %d failed: %v", i, result)
+			}
+		} else if !errors.Is(result, unix.ENODEV) {
+			t.Fatalf("expected ENODEV for request %d, got %v", i, result)
+		}
+	}
+}
+
 func TestReceiveTimeout(t *testing.T) {
 	nlSock, err := getNetlinkSocket(unix.NETLINK_ROUTE)
 	if err != nil {
@@ -0,0 +1,78 @@
+package nl
+
+import "unsafe"
+
+// Attributes of RTM_NEWPREFIX messages
+const (
+	PREFIX_UNSPEC = iota
+	PREFIX_ADDRESS
+	PREFIX_CACHEINFO
+	PREFIX_MAX = PREFIX_CACHEINFO
+)
+
+// Flags of the prefix information option, in PrefixMsg.Flags
+const (
+	IF_PREFIX_ONLINK   = 0x01
+	IF_PREFIX_AUTOCONF = 0x02
+)
+
+const (
+	SizeofPrefixMsg       = 0x0c
+	SizeofPrefixCacheinfo = 0x08
+)
+
+// struct prefixmsg {
+//   unsigned char  prefix_family;
+//   unsigned char  prefix_pad1;
+//   unsigned short prefix_pad2;
+//   int            prefix_ifindex;
+//   unsigned char  prefix_type;
+//   unsigned char  prefix_len;
+//   unsigned char  prefix_flags;
+//   unsigned char  prefix_pad3;
+// };
+
+type PrefixMsg struct {
+	Family    uint8
+	Pad1      uint8
+	Pad2      uint16
+	Ifindex   int32
+	Type      uint8
+	Prefixlen uint8
+	Flags     uint8
+	Pad3      uint8
+}
+
+func (msg *PrefixMsg) Len() int {
+	return SizeofPrefixMsg
+}
+
+func DeserializePrefixMsg(b []byte) *PrefixMsg {
+	return (*PrefixMsg)(unsafe.Pointer(&b[0:SizeofPrefixMsg][0]))
+}
+
+func (msg *PrefixMsg) Serialize() []byte {
+	return (*(*[SizeofPrefixMsg]byte)(unsafe.Pointer(msg)))[:]
+}
+
+// struct prefix_cacheinfo {
+//   __u32 preferred_time;
+//   __u32 valid_time;
+// };
+
+type PrefixCacheinfo struct {
+	PreferredTime uint32
+	ValidTime     uint32
+}
+
+func (msg *PrefixCacheinfo) Len() int {
+	return SizeofPrefixCacheinfo
+}
+
+func DeserializePrefixCacheinfo(b []byte) *PrefixCacheinfo {
+	return (*PrefixCacheinfo)(unsafe.Pointer(&b[0:SizeofPrefixCacheinfo][0]))
+}
+
+func (msg *PrefixCacheinfo) Serialize() []byte {
+	return (*(*[SizeofPrefixCacheinfo]byte)(unsafe.Pointer(msg)))[:]
+}
@@ -6,6 +6,9 @@ import (
 	"golang.org/x/sys/unix"
 )
 
+// RTA_NH_ID is missing from golang.org/x/sys/unix.
+const RTA_NH_ID = 0x1e
+
 type RtMsg struct {
 	unix.RtMsg
 }
@@ -0,0 +1,15 @@
+package nl
+
+// from include/uapi/linux/rpl_iptunnel.h
+const (
+	RPL_IPTUNNEL_UNSPEC = iota
+	RPL_IPTUNNEL_SRH
+	__RPL_IPTUNNEL_MAX
+)
+
+const (
+	RPL_IPTUNNEL_MAX = __RPL_IPTUNNEL_MAX - 1
+)
+
+// size of struct ipv6_rpl_sr_hdr without the segments
+const SizeofRplSrHdr = 8
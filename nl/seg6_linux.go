@@ -1,6 +1,7 @@
 package nl
 
 import (
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"net"
@@ -40,6 +41,23 @@ func (s1 *IPv6SrHdr) Equal(s2 IPv6SrHdr) bool {
 const (
 	SEG6_IPTUN_MODE_INLINE = iota
 	SEG6_IPTUN_MODE_ENCAP
+	SEG6_IPTUN_MODE_L2ENCAP
+	SEG6_IPTUN_MODE_ENCAP_RED
+	SEG6_IPTUN_MODE_L2ENCAP_RED
+)
+
+// srh flags and TLVs
+// from include/uapi/linux/seg6.h and include/uapi/linux/seg6_hmac.h
+const (
+	SR6_FLAG1_PROTECTED = 1 << 6
+	SR6_FLAG1_OAM       = 1 << 5
+	SR6_FLAG1_ALERT     = 1 << 4
+	SR6_FLAG1_HMAC      = 1 << 3
+
+	SR6_TLV_HMAC        = 5
+	SEG6_HMAC_FIELD_LEN = 32
+	// size of struct sr6_tlv_hmac
+	SizeofSr6TlvHmac = 8 + SEG6_HMAC_FIELD_LEN
 )
 
 // number of nested RTATTR
@@ -54,28 +72,60 @@ const (
 )
 
 func EncodeSEG6Encap(mode int, segments []net.IP) ([]byte, error) {
+	return EncodeSEG6EncapHmac(mode, segments, 0)
+}
+
+// EncodeSEG6EncapHmac works like EncodeSEG6Encap, additionally appending
+// an HMAC TLV for the key hmac when it is not 0. The kernel computes the
+// HMAC itself with the key configured for that id.
+func EncodeSEG6EncapHmac(mode int, segments []net.IP, hmac uint32) ([]byte, error) {
 	nsegs := len(segments) // nsegs: number of segments
 	if nsegs == 0 {
 		return nil, errors.New("EncodeSEG6Encap: No Segment in srh")
 	}
-	b := make([]byte, 12, 12+len(segments)*16)
+	srhlen := 16 * nsegs
+	if hmac != 0 {
+		srhlen += SizeofSr6TlvHmac
+	}
+	b := make([]byte, 12, 12+srhlen)
 	native := NativeEndian()
 	native.PutUint32(b, uint32(mode))
-	b[4] = 0                      // srh.nextHdr (0 when calling netlink)
-	b[5] = uint8(16 * nsegs >> 3) // srh.hdrLen (in 8-octets unit)
-	b[6] = IPV6_SRCRT_TYPE_4      // srh.routingType (assigned by IANA)
-	b[7] = uint8(nsegs - 1)       // srh.segmentsLeft
-	b[8] = uint8(nsegs - 1)       // srh.firstSegment
-	b[9] = 0                      // srh.flags (SR6_FLAG1_HMAC for srh_hmac)
+	b[4] = 0                  // srh.nextHdr (0 when calling netlink)
+	b[5] = uint8(srhlen >> 3) // srh.hdrLen (in 8-octets unit)
+	b[6] = IPV6_SRCRT_TYPE_4  // srh.routingType (assigned by IANA)
+	b[7] = uint8(nsegs - 1)   // srh.segmentsLeft
+	b[8] = uint8(nsegs - 1)   // srh.firstSegment
+	b[9] = 0                  // srh.flags (SR6_FLAG1_HMAC for srh_hmac)
+	if hmac != 0 {
+		b[9] = SR6_FLAG1_HMAC
+	}
 	// srh.reserved: Defined as "Tag" in draft-ietf-6man-segment-routing-header-07
 	native.PutUint16(b[10:], 0) // srh.reserved
 	for _, netIP := range segments {
 		b = append(b, netIP...) // srh.Segments
 	}
+	if hmac != 0 {
+		tlv := make([]byte, SizeofSr6TlvHmac)
+		tlv[0] = SR6_TLV_HMAC
+		tlv[1] = SizeofSr6TlvHmac - 2
+		// tlv[2:4] is reserved, the key id is in network byte order
+		binary.BigEndian.PutUint32(tlv[4:], hmac)
+		b = append(b, tlv...)
+	}
 	return b, nil
 }
 
 func DecodeSEG6Encap(buf []byte) (int, []net.IP, error) {
+	mode, segments, _, err := DecodeSEG6EncapHmac(buf)
+	return mode, segments, err
+}
+
+// DecodeSEG6EncapHmac works like DecodeSEG6Encap, additionally returning
+// the key id of the HMAC TLV, or 0 if there is none.
+func DecodeSEG6EncapHmac(buf []byte) (int, []net.IP, uint32, error) {
+	if len(buf) < 12 {
+		return 0, nil, 0, fmt.Errorf("DecodeSEG6Encap: lack of bytes (buf len: %d)", len(buf))
+	}
 	native := NativeEndian()
 	mode := int(native.Uint32(buf))
 	srh := IPv6SrHdr{
@@ -88,15 +138,31 @@ func DecodeSEG6Encap(buf []byte) (int, []net.IP, error) {
 		reserved:     native.Uint16(buf[10:12]),
 	}
 	buf = buf[12:]
-	if len(buf)%16 != 0 {
+	nsegs := int(srh.firstSegment) + 1
+	if len(buf) < nsegs*16 {
 		err := fmt.Errorf("DecodeSEG6Encap: error parsing Segment List (buf len: %d)", len(buf))
-		return mode, nil, err
+		return mode, nil, 0, err
 	}
-	for len(buf) > 0 {
+	for i := 0; i < nsegs; i++ {
 		srh.Segments = append(srh.Segments, net.IP(buf[:16]))
 		buf = buf[16:]
 	}
-	return mode, srh.Segments, nil
+	var hmac uint32
+	// the remaining bytes are TLVs, a Pad1 TLV is a single byte
+	for len(buf) > 0 {
+		if buf[0] == 0 {
+			buf = buf[1:]
+			continue
+		}
+		if len(buf) < 2 || len(buf) < int(buf[1])+2 {
+			return mode, srh.Segments, 0, fmt.Errorf("DecodeSEG6Encap: error parsing TLVs (buf len: %d)", len(buf))
+		}
+		if buf[0] == SR6_TLV_HMAC && int(buf[1])+2 >= 8 {
+			hmac = binary.BigEndian.Uint32(buf[4:8])
+		}
+		buf = buf[int(buf[1])+2:]
+	}
+	return mode, srh.Segments, hmac, nil
 }
 
 func DecodeSEG6Srh(buf []byte) ([]net.IP, error) {
@@ -149,6 +215,12 @@ func SEG6EncapModeString(mode int) string {
 		return "inline"
 	case SEG6_IPTUN_MODE_ENCAP:
 		return "encap"
+	case SEG6_IPTUN_MODE_L2ENCAP:
+		return "l2encap"
+	case SEG6_IPTUN_MODE_ENCAP_RED:
+		return "encap.red"
+	case SEG6_IPTUN_MODE_L2ENCAP_RED:
+		return "l2encap.red"
 	}
 	return "unknown"
 }
@@ -0,0 +1,124 @@
+package nl
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// Stats accumulates counters about the requests executed with it set as
+// their Stats. It is safe for concurrent use and its zero value is ready
+// to use. A nil *Stats counts nothing.
+type Stats struct {
+	requests      sync.Map // uint16 -> *atomic.Uint64
+	errors        sync.Map // syscall.Errno -> *atomic.Uint64
+	acks          atomic.Uint64
+	bytesSent     atomic.Uint64
+	bytesReceived atomic.Uint64
+	dumpMessages  atomic.Uint64
+}
+
+// StatsSnapshot is a copy of the counters of a Stats at one point in time.
+type StatsSnapshot struct {
+	// Requests counts the requests sent, by netlink message type
+	Requests map[uint16]uint64
+	// Acks counts the requests completed without an error, either
+	// acknowledged or at the end of a dump
+	Acks uint64
+	// Errors counts the requests answered with an error, by errno
+	Errors        map[syscall.Errno]uint64
+	BytesSent     uint64
+	BytesReceived uint64
+	// DumpMessages counts the messages received in response to dumps
+	DumpMessages uint64
+}
+
+// Snapshot returns the current value of the counters.
+func (s *Stats) Snapshot() StatsSnapshot {
+	snap := StatsSnapshot{
+		Requests: make(map[uint16]uint64),
+		Errors:   make(map[syscall.Errno]uint64),
+	}
+	if s == nil {
+		return snap
+	}
+	s.requests.Range(func(k, v any) bool {
+		if n := v.(*atomic.Uint64).Load(); n > 0 {
+			snap.Requests[k.(uint16)] = n
+		}
+		return true
+	})
+	s.errors.Range(func(k, v any) bool {
+		if n := v.(*atomic.Uint64).Load(); n > 0 {
+			snap.Errors[k.(syscall.Errno)] = n
+		}
+		return true
+	})
+	snap.Acks = s.acks.Load()
+	snap.BytesSent = s.bytesSent.Load()
+	snap.BytesReceived = s.bytesReceived.Load()
+	snap.DumpMessages = s.dumpMessages.Load()
+	return snap
+}
+
+// Reset sets all the counters back to zero.
+func (s *Stats) Reset() {
+	if s == nil {
+		return
+	}
+	reset := func(_, v any) bool {
+		v.(*atomic.Uint64).Store(0)
+		return true
+	}
+	s.requests.Range(reset)
+	s.errors.Range(reset)
+	s.acks.Store(0)
+	s.bytesSent.Store(0)
+	s.bytesReceived.Store(0)
+	s.dumpMessages.Store(0)
+}
+
+func statsCounter(m *sync.Map, key any) *atomic.Uint64 {
+	if v, ok := m.Load(key); ok {
+		return v.(*atomic.Uint64)
+	}
+	v, _ := m.LoadOrStore(key, new(atomic.Uint64))
+	return v.(*atomic.Uint64)
+}
+
+func (s *Stats) requestSent(msgType uint16, size int) {
+	if s == nil {
+		return
+	}
+	statsCounter(&s.requests, msgType).Add(1)
+	s.bytesSent.Add(uint64(size))
+}
+
+func (s *Stats) messageReceived(m *syscall.NetlinkMessage, dump bool) {
+	if s == nil {
+		return
+	}
+	s.bytesReceived.Add(uint64(m.Header.Len))
+	if dump && m.Header.Type != unix.NLMSG_DONE && m.Header.Type != unix.NLMSG_ERROR {
+		s.dumpMessages.Add(1)
+	}
+}
+
+// ackReceived counts the outcome of a request, err being the error the
+// kernel answered it with.
+func (s *Stats) ackReceived(err error) {
+	if s == nil {
+		return
+	}
+	if err == nil {
+		s.acks.Add(1)
+		return
+	}
+	var errno syscall.Errno
+	if errors.As(err, &errno) {
+		statsCounter(&s.errors, errno).Add(1)
+	}
+}
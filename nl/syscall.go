@@ -66,6 +66,9 @@ const (
 	LWTUNNEL_ENCAP_SEG6
 	LWTUNNEL_ENCAP_BPF
 	LWTUNNEL_ENCAP_SEG6_LOCAL
+	LWTUNNEL_ENCAP_RPL
+	LWTUNNEL_ENCAP_IOAM6
+	LWTUNNEL_ENCAP_XFRM
 )
 
 // routing header types
@@ -73,5 +76,6 @@ const (
 	IPV6_SRCRT_STRICT = 0x01 // Deprecated; will be removed
 	IPV6_SRCRT_TYPE_0 = 0    // Deprecated; will be removed
 	IPV6_SRCRT_TYPE_2 = 2    // IPv6 type 2 Routing Header
+	IPV6_SRCRT_TYPE_3 = 3    // RPL Segment Routing with IPv6
 	IPV6_SRCRT_TYPE_4 = 4    // Segment Routing with IPv6
 )
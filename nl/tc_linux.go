@@ -77,6 +77,15 @@ const (
 	TCA_ACT_MAX
 )
 
+// TC_COOKIE_MAX_SIZE is the maximum length of a TCA_ACT_COOKIE.
+const TC_COOKIE_MAX_SIZE = 16
+
+const (
+	TCA_ACT_HW_STATS_IMMEDIATE = 1 << 0
+	TCA_ACT_HW_STATS_DELAYED   = 1 << 1
+	TCA_ACT_HW_STATS_ANY       = TCA_ACT_HW_STATS_IMMEDIATE | TCA_ACT_HW_STATS_DELAYED
+)
+
 const (
 	TCA_ACT_SAMPLE_UNSPEC = iota
 	TCA_ACT_SAMPLE_TM
@@ -129,11 +138,16 @@ const (
 	SizeofTcVlan         = SizeofTcGen + 0x04
 	SizeofTcTunnelKey    = SizeofTcGen + 0x04
 	SizeofTcSkbEdit      = SizeofTcGen
+	SizeofTcMpls         = SizeofTcGen + 0x04
+	SizeofTcGate         = SizeofTcGen
+	SizeofTcCtInfo       = SizeofTcGen
 	SizeofTcPolice       = 2*SizeofTcRateSpec + 0x20
 	SizeofTcSfqQopt      = 0x0b
 	SizeofTcSfqRedStats  = 0x18
 	SizeofTcSfqQoptV1    = SizeofTcSfqQopt + SizeofTcSfqRedStats + 0x1c
 	SizeofUint32Bitfield = 0x8
+	SizeofTcPlugQopt     = 0x08
+	SizeofTcFqQdStats    = 0x68
 )
 
 // struct tcmsg {
@@ -146,6 +160,10 @@ const (
 //   __u32   tcm_info;
 // };
 
+// TCM_IFINDEX_MAGIC_BLOCK in tcm_ifindex selects a shared filter block,
+// whose index is then carried in tcm_parent.
+const TCM_IFINDEX_MAGIC_BLOCK = 0xFFFFFFFF
+
 type TcMsg struct {
 	Family  uint8
 	Pad     [3]byte
@@ -206,6 +224,11 @@ const (
 	TC_PRIO_MAX = 15
 )
 
+const (
+	TCQ_PRIO_BANDS     = 16
+	TCQ_MIN_PRIO_BANDS = 2
+)
+
 // struct tc_prio_qopt {
 // 	int bands;      /* Number of bands */
 // 	__u8  priomap[TC_PRIO_MAX+1]; /* Map: logical priority -> PRIO band */
@@ -442,7 +465,9 @@ const (
 	TCA_HTB_DIRECT_QLEN
 	TCA_HTB_RATE64
 	TCA_HTB_CEIL64
-	TCA_HTB_MAX = TCA_HTB_CEIL64
+	TCA_HTB_PAD
+	TCA_HTB_OFFLOAD
+	TCA_HTB_MAX = TCA_HTB_OFFLOAD
 )
 
 //struct tc_htb_opt {
@@ -560,7 +585,9 @@ const (
 	TCA_U32_INDEV
 	TCA_U32_PCNT
 	TCA_U32_MARK
-	TCA_U32_MAX = TCA_U32_MARK
+	TCA_U32_FLAGS
+	TCA_U32_PAD
+	TCA_U32_MAX = TCA_U32_PAD
 )
 
 // struct tc_u32_key {
@@ -898,6 +925,100 @@ func (x *TcTunnelKey) Serialize() []byte {
 	return (*(*[SizeofTcTunnelKey]byte)(unsafe.Pointer(x)))[:]
 }
 
+const (
+	TCA_MPLS_UNSPEC = iota
+	TCA_MPLS_TM
+	TCA_MPLS_PARMS
+	TCA_MPLS_PAD
+	TCA_MPLS_PROTO
+	TCA_MPLS_LABEL
+	TCA_MPLS_TC
+	TCA_MPLS_TTL
+	TCA_MPLS_BOS
+	TCA_MPLS_MAX = TCA_MPLS_BOS
+)
+
+const (
+	TCA_MPLS_ACT_POP = iota + 1
+	TCA_MPLS_ACT_PUSH
+	TCA_MPLS_ACT_MODIFY
+	TCA_MPLS_ACT_DEC_TTL
+	TCA_MPLS_ACT_MAC_PUSH
+)
+
+//struct tc_mpls {
+//	tc_gen;
+//	int m_action;
+//};
+
+type TcMpls struct {
+	TcGen
+	MAction int32
+}
+
+func (x *TcMpls) Len() int {
+	return SizeofTcMpls
+}
+
+func DeserializeTcMpls(b []byte) *TcMpls {
+	return (*TcMpls)(unsafe.Pointer(&b[0:SizeofTcMpls][0]))
+}
+
+func (x *TcMpls) Serialize() []byte {
+	return (*(*[SizeofTcMpls]byte)(unsafe.Pointer(x)))[:]
+}
+
+const (
+	TCA_GATE_ENTRY_UNSPEC = iota
+	TCA_GATE_ENTRY_INDEX
+	TCA_GATE_ENTRY_GATE
+	TCA_GATE_ENTRY_INTERVAL
+	TCA_GATE_ENTRY_IPV
+	TCA_GATE_ENTRY_MAX_OCTETS
+	TCA_GATE_ENTRY_MAX = TCA_GATE_ENTRY_MAX_OCTETS
+)
+
+const (
+	TCA_GATE_ONE_ENTRY_UNSPEC = iota
+	TCA_GATE_ONE_ENTRY
+	TCA_GATE_ONE_ENTRY_MAX = TCA_GATE_ONE_ENTRY
+)
+
+const (
+	TCA_GATE_UNSPEC = iota
+	TCA_GATE_TM
+	TCA_GATE_PARMS
+	TCA_GATE_PAD
+	TCA_GATE_PRIORITY
+	TCA_GATE_ENTRY_LIST
+	TCA_GATE_BASE_TIME
+	TCA_GATE_CYCLE_TIME
+	TCA_GATE_CYCLE_TIME_EXT
+	TCA_GATE_FLAGS
+	TCA_GATE_CLOCKID
+	TCA_GATE_MAX = TCA_GATE_CLOCKID
+)
+
+//struct tc_gate {
+//	tc_gen;
+//};
+
+type TcGate struct {
+	TcGen
+}
+
+func (x *TcGate) Len() int {
+	return SizeofTcGate
+}
+
+func DeserializeTcGate(b []byte) *TcGate {
+	return (*TcGate)(unsafe.Pointer(&b[0:SizeofTcGate][0]))
+}
+
+func (x *TcGate) Serialize() []byte {
+	return (*(*[SizeofTcGate]byte)(unsafe.Pointer(x)))[:]
+}
+
 const (
 	TCA_SKBEDIT_UNSPEC = iota
 	TCA_SKBEDIT_TM
@@ -927,6 +1048,76 @@ func (x *TcSkbEdit) Serialize() []byte {
 	return (*(*[SizeofTcSkbEdit]byte)(unsafe.Pointer(x)))[:]
 }
 
+const (
+	TCA_SKBMOD_UNSPEC = iota
+	TCA_SKBMOD_TM
+	TCA_SKBMOD_PARMS
+	TCA_SKBMOD_DMAC
+	TCA_SKBMOD_SMAC
+	TCA_SKBMOD_ETYPE
+	TCA_SKBMOD_PAD
+	TCA_SKBMOD_MAX = TCA_SKBMOD_PAD
+)
+
+//struct tc_skbmod {
+//	tc_gen;
+//	__u64 flags;
+//};
+
+type TcSkbMod struct {
+	TcGen
+	Flags uint64
+}
+
+func (x *TcSkbMod) Len() int {
+	return int(unsafe.Sizeof(*x))
+}
+
+func DeserializeTcSkbMod(b []byte) *TcSkbMod {
+	const size = int(unsafe.Sizeof(TcSkbMod{}))
+	return (*TcSkbMod)(unsafe.Pointer(&b[0:size][0]))
+}
+
+func (x *TcSkbMod) Serialize() []byte {
+	const size = int(unsafe.Sizeof(TcSkbMod{}))
+	return (*(*[size]byte)(unsafe.Pointer(x)))[:]
+}
+
+const (
+	TCA_CTINFO_UNSPEC = iota
+	TCA_CTINFO_PAD
+	TCA_CTINFO_TM
+	TCA_CTINFO_ACT
+	TCA_CTINFO_ZONE
+	TCA_CTINFO_PARMS_DSCP_MASK
+	TCA_CTINFO_PARMS_DSCP_STATEMASK
+	TCA_CTINFO_PARMS_CPMARK_MASK
+	TCA_CTINFO_STATS_DSCP_SET
+	TCA_CTINFO_STATS_DSCP_ERROR
+	TCA_CTINFO_STATS_CPMARK_SET
+	TCA_CTINFO_MAX = TCA_CTINFO_STATS_CPMARK_SET
+)
+
+//struct tc_ctinfo {
+//	tc_gen;
+//};
+
+type TcCtInfo struct {
+	TcGen
+}
+
+func (x *TcCtInfo) Len() int {
+	return SizeofTcCtInfo
+}
+
+func DeserializeTcCtInfo(b []byte) *TcCtInfo {
+	return (*TcCtInfo)(unsafe.Pointer(&b[0:SizeofTcCtInfo][0]))
+}
+
+func (x *TcCtInfo) Serialize() []byte {
+	return (*(*[SizeofTcCtInfo]byte)(unsafe.Pointer(x)))[:]
+}
+
 // struct tc_police {
 // 	__u32			index;
 // 	int			action;
@@ -980,6 +1171,8 @@ const (
 	TCA_MATCHALL_CLASSID
 	TCA_MATCHALL_ACT
 	TCA_MATCHALL_FLAGS
+	TCA_MATCHALL_PCNT
+	TCA_MATCHALL_PAD
 )
 
 const (
@@ -1021,6 +1214,41 @@ const (
 	TCA_HFSC_USC
 )
 
+const (
+	TCA_QFQ_UNSPEC = iota
+	TCA_QFQ_WEIGHT
+	TCA_QFQ_LMAX
+)
+
+const (
+	TCQ_PLUG_BUFFER = iota
+	TCQ_PLUG_RELEASE_ONE
+	TCQ_PLUG_RELEASE_INDEFINITE
+	TCQ_PLUG_LIMIT
+)
+
+// struct tc_plug_qopt {
+//   int action;
+//   __u32 limit;
+// };
+
+type TcPlugQopt struct {
+	Action int32
+	Limit  uint32
+}
+
+func (x *TcPlugQopt) Len() int {
+	return SizeofTcPlugQopt
+}
+
+func DeserializeTcPlugQopt(b []byte) *TcPlugQopt {
+	return (*TcPlugQopt)(unsafe.Pointer(&b[0:SizeofTcPlugQopt][0]))
+}
+
+func (x *TcPlugQopt) Serialize() []byte {
+	return (*(*[SizeofTcPlugQopt]byte)(unsafe.Pointer(x)))[:]
+}
+
 const (
 	TCA_FLOWER_UNSPEC = iota
 	TCA_FLOWER_CLASSID
@@ -1133,8 +1361,11 @@ const (
 	__TCA_FLOWER_MAX
 )
 
-const TCA_CLS_FLAGS_SKIP_HW = 1 << 0 /* don't offload filter to HW */
-const TCA_CLS_FLAGS_SKIP_SW = 1 << 1 /* don't use filter in SW */
+const TCA_CLS_FLAGS_SKIP_HW = 1 << 0   /* don't offload filter to HW */
+const TCA_CLS_FLAGS_SKIP_SW = 1 << 1   /* don't use filter in SW */
+const TCA_CLS_FLAGS_IN_HW = 1 << 2     /* filter is offloaded to HW */
+const TCA_CLS_FLAGS_NOT_IN_HW = 1 << 3 /* filter isn't offloaded to HW */
+const TCA_CLS_FLAGS_VERBOSE = 1 << 4   /* verbose logging */
 
 // struct tc_sfq_qopt {
 // 	unsigned	quantum;	/* Bytes per round allocated to flow */
@@ -1240,6 +1471,58 @@ func (x *TcSfqQoptV1) Serialize() []byte {
 	return (*(*[SizeofTcSfqQoptV1]byte)(unsafe.Pointer(x)))[:]
 }
 
+// struct tc_fq_qd_stats {
+//   __u64 gc_flows;
+//   __u64 highprio_packets;
+//   __u64 tcp_retrans;
+//   __u64 throttled;
+//   __u64 flows_plimit;
+//   __u64 pkts_too_long;
+//   __u64 allocation_errors;
+//   __s64 time_next_delayed_flow;
+//   __u32 flows;
+//   __u32 inactive_flows;
+//   __u32 throttled_flows;
+//   __u32 unthrottle_latency_ns;
+//   __u64 ce_mark;
+//   __u64 horizon_drops;
+//   __u64 horizon_caps;
+// };
+
+type TcFqQdStats struct {
+	GcFlows             uint64
+	HighprioPackets     uint64
+	TcpRetrans          uint64
+	Throttled           uint64
+	FlowsPlimit         uint64
+	PktsTooLong         uint64
+	AllocationErrors    uint64
+	TimeNextDelayedFlow int64
+	Flows               uint32
+	InactiveFlows       uint32
+	ThrottledFlows      uint32
+	UnthrottleLatencyNs uint32
+	CeMark              uint64
+	HorizonDrops        uint64
+	HorizonCaps         uint64
+}
+
+func (x *TcFqQdStats) Len() int {
+	return SizeofTcFqQdStats
+}
+
+// DeserializeTcFqQdStats decodes fq xstats. Older kernels send a shorter
+// struct, the fields they lack are left zero.
+func DeserializeTcFqQdStats(b []byte) *TcFqQdStats {
+	x := &TcFqQdStats{}
+	copy((*(*[SizeofTcFqQdStats]byte)(unsafe.Pointer(x)))[:], b)
+	return x
+}
+
+func (x *TcFqQdStats) Serialize() []byte {
+	return (*(*[SizeofTcFqQdStats]byte)(unsafe.Pointer(x)))[:]
+}
+
 // IPProto represents Flower ip_proto attribute
 type IPProto uint8
 
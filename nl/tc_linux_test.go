@@ -171,3 +171,36 @@ func TestTcHtbCoptDeserializeSerialize(t *testing.T) {
 	msg := DeserializeTcHtbCopt(orig)
 	testDeserializeSerialize(t, orig, safemsg, msg)
 }
+
+func (msg *TcFqQdStats) serializeSafe() []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, NativeEndian(), msg)
+	return buf.Bytes()
+}
+
+func deserializeTcFqQdStatsSafe(b []byte) *TcFqQdStats {
+	var msg = TcFqQdStats{}
+	binary.Read(bytes.NewReader(b[0:SizeofTcFqQdStats]), NativeEndian(), &msg)
+	return &msg
+}
+
+func TestTcFqQdStatsDeserializeSerialize(t *testing.T) {
+	var orig = make([]byte, SizeofTcFqQdStats)
+	rand.Read(orig)
+	safemsg := deserializeTcFqQdStatsSafe(orig)
+	msg := DeserializeTcFqQdStats(orig)
+	testDeserializeSerialize(t, orig, safemsg, msg)
+}
+
+func TestTcFqQdStatsDeserializeShort(t *testing.T) {
+	// Kernels before 5.7 do not report horizon_drops and horizon_caps.
+	orig := make([]byte, SizeofTcFqQdStats-16)
+	rand.Read(orig)
+	msg := DeserializeTcFqQdStats(orig)
+	if !bytes.Equal(msg.Serialize()[:len(orig)], orig) {
+		t.Fatal("short fq stats were not decoded")
+	}
+	if msg.HorizonDrops != 0 || msg.HorizonCaps != 0 {
+		t.Fatal("missing fields must be zero")
+	}
+}
@@ -0,0 +1,77 @@
+package nl
+
+const (
+	TEAM_GENL_NAME    = "team"
+	TEAM_GENL_VERSION = 0x1
+)
+
+const (
+	TEAM_CMD_NOOP = iota
+	TEAM_CMD_OPTIONS_SET
+	TEAM_CMD_OPTIONS_GET
+	TEAM_CMD_PORT_LIST_GET
+)
+
+/* Team attributes
+ * [TEAM_ATTR_TEAM_IFINDEX]
+ * [TEAM_ATTR_LIST_OPTION] = {
+ *     [TEAM_ATTR_ITEM_OPTION] = {
+ *         [TEAM_ATTR_OPTION_*]
+ *     }
+ *     ...
+ * }
+ * [TEAM_ATTR_LIST_PORT] = {
+ *     [TEAM_ATTR_ITEM_PORT] = {
+ *         [TEAM_ATTR_PORT_*]
+ *     }
+ *     ...
+ * }
+ */
+const (
+	TEAM_ATTR_UNSPEC = iota
+	TEAM_ATTR_TEAM_IFINDEX
+	TEAM_ATTR_LIST_OPTION
+	TEAM_ATTR_LIST_PORT
+)
+
+const (
+	TEAM_ATTR_ITEM_OPTION_UNSPEC = iota
+	TEAM_ATTR_ITEM_OPTION
+)
+
+const (
+	TEAM_ATTR_OPTION_UNSPEC = iota
+	TEAM_ATTR_OPTION_NAME
+	TEAM_ATTR_OPTION_CHANGED
+	TEAM_ATTR_OPTION_TYPE
+	TEAM_ATTR_OPTION_DATA
+	TEAM_ATTR_OPTION_REMOVED
+	TEAM_ATTR_OPTION_PORT_IFINDEX
+	TEAM_ATTR_OPTION_ARRAY_INDEX
+)
+
+const (
+	TEAM_ATTR_ITEM_PORT_UNSPEC = iota
+	TEAM_ATTR_ITEM_PORT
+)
+
+const (
+	TEAM_ATTR_PORT_UNSPEC = iota
+	TEAM_ATTR_PORT_IFINDEX
+	TEAM_ATTR_PORT_CHANGED
+	TEAM_ATTR_PORT_LINKUP
+	TEAM_ATTR_PORT_SPEED
+	TEAM_ATTR_PORT_DUPLEX
+	TEAM_ATTR_PORT_REMOVED
+)
+
+/* Option data types, TEAM_ATTR_OPTION_TYPE carries the netlink policy type
+ * of TEAM_ATTR_OPTION_DATA.
+ */
+const (
+	TEAM_OPTION_TYPE_U32    = 3  /* NLA_U32 */
+	TEAM_OPTION_TYPE_STRING = 5  /* NLA_STRING */
+	TEAM_OPTION_TYPE_BOOL   = 6  /* NLA_FLAG, no data when false */
+	TEAM_OPTION_TYPE_BINARY = 11 /* NLA_BINARY */
+	TEAM_OPTION_TYPE_S32    = 14 /* NLA_S32 */
+)
@@ -0,0 +1,456 @@
+package nl
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"syscall"
+	"unicode"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// Direction tells whether a traced message was sent to or received from
+// the kernel.
+type Direction uint8
+
+const (
+	DirectionSend Direction = iota
+	DirectionReceive
+)
+
+func (d Direction) String() string {
+	if d == DirectionSend {
+		return "send"
+	}
+	return "recv"
+}
+
+// TraceFunc is called with every serialized request and every raw
+// response message before it is decoded. proto is the netlink protocol
+// of the socket, e.g. unix.NETLINK_ROUTE. msg must not be retained.
+type TraceFunc func(dir Direction, proto int, msg []byte)
+
+// TraceWriter returns a TraceFunc writing each message to w, rendered by
+// FormatMessage.
+func TraceWriter(w io.Writer) TraceFunc {
+	return func(dir Direction, proto int, msg []byte) {
+		fmt.Fprintf(w, "%s %s", dir, FormatMessage(proto, msg))
+	}
+}
+
+func traceMessage(req *NetlinkRequest, proto int, m *syscall.NetlinkMessage) {
+	req.Trace(DirectionReceive, proto, serializeMessage(m))
+}
+
+// serializeMessage returns the wire format of a received message.
+func serializeMessage(m *syscall.NetlinkMessage) []byte {
+	b := make([]byte, unix.SizeofNlMsghdr+len(m.Data))
+	copy(b, (*(*[unix.SizeofNlMsghdr]byte)(unsafe.Pointer(&m.Header)))[:])
+	copy(b[unix.SizeofNlMsghdr:], m.Data)
+	return b
+}
+
+var rtmTypeNames = map[uint16]string{
+	unix.RTM_NEWLINK:     "RTM_NEWLINK",
+	unix.RTM_DELLINK:     "RTM_DELLINK",
+	unix.RTM_GETLINK:     "RTM_GETLINK",
+	unix.RTM_SETLINK:     "RTM_SETLINK",
+	unix.RTM_NEWADDR:     "RTM_NEWADDR",
+	unix.RTM_DELADDR:     "RTM_DELADDR",
+	unix.RTM_GETADDR:     "RTM_GETADDR",
+	unix.RTM_NEWROUTE:    "RTM_NEWROUTE",
+	unix.RTM_DELROUTE:    "RTM_DELROUTE",
+	unix.RTM_GETROUTE:    "RTM_GETROUTE",
+	unix.RTM_NEWNEIGH:    "RTM_NEWNEIGH",
+	unix.RTM_DELNEIGH:    "RTM_DELNEIGH",
+	unix.RTM_GETNEIGH:    "RTM_GETNEIGH",
+	unix.RTM_NEWRULE:     "RTM_NEWRULE",
+	unix.RTM_DELRULE:     "RTM_DELRULE",
+	unix.RTM_GETRULE:     "RTM_GETRULE",
+	unix.RTM_NEWQDISC:    "RTM_NEWQDISC",
+	unix.RTM_DELQDISC:    "RTM_DELQDISC",
+	unix.RTM_GETQDISC:    "RTM_GETQDISC",
+	unix.RTM_NEWTCLASS:   "RTM_NEWTCLASS",
+	unix.RTM_DELTCLASS:   "RTM_DELTCLASS",
+	unix.RTM_GETTCLASS:   "RTM_GETTCLASS",
+	unix.RTM_NEWTFILTER:  "RTM_NEWTFILTER",
+	unix.RTM_DELTFILTER:  "RTM_DELTFILTER",
+	unix.RTM_GETTFILTER:  "RTM_GETTFILTER",
+	unix.RTM_NEWACTION:   "RTM_NEWACTION",
+	unix.RTM_DELACTION:   "RTM_DELACTION",
+	unix.RTM_GETACTION:   "RTM_GETACTION",
+	unix.RTM_NEWNEXTHOP:  "RTM_NEWNEXTHOP",
+	unix.RTM_DELNEXTHOP:  "RTM_DELNEXTHOP",
+	unix.RTM_GETNEXTHOP:  "RTM_GETNEXTHOP",
+	RTM_NEWNEXTHOPBUCKET: "RTM_NEWNEXTHOPBUCKET",
+	RTM_DELNEXTHOPBUCKET: "RTM_DELNEXTHOPBUCKET",
+	RTM_GETNEXTHOPBUCKET: "RTM_GETNEXTHOPBUCKET",
+	unix.RTM_NEWCHAIN:    "RTM_NEWCHAIN",
+	unix.RTM_DELCHAIN:    "RTM_DELCHAIN",
+	unix.RTM_GETCHAIN:    "RTM_GETCHAIN",
+	unix.RTM_NEWNSID:     "RTM_NEWNSID",
+	unix.RTM_GETNSID:     "RTM_GETNSID",
+	unix.RTM_NEWLINKPROP: "RTM_NEWLINKPROP",
+	unix.RTM_DELLINKPROP: "RTM_DELLINKPROP",
+}
+
+// rtmFamilyHeader describes the fixed header preceding the attributes of
+// an rtnetlink message family, along with the names of its attributes.
+type rtmFamilyHeader struct {
+	name  string
+	len   int
+	attrs map[uint16]string
+}
+
+var (
+	iflaNames = map[uint16]string{
+		unix.IFLA_ADDRESS:         "IFLA_ADDRESS",
+		unix.IFLA_BROADCAST:       "IFLA_BROADCAST",
+		unix.IFLA_IFNAME:          "IFLA_IFNAME",
+		unix.IFLA_MTU:             "IFLA_MTU",
+		unix.IFLA_LINK:            "IFLA_LINK",
+		unix.IFLA_QDISC:           "IFLA_QDISC",
+		unix.IFLA_STATS:           "IFLA_STATS",
+		unix.IFLA_MASTER:          "IFLA_MASTER",
+		unix.IFLA_TXQLEN:          "IFLA_TXQLEN",
+		unix.IFLA_OPERSTATE:       "IFLA_OPERSTATE",
+		unix.IFLA_LINKMODE:        "IFLA_LINKMODE",
+		unix.IFLA_LINKINFO:        "IFLA_LINKINFO",
+		unix.IFLA_NET_NS_PID:      "IFLA_NET_NS_PID",
+		unix.IFLA_IFALIAS:         "IFLA_IFALIAS",
+		unix.IFLA_NUM_VF:          "IFLA_NUM_VF",
+		unix.IFLA_VFINFO_LIST:     "IFLA_VFINFO_LIST",
+		unix.IFLA_STATS64:         "IFLA_STATS64",
+		unix.IFLA_AF_SPEC:         "IFLA_AF_SPEC",
+		unix.IFLA_GROUP:           "IFLA_GROUP",
+		unix.IFLA_NET_NS_FD:       "IFLA_NET_NS_FD",
+		unix.IFLA_EXT_MASK:        "IFLA_EXT_MASK",
+		unix.IFLA_PROMISCUITY:     "IFLA_PROMISCUITY",
+		unix.IFLA_NUM_TX_QUEUES:   "IFLA_NUM_TX_QUEUES",
+		unix.IFLA_NUM_RX_QUEUES:   "IFLA_NUM_RX_QUEUES",
+		unix.IFLA_CARRIER:         "IFLA_CARRIER",
+		unix.IFLA_LINK_NETNSID:    "IFLA_LINK_NETNSID",
+		unix.IFLA_XDP:             "IFLA_XDP",
+		unix.IFLA_MIN_MTU:         "IFLA_MIN_MTU",
+		unix.IFLA_MAX_MTU:         "IFLA_MAX_MTU",
+		unix.IFLA_PROP_LIST:       "IFLA_PROP_LIST",
+		unix.IFLA_ALT_IFNAME:      "IFLA_ALT_IFNAME",
+		unix.IFLA_PARENT_DEV_NAME: "IFLA_PARENT_DEV_NAME",
+	}
+	ifaNames = map[uint16]string{
+		unix.IFA_ADDRESS:     "IFA_ADDRESS",
+		unix.IFA_LOCAL:       "IFA_LOCAL",
+		unix.IFA_LABEL:       "IFA_LABEL",
+		unix.IFA_BROADCAST:   "IFA_BROADCAST",
+		unix.IFA_ANYCAST:     "IFA_ANYCAST",
+		unix.IFA_CACHEINFO:   "IFA_CACHEINFO",
+		unix.IFA_FLAGS:       "IFA_FLAGS",
+		unix.IFA_RT_PRIORITY: "IFA_RT_PRIORITY",
+	}
+	rtaNames = map[uint16]string{
+		unix.RTA_DST:        "RTA_DST",
+		unix.RTA_SRC:        "RTA_SRC",
+		unix.RTA_IIF:        "RTA_IIF",
+		unix.RTA_OIF:        "RTA_OIF",
+		unix.RTA_GATEWAY:    "RTA_GATEWAY",
+		unix.RTA_PRIORITY:   "RTA_PRIORITY",
+		unix.RTA_PREFSRC:    "RTA_PREFSRC",
+		unix.RTA_METRICS:    "RTA_METRICS",
+		unix.RTA_MULTIPATH:  "RTA_MULTIPATH",
+		unix.RTA_FLOW:       "RTA_FLOW",
+		unix.RTA_CACHEINFO:  "RTA_CACHEINFO",
+		unix.RTA_TABLE:      "RTA_TABLE",
+		unix.RTA_MARK:       "RTA_MARK",
+		unix.RTA_VIA:        "RTA_VIA",
+		unix.RTA_NEWDST:     "RTA_NEWDST",
+		unix.RTA_PREF:       "RTA_PREF",
+		unix.RTA_ENCAP_TYPE: "RTA_ENCAP_TYPE",
+		unix.RTA_ENCAP:      "RTA_ENCAP",
+		unix.RTA_EXPIRES:    "RTA_EXPIRES",
+		unix.RTA_UID:        "RTA_UID",
+	}
+	ndaNames = map[uint16]string{
+		unix.NDA_DST:       "NDA_DST",
+		unix.NDA_LLADDR:    "NDA_LLADDR",
+		unix.NDA_CACHEINFO: "NDA_CACHEINFO",
+		unix.NDA_PROBES:    "NDA_PROBES",
+		unix.NDA_VLAN:      "NDA_VLAN",
+		unix.NDA_PORT:      "NDA_PORT",
+		unix.NDA_VNI:       "NDA_VNI",
+		unix.NDA_IFINDEX:   "NDA_IFINDEX",
+		unix.NDA_MASTER:    "NDA_MASTER",
+	}
+	tcaNames = map[uint16]string{
+		TCA_KIND:       "TCA_KIND",
+		TCA_OPTIONS:    "TCA_OPTIONS",
+		TCA_STATS:      "TCA_STATS",
+		TCA_XSTATS:     "TCA_XSTATS",
+		TCA_RATE:       "TCA_RATE",
+		TCA_FCNT:       "TCA_FCNT",
+		TCA_STATS2:     "TCA_STATS2",
+		TCA_STAB:       "TCA_STAB",
+		TCA_CHAIN:      "TCA_CHAIN",
+		TCA_HW_OFFLOAD: "TCA_HW_OFFLOAD",
+	}
+)
+
+// nestedAttrNames holds the names of the attributes nested in the
+// attribute named by the key.
+var nestedAttrNames = map[string]map[uint16]string{
+	"IFLA_LINKINFO": {
+		IFLA_INFO_KIND:       "IFLA_INFO_KIND",
+		IFLA_INFO_DATA:       "IFLA_INFO_DATA",
+		IFLA_INFO_XSTATS:     "IFLA_INFO_XSTATS",
+		IFLA_INFO_SLAVE_KIND: "IFLA_INFO_SLAVE_KIND",
+		IFLA_INFO_SLAVE_DATA: "IFLA_INFO_SLAVE_DATA",
+	},
+	"IFLA_AF_SPEC": {
+		unix.AF_INET:   "AF_INET",
+		unix.AF_INET6:  "AF_INET6",
+		unix.AF_BRIDGE: "AF_BRIDGE",
+	},
+}
+
+var nlmsgerrAttrNames = map[uint16]string{
+	NLMSGERR_ATTR_MSG:    "NLMSGERR_ATTR_MSG",
+	NLMSGERR_ATTR_OFFS:   "NLMSGERR_ATTR_OFFS",
+	NLMSGERR_ATTR_COOKIE: "NLMSGERR_ATTR_COOKIE",
+	NLMSGERR_ATTR_POLICY: "NLMSGERR_ATTR_POLICY",
+}
+
+func rtmHeader(msgType uint16) *rtmFamilyHeader {
+	switch {
+	case msgType >= unix.RTM_NEWLINK && msgType <= unix.RTM_SETLINK:
+		return &rtmFamilyHeader{"ifinfomsg", unix.SizeofIfInfomsg, iflaNames}
+	case msgType >= unix.RTM_NEWADDR && msgType <= unix.RTM_GETADDR:
+		return &rtmFamilyHeader{"ifaddrmsg", unix.SizeofIfAddrmsg, ifaNames}
+	case msgType >= unix.RTM_NEWROUTE && msgType <= unix.RTM_GETROUTE:
+		return &rtmFamilyHeader{"rtmsg", unix.SizeofRtMsg, rtaNames}
+	case msgType >= unix.RTM_NEWNEIGH && msgType <= unix.RTM_GETNEIGH:
+		return &rtmFamilyHeader{"ndmsg", unix.SizeofNdMsg, ndaNames}
+	case msgType >= unix.RTM_NEWRULE && msgType <= unix.RTM_GETRULE:
+		return &rtmFamilyHeader{"fib_rule_hdr", 12, nil}
+	case msgType >= unix.RTM_NEWQDISC && msgType <= unix.RTM_GETTFILTER,
+		msgType >= unix.RTM_NEWCHAIN && msgType <= unix.RTM_GETCHAIN:
+		return &rtmFamilyHeader{"tcmsg", SizeofTcMsg, tcaNames}
+	case msgType >= unix.RTM_NEWACTION && msgType <= unix.RTM_GETACTION:
+		return &rtmFamilyHeader{"tcamsg", 4, nil}
+	case msgType >= unix.RTM_NEWNEXTHOP && msgType <= unix.RTM_GETNEXTHOP,
+		msgType >= RTM_NEWNEXTHOPBUCKET && msgType <= RTM_GETNEXTHOPBUCKET:
+		return &rtmFamilyHeader{"nhmsg", SizeofNhmsg, nil}
+	case msgType == unix.RTM_NEWLINKPROP || msgType == unix.RTM_DELLINKPROP:
+		return &rtmFamilyHeader{"ifinfomsg", unix.SizeofIfInfomsg, iflaNames}
+	case msgType == unix.RTM_NEWNSID || msgType == unix.RTM_GETNSID:
+		return &rtmFamilyHeader{"rtgenmsg", 1, nil}
+	}
+	return nil
+}
+
+func formatMsgFlags(msgType uint16, flags uint16) string {
+	var names []string
+	add := func(flag uint16, name string) {
+		if flags&flag != 0 {
+			names = append(names, name)
+			flags &^= flag
+		}
+	}
+	add(unix.NLM_F_REQUEST, "REQUEST")
+	add(unix.NLM_F_MULTI, "MULTI")
+	add(unix.NLM_F_ACK, "ACK")
+	add(unix.NLM_F_ECHO, "ECHO")
+	add(unix.NLM_F_DUMP_INTR, "DUMP_INTR")
+	add(unix.NLM_F_DUMP_FILTERED, "DUMP_FILTERED")
+	if msgType == unix.NLMSG_ERROR {
+		add(unix.NLM_F_CAPPED, "CAPPED")
+		add(unix.NLM_F_ACK_TLVS, "ACK_TLVS")
+	} else if msgType >= unix.RTM_BASE && (msgType-unix.RTM_BASE)%4 == 2 {
+		add(unix.NLM_F_ROOT, "ROOT")
+		add(unix.NLM_F_MATCH, "MATCH")
+		add(unix.NLM_F_ATOMIC, "ATOMIC")
+	} else if msgType >= unix.RTM_BASE {
+		add(unix.NLM_F_REPLACE, "REPLACE")
+		add(unix.NLM_F_EXCL, "EXCL")
+		add(unix.NLM_F_CREATE, "CREATE")
+		add(unix.NLM_F_APPEND, "APPEND")
+	}
+	if flags != 0 || len(names) == 0 {
+		names = append(names, fmt.Sprintf("%#x", flags))
+	}
+	return strings.Join(names, "|")
+}
+
+// FormatMessage renders a single netlink message, as seen by a TraceFunc,
+// in a human readable form: the nlmsghdr, the fixed family header and the
+// tree of attributes, named after their rtnetlink constants where known.
+// Payloads of protocols other than NETLINK_ROUTE are dumped in hex.
+func FormatMessage(proto int, msg []byte) string {
+	var sb strings.Builder
+	if len(msg) < unix.SizeofNlMsghdr {
+		fmt.Fprintf(&sb, "short message: %s\n", hex.EncodeToString(msg))
+		return sb.String()
+	}
+	hdr := (*unix.NlMsghdr)(unsafe.Pointer(&msg[0]))
+	data := msg[unix.SizeofNlMsghdr:]
+	if int(hdr.Len) >= unix.SizeofNlMsghdr && int(hdr.Len) <= len(msg) {
+		data = msg[unix.SizeofNlMsghdr:hdr.Len]
+	}
+
+	name := fmt.Sprintf("type=%d", hdr.Type)
+	switch {
+	case hdr.Type == unix.NLMSG_ERROR:
+		name = "NLMSG_ERROR"
+	case hdr.Type == unix.NLMSG_DONE:
+		name = "NLMSG_DONE"
+	case hdr.Type == unix.NLMSG_NOOP:
+		name = "NLMSG_NOOP"
+	case proto == unix.NETLINK_ROUTE && rtmTypeNames[hdr.Type] != "":
+		name = rtmTypeNames[hdr.Type]
+	}
+	flagType := hdr.Type
+	if proto != unix.NETLINK_ROUTE && hdr.Type >= unix.RTM_BASE {
+		flagType = 0
+	}
+	fmt.Fprintf(&sb, "%s len=%d flags=%s seq=%d pid=%d\n",
+		name, hdr.Len, formatMsgFlags(flagType, hdr.Flags), hdr.Seq, hdr.Pid)
+
+	switch {
+	case hdr.Type == unix.NLMSG_ERROR || hdr.Type == unix.NLMSG_DONE:
+		if len(data) < 4 {
+			break
+		}
+		errno := int32(NativeEndian().Uint32(data[0:4]))
+		if errno == 0 {
+			if hdr.Type == unix.NLMSG_ERROR {
+				fmt.Fprintf(&sb, "  ack\n")
+			}
+		} else {
+			fmt.Fprintf(&sb, "  error %d: %v\n", errno, syscall.Errno(-errno))
+		}
+		if hdr.Type != unix.NLMSG_ERROR || len(data) < 4+unix.SizeofNlMsghdr {
+			break
+		}
+		req := (*unix.NlMsghdr)(unsafe.Pointer(&data[4]))
+		fmt.Fprintf(&sb, "  in reply to seq=%d type=%d\n", req.Seq, req.Type)
+		if hdr.Flags&unix.NLM_F_ACK_TLVS == 0 {
+			break
+		}
+		// The extended ack attributes follow the echoed request, of
+		// which only the header is included when NLM_F_CAPPED is set.
+		off := 4 + unix.SizeofNlMsghdr
+		if hdr.Flags&unix.NLM_F_CAPPED == 0 {
+			off = 4 + nlmAlignOf(int(req.Len))
+		}
+		if off < len(data) {
+			formatAttrs(&sb, data[off:], nlmsgerrAttrNames, 1)
+		}
+	case proto == unix.NETLINK_ROUTE && rtmHeader(hdr.Type) != nil:
+		fh := rtmHeader(hdr.Type)
+		if len(data) < fh.len {
+			fmt.Fprintf(&sb, "  %s (truncated) %s\n", fh.name, hex.EncodeToString(data))
+			break
+		}
+		fmt.Fprintf(&sb, "  %s %s\n", fh.name, hex.EncodeToString(data[:fh.len]))
+		formatAttrs(&sb, data[rtaAlignOf(fh.len):], fh.attrs, 1)
+	default:
+		if len(data) > 0 {
+			fmt.Fprintf(&sb, "  %s\n", hex.EncodeToString(data))
+		}
+	}
+	return sb.String()
+}
+
+// splitAttrs splits b into attributes, failing unless they cover b exactly.
+func splitAttrs(b []byte) ([]syscall.NetlinkRouteAttr, bool) {
+	var attrs []syscall.NetlinkRouteAttr
+	for len(b) > 0 {
+		if len(b) < unix.SizeofRtAttr {
+			return nil, false
+		}
+		a := (*unix.RtAttr)(unsafe.Pointer(&b[0]))
+		if int(a.Len) < unix.SizeofRtAttr || int(a.Len) > len(b) {
+			return nil, false
+		}
+		attrs = append(attrs, syscall.NetlinkRouteAttr{
+			Attr:  syscall.RtAttr{Len: a.Len, Type: a.Type},
+			Value: b[unix.SizeofRtAttr:a.Len],
+		})
+		next := rtaAlignOf(int(a.Len))
+		if next > len(b) {
+			next = len(b)
+		}
+		b = b[next:]
+	}
+	return attrs, true
+}
+
+func formatAttrs(sb *strings.Builder, b []byte, names map[uint16]string, depth int) {
+	attrs, ok := splitAttrs(b)
+	indent := strings.Repeat("  ", depth)
+	if !ok {
+		fmt.Fprintf(sb, "%s%s\n", indent, hex.EncodeToString(b))
+		return
+	}
+	for _, a := range attrs {
+		typ := a.Attr.Type & NLA_TYPE_MASK
+		name := names[typ]
+		if name == "" {
+			name = fmt.Sprintf("attr %d", typ)
+		} else {
+			name = fmt.Sprintf("%s (%d)", name, typ)
+		}
+		if a.Attr.Type&NLA_F_NESTED != 0 {
+			name += " nested"
+		}
+		// Nested attributes are not always flagged as such, so descend
+		// into any value which splits into attributes without leftovers.
+		if _, nested := splitAttrs(a.Value); nested && len(a.Value) >= unix.SizeofRtAttr &&
+			(a.Attr.Type&NLA_F_NESTED != 0 || !looksLikeString(a.Value)) {
+			fmt.Fprintf(sb, "%s%s len=%d\n", indent, name, a.Attr.Len)
+			formatAttrs(sb, a.Value, nestedAttrNames[names[typ]], depth+1)
+			continue
+		}
+		if len(a.Value) == 0 {
+			fmt.Fprintf(sb, "%s%s len=%d\n", indent, name, a.Attr.Len)
+			continue
+		}
+		fmt.Fprintf(sb, "%s%s len=%d: %s\n", indent, name, a.Attr.Len, formatAttrValue(a.Value))
+	}
+}
+
+func isPrintable(b []byte) bool {
+	for _, c := range b {
+		if c > unicode.MaxASCII || !unicode.IsPrint(rune(c)) {
+			return false
+		}
+	}
+	return len(b) > 0
+}
+
+// looksLikeString reports whether b holds a NUL terminated string.
+func looksLikeString(b []byte) bool {
+	return len(b) >= 2 && b[len(b)-1] == 0 && isPrintable(b[:len(b)-1])
+}
+
+func formatAttrValue(b []byte) string {
+	var s string
+	switch {
+	case looksLikeString(b):
+		return fmt.Sprintf("%q", b[:len(b)-1])
+	case len(b) == 4:
+		s = fmt.Sprintf("%s (%d)", hex.EncodeToString(b), NativeEndian().Uint32(b))
+	case len(b) == 2:
+		s = fmt.Sprintf("%s (%d)", hex.EncodeToString(b), NativeEndian().Uint16(b))
+	case len(b) == 1:
+		return fmt.Sprintf("%s (%d)", hex.EncodeToString(b), b[0])
+	default:
+		s = hex.EncodeToString(b)
+	}
+	// Some strings, such as IFLA_INFO_KIND, are sent unterminated.
+	if len(b) > 2 && isPrintable(b) {
+		s += fmt.Sprintf(" %q", b)
+	}
+	return s
+}
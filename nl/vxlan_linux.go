@@ -0,0 +1,75 @@
+package nl
+
+import (
+	"unsafe"
+)
+
+const (
+	SizeofTunnelMsg = 0x08
+)
+
+const (
+	TUNNEL_MSG_FLAG_STATS = 0x01
+)
+
+/* Vxlan vni filter nested attributes
+ * [VXLAN_VNIFILTER_ENTRY] = {
+ *     [VXLAN_VNIFILTER_ENTRY_START]
+ *     [VXLAN_VNIFILTER_ENTRY_END]
+ *     [VXLAN_VNIFILTER_ENTRY_GROUP]
+ *     [VXLAN_VNIFILTER_ENTRY_GROUP6]
+ *     [VXLAN_VNIFILTER_ENTRY_STATS]
+ * }
+ */
+const (
+	VXLAN_VNIFILTER_UNSPEC = iota
+	VXLAN_VNIFILTER_ENTRY
+)
+
+const (
+	VXLAN_VNIFILTER_ENTRY_UNSPEC = iota
+	VXLAN_VNIFILTER_ENTRY_START
+	VXLAN_VNIFILTER_ENTRY_END
+	VXLAN_VNIFILTER_ENTRY_GROUP
+	VXLAN_VNIFILTER_ENTRY_GROUP6
+	VXLAN_VNIFILTER_ENTRY_STATS
+)
+
+const (
+	VNIFILTER_ENTRY_STATS_UNSPEC = iota
+	VNIFILTER_ENTRY_STATS_RX_BYTES
+	VNIFILTER_ENTRY_STATS_RX_PKTS
+	VNIFILTER_ENTRY_STATS_RX_DROPS
+	VNIFILTER_ENTRY_STATS_RX_ERRORS
+	VNIFILTER_ENTRY_STATS_TX_BYTES
+	VNIFILTER_ENTRY_STATS_TX_PKTS
+	VNIFILTER_ENTRY_STATS_TX_DROPS
+	VNIFILTER_ENTRY_STATS_TX_ERRORS
+	VNIFILTER_ENTRY_STATS_PAD
+)
+
+// struct tunnel_msg {
+//   __u8 family;
+//   __u8 flags;
+//   __u16 reserved2;
+//   __u32 ifindex;
+// };
+
+type TunnelMsg struct {
+	Family    uint8
+	Flags     uint8
+	Reserved2 uint16
+	Ifindex   uint32
+}
+
+func (msg *TunnelMsg) Len() int {
+	return SizeofTunnelMsg
+}
+
+func DeserializeTunnelMsg(b []byte) *TunnelMsg {
+	return (*TunnelMsg)(unsafe.Pointer(&b[0:SizeofTunnelMsg][0]))
+}
+
+func (msg *TunnelMsg) Serialize() []byte {
+	return (*(*[SizeofTunnelMsg]byte)(unsafe.Pointer(msg)))[:]
+}
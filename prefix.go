@@ -0,0 +1,33 @@
+package netlink
+
+import (
+	"fmt"
+	"net"
+)
+
+// Prefix is an IPv6 prefix information option of a router advertisement,
+// as forwarded by the kernel in RTM_NEWPREFIX messages.
+type Prefix struct {
+	LinkIndex int
+	Prefix    *net.IPNet
+	// OnLink is the L flag, the prefix can be reached without a router
+	OnLink bool
+	// Autonomous is the A flag, the prefix can be used for SLAAC
+	Autonomous bool
+	// PreferredLifetime and ValidLifetime are in seconds, 0xffffffff
+	// meaning infinity
+	PreferredLifetime uint32
+	ValidLifetime     uint32
+}
+
+func (p Prefix) String() string {
+	return fmt.Sprintf("{Ifindex: %d Prefix: %s OnLink: %t Autonomous: %t Preferred: %d Valid: %d}",
+		p.LinkIndex, p.Prefix, p.OnLink, p.Autonomous, p.PreferredLifetime, p.ValidLifetime)
+}
+
+// PrefixUpdate is sent when the kernel receives a prefix in a router
+// advertisement - type is RTM_NEWPREFIX.
+type PrefixUpdate struct {
+	Type uint16
+	Prefix
+}
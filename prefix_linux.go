@@ -0,0 +1,154 @@
+package netlink
+
+import (
+	"fmt"
+	"net"
+	"syscall"
+
+	"github.com/vishvananda/netlink/nl"
+	"github.com/vishvananda/netns"
+	"golang.org/x/sys/unix"
+)
+
+func parsePrefix(m []byte) (*Prefix, error) {
+	if len(m) < nl.SizeofPrefixMsg {
+		return nil, fmt.Errorf("invalid prefixmsg length %d", len(m))
+	}
+	msg := nl.DeserializePrefixMsg(m)
+	attrs, err := nl.ParseRouteAttr(m[msg.Len():])
+	if err != nil {
+		return nil, err
+	}
+	p := &Prefix{
+		LinkIndex:  int(msg.Ifindex),
+		OnLink:     msg.Flags&nl.IF_PREFIX_ONLINK != 0,
+		Autonomous: msg.Flags&nl.IF_PREFIX_AUTOCONF != 0,
+	}
+	for _, attr := range attrs {
+		switch attr.Attr.Type {
+		case nl.PREFIX_ADDRESS:
+			if len(attr.Value) != net.IPv6len {
+				return nil, fmt.Errorf("invalid PREFIX_ADDRESS length %d", len(attr.Value))
+			}
+			p.Prefix = &net.IPNet{
+				IP:   net.IP(attr.Value),
+				Mask: net.CIDRMask(int(msg.Prefixlen), 8*net.IPv6len),
+			}
+		case nl.PREFIX_CACHEINFO:
+			if len(attr.Value) < nl.SizeofPrefixCacheinfo {
+				return nil, fmt.Errorf("invalid PREFIX_CACHEINFO length %d", len(attr.Value))
+			}
+			ci := nl.DeserializePrefixCacheinfo(attr.Value)
+			p.PreferredLifetime = ci.PreferredTime
+			p.ValidLifetime = ci.ValidTime
+		}
+	}
+	return p, nil
+}
+
+// PrefixSubscribe takes a chan down which the prefixes of the router
+// advertisements received by the kernel will be sent. Close the 'done'
+// chan to stop subscription.
+func PrefixSubscribe(ch chan<- PrefixUpdate, done <-chan struct{}) error {
+	return prefixSubscribeAt(netns.None(), netns.None(), ch, done, nil, 0, nil, false)
+}
+
+// PrefixSubscribeAt works like PrefixSubscribe plus it allows the caller
+// to choose the network namespace in which to subscribe (ns).
+func PrefixSubscribeAt(ns netns.NsHandle, ch chan<- PrefixUpdate, done <-chan struct{}) error {
+	return prefixSubscribeAt(ns, netns.None(), ch, done, nil, 0, nil, false)
+}
+
+// PrefixSubscribeOptions contains a set of options to use with
+// PrefixSubscribeWithOptions.
+type PrefixSubscribeOptions struct {
+	Namespace     *netns.NsHandle
+	ErrorCallback func(error)
+
+	// max size is based on value of /proc/sys/net/core/rmem_max
+	ReceiveBufferSize      int
+	ReceiveBufferForceSize bool
+	ReceiveTimeout         *unix.Timeval
+}
+
+// PrefixSubscribeWithOptions work like PrefixSubscribe but enable to
+// provide additional options to modify the behavior.
+func PrefixSubscribeWithOptions(ch chan<- PrefixUpdate, done <-chan struct{}, options PrefixSubscribeOptions) error {
+	if options.Namespace == nil {
+		none := netns.None()
+		options.Namespace = &none
+	}
+	return prefixSubscribeAt(*options.Namespace, netns.None(), ch, done, options.ErrorCallback,
+		options.ReceiveBufferSize, options.ReceiveTimeout, options.ReceiveBufferForceSize)
+}
+
+func prefixSubscribeAt(newNs, curNs netns.NsHandle, ch chan<- PrefixUpdate, done <-chan struct{}, cberr func(error),
+	rcvbuf int, rcvTimeout *unix.Timeval, rcvbufForce bool) error {
+	s, err := nl.SubscribeAt(newNs, curNs, unix.NETLINK_ROUTE, unix.RTNLGRP_IPV6_PREFIX)
+	if err != nil {
+		return err
+	}
+	if rcvTimeout != nil {
+		if err := s.SetReceiveTimeout(rcvTimeout); err != nil {
+			return err
+		}
+	}
+	if rcvbuf != 0 {
+		err = s.SetReceiveBufferSize(rcvbuf, rcvbufForce)
+		if err != nil {
+			return err
+		}
+	}
+	if done != nil {
+		go func() {
+			<-done
+			s.Close()
+		}()
+	}
+	go func() {
+		defer close(ch)
+		for {
+			msgs, from, err := s.Receive()
+			if err != nil {
+				if cberr != nil {
+					cberr(err)
+				}
+				return
+			}
+			if from.Pid != nl.PidKernel {
+				if cberr != nil {
+					cberr(fmt.Errorf("Wrong sender portid %d, expected %d", from.Pid, nl.PidKernel))
+				}
+				continue
+			}
+			for _, m := range msgs {
+				if m.Header.Type == unix.NLMSG_DONE {
+					continue
+				}
+				if m.Header.Type == unix.NLMSG_ERROR {
+					nError := int32(native.Uint32(m.Data[0:4]))
+					if nError == 0 {
+						continue
+					}
+					if cberr != nil {
+						cberr(syscall.Errno(-nError))
+					}
+					return
+				}
+				if m.Header.Type != unix.RTM_NEWPREFIX {
+					continue
+				}
+				p, err := parsePrefix(m.Data)
+				if err != nil {
+					if cberr != nil {
+						cberr(err)
+					}
+					continue
+				}
+				ch <- PrefixUpdate{Type: m.Header.Type, Prefix: *p}
+			}
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,70 @@
+//go:build linux
+// +build linux
+
+package netlink
+
+import (
+	"net"
+	"testing"
+
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+func TestParsePrefix(t *testing.T) {
+	// RTM_NEWPREFIX payload of an RA announcing 2001:db8:1::/64 on link 2
+	msg := nl.PrefixMsg{
+		Family:    unix.AF_INET6,
+		Ifindex:   2,
+		Type:      3, // ND_OPT_PREFIX_INFORMATION
+		Prefixlen: 64,
+		Flags:     nl.IF_PREFIX_ONLINK | nl.IF_PREFIX_AUTOCONF,
+	}
+	ci := nl.PrefixCacheinfo{PreferredTime: 14400, ValidTime: 86400}
+	m := msg.Serialize()
+	m = append(m, nl.NewRtAttr(nl.PREFIX_ADDRESS, net.ParseIP("2001:db8:1::")).Serialize()...)
+	m = append(m, nl.NewRtAttr(nl.PREFIX_CACHEINFO, ci.Serialize()).Serialize()...)
+
+	p, err := parsePrefix(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.LinkIndex != 2 {
+		t.Errorf("LinkIndex is %d, should be 2", p.LinkIndex)
+	}
+	if p.Prefix == nil || p.Prefix.String() != "2001:db8:1::/64" {
+		t.Errorf("Prefix is %v, should be 2001:db8:1::/64", p.Prefix)
+	}
+	if !p.OnLink || !p.Autonomous {
+		t.Errorf("flags not decoded: %v", p)
+	}
+	if p.PreferredLifetime != 14400 || p.ValidLifetime != 86400 {
+		t.Errorf("lifetimes not decoded: %v", p)
+	}
+
+	msg.Flags = nl.IF_PREFIX_ONLINK
+	p, err = parsePrefix(msg.Serialize())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.OnLink || p.Autonomous {
+		t.Errorf("flags not decoded: %v", p)
+	}
+
+	if _, err := parsePrefix(m[:nl.SizeofPrefixMsg-1]); err == nil {
+		t.Error("truncated prefixmsg accepted")
+	}
+}
+
+func TestPrefixSubscribe(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+
+	ch := make(chan PrefixUpdate)
+	done := make(chan struct{})
+	if err := PrefixSubscribe(ch, done); err != nil {
+		t.Fatal(err)
+	}
+	close(done)
+	for range ch {
+	}
+}
@@ -4,6 +4,14 @@ import (
 	"strings"
 )
 
+// Multicast router modes of a bridge port
+const (
+	MDB_RTR_TYPE_DISABLED   = iota // never a multicast router port
+	MDB_RTR_TYPE_TEMP_QUERY        // a router port while queries are received, the default
+	MDB_RTR_TYPE_PERM              // always a multicast router port
+	MDB_RTR_TYPE_TEMP              // a router port until the membership interval expires
+)
+
 // Protinfo represents bridge flags from netlink.
 type Protinfo struct {
 	Hairpin       bool
@@ -17,6 +25,11 @@ type Protinfo struct {
 	Isolated      bool
 	NeighSuppress bool
 	VlanTunnel    bool
+	// McastRouter is one of the MDB_RTR_TYPE_* modes
+	McastRouter int
+	// BackupPort is the index of the port taking over when this one loses
+	// carrier, 0 when there is none
+	BackupPort int
 }
 
 // String returns a list of enabled flags
@@ -79,6 +79,10 @@ func parseProtinfo(infos []syscall.NetlinkRouteAttr) (pi Protinfo) {
 			pi.NeighSuppress = byteToBool(info.Value[0])
 		case nl.IFLA_BRPORT_VLAN_TUNNEL:
 			pi.VlanTunnel = byteToBool(info.Value[0])
+		case nl.IFLA_BRPORT_MULTICAST_ROUTER:
+			pi.McastRouter = int(info.Value[0])
+		case nl.IFLA_BRPORT_BACKUP_PORT:
+			pi.BackupPort = int(native.Uint32(info.Value[0:4]))
 		}
 
 	}
@@ -196,3 +196,79 @@ func TestProtinfo(t *testing.T) {
 		t.Fatalf("Isolated mode is not enabled for %s, but should", iface1.Name)
 	}
 }
+
+func TestProtinfoMcastRouter(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+	master := &Bridge{LinkAttrs: LinkAttrs{Name: "foo"}}
+	if err := LinkAdd(master); err != nil {
+		t.Fatal(err)
+	}
+	iface := &Dummy{LinkAttrs{Name: "bar", MasterIndex: master.Index}}
+	if err := LinkAdd(iface); err != nil {
+		t.Fatal(err)
+	}
+
+	pi, err := LinkGetProtinfo(iface)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pi.McastRouter != MDB_RTR_TYPE_TEMP_QUERY {
+		t.Fatalf("expected the default mcast router mode %d, got %d", MDB_RTR_TYPE_TEMP_QUERY, pi.McastRouter)
+	}
+
+	if err := LinkSetBrPortMcastRouter(iface, MDB_RTR_TYPE_PERM); err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkSetFastLeave(iface, true); err != nil {
+		t.Fatal(err)
+	}
+	pi, err = LinkGetProtinfo(iface)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pi.McastRouter != MDB_RTR_TYPE_PERM {
+		t.Fatalf("expected mcast router mode %d, got %d", MDB_RTR_TYPE_PERM, pi.McastRouter)
+	}
+	if !pi.FastLeave {
+		t.Fatalf("FastLeave is not enabled for %s, but should", iface.Name)
+	}
+}
+
+func TestProtinfoBackupPort(t *testing.T) {
+	minKernelRequired(t, 4, 19)
+	t.Cleanup(setUpNetlinkTest(t))
+	master := &Bridge{LinkAttrs: LinkAttrs{Name: "foo"}}
+	if err := LinkAdd(master); err != nil {
+		t.Fatal(err)
+	}
+	primary := &Dummy{LinkAttrs{Name: "bar1", MasterIndex: master.Index}}
+	if err := LinkAdd(primary); err != nil {
+		t.Fatal(err)
+	}
+	backup := &Dummy{LinkAttrs{Name: "bar2", MasterIndex: master.Index}}
+	if err := LinkAdd(backup); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := LinkSetBrPortBackup(primary, backup); err != nil {
+		t.Fatal(err)
+	}
+	pi, err := LinkGetProtinfo(primary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pi.BackupPort != backup.Index {
+		t.Fatalf("expected backup port %d, got %d", backup.Index, pi.BackupPort)
+	}
+
+	if err := LinkClearBrPortBackup(primary); err != nil {
+		t.Fatal(err)
+	}
+	pi, err = LinkGetProtinfo(primary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pi.BackupPort != 0 {
+		t.Fatalf("backup port %d still set", pi.BackupPort)
+	}
+}
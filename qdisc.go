@@ -1,10 +1,21 @@
 package netlink
 
 import (
+	"errors"
 	"fmt"
 	"math"
+	"strconv"
+	"strings"
 )
 
+// ErrQdiscNotFound is returned by QdiscGet when the link has no qdisc with
+// the requested handle. It wraps the kernel's ENOENT.
+var ErrQdiscNotFound = errors.New("qdisc not found")
+
+// ErrQdiscExists is returned by QdiscAddIdempotent when the link already
+// has a different qdisc at the requested parent.
+var ErrQdiscExists = errors.New("a different qdisc already exists")
+
 const (
 	HANDLE_NONE      = 0
 	HANDLE_INGRESS   = 0xFFFFFFF1
@@ -37,9 +48,14 @@ type QdiscAttrs struct {
 	LinkIndex    int
 	Handle       uint32
 	Parent       uint32
-	Refcnt       uint32 // read only
-	IngressBlock *uint32
+	Refcnt       uint32  // read only
+	IngressBlock *uint32 // shared filter block of ingress and clsact qdiscs
+	EgressBlock  *uint32 // shared filter block of clsact qdiscs
+	HwOffload    bool    // read only
 	Statistics   *QdiscStatistics
+	// TxQueue is the index of the tx queue that a child of a mq qdisc is
+	// attached to, nil for other qdiscs. It is set by QdiscList.
+	TxQueue *int
 }
 
 func (q QdiscAttrs) String() string {
@@ -68,6 +84,61 @@ func HandleStr(handle uint32) string {
 	}
 }
 
+// ParseHandle parses a qdisc, class or filter handle in the syntax of tc:
+// "maj:min" with both parts in hex and either of them possibly empty,
+// a plain 32 bit hex value, or one of "root", "none", "ingress" and
+// "clsact". It is the inverse of HandleStr.
+func ParseHandle(s string) (uint32, error) {
+	switch s {
+	case "root":
+		return HANDLE_ROOT, nil
+	case "none":
+		return HANDLE_NONE, nil
+	case "ingress", "clsact":
+		return HANDLE_INGRESS, nil
+	}
+	parseHex := func(v string, bits int) (uint32, error) {
+		if v == "" {
+			return 0, nil
+		}
+		n, err := strconv.ParseUint(strings.TrimPrefix(v, "0x"), 16, bits)
+		return uint32(n), err
+	}
+	major, minor, ok := strings.Cut(s, ":")
+	if !ok {
+		if s == "" {
+			return 0, fmt.Errorf("invalid handle %q", s)
+		}
+		handle, err := parseHex(s, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid handle %q", s)
+		}
+		return handle, nil
+	}
+	maj, err := parseHex(major, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid major %q in handle %q", major, s)
+	}
+	min, err := parseHex(minor, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid minor %q in handle %q", minor, s)
+	}
+	return MakeHandle(uint16(maj), uint16(min)), nil
+}
+
+// QdiscIsDefault reports whether qdisc looks like one the kernel attached
+// implicitly (pfifo_fast, fq_codel, noqueue, mq and its per-queue children)
+// rather than one that was explicitly installed. Implicit qdiscs are created
+// with a zero major handle and are never offloaded to hardware.
+func QdiscIsDefault(qdisc Qdisc) bool {
+	attrs := qdisc.Attrs()
+	if attrs.HwOffload {
+		return false
+	}
+	major, _ := MajorMinor(attrs.Handle)
+	return major == 0
+}
+
 func Percentage2u32(percentage float32) uint32 {
 	// FIXME this is most likely not the best way to convert from % to uint32
 	if percentage == 100 {
@@ -115,6 +186,14 @@ func (qdisc *Prio) Type() string {
 	return "prio"
 }
 
+// PrioBandHandle returns the handle of the class holding band of qdisc,
+// counting bands from 0 as PriorityMap does. Use it as the Parent of a
+// qdisc to attach to that band.
+func PrioBandHandle(qdisc *Prio, band uint8) uint32 {
+	major, _ := MajorMinor(qdisc.Handle)
+	return MakeHandle(major, uint16(band)+1)
+}
+
 // Htb is a classful qdisc that rate limits based on tokens
 type Htb struct {
 	QdiscAttrs
@@ -124,6 +203,9 @@ type Htb struct {
 	Debug        uint32
 	DirectPkts   uint32
 	DirectQlen   *uint32
+	// Offload asks the driver to run the whole HTB tree in hardware. It
+	// is only valid on the root qdisc and can't be changed afterwards.
+	Offload bool
 }
 
 func NewHtb(attrs QdiscAttrs) *Htb {
@@ -213,6 +295,12 @@ type Tbf struct {
 	Buffer   uint32
 	Peakrate uint64
 	Minburst uint32
+	// LinkLayer is one of the nl.LINKLAYER_* values. With nl.LINKLAYER_ATM
+	// the kernel accounts for ATM cell framing, including the per packet
+	// Overhead in bytes. Tbf sends no rate table, so there is no cell log
+	// to configure.
+	LinkLayer int
+	Overhead  uint16
 	// TODO: handle other settings
 }
 
@@ -237,6 +325,31 @@ func (qdisc *Clsact) Type() string {
 	return "clsact"
 }
 
+// Mq is the multiqueue qdisc, which holds one child qdisc per tx queue
+// of a multi-queue device. The kernel attaches one to these devices by
+// default, but with handle 0: it can't be addressed as a parent, so add
+// one with a handle to change the qdiscs of the queues.
+type Mq struct {
+	QdiscAttrs
+}
+
+func (qdisc *Mq) Attrs() *QdiscAttrs {
+	return &qdisc.QdiscAttrs
+}
+
+func (qdisc *Mq) Type() string {
+	return "mq"
+}
+
+// MqQueueHandle returns the minor number of the mq class holding the
+// child qdisc of the given tx queue. Combined with the handle of the mq
+// qdisc, it gives the parent to use to change the qdisc of that queue:
+//
+//	parent := mq.Handle | MqQueueHandle(queue)
+func MqQueueHandle(queue int) uint32 {
+	return MakeHandle(0, uint16(queue+1))
+}
+
 // Ingress is a qdisc for adding ingress filters
 type Ingress struct {
 	QdiscAttrs
@@ -310,6 +423,32 @@ type Fq struct {
 	LowRateThreshold  uint32
 	Horizon           uint32
 	HorizonDropPolicy uint8
+	// The following are left to the kernel default when nil.
+	// CEThreshold and TimerSlack are in us and ns respectively.
+	CEThreshold *uint32
+	TimerSlack  *uint32
+	OrphanMask  *uint32
+	// XStats is only filled in when the qdisc is read back from the kernel.
+	XStats *FqXStats
+}
+
+// FqXStats holds the fq specific statistics reported in TCA_XSTATS.
+type FqXStats struct {
+	GcFlows             uint64
+	HighprioPackets     uint64
+	TcpRetrans          uint64
+	Throttled           uint64
+	FlowsPlimit         uint64
+	PktsTooLong         uint64
+	AllocationErrors    uint64
+	TimeNextDelayedFlow int64
+	Flows               uint32
+	InactiveFlows       uint32
+	ThrottledFlows      uint32
+	UnthrottleLatencyNs uint32
+	CEMark              uint64
+	HorizonDrops        uint64
+	HorizonCaps         uint64
 }
 
 func (fq *Fq) String() string {
@@ -394,3 +533,37 @@ func (qdisc *Sfq) Attrs() *QdiscAttrs {
 func (qdisc *Sfq) Type() string {
 	return "sfq"
 }
+
+// Plug is a classless qdisc that buffers packets until it is told to
+// release them, see PlugBuffer, PlugReleaseOne and PlugReleaseIndefinite.
+type Plug struct {
+	QdiscAttrs
+	// Limit is the maximum number of bytes that can be buffered. The
+	// kernel defaults to one tx queue worth of MTU sized packets.
+	Limit uint32
+}
+
+func (plug *Plug) String() string {
+	return fmt.Sprintf("{%v -- Limit: %d}", plug.Attrs(), plug.Limit)
+}
+
+func (qdisc *Plug) Attrs() *QdiscAttrs {
+	return &qdisc.QdiscAttrs
+}
+
+func (qdisc *Plug) Type() string {
+	return "plug"
+}
+
+// Qfq is the Quick Fair Queueing classful qdisc. Its classes are QfqClass.
+type Qfq struct {
+	QdiscAttrs
+}
+
+func (qdisc *Qfq) Attrs() *QdiscAttrs {
+	return &qdisc.QdiscAttrs
+}
+
+func (qdisc *Qfq) Type() string {
+	return "qfq"
+}
@@ -93,6 +93,54 @@ func (h *Handle) QdiscDel(qdisc Qdisc) error {
 	return h.qdiscModify(unix.RTM_DELQDISC, 0, qdisc)
 }
 
+// QdiscReset restores the default root qdisc of link.
+// Equivalent to: `tc qdisc del dev $link root`
+//
+// The kernel attaches the default in the same operation that removes the
+// root qdisc, so the device never runs without one while it is up:
+// devices without a transmit queue (veth, bridge, vlan, macvlan, dummy,
+// loopback, ...) get noqueue, single queue devices get the qdisc selected
+// by net.core.default_qdisc (pfifo_fast unless changed) and multiqueue
+// devices get mq with one default qdisc per queue. On a link that is down
+// the root stays noop until the link is brought up. QdiscReset does
+// nothing if the root qdisc is already the default.
+func QdiscReset(link Link) error {
+	return pkgHandle.QdiscReset(link)
+}
+
+// QdiscReset restores the default root qdisc of link.
+// Equivalent to: `tc qdisc del dev $link root`
+//
+// The kernel attaches the default in the same operation that removes the
+// root qdisc, so the device never runs without one while it is up:
+// devices without a transmit queue (veth, bridge, vlan, macvlan, dummy,
+// loopback, ...) get noqueue, single queue devices get the qdisc selected
+// by net.core.default_qdisc (pfifo_fast unless changed) and multiqueue
+// devices get mq with one default qdisc per queue. On a link that is down
+// the root stays noop until the link is brought up. QdiscReset does
+// nothing if the root qdisc is already the default.
+func (h *Handle) QdiscReset(link Link) error {
+	qdiscs, err := h.QdiscList(link)
+	if err != nil {
+		return err
+	}
+	for _, qdisc := range qdiscs {
+		attrs := qdisc.Attrs()
+		if attrs.Parent != HANDLE_ROOT || QdiscIsDefault(qdisc) {
+			continue
+		}
+		return h.QdiscDel(&GenericQdisc{
+			QdiscAttrs: QdiscAttrs{
+				LinkIndex: attrs.LinkIndex,
+				Handle:    attrs.Handle,
+				Parent:    HANDLE_ROOT,
+			},
+			QdiscType: qdisc.Type(),
+		})
+	}
+	return nil
+}
+
 // QdiscChange will change a qdisc in place
 // Equivalent to: `tc qdisc change $qdisc`
 // The parent and handle MUST NOT be changed.
@@ -124,6 +172,112 @@ func (h *Handle) QdiscReplace(qdisc Qdisc) error {
 		qdisc)
 }
 
+// QdiscEnsure makes qdisc the root qdisc of link, replacing the current
+// root only when it differs structurally (kind, handle or parent).
+// The parameters of the qdisc are not compared: a root of the same kind
+// and handle is kept as it is, use QdiscChange or QdiscReplace to update
+// them. If qdisc has no handle set and the current root was not attached
+// implicitly by the kernel, the existing handle is preserved so that
+// classes and filters referring to it stay valid. The LinkIndex, Parent
+// and Handle of qdisc.Attrs() are set to the values used.
+func QdiscEnsure(link Link, qdisc Qdisc) error {
+	return pkgHandle.QdiscEnsure(link, qdisc)
+}
+
+// QdiscEnsure makes qdisc the root qdisc of link, replacing the current
+// root only when it differs structurally (kind, handle or parent).
+// The parameters of the qdisc are not compared: a root of the same kind
+// and handle is kept as it is, use QdiscChange or QdiscReplace to update
+// them. If qdisc has no handle set and the current root was not attached
+// implicitly by the kernel, the existing handle is preserved so that
+// classes and filters referring to it stay valid. The LinkIndex, Parent
+// and Handle of qdisc.Attrs() are set to the values used.
+func (h *Handle) QdiscEnsure(link Link, qdisc Qdisc) error {
+	base := link.Attrs()
+	h.ensureIndex(base)
+	attrs := qdisc.Attrs()
+	attrs.LinkIndex = base.Index
+	if attrs.Parent == HANDLE_NONE {
+		attrs.Parent = HANDLE_ROOT
+	}
+
+	qdiscs, err := h.QdiscList(link)
+	if err != nil {
+		return err
+	}
+	for _, q := range qdiscs {
+		cur := q.Attrs()
+		if cur.Parent != HANDLE_ROOT {
+			continue
+		}
+		if attrs.Handle == HANDLE_NONE && !QdiscIsDefault(q) {
+			attrs.Handle = cur.Handle
+		}
+		if q.Type() == qdisc.Type() && cur.Handle == attrs.Handle {
+			return nil
+		}
+		break
+	}
+	return h.QdiscReplace(qdisc)
+}
+
+// QdiscAddIdempotent adds qdisc like QdiscAdd but succeeds without
+// changes when an identical qdisc (same kind and parent, and the same
+// handle and shared blocks where set) already exists, which makes
+// installing ingress and clsact qdiscs safe to repeat. created reports
+// whether the qdisc was added. A different qdisc at the same parent returns
+// an error wrapping ErrQdiscExists.
+// Equivalent to: `tc qdisc add $qdisc`
+func QdiscAddIdempotent(qdisc Qdisc) (created bool, err error) {
+	return pkgHandle.QdiscAddIdempotent(qdisc)
+}
+
+// QdiscAddIdempotent adds qdisc like QdiscAdd but succeeds without
+// changes when an identical qdisc (same kind and parent, and the same
+// handle and shared blocks where set) already exists, which makes
+// installing ingress and clsact qdiscs safe to repeat. created reports
+// whether the qdisc was added. A different qdisc at the same parent returns
+// an error wrapping ErrQdiscExists.
+// Equivalent to: `tc qdisc add $qdisc`
+func (h *Handle) QdiscAddIdempotent(qdisc Qdisc) (created bool, err error) {
+	err = h.QdiscAdd(qdisc)
+	if err == nil {
+		return true, nil
+	}
+	if !errors.Is(err, unix.EEXIST) {
+		return false, err
+	}
+
+	attrs := qdisc.Attrs()
+	qdiscs, listErr := h.QdiscList(&Device{LinkAttrs{Index: attrs.LinkIndex}})
+	if listErr != nil {
+		return false, listErr
+	}
+	for _, q := range qdiscs {
+		cur := q.Attrs()
+		if cur.Parent != attrs.Parent && (attrs.Handle == HANDLE_NONE || cur.Handle != attrs.Handle) {
+			continue
+		}
+		if q.Type() == qdisc.Type() && cur.Parent == attrs.Parent &&
+			(attrs.Handle == HANDLE_NONE || cur.Handle == attrs.Handle) &&
+			sameQdiscBlock(attrs.IngressBlock, cur.IngressBlock) &&
+			sameQdiscBlock(attrs.EgressBlock, cur.EgressBlock) {
+			return false, nil
+		}
+		return false, fmt.Errorf("%w: %s %s at parent %s: %w", ErrQdiscExists, q.Type(), HandleStr(cur.Handle), HandleStr(cur.Parent), err)
+	}
+	return false, err
+}
+
+// sameQdiscBlock reports whether the block of an existing qdisc matches
+// the requested one, an unset request matches any block.
+func sameQdiscBlock(requested, current *uint32) bool {
+	if requested == nil {
+		return true
+	}
+	return current != nil && *current == *requested
+}
+
 // QdiscAdd will add a qdisc to the system.
 // Equivalent to: `tc qdisc add $qdisc`
 func QdiscAdd(qdisc Qdisc) error {
@@ -139,6 +293,80 @@ func (h *Handle) QdiscAdd(qdisc Qdisc) error {
 		qdisc)
 }
 
+// PlugBuffer makes the plug qdisc of link start buffering packets again
+// after a release. Packets queued before this point can still be released.
+// Equivalent to: `tc qdisc change dev $link root plug block`
+func PlugBuffer(link Link) error {
+	return pkgHandle.PlugBuffer(link)
+}
+
+// PlugBuffer makes the plug qdisc of link start buffering packets again
+// after a release. Packets queued before this point can still be released.
+// Equivalent to: `tc qdisc change dev $link root plug block`
+func (h *Handle) PlugBuffer(link Link) error {
+	return h.plugControl(link, nl.TCQ_PLUG_BUFFER)
+}
+
+// PlugReleaseOne dequeues the packets buffered up to the most recent
+// PlugBuffer call on the plug qdisc of link.
+// Equivalent to: `tc qdisc change dev $link root plug release`
+func PlugReleaseOne(link Link) error {
+	return pkgHandle.PlugReleaseOne(link)
+}
+
+// PlugReleaseOne dequeues the packets buffered up to the most recent
+// PlugBuffer call on the plug qdisc of link.
+// Equivalent to: `tc qdisc change dev $link root plug release`
+func (h *Handle) PlugReleaseOne(link Link) error {
+	return h.plugControl(link, nl.TCQ_PLUG_RELEASE_ONE)
+}
+
+// PlugReleaseIndefinite unplugs the plug qdisc of link so that it passes
+// all traffic through until PlugBuffer is called again.
+// Equivalent to: `tc qdisc change dev $link root plug release_indefinite`
+func PlugReleaseIndefinite(link Link) error {
+	return pkgHandle.PlugReleaseIndefinite(link)
+}
+
+// PlugReleaseIndefinite unplugs the plug qdisc of link so that it passes
+// all traffic through until PlugBuffer is called again.
+// Equivalent to: `tc qdisc change dev $link root plug release_indefinite`
+func (h *Handle) PlugReleaseIndefinite(link Link) error {
+	return h.plugControl(link, nl.TCQ_PLUG_RELEASE_INDEFINITE)
+}
+
+func (h *Handle) plugControl(link Link, action int32) error {
+	qdiscs, err := h.QdiscList(link)
+	if err != nil {
+		return err
+	}
+	var plug *Plug
+	for _, q := range qdiscs {
+		if p, ok := q.(*Plug); ok {
+			plug = p
+			break
+		}
+	}
+	if plug == nil {
+		return fmt.Errorf("no plug qdisc on link %s", link.Attrs().Name)
+	}
+
+	req := h.newNetlinkRequest(unix.RTM_NEWQDISC, unix.NLM_F_ACK)
+	msg := &nl.TcMsg{
+		Family:  nl.FAMILY_ALL,
+		Ifindex: int32(plug.LinkIndex),
+		Handle:  plug.Handle,
+		Parent:  plug.Parent,
+	}
+	req.AddData(msg)
+	req.AddData(nl.NewRtAttr(nl.TCA_KIND, nl.ZeroTerminated(plug.Type())))
+	opt := nl.TcPlugQopt{Action: action}
+	req.AddData(nl.NewRtAttr(nl.TCA_OPTIONS, opt.Serialize()))
+
+	_, err = req.Execute(unix.NETLINK_ROUTE, 0)
+	return err
+}
+
 func (h *Handle) qdiscModify(cmd, flags int, qdisc Qdisc) error {
 	req := h.newNetlinkRequest(cmd, flags|unix.NLM_F_ACK)
 	base := qdisc.Attrs()
@@ -158,6 +386,9 @@ func (h *Handle) qdiscModify(cmd, flags int, qdisc Qdisc) error {
 	}
 
 	_, err := req.Execute(unix.NETLINK_ROUTE, 0)
+	if htb, ok := qdisc.(*Htb); ok && htb.Offload && errors.Is(err, unix.EOPNOTSUPP) {
+		return fmt.Errorf("htb offload is not supported by the device: %w", err)
+	}
 	return err
 }
 
@@ -167,11 +398,22 @@ func qdiscPayload(req *nl.NetlinkRequest, qdisc Qdisc) error {
 	if qdisc.Attrs().IngressBlock != nil {
 		req.AddData(nl.NewRtAttr(nl.TCA_INGRESS_BLOCK, nl.Uint32Attr(*qdisc.Attrs().IngressBlock)))
 	}
+	if qdisc.Attrs().EgressBlock != nil {
+		req.AddData(nl.NewRtAttr(nl.TCA_EGRESS_BLOCK, nl.Uint32Attr(*qdisc.Attrs().EgressBlock)))
+	}
 
 	options := nl.NewRtAttr(nl.TCA_OPTIONS, nil)
 
 	switch qdisc := qdisc.(type) {
 	case *Prio:
+		if qdisc.Bands < nl.TCQ_MIN_PRIO_BANDS || qdisc.Bands > nl.TCQ_PRIO_BANDS {
+			return fmt.Errorf("prio bands must be between %d and %d, got %d", nl.TCQ_MIN_PRIO_BANDS, nl.TCQ_PRIO_BANDS, qdisc.Bands)
+		}
+		for prio, band := range qdisc.PriorityMap {
+			if band >= qdisc.Bands {
+				return fmt.Errorf("priority %d maps to band %d, but prio has %d bands", prio, band, qdisc.Bands)
+			}
+		}
 		tcmap := nl.TcPrioMap{
 			Bands:   int32(qdisc.Bands),
 			Priomap: qdisc.PriorityMap,
@@ -181,6 +423,10 @@ func qdiscPayload(req *nl.NetlinkRequest, qdisc Qdisc) error {
 		opt := nl.TcTbfQopt{}
 		opt.Rate.Rate = uint32(qdisc.Rate)
 		opt.Peakrate.Rate = uint32(qdisc.Peakrate)
+		for _, rate := range []*nl.TcRateSpec{&opt.Rate, &opt.Peakrate} {
+			rate.Linklayer = uint8(qdisc.LinkLayer & nl.TC_LINKLAYER_MASK)
+			rate.Overhead = qdisc.Overhead
+		}
 		opt.Limit = qdisc.Limit
 		opt.Buffer = qdisc.Buffer
 		options.AddRtAttr(nl.TCA_TBF_PARMS, opt.Serialize())
@@ -194,6 +440,9 @@ func qdiscPayload(req *nl.NetlinkRequest, qdisc Qdisc) error {
 			options.AddRtAttr(nl.TCA_TBF_PBURST, nl.Uint32Attr(qdisc.Minburst))
 		}
 	case *Htb:
+		if qdisc.Offload && qdisc.Attrs().Parent != HANDLE_ROOT {
+			return fmt.Errorf("htb offload is only supported on the root qdisc")
+		}
 		opt := nl.TcHtbGlob{}
 		opt.Version = qdisc.Version
 		opt.Rate2Quantum = qdisc.Rate2Quantum
@@ -205,6 +454,9 @@ func qdiscPayload(req *nl.NetlinkRequest, qdisc Qdisc) error {
 		if qdisc.DirectQlen != nil {
 			options.AddRtAttr(nl.TCA_HTB_DIRECT_QLEN, nl.Uint32Attr(*qdisc.DirectQlen))
 		}
+		if qdisc.Offload {
+			options.AddRtAttr(nl.TCA_HTB_OFFLOAD, nil)
+		}
 	case *Hfsc:
 		opt := nl.TcHfscOpt{}
 		opt.Defcls = qdisc.Defcls
@@ -318,6 +570,15 @@ func qdiscPayload(req *nl.NetlinkRequest, qdisc Qdisc) error {
 		if qdisc.HorizonDropPolicy != HORIZON_DROP_POLICY_DEFAULT {
 			options.AddRtAttr(nl.TCA_FQ_HORIZON_DROP, nl.Uint8Attr(qdisc.HorizonDropPolicy))
 		}
+		if qdisc.CEThreshold != nil {
+			options.AddRtAttr(nl.TCA_FQ_CE_THRESHOLD, nl.Uint32Attr(*qdisc.CEThreshold))
+		}
+		if qdisc.TimerSlack != nil {
+			options.AddRtAttr(nl.TCA_FQ_TIMER_SLACK, nl.Uint32Attr(*qdisc.TimerSlack))
+		}
+		if qdisc.OrphanMask != nil {
+			options.AddRtAttr(nl.TCA_FQ_ORPHAN_MASK, nl.Uint32Attr(*qdisc.OrphanMask))
+		}
 	case *Sfq:
 		opt := nl.TcSfqQoptV1{}
 		opt.TcSfqQopt.Quantum = qdisc.Quantum
@@ -326,6 +587,12 @@ func qdiscPayload(req *nl.NetlinkRequest, qdisc Qdisc) error {
 		opt.TcSfqQopt.Divisor = qdisc.Divisor
 
 		options = nl.NewRtAttr(nl.TCA_OPTIONS, opt.Serialize())
+	case *Plug:
+		options = nil
+		if qdisc.Limit != 0 {
+			opt := nl.TcPlugQopt{Action: nl.TCQ_PLUG_LIMIT, Limit: qdisc.Limit}
+			options = nl.NewRtAttr(nl.TCA_OPTIONS, opt.Serialize())
+		}
 	default:
 		options = nil
 	}
@@ -375,136 +642,232 @@ func (h *Handle) QdiscList(link Link) ([]Qdisc, error) {
 	for _, m := range msgs {
 		msg := nl.DeserializeTcMsg(m)
 
-		attrs, err := nl.ParseRouteAttr(m[msg.Len():])
+		// skip qdiscs from other interfaces
+		if link != nil && msg.Ifindex != index {
+			continue
+		}
+
+		qdisc, err := parseQdisc(m)
 		if err != nil {
 			return nil, err
 		}
+		res = append(res, qdisc)
+	}
+	setMqTxQueues(res)
 
-		// skip qdiscs from other interfaces
-		if link != nil && msg.Ifindex != index {
+	return res, executeErr
+}
+
+// setMqTxQueues sets the TxQueue of the children of the mq qdiscs among
+// qdiscs, whose minor parent number is the tx queue index plus one.
+func setMqTxQueues(qdiscs []Qdisc) {
+	type mqKey struct {
+		linkIndex int
+		major     uint16
+	}
+	mqs := make(map[mqKey]bool)
+	for _, qdisc := range qdiscs {
+		if mq, ok := qdisc.(*Mq); ok {
+			major, _ := MajorMinor(mq.Handle)
+			mqs[mqKey{mq.LinkIndex, major}] = true
+		}
+	}
+	if len(mqs) == 0 {
+		return
+	}
+	for _, qdisc := range qdiscs {
+		attrs := qdisc.Attrs()
+		if attrs.Parent == HANDLE_ROOT {
+			continue
+		}
+		major, minor := MajorMinor(attrs.Parent)
+		if minor == 0 || !mqs[mqKey{attrs.LinkIndex, major}] {
 			continue
 		}
+		queue := int(minor) - 1
+		attrs.TxQueue = &queue
+	}
+}
+
+// QdiscGet gets the qdisc of link with the given handle, without dumping
+// every qdisc of the system. The kernel does not report the parent of a
+// qdisc looked up by handle, so Parent is always HANDLE_NONE.
+// Equivalent to: `tc qdisc show dev $link handle $handle`.
+func QdiscGet(link Link, handle uint32) (Qdisc, error) {
+	return pkgHandle.QdiscGet(link, handle)
+}
+
+// QdiscGet gets the qdisc of link with the given handle, without dumping
+// every qdisc of the system. The kernel does not report the parent of a
+// qdisc looked up by handle, so Parent is always HANDLE_NONE.
+// Equivalent to: `tc qdisc show dev $link handle $handle`.
+func (h *Handle) QdiscGet(link Link, handle uint32) (Qdisc, error) {
+	base := link.Attrs()
+	h.ensureIndex(base)
+	// the kernel only unicasts the reply to a qdisc get when asked to echo
+	req := h.newNetlinkRequest(unix.RTM_GETQDISC, unix.NLM_F_ECHO)
+	msg := &nl.TcMsg{
+		Family:  nl.FAMILY_ALL,
+		Ifindex: int32(base.Index),
+		Handle:  handle,
+	}
+	req.AddData(msg)
+
+	msgs, err := req.Execute(unix.NETLINK_ROUTE, unix.RTM_NEWQDISC)
+	if err != nil {
+		if errors.Is(err, unix.ENOENT) {
+			return nil, fmt.Errorf("%w: %w", ErrQdiscNotFound, err)
+		}
+		return nil, err
+	}
+	if len(msgs) != 1 {
+		return nil, fmt.Errorf("expected 1 qdisc, got %d", len(msgs))
+	}
+	return parseQdisc(msgs[0])
+}
+
+// parseQdisc decodes a RTM_NEWQDISC message.
+func parseQdisc(m []byte) (Qdisc, error) {
+	msg := nl.DeserializeTcMsg(m)
+
+	attrs, err := nl.ParseRouteAttr(m[msg.Len():])
+	if err != nil {
+		return nil, err
+	}
 
-		base := QdiscAttrs{
-			LinkIndex: int(msg.Ifindex),
-			Handle:    msg.Handle,
-			Parent:    msg.Parent,
-			Refcnt:    msg.Info,
-		}
-		var qdisc Qdisc
-		qdiscType := ""
-		for _, attr := range attrs {
-			switch attr.Attr.Type {
-			case nl.TCA_KIND:
-				qdiscType = string(attr.Value[:len(attr.Value)-1])
-				switch qdiscType {
-				case "pfifo_fast":
-					qdisc = &PfifoFast{}
-				case "prio":
-					qdisc = &Prio{}
-				case "tbf":
-					qdisc = &Tbf{}
-				case "ingress":
-					qdisc = &Ingress{}
-				case "htb":
-					qdisc = &Htb{}
-				case "fq":
-					qdisc = &Fq{}
-				case "hfsc":
-					qdisc = &Hfsc{}
-				case "fq_codel":
-					qdisc = &FqCodel{}
-				case "netem":
-					qdisc = &Netem{}
-				case "sfq":
-					qdisc = &Sfq{}
-				case "clsact":
-					qdisc = &Clsact{}
-				default:
-					qdisc = &GenericQdisc{QdiscType: qdiscType}
+	base := QdiscAttrs{
+		LinkIndex: int(msg.Ifindex),
+		Handle:    msg.Handle,
+		Parent:    msg.Parent,
+		Refcnt:    msg.Info,
+	}
+	var qdisc Qdisc
+	qdiscType := ""
+	for _, attr := range attrs {
+		switch attr.Attr.Type {
+		case nl.TCA_KIND:
+			qdiscType = string(attr.Value[:len(attr.Value)-1])
+			switch qdiscType {
+			case "pfifo_fast":
+				qdisc = &PfifoFast{}
+			case "prio":
+				qdisc = &Prio{}
+			case "tbf":
+				qdisc = &Tbf{}
+			case "ingress":
+				qdisc = &Ingress{}
+			case "mq":
+				qdisc = &Mq{}
+			case "htb":
+				qdisc = &Htb{}
+			case "fq":
+				qdisc = &Fq{}
+			case "hfsc":
+				qdisc = &Hfsc{}
+			case "fq_codel":
+				qdisc = &FqCodel{}
+			case "netem":
+				qdisc = &Netem{}
+			case "sfq":
+				qdisc = &Sfq{}
+			case "clsact":
+				qdisc = &Clsact{}
+			case "plug":
+				qdisc = &Plug{}
+			case "qfq":
+				qdisc = &Qfq{}
+			default:
+				qdisc = &GenericQdisc{QdiscType: qdiscType}
+			}
+		case nl.TCA_OPTIONS:
+			switch qdiscType {
+			case "pfifo_fast":
+				// pfifo returns TcPrioMap directly without wrapping it in rtattr
+				if err := parsePfifoFastData(qdisc, attr.Value); err != nil {
+					return nil, err
+				}
+			case "prio":
+				// prio returns TcPrioMap directly without wrapping it in rtattr
+				if err := parsePrioData(qdisc, attr.Value); err != nil {
+					return nil, err
 				}
-			case nl.TCA_OPTIONS:
-				switch qdiscType {
-				case "pfifo_fast":
-					// pfifo returns TcPrioMap directly without wrapping it in rtattr
-					if err := parsePfifoFastData(qdisc, attr.Value); err != nil {
-						return nil, err
-					}
-				case "prio":
-					// prio returns TcPrioMap directly without wrapping it in rtattr
-					if err := parsePrioData(qdisc, attr.Value); err != nil {
-						return nil, err
-					}
-				case "tbf":
-					data, err := nl.ParseRouteAttr(attr.Value)
-					if err != nil {
-						return nil, err
-					}
-					if err := parseTbfData(qdisc, data); err != nil {
-						return nil, err
-					}
-				case "hfsc":
-					if err := parseHfscData(qdisc, attr.Value); err != nil {
-						return nil, err
-					}
-				case "htb":
-					data, err := nl.ParseRouteAttr(attr.Value)
-					if err != nil {
-						return nil, err
-					}
-					if err := parseHtbData(qdisc, data); err != nil {
-						return nil, err
-					}
-				case "fq":
-					data, err := nl.ParseRouteAttr(attr.Value)
-					if err != nil {
-						return nil, err
-					}
-					if err := parseFqData(qdisc, data); err != nil {
-						return nil, err
-					}
-				case "fq_codel":
-					data, err := nl.ParseRouteAttr(attr.Value)
-					if err != nil {
-						return nil, err
-					}
-					if err := parseFqCodelData(qdisc, data); err != nil {
-						return nil, err
-					}
-				case "netem":
-					if err := parseNetemData(qdisc, attr.Value); err != nil {
-						return nil, err
-					}
-				case "sfq":
-					if err := parseSfqData(qdisc, attr.Value); err != nil {
-						return nil, err
-					}
-
-					// no options for ingress
+			case "tbf":
+				data, err := nl.ParseRouteAttr(attr.Value)
+				if err != nil {
+					return nil, err
+				}
+				if err := parseTbfData(qdisc, data); err != nil {
+					return nil, err
+				}
+			case "hfsc":
+				if err := parseHfscData(qdisc, attr.Value); err != nil {
+					return nil, err
 				}
-			case nl.TCA_INGRESS_BLOCK:
-				ingressBlock := new(uint32)
-				*ingressBlock = native.Uint32(attr.Value)
-				base.IngressBlock = ingressBlock
-			case nl.TCA_STATS:
-				s, err := parseTcStats(attr.Value)
+			case "htb":
+				data, err := nl.ParseRouteAttr(attr.Value)
 				if err != nil {
 					return nil, err
 				}
-				base.Statistics = (*QdiscStatistics)(s)
-			case nl.TCA_STATS2:
-				s, err := parseTcStats2(attr.Value)
+				if err := parseHtbData(qdisc, data); err != nil {
+					return nil, err
+				}
+			case "fq":
+				data, err := nl.ParseRouteAttr(attr.Value)
 				if err != nil {
 					return nil, err
 				}
-				base.Statistics = (*QdiscStatistics)(s)
+				if err := parseFqData(qdisc, data); err != nil {
+					return nil, err
+				}
+			case "fq_codel":
+				data, err := nl.ParseRouteAttr(attr.Value)
+				if err != nil {
+					return nil, err
+				}
+				if err := parseFqCodelData(qdisc, data); err != nil {
+					return nil, err
+				}
+			case "netem":
+				if err := parseNetemData(qdisc, attr.Value); err != nil {
+					return nil, err
+				}
+			case "sfq":
+				if err := parseSfqData(qdisc, attr.Value); err != nil {
+					return nil, err
+				}
+
+				// no options for ingress
+			}
+		case nl.TCA_INGRESS_BLOCK:
+			ingressBlock := new(uint32)
+			*ingressBlock = native.Uint32(attr.Value)
+			base.IngressBlock = ingressBlock
+		case nl.TCA_EGRESS_BLOCK:
+			egressBlock := new(uint32)
+			*egressBlock = native.Uint32(attr.Value)
+			base.EgressBlock = egressBlock
+		case nl.TCA_HW_OFFLOAD:
+			base.HwOffload = attr.Value[0] != 0
+		case nl.TCA_STATS:
+			s, err := parseTcStats(attr.Value)
+			if err != nil {
+				return nil, err
+			}
+			base.Statistics = (*QdiscStatistics)(s)
+		case nl.TCA_STATS2:
+			s, err := parseTcStats2(attr.Value)
+			if err != nil {
+				return nil, err
+			}
+			base.Statistics = (*QdiscStatistics)(s)
+		case nl.TCA_XSTATS:
+			if fq, ok := qdisc.(*Fq); ok {
+				parseFqXStats(fq, attr.Value)
 			}
 		}
-		*qdisc.Attrs() = base
-		res = append(res, qdisc)
 	}
-
-	return res, executeErr
+	*qdisc.Attrs() = base
+	return qdisc, nil
 }
 
 func parsePfifoFastData(qdisc Qdisc, value []byte) error {
@@ -537,6 +900,8 @@ func parseHtbData(qdisc Qdisc, data []syscall.NetlinkRouteAttr) error {
 		case nl.TCA_HTB_DIRECT_QLEN:
 			directQlen := native.Uint32(datum.Value)
 			htb.DirectQlen = &directQlen
+		case nl.TCA_HTB_OFFLOAD:
+			htb.Offload = true
 		}
 	}
 	return nil
@@ -591,7 +956,14 @@ func parseFqData(qdisc Qdisc, data []syscall.NetlinkRouteAttr) error {
 		case nl.TCA_FQ_INITIAL_QUANTUM:
 			fq.InitialQuantum = native.Uint32(datum.Value)
 		case nl.TCA_FQ_ORPHAN_MASK:
-			// TODO
+			orphanMask := native.Uint32(datum.Value)
+			fq.OrphanMask = &orphanMask
+		case nl.TCA_FQ_CE_THRESHOLD:
+			ceThreshold := native.Uint32(datum.Value)
+			fq.CEThreshold = &ceThreshold
+		case nl.TCA_FQ_TIMER_SLACK:
+			timerSlack := native.Uint32(datum.Value)
+			fq.TimerSlack = &timerSlack
 		case nl.TCA_FQ_FLOW_REFILL_DELAY:
 			fq.FlowRefillDelay = native.Uint32(datum.Value)
 		case nl.TCA_FQ_FLOW_PLIMIT:
@@ -612,6 +984,27 @@ func parseFqData(qdisc Qdisc, data []syscall.NetlinkRouteAttr) error {
 	return nil
 }
 
+func parseFqXStats(fq *Fq, value []byte) {
+	s := nl.DeserializeTcFqQdStats(value)
+	fq.XStats = &FqXStats{
+		GcFlows:             s.GcFlows,
+		HighprioPackets:     s.HighprioPackets,
+		TcpRetrans:          s.TcpRetrans,
+		Throttled:           s.Throttled,
+		FlowsPlimit:         s.FlowsPlimit,
+		PktsTooLong:         s.PktsTooLong,
+		AllocationErrors:    s.AllocationErrors,
+		TimeNextDelayedFlow: s.TimeNextDelayedFlow,
+		Flows:               s.Flows,
+		InactiveFlows:       s.InactiveFlows,
+		ThrottledFlows:      s.ThrottledFlows,
+		UnthrottleLatencyNs: s.UnthrottleLatencyNs,
+		CEMark:              s.CeMark,
+		HorizonDrops:        s.HorizonDrops,
+		HorizonCaps:         s.HorizonCaps,
+	}
+}
+
 func parseNetemData(qdisc Qdisc, value []byte) error {
 	netem := qdisc.(*Netem)
 	opt := nl.DeserializeTcNetemQopt(value)
@@ -668,6 +1061,8 @@ func parseTbfData(qdisc Qdisc, data []syscall.NetlinkRouteAttr) error {
 			tbf.Peakrate = uint64(opt.Peakrate.Rate)
 			tbf.Limit = opt.Limit
 			tbf.Buffer = opt.Buffer
+			tbf.LinkLayer = int(opt.Rate.Linklayer) & nl.TC_LINKLAYER_MASK
+			tbf.Overhead = opt.Rate.Overhead
 		case nl.TCA_TBF_RATE64:
 			tbf.Rate = native.Uint64(datum.Value[0:8])
 		case nl.TCA_TBF_PRATE64:
@@ -4,7 +4,11 @@
 package netlink
 
 import (
+	"errors"
 	"testing"
+
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
 )
 
 func TestTbfAddDel(t *testing.T) {
@@ -25,9 +29,11 @@ func TestTbfAddDel(t *testing.T) {
 			Handle:    MakeHandle(1, 0),
 			Parent:    HANDLE_ROOT,
 		},
-		Rate:   131072,
-		Limit:  1220703,
-		Buffer: 16793,
+		Rate:      131072,
+		Limit:     1220703,
+		Buffer:    16793,
+		LinkLayer: nl.LINKLAYER_ATM,
+		Overhead:  10,
 	}
 	if err := QdiscAdd(qdisc); err != nil {
 		t.Fatal(err)
@@ -52,6 +58,21 @@ func TestTbfAddDel(t *testing.T) {
 	if tbf.Buffer != qdisc.Buffer {
 		t.Fatal("Buffer doesn't match")
 	}
+	if tbf.LinkLayer != qdisc.LinkLayer || tbf.Overhead != qdisc.Overhead {
+		t.Fatalf("LinkLayer %d and Overhead %d don't match", tbf.LinkLayer, tbf.Overhead)
+	}
+	got, err := QdiscGet(link, qdisc.Handle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotTbf, ok := got.(*Tbf)
+	if !ok {
+		t.Fatal("QdiscGet returned the wrong type")
+	}
+	if gotTbf.Handle != tbf.Handle || gotTbf.Rate != tbf.Rate || gotTbf.Limit != tbf.Limit ||
+		gotTbf.Buffer != tbf.Buffer {
+		t.Fatalf("QdiscGet %+v doesn't match QdiscList %+v", gotTbf, tbf)
+	}
 	if err := QdiscDel(qdisc); err != nil {
 		t.Fatal(err)
 	}
@@ -62,6 +83,92 @@ func TestTbfAddDel(t *testing.T) {
 	if len(qdiscs) != 0 {
 		t.Fatal("Failed to remove qdisc")
 	}
+	if _, err := QdiscGet(link, qdisc.Handle); !errors.Is(err, ErrQdiscNotFound) {
+		t.Fatalf("expected ErrQdiscNotFound, got %v", err)
+	}
+}
+
+// sendRawFrames writes count copies of the ethernet frame out of link and
+// returns how many packets the link's tx counter advanced by.
+func sendRawFrames(t *testing.T, link Link, frame []byte, count int) uint64 {
+	t.Helper()
+	proto := int(htons(unix.ETH_P_ALL)[0]) | int(htons(unix.ETH_P_ALL)[1])<<8
+	fd, err := unix.Socket(unix.AF_PACKET, unix.SOCK_RAW, proto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unix.Close(fd)
+
+	before, err := LinkByIndex(link.Attrs().Index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := &unix.SockaddrLinklayer{Ifindex: link.Attrs().Index, Protocol: uint16(proto)}
+	for i := 0; i < count; i++ {
+		if err := unix.Sendto(fd, frame, 0, addr); err != nil {
+			t.Fatal(err)
+		}
+	}
+	after, err := LinkByIndex(link.Attrs().Index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return after.Attrs().Statistics.TxPackets - before.Attrs().Statistics.TxPackets
+}
+
+func TestQdiscReset(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+	if err := LinkAdd(&Veth{LinkAttrs: LinkAttrs{Name: "foo"}, PeerName: "bar"}); err != nil {
+		t.Fatal(err)
+	}
+	link, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	peer, err := LinkByName("bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkSetUp(link); err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkSetUp(peer); err != nil {
+		t.Fatal(err)
+	}
+
+	// nothing to do while the default is installed
+	if err := QdiscReset(link); err != nil {
+		t.Fatal(err)
+	}
+
+	htb := NewHtb(QdiscAttrs{
+		LinkIndex: link.Attrs().Index,
+		Handle:    MakeHandle(1, 0),
+		Parent:    HANDLE_ROOT,
+	})
+	if err := QdiscAdd(htb); err != nil {
+		t.Fatal(err)
+	}
+	if err := QdiscReset(link); err != nil {
+		t.Fatal(err)
+	}
+	qdiscs, err := QdiscList(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(qdiscs) != 1 || qdiscs[0].Type() != "noqueue" || !QdiscIsDefault(qdiscs[0]) {
+		t.Fatalf("expected only the default noqueue root on veth after reset, got %v", qdiscs)
+	}
+
+	// broadcast frame of a local experimental ethertype
+	frame := make([]byte, 60)
+	copy(frame, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x02, 0, 0, 0, 0, 1, 0x88, 0xb5})
+	if sent := sendRawFrames(t, link, frame, 5); sent < 5 {
+		t.Fatalf("expected 5 packets to be transmitted after reset, got %d", sent)
+	}
+	if err := QdiscReset(link); err != nil {
+		t.Fatal(err)
+	}
 }
 
 func TestHtbAddDel(t *testing.T) {
@@ -114,6 +221,9 @@ func TestHtbAddDel(t *testing.T) {
 	if htb.DirectQlen == nil || *htb.DirectQlen != directQlen {
 		t.Fatalf("DirectQlen doesn't match. Expected %d, got %v", directQlen, htb.DirectQlen)
 	}
+	if htb.Offload {
+		t.Fatal("Offload doesn't match")
+	}
 	if err := QdiscDel(qdisc); err != nil {
 		t.Fatal(err)
 	}
@@ -124,6 +234,21 @@ func TestHtbAddDel(t *testing.T) {
 	if len(qdiscs) != 0 {
 		t.Fatal("Failed to remove qdisc")
 	}
+
+	// ifb can't offload htb
+	qdisc.Offload = true
+	if err := QdiscAdd(qdisc); !errors.Is(err, unix.EOPNOTSUPP) {
+		t.Fatalf("expected EOPNOTSUPP adding an offloaded htb, got %v", err)
+	}
+	child := NewHtb(QdiscAttrs{
+		LinkIndex: link.Attrs().Index,
+		Handle:    MakeHandle(2, 0),
+		Parent:    MakeHandle(1, 1),
+	})
+	child.Offload = true
+	if err := QdiscAdd(child); err == nil {
+		t.Fatal("expected an error adding an offloaded htb below the root")
+	}
 }
 
 func TestSfqAddDel(t *testing.T) {
@@ -234,6 +359,82 @@ func TestPrioAddDel(t *testing.T) {
 	}
 }
 
+func TestPrioBands(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+	if err := LinkAdd(&Ifb{LinkAttrs{Name: "foo"}}); err != nil {
+		t.Fatal(err)
+	}
+	link, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkSetUp(link); err != nil {
+		t.Fatal(err)
+	}
+	prio := &Prio{
+		QdiscAttrs: QdiscAttrs{
+			LinkIndex: link.Attrs().Index,
+			Handle:    MakeHandle(1, 0),
+			Parent:    HANDLE_ROOT,
+		},
+		Bands:       4,
+		PriorityMap: [PRIORITY_MAP_LEN]uint8{3, 2, 1, 0, 3, 2, 1, 0, 3, 3, 3, 3, 2, 2, 2, 2},
+	}
+
+	prio.Bands = 1
+	if err := QdiscAdd(prio); err == nil {
+		t.Fatal("expected an error for a single band")
+	}
+	prio.Bands = 3
+	if err := QdiscAdd(prio); err == nil {
+		t.Fatal("expected an error for a priority mapped past the last band")
+	}
+	prio.Bands = 4
+	if err := QdiscAdd(prio); err != nil {
+		t.Fatal(err)
+	}
+
+	if handle := PrioBandHandle(prio, 2); handle != MakeHandle(1, 3) {
+		t.Fatalf("band 2 handle is %s, expected 1:3", HandleStr(handle))
+	}
+	pfifo := &GenericQdisc{
+		QdiscAttrs: QdiscAttrs{
+			LinkIndex: link.Attrs().Index,
+			Handle:    MakeHandle(10, 0),
+			Parent:    PrioBandHandle(prio, 2),
+		},
+		QdiscType: "pfifo",
+	}
+	if err := QdiscAdd(pfifo); err != nil {
+		t.Fatal(err)
+	}
+
+	qdiscs, err := SafeQdiscList(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(qdiscs) != 2 {
+		t.Fatalf("expected 2 qdiscs, got %d", len(qdiscs))
+	}
+	var found bool
+	for _, q := range qdiscs {
+		switch q := q.(type) {
+		case *Prio:
+			if q.Bands != prio.Bands || q.PriorityMap != prio.PriorityMap {
+				t.Fatalf("prio round trip mismatch: %d bands, map %v", q.Bands, q.PriorityMap)
+			}
+		default:
+			if q.Type() != "pfifo" || q.Attrs().Parent != MakeHandle(1, 3) {
+				t.Fatalf("unexpected qdisc %s with parent %s", q.Type(), HandleStr(q.Attrs().Parent))
+			}
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("pfifo not attached to band 2")
+	}
+}
+
 func TestTbfAddHtbReplaceDel(t *testing.T) {
 	t.Cleanup(setUpNetlinkTest(t))
 	if err := LinkAdd(&Ifb{LinkAttrs{Name: "foo"}}); err != nil {
@@ -524,6 +725,92 @@ func TestFqHorizon(t *testing.T) {
 	}
 }
 
+func TestFqPacingParameters(t *testing.T) {
+	minKernelRequired(t, 5, 7)
+
+	t.Cleanup(setUpNetlinkTest(t))
+	if err := LinkAdd(&Ifb{LinkAttrs{Name: "foo"}}); err != nil {
+		t.Fatal(err)
+	}
+	link, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkSetUp(link); err != nil {
+		t.Fatal(err)
+	}
+	ceThreshold := uint32(2000)
+	timerSlack := uint32(20000)
+	qdisc := NewFq(QdiscAttrs{
+		LinkIndex: link.Attrs().Index,
+		Handle:    MakeHandle(1, 0),
+		Parent:    HANDLE_ROOT,
+	})
+	qdisc.FlowMaxRate = 125000000
+	qdisc.Horizon = 2000000
+	qdisc.HorizonDropPolicy = HORIZON_DROP_POLICY_DROP
+	qdisc.Quantum = 3028
+	qdisc.InitialQuantum = 15140
+	qdisc.LowRateThreshold = 68750
+	qdisc.CEThreshold = &ceThreshold
+	qdisc.TimerSlack = &timerSlack
+	if err := QdiscAdd(qdisc); err != nil {
+		t.Fatal(err)
+	}
+	qdiscs, err := SafeQdiscList(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(qdiscs) != 1 {
+		t.Fatal("Failed to add qdisc")
+	}
+	fq, ok := qdiscs[0].(*Fq)
+	if !ok {
+		t.Fatal("Qdisc is the wrong type")
+	}
+	if fq.FlowMaxRate != qdisc.FlowMaxRate {
+		t.Fatalf("FlowMaxRate %d, expected %d", fq.FlowMaxRate, qdisc.FlowMaxRate)
+	}
+	if fq.Horizon != qdisc.Horizon {
+		t.Fatalf("Horizon %d, expected %d", fq.Horizon, qdisc.Horizon)
+	}
+	if fq.HorizonDropPolicy != qdisc.HorizonDropPolicy {
+		t.Fatal("HorizonDropPolicy does not match")
+	}
+	if fq.Quantum != qdisc.Quantum || fq.InitialQuantum != qdisc.InitialQuantum {
+		t.Fatal("Quantum does not match")
+	}
+	if fq.LowRateThreshold != qdisc.LowRateThreshold {
+		t.Fatal("LowRateThreshold does not match")
+	}
+	if fq.CEThreshold == nil || *fq.CEThreshold != ceThreshold {
+		t.Fatalf("CEThreshold %v, expected %d", fq.CEThreshold, ceThreshold)
+	}
+	if fq.TimerSlack == nil || *fq.TimerSlack != timerSlack {
+		t.Fatalf("TimerSlack %v, expected %d", fq.TimerSlack, timerSlack)
+	}
+	if fq.XStats == nil {
+		t.Fatal("XStats is nil")
+	}
+
+	qdisc.FlowMaxRate = 250000000
+	qdisc.Horizon = 4000000
+	if err := QdiscChange(qdisc); err != nil {
+		t.Fatal(err)
+	}
+	qdiscs, err = SafeQdiscList(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fq = qdiscs[0].(*Fq)
+	if fq.FlowMaxRate != qdisc.FlowMaxRate || fq.Horizon != qdisc.Horizon {
+		t.Fatalf("change not applied: maxrate %d horizon %d", fq.FlowMaxRate, fq.Horizon)
+	}
+	if err := QdiscDel(qdisc); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestFqCodelAddChangeDel(t *testing.T) {
 	minKernelRequired(t, 3, 4)
 
@@ -626,3 +913,330 @@ func TestIngressAddDel(t *testing.T) {
 		t.Fatal("Failed to remove qdisc")
 	}
 }
+
+func TestQdiscIsDefault(t *testing.T) {
+	tests := []struct {
+		qdisc    Qdisc
+		expected bool
+	}{
+		{&FqCodel{QdiscAttrs: QdiscAttrs{Handle: HANDLE_NONE, Parent: HANDLE_ROOT}}, true},
+		{&PfifoFast{QdiscAttrs: QdiscAttrs{Handle: HANDLE_NONE, Parent: MakeHandle(0x8001, 1)}}, true},
+		{&FqCodel{QdiscAttrs: QdiscAttrs{Handle: HANDLE_NONE, Parent: HANDLE_ROOT, HwOffload: true}}, false},
+		{&Htb{QdiscAttrs: QdiscAttrs{Handle: MakeHandle(1, 0), Parent: HANDLE_ROOT}}, false},
+		{&Ingress{QdiscAttrs: QdiscAttrs{Handle: MakeHandle(0xffff, 0), Parent: HANDLE_INGRESS}}, false},
+	}
+	for _, tt := range tests {
+		if got := QdiscIsDefault(tt.qdisc); got != tt.expected {
+			t.Errorf("QdiscIsDefault(%s %s) = %v, expected %v", tt.qdisc.Type(), tt.qdisc.Attrs(), got, tt.expected)
+		}
+	}
+}
+
+func TestQdiscEnsure(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+	if err := LinkAdd(&Ifb{LinkAttrs{Name: "foo"}}); err != nil {
+		t.Fatal(err)
+	}
+	link, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkSetUp(link); err != nil {
+		t.Fatal(err)
+	}
+
+	htb := NewHtb(QdiscAttrs{Handle: MakeHandle(1, 0), Parent: HANDLE_ROOT})
+	if err := QdiscEnsure(link, htb); err != nil {
+		t.Fatal(err)
+	}
+	class := NewHtbClass(ClassAttrs{
+		LinkIndex: link.Attrs().Index,
+		Parent:    MakeHandle(1, 0),
+		Handle:    MakeHandle(1, 1),
+	}, HtbClassAttrs{Rate: 1000000})
+	if err := ClassAdd(class); err != nil {
+		t.Fatal(err)
+	}
+
+	// The root already matches, ensuring again without a handle must keep
+	// the existing qdisc and its classes.
+	if err := QdiscEnsure(link, NewHtb(QdiscAttrs{})); err != nil {
+		t.Fatal(err)
+	}
+	qdiscs, err := SafeQdiscList(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(qdiscs) != 1 || qdiscs[0].Type() != "htb" || qdiscs[0].Attrs().Handle != MakeHandle(1, 0) {
+		t.Fatalf("unexpected qdiscs after ensure: %v", qdiscs)
+	}
+	classes, err := ClassList(link, MakeHandle(1, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(classes) != 1 {
+		t.Fatal("QdiscEnsure churned the root qdisc")
+	}
+
+	tbf := &Tbf{
+		QdiscAttrs: QdiscAttrs{Handle: MakeHandle(2, 0), Parent: HANDLE_ROOT},
+		Rate:       131072,
+		Limit:      1220703,
+		Buffer:     16793,
+	}
+	if err := QdiscEnsure(link, tbf); err != nil {
+		t.Fatal(err)
+	}
+	qdiscs, err = SafeQdiscList(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(qdiscs) != 1 || qdiscs[0].Type() != "tbf" {
+		t.Fatalf("expected root to be replaced by tbf, got %v", qdiscs)
+	}
+}
+
+func TestQdiscAddIdempotent(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+	if err := LinkAdd(&Ifb{LinkAttrs{Name: "foo"}}); err != nil {
+		t.Fatal(err)
+	}
+	link, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clsact := &Clsact{
+		QdiscAttrs: QdiscAttrs{
+			LinkIndex: link.Attrs().Index,
+			Handle:    MakeHandle(0xffff, 0),
+			Parent:    HANDLE_CLSACT,
+		},
+	}
+	created, err := QdiscAddIdempotent(clsact)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created {
+		t.Fatal("expected clsact to be created")
+	}
+	created, err = QdiscAddIdempotent(clsact)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created {
+		t.Fatal("expected the existing clsact to be kept")
+	}
+
+	ingress := &Ingress{
+		QdiscAttrs: QdiscAttrs{
+			LinkIndex: link.Attrs().Index,
+			Handle:    MakeHandle(0xffff, 0),
+			Parent:    HANDLE_INGRESS,
+		},
+	}
+	created, err = QdiscAddIdempotent(ingress)
+	if !errors.Is(err, ErrQdiscExists) {
+		t.Fatalf("expected ErrQdiscExists, got %v", err)
+	}
+	if !errors.Is(err, unix.EEXIST) {
+		t.Fatalf("expected the error to wrap EEXIST, got %v", err)
+	}
+	if created {
+		t.Fatal("ingress must not be reported as created")
+	}
+
+	qdiscs, err := SafeQdiscList(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(qdiscs) != 1 || qdiscs[0].Type() != "clsact" {
+		t.Fatalf("expected a single clsact, got %v", qdiscs)
+	}
+}
+
+func TestPlugControl(t *testing.T) {
+	t.Cleanup(setUpNetlinkTestWithKModule(t, "sch_plug"))
+	if err := LinkAdd(&Veth{LinkAttrs: LinkAttrs{Name: "foo"}, PeerName: "bar"}); err != nil {
+		t.Fatal(err)
+	}
+	link, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkSetUp(link); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := PlugBuffer(link); err == nil {
+		t.Fatal("PlugBuffer succeeded without a plug qdisc")
+	}
+
+	qdisc := &Plug{
+		QdiscAttrs: QdiscAttrs{
+			LinkIndex: link.Attrs().Index,
+			Handle:    MakeHandle(1, 0),
+			Parent:    HANDLE_ROOT,
+		},
+		Limit: 32 * 1024,
+	}
+	if err := QdiscAdd(qdisc); err != nil {
+		t.Fatal(err)
+	}
+	qdiscs, err := SafeQdiscList(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(qdiscs) != 1 {
+		t.Fatal("Failed to add qdisc")
+	}
+	if _, ok := qdiscs[0].(*Plug); !ok {
+		t.Fatalf("Qdisc is the wrong type: %T", qdiscs[0])
+	}
+
+	if err := PlugBuffer(link); err != nil {
+		t.Fatal(err)
+	}
+	if err := PlugReleaseOne(link); err != nil {
+		t.Fatal(err)
+	}
+	if err := PlugReleaseIndefinite(link); err != nil {
+		t.Fatal(err)
+	}
+	if err := PlugBuffer(link); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := QdiscDel(qdisc); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseHandle(t *testing.T) {
+	tests := []struct {
+		in     string
+		handle uint32
+	}{
+		{"root", HANDLE_ROOT},
+		{"none", HANDLE_NONE},
+		{"ingress", HANDLE_INGRESS},
+		{"clsact", HANDLE_CLSACT},
+		{"1:", 0x10000},
+		{"1:0", 0x10000},
+		{"1:a", 0x1000a},
+		{"ffff:", 0xffff0000},
+		{"ffff:fff2", HANDLE_MIN_INGRESS},
+		{":5", 0x5},
+		{":", 0},
+		{"0x10:0x20", 0x100020},
+		{"10020", 0x10020},
+		{"ffffffff", HANDLE_ROOT},
+	}
+	for _, tt := range tests {
+		handle, err := ParseHandle(tt.in)
+		if err != nil {
+			t.Errorf("ParseHandle(%q): %v", tt.in, err)
+			continue
+		}
+		if handle != tt.handle {
+			t.Errorf("ParseHandle(%q) = %x, want %x", tt.in, handle, tt.handle)
+		}
+	}
+
+	for _, in := range []string{"", "x", "1:g", "g:1", "10000:", ":10000", "1:2:3", "100000000", "1: ", "-1:"} {
+		if handle, err := ParseHandle(in); err == nil {
+			t.Errorf("ParseHandle(%q) = %x, want error", in, handle)
+		}
+	}
+
+	for _, handle := range []uint32{HANDLE_NONE, HANDLE_ROOT, HANDLE_INGRESS, 0x10000, 0x1000a, 0xffff0001, HANDLE_MIN_EGRESS} {
+		parsed, err := ParseHandle(HandleStr(handle))
+		if err != nil {
+			t.Errorf("ParseHandle(HandleStr(%x)): %v", handle, err)
+			continue
+		}
+		if parsed != handle {
+			t.Errorf("ParseHandle(HandleStr(%x)) = %x", handle, parsed)
+		}
+	}
+}
+
+func TestMqQueueQdisc(t *testing.T) {
+	minKernelRequired(t, 4, 18)
+	t.Cleanup(setUpNetlinkTest(t))
+
+	tuntap := &Tuntap{
+		LinkAttrs: LinkAttrs{Name: "foo"},
+		Mode:      TUNTAP_MODE_TAP,
+		Queues:    4,
+		Flags:     TUNTAP_MULTI_QUEUE_DEFAULTS,
+	}
+	if err := LinkAdd(tuntap); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		for _, fd := range tuntap.Fds {
+			fd.Close()
+		}
+	}()
+	link, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkSetUp(link); err != nil {
+		t.Fatal(err)
+	}
+
+	mqAttrs := QdiscAttrs{
+		LinkIndex: link.Attrs().Index,
+		Handle:    MakeHandle(1, 0),
+		Parent:    HANDLE_ROOT,
+	}
+	if err := QdiscReplace(&Mq{QdiscAttrs: mqAttrs}); err != nil {
+		t.Fatal(err)
+	}
+	pfifo := &GenericQdisc{
+		QdiscAttrs: QdiscAttrs{
+			LinkIndex: link.Attrs().Index,
+			Handle:    MakeHandle(0x10, 0),
+			Parent:    mqAttrs.Handle | MqQueueHandle(2),
+		},
+		QdiscType: "pfifo",
+	}
+	if err := QdiscReplace(pfifo); err != nil {
+		t.Fatal(err)
+	}
+
+	qdiscs, err := QdiscList(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mq *Mq
+	children := 0
+	for _, qdisc := range qdiscs {
+		if q, ok := qdisc.(*Mq); ok {
+			mq = q
+			continue
+		}
+		attrs := qdisc.Attrs()
+		if attrs.TxQueue == nil {
+			t.Fatalf("TxQueue of %s not set", attrs)
+		}
+		children++
+		if (qdisc.Type() == "pfifo") != (*attrs.TxQueue == 2) {
+			t.Fatalf("%s qdisc %s found on tx queue %d", qdisc.Type(), attrs, *attrs.TxQueue)
+		}
+		if qdisc.Type() == "pfifo" && attrs.Handle != pfifo.Handle {
+			t.Fatalf("expected pfifo handle %s, got %s", HandleStr(pfifo.Handle), HandleStr(attrs.Handle))
+		}
+	}
+	if mq == nil || mq.Handle != mqAttrs.Handle {
+		t.Fatalf("mq qdisc not found in %v", qdiscs)
+	}
+	if mq.TxQueue != nil {
+		t.Fatal("TxQueue of the mq qdisc set")
+	}
+	if children != tuntap.Queues {
+		t.Fatalf("expected %d mq children, got %d", tuntap.Queues, children)
+	}
+}
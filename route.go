@@ -27,6 +27,10 @@ const (
 	RT_FILTER_MARK
 	RT_FILTER_MASK
 	RT_FILTER_REALM
+	// RT_FILTER_NOAUTOCONF drops the routes learned from router
+	// advertisements, see Route.IsAutoconf. It uses no field of the filter.
+	RT_FILTER_NOAUTOCONF
+	RT_FILTER_TUN_ID
 )
 
 type Destination interface {
@@ -50,22 +54,26 @@ type RouteProtocol int
 
 // Route represents a netlink route.
 type Route struct {
-	LinkIndex        int
-	ILinkIndex       int
-	Scope            Scope
-	Dst              *net.IPNet
-	Src              net.IP
-	Gw               net.IP
-	MultiPath        []*NexthopInfo
-	Protocol         RouteProtocol
-	Priority         int
-	Family           int
-	Table            int
-	Type             int
-	Tos              int
-	Flags            int
-	MPLSDst          *int
-	NewDst           Destination
+	LinkIndex  int
+	ILinkIndex int
+	Scope      Scope
+	Dst        *net.IPNet
+	Src        net.IP
+	Gw         net.IP
+	MultiPath  []*NexthopInfo
+	Protocol   RouteProtocol
+	Priority   int
+	Family     int
+	Table      int
+	Type       int
+	Tos        int
+	Flags      int
+	MPLSDst    *int
+	NewDst     Destination
+	// TTLPropagate overrides for an MPLS route whether the TTL is copied
+	// between the label and the IP header, nil follows the
+	// net.mpls.ip_ttl_propagate sysctl.
+	TTLPropagate     *bool
 	Encap            Encap
 	Via              Destination
 	Realm            int
@@ -87,6 +95,24 @@ type Route struct {
 	QuickACK         int
 	Congctl          string
 	FastOpenNoCookie int
+	CacheInfo        *RouteCacheInfo
+	// Extras holds the top-level attributes newer than this library, keyed
+	// by type as received (NLA_F_NESTED included), when read through a
+	// Handle set to RetainUnknownAttributes.
+	// They are sent back when adding or replacing the route and are
+	// ignored by Route.Equal.
+	Extras map[uint16][]byte
+}
+
+// RouteCacheInfo holds the diagnostic counters the kernel reports in
+// RTA_CACHEINFO (struct rta_cacheinfo). It is only populated when reading
+// routes and is ignored by Route.Equal.
+type RouteCacheInfo struct {
+	ClntRef uint32
+	LastUse uint32
+	Expires int32
+	Error   uint32
+	Used    uint32
 }
 
 func (r Route) String() string {
@@ -117,6 +143,19 @@ func (r Route) String() string {
 	elems = append(elems, fmt.Sprintf("Flags: %s", r.ListFlags()))
 	elems = append(elems, fmt.Sprintf("Table: %d", r.Table))
 	elems = append(elems, fmt.Sprintf("Realm: %d", r.Realm))
+	// locked metrics are shown the way iproute2 does
+	lock := func(locked bool) string {
+		if locked {
+			return "lock "
+		}
+		return ""
+	}
+	if r.MTU != 0 || r.MTULock {
+		elems = append(elems, fmt.Sprintf("%smtu %d", lock(r.MTULock), r.MTU))
+	}
+	if r.RtoMin != 0 || r.RtoMinLock {
+		elems = append(elems, fmt.Sprintf("%srto_min %dms", lock(r.RtoMinLock), r.RtoMin))
+	}
 	return fmt.Sprintf("{%s}", strings.Join(elems, " "))
 }
 
@@ -136,7 +175,12 @@ func (r Route) Equal(x Route) bool {
 		r.Tos == x.Tos &&
 		r.Hoplimit == x.Hoplimit &&
 		r.Flags == x.Flags &&
+		r.MTU == x.MTU &&
+		r.MTULock == x.MTULock &&
+		r.RtoMin == x.RtoMin &&
+		r.RtoMinLock == x.RtoMinLock &&
 		(r.MPLSDst == x.MPLSDst || (r.MPLSDst != nil && x.MPLSDst != nil && *r.MPLSDst == *x.MPLSDst)) &&
+		(r.TTLPropagate == x.TTLPropagate || (r.TTLPropagate != nil && x.TTLPropagate != nil && *r.TTLPropagate == *x.TTLPropagate)) &&
 		(r.NewDst == x.NewDst || (r.NewDst != nil && r.NewDst.Equal(x.NewDst))) &&
 		(r.Via == x.Via || (r.Via != nil && r.Via.Equal(x.Via))) &&
 		(r.Encap == x.Encap || (r.Encap != nil && r.Encap.Equal(x.Encap)))
@@ -166,6 +210,11 @@ type RouteUpdate struct {
 	Type    uint16
 	NlFlags uint16
 	Route
+	// NsID is the id, in the subscribing namespace, of the network
+	// namespace the update comes from. It is -1 for updates from the
+	// subscription's own namespace. Other namespaces are only heard from
+	// when subscribing with ListenAllNsid.
+	NsID int
 }
 
 type NexthopInfo struct {
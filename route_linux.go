@@ -63,7 +63,19 @@ func listFlags(flag int) []string {
 }
 
 func (r *Route) ListFlags() []string {
-	return listFlags(r.Flags)
+	flags := listFlags(r.Flags)
+	if r.IsAutoconf() {
+		flags = append(flags, "autoconf")
+	}
+	return flags
+}
+
+// IsAutoconf returns whether the kernel installed the route from a router
+// advertisement, a default router or a route information option, rather
+// than it being configured. The prefix routes of SLAAC addresses are
+// RTPROT_KERNEL, like the other connected routes.
+func (r *Route) IsAutoconf() bool {
+	return r.Protocol == unix.RTPROT_RA
 }
 
 func (n *NexthopInfo) ListFlags() []string {
@@ -84,6 +96,12 @@ func (d *MPLSDestination) Decode(buf []byte) error {
 }
 
 func (d *MPLSDestination) Encode() ([]byte, error) {
+	for _, label := range d.Labels {
+		// it is only advertised, never pushed
+		if label == nl.MPLS_LABEL_IMPLNULL {
+			return nil, fmt.Errorf("implicit null label %d can not be pushed", label)
+		}
+	}
 	return nl.EncodeMPLSStack(d.Labels...), nil
 }
 
@@ -196,6 +214,9 @@ func (e *MPLSEncap) Equal(x Encap) bool {
 type SEG6Encap struct {
 	Mode     int
 	Segments []net.IP
+	// Hmac is the id of the key the kernel uses to add an HMAC TLV to
+	// the SRH, 0 for none.
+	Hmac uint32
 }
 
 func (e *SEG6Encap) Type() int {
@@ -218,12 +239,12 @@ func (e *SEG6Encap) Decode(buf []byte) error {
 	}
 
 	var err error
-	e.Mode, e.Segments, err = nl.DecodeSEG6Encap(buf[4:])
+	e.Mode, e.Segments, e.Hmac, err = nl.DecodeSEG6EncapHmac(buf[4:])
 
 	return err
 }
 func (e *SEG6Encap) Encode() ([]byte, error) {
-	s, err := nl.EncodeSEG6Encap(e.Mode, e.Segments)
+	s, err := nl.EncodeSEG6EncapHmac(e.Mode, e.Segments, e.Hmac)
 	hdr := make([]byte, 4)
 	native.PutUint16(hdr, uint16(len(s)+4))
 	native.PutUint16(hdr[2:], nl.SEG6_IPTUNNEL_SRH)
@@ -237,6 +258,9 @@ func (e *SEG6Encap) String() string {
 	}
 	str := fmt.Sprintf("mode %s segs %d [ %s ]", nl.SEG6EncapModeString(e.Mode),
 		len(e.Segments), strings.Join(segs, " "))
+	if e.Hmac != 0 {
+		str += fmt.Sprintf(" hmac 0x%X", e.Hmac)
+	}
 	return str
 }
 func (e *SEG6Encap) Equal(x Encap) bool {
@@ -250,7 +274,7 @@ func (e *SEG6Encap) Equal(x Encap) bool {
 	if e == nil || o == nil {
 		return false
 	}
-	if e.Mode != o.Mode {
+	if e.Mode != o.Mode || e.Hmac != o.Hmac {
 		return false
 	}
 	if len(e.Segments) != len(o.Segments) {
@@ -647,14 +671,117 @@ func (e *BpfEncap) Equal(x Encap) bool {
 	return true
 }
 
-// IP6tnlEncap definition
+// IPEncap is the tunnel metadata of a route over a flow based, or
+// external, IPv4 tunnel device such as vxlan, geneve or gretap.
+// Equivalent to: `ip route add $dst encap ip id $id dst $dst dev $tundev`
+type IPEncap struct {
+	ID  uint64
+	Dst net.IP
+	Src net.IP
+	TTL uint8
+	Tos uint8
+	// Flags holds nl.TUNNEL_* flags
+	Flags uint16
+}
+
+func (e *IPEncap) Type() int {
+	return nl.LWTUNNEL_ENCAP_IP
+}
+
+func (e *IPEncap) Decode(buf []byte) error {
+	attrs, err := nl.ParseRouteAttr(buf)
+	if err != nil {
+		return err
+	}
+	for _, attr := range attrs {
+		switch attr.Attr.Type {
+		case nl.LWTUNNEL_IP_ID:
+			e.ID = binary.BigEndian.Uint64(attr.Value[0:8])
+		case nl.LWTUNNEL_IP_DST:
+			// unset addresses are reported as zeros
+			if ip := net.IP(attr.Value[:net.IPv4len]); !ip.IsUnspecified() {
+				e.Dst = ip
+			}
+		case nl.LWTUNNEL_IP_SRC:
+			if ip := net.IP(attr.Value[:net.IPv4len]); !ip.IsUnspecified() {
+				e.Src = ip
+			}
+		case nl.LWTUNNEL_IP_TTL:
+			e.TTL = attr.Value[0]
+		case nl.LWTUNNEL_IP_TOS:
+			e.Tos = attr.Value[0]
+		case nl.LWTUNNEL_IP_FLAGS:
+			e.Flags = binary.BigEndian.Uint16(attr.Value[0:2])
+		}
+	}
+	return nil
+}
+
+func (e *IPEncap) Encode() ([]byte, error) {
+	var buf []byte
+	if e.ID != 0 {
+		id := make([]byte, 8)
+		binary.BigEndian.PutUint64(id, e.ID)
+		buf = append(buf, nl.NewRtAttr(nl.LWTUNNEL_IP_ID, id).Serialize()...)
+	}
+	if e.Dst != nil {
+		dst := e.Dst.To4()
+		if dst == nil {
+			return nil, fmt.Errorf("lwt ip encode: dst %s is not IPv4", e.Dst)
+		}
+		buf = append(buf, nl.NewRtAttr(nl.LWTUNNEL_IP_DST, dst).Serialize()...)
+	}
+	if e.Src != nil {
+		src := e.Src.To4()
+		if src == nil {
+			return nil, fmt.Errorf("lwt ip encode: src %s is not IPv4", e.Src)
+		}
+		buf = append(buf, nl.NewRtAttr(nl.LWTUNNEL_IP_SRC, src).Serialize()...)
+	}
+	if e.TTL != 0 {
+		buf = append(buf, nl.NewRtAttr(nl.LWTUNNEL_IP_TTL, nl.Uint8Attr(e.TTL)).Serialize()...)
+	}
+	if e.Tos != 0 {
+		buf = append(buf, nl.NewRtAttr(nl.LWTUNNEL_IP_TOS, nl.Uint8Attr(e.Tos)).Serialize()...)
+	}
+	if e.Flags != 0 {
+		flags := make([]byte, 2)
+		binary.BigEndian.PutUint16(flags, e.Flags)
+		buf = append(buf, nl.NewRtAttr(nl.LWTUNNEL_IP_FLAGS, flags).Serialize()...)
+	}
+	return buf, nil
+}
+
+func (e *IPEncap) String() string {
+	return fmt.Sprintf("id %d src %s dst %s ttl %d tos %d flags 0x%.4x", e.ID, e.Src, e.Dst, e.TTL, e.Tos, e.Flags)
+}
+
+func (e *IPEncap) Equal(x Encap) bool {
+	o, ok := x.(*IPEncap)
+	if !ok {
+		return false
+	}
+	if e == o {
+		return true
+	}
+	if e == nil || o == nil {
+		return false
+	}
+	return e.ID == o.ID && e.Dst.Equal(o.Dst) && e.Src.Equal(o.Src) &&
+		e.TTL == o.TTL && e.Tos == o.Tos && e.Flags == o.Flags
+}
+
+// IP6tnlEncap is the tunnel metadata of a route over a flow based, or
+// external, IPv6 tunnel device.
+// Equivalent to: `ip route add $dst encap ip6 id $id dst $dst dev $tundev`
 type IP6tnlEncap struct {
 	ID       uint64
 	Dst      net.IP
 	Src      net.IP
 	Hoplimit uint8
 	TC       uint8
-	Flags    uint16
+	// Flags holds nl.TUNNEL_* flags
+	Flags uint16
 }
 
 func (e *IP6tnlEncap) Type() int {
@@ -669,85 +796,260 @@ func (e *IP6tnlEncap) Decode(buf []byte) error {
 	for _, attr := range attrs {
 		switch attr.Attr.Type {
 		case nl.LWTUNNEL_IP6_ID:
-			e.ID = uint64(native.Uint64(attr.Value[0:4]))
+			e.ID = binary.BigEndian.Uint64(attr.Value[0:8])
 		case nl.LWTUNNEL_IP6_DST:
-			e.Dst = net.IP(attr.Value[:])
+			if ip := net.IP(attr.Value[:net.IPv6len]); !ip.IsUnspecified() {
+				e.Dst = ip
+			}
 		case nl.LWTUNNEL_IP6_SRC:
-			e.Src = net.IP(attr.Value[:])
+			if ip := net.IP(attr.Value[:net.IPv6len]); !ip.IsUnspecified() {
+				e.Src = ip
+			}
 		case nl.LWTUNNEL_IP6_HOPLIMIT:
 			e.Hoplimit = attr.Value[0]
 		case nl.LWTUNNEL_IP6_TC:
-			// e.TC = attr.Value[0]
-			err = fmt.Errorf("decoding TC in IP6tnlEncap is not supported")
+			e.TC = attr.Value[0]
 		case nl.LWTUNNEL_IP6_FLAGS:
-			// e.Flags = uint16(native.Uint16(attr.Value[0:2]))
-			err = fmt.Errorf("decoding FLAG in IP6tnlEncap is not supported")
-		case nl.LWTUNNEL_IP6_PAD:
-			err = fmt.Errorf("decoding PAD in IP6tnlEncap is not supported")
-		case nl.LWTUNNEL_IP6_OPTS:
-			err = fmt.Errorf("decoding OPTS in IP6tnlEncap is not supported")
+			e.Flags = binary.BigEndian.Uint16(attr.Value[0:2])
 		}
 	}
-	return err
+	return nil
 }
 
 func (e *IP6tnlEncap) Encode() ([]byte, error) {
+	var buf []byte
+	if e.ID != 0 {
+		id := make([]byte, 8)
+		binary.BigEndian.PutUint64(id, e.ID)
+		buf = append(buf, nl.NewRtAttr(nl.LWTUNNEL_IP6_ID, id).Serialize()...)
+	}
+	if e.Dst != nil {
+		buf = append(buf, nl.NewRtAttr(nl.LWTUNNEL_IP6_DST, e.Dst.To16()).Serialize()...)
+	}
+	if e.Src != nil {
+		buf = append(buf, nl.NewRtAttr(nl.LWTUNNEL_IP6_SRC, e.Src.To16()).Serialize()...)
+	}
+	if e.Hoplimit != 0 {
+		buf = append(buf, nl.NewRtAttr(nl.LWTUNNEL_IP6_HOPLIMIT, nl.Uint8Attr(e.Hoplimit)).Serialize()...)
+	}
+	if e.TC != 0 {
+		buf = append(buf, nl.NewRtAttr(nl.LWTUNNEL_IP6_TC, nl.Uint8Attr(e.TC)).Serialize()...)
+	}
+	if e.Flags != 0 {
+		flags := make([]byte, 2)
+		binary.BigEndian.PutUint16(flags, e.Flags)
+		buf = append(buf, nl.NewRtAttr(nl.LWTUNNEL_IP6_FLAGS, flags).Serialize()...)
+	}
+	return buf, nil
+}
 
-	final := []byte{}
+func (e *IP6tnlEncap) String() string {
+	return fmt.Sprintf("id %d src %s dst %s hoplimit %d tc %d flags 0x%.4x", e.ID, e.Src, e.Dst, e.Hoplimit, e.TC, e.Flags)
+}
 
-	resID := make([]byte, 12)
-	native.PutUint16(resID, 12) //  2+2+8
-	native.PutUint16(resID[2:], nl.LWTUNNEL_IP6_ID)
-	native.PutUint64(resID[4:], 0)
-	final = append(final, resID...)
+func (e *IP6tnlEncap) Equal(x Encap) bool {
+	o, ok := x.(*IP6tnlEncap)
+	if !ok {
+		return false
+	}
+	if e == o {
+		return true
+	}
+	if e == nil || o == nil {
+		return false
+	}
+	return e.ID == o.ID && e.Dst.Equal(o.Dst) && e.Src.Equal(o.Src) &&
+		e.Hoplimit == o.Hoplimit && e.TC == o.TC && e.Flags == o.Flags
+}
 
-	resDst := make([]byte, 4)
-	native.PutUint16(resDst, 20) //  2+2+16
-	native.PutUint16(resDst[2:], nl.LWTUNNEL_IP6_DST)
-	resDst = append(resDst, e.Dst...)
-	final = append(final, resDst...)
+// RplEncap definitions
+type RplEncap struct {
+	// Segments are in SRH order, the last one is visited first.
+	Segments []net.IP
+}
 
-	resSrc := make([]byte, 4)
-	native.PutUint16(resSrc, 20)
-	native.PutUint16(resSrc[2:], nl.LWTUNNEL_IP6_SRC)
-	resSrc = append(resSrc, e.Src...)
-	final = append(final, resSrc...)
+func (e *RplEncap) Type() int {
+	return nl.LWTUNNEL_ENCAP_RPL
+}
 
-	// resTc := make([]byte, 5)
-	// native.PutUint16(resTc, 5)
-	// native.PutUint16(resTc[2:], nl.LWTUNNEL_IP6_TC)
-	// resTc[4] = e.TC
-	// final = append(final,resTc...)
+func (e *RplEncap) Decode(buf []byte) error {
+	attrs, err := nl.ParseRouteAttr(buf)
+	if err != nil {
+		return err
+	}
+	e.Segments = nil
+	for _, attr := range attrs {
+		if attr.Attr.Type != nl.RPL_IPTUNNEL_SRH {
+			continue
+		}
+		if len(attr.Value) < nl.SizeofRplSrHdr || (len(attr.Value)-nl.SizeofRplSrHdr)%net.IPv6len != 0 {
+			return fmt.Errorf("lwt rpl decode: invalid srh length %d", len(attr.Value))
+		}
+		for seg := attr.Value[nl.SizeofRplSrHdr:]; len(seg) > 0; seg = seg[net.IPv6len:] {
+			e.Segments = append(e.Segments, net.IP(seg[:net.IPv6len]))
+		}
+	}
+	return nil
+}
 
-	resHops := make([]byte, 5)
-	native.PutUint16(resHops, 5)
-	native.PutUint16(resHops[2:], nl.LWTUNNEL_IP6_HOPLIMIT)
-	resHops[4] = e.Hoplimit
-	final = append(final, resHops...)
+func (e *RplEncap) Encode() ([]byte, error) {
+	nsegs := len(e.Segments)
+	if nsegs == 0 {
+		return nil, fmt.Errorf("lwt rpl encode: no segments")
+	}
+	srh := make([]byte, nl.SizeofRplSrHdr, nl.SizeofRplSrHdr+nsegs*net.IPv6len)
+	srh[1] = uint8(nsegs * net.IPv6len >> 3) // hdrlen (in 8-octets unit)
+	srh[2] = nl.IPV6_SRCRT_TYPE_3
+	srh[3] = uint8(nsegs) // segments_left
+	// no compression: cmpri, cmpre and pad are 0
+	for _, seg := range e.Segments {
+		ip := seg.To16()
+		if ip == nil {
+			return nil, fmt.Errorf("lwt rpl encode: invalid segment %s", seg)
+		}
+		srh = append(srh, ip...)
+	}
+	return nl.NewRtAttr(nl.RPL_IPTUNNEL_SRH, srh).Serialize(), nil
+}
 
-	// resFlags := make([]byte, 6)
-	// native.PutUint16(resFlags, 6)
-	// native.PutUint16(resFlags[2:], nl.LWTUNNEL_IP6_FLAGS)
-	// native.PutUint16(resFlags[4:], e.Flags)
-	// final = append(final,resFlags...)
+func (e *RplEncap) String() string {
+	segs := make([]string, 0, len(e.Segments))
+	// append segment backwards since the last one is visited first
+	for i := len(e.Segments); i > 0; i-- {
+		segs = append(segs, e.Segments[i-1].String())
+	}
+	return fmt.Sprintf("segs %d [ %s ]", len(e.Segments), strings.Join(segs, " "))
+}
 
-	return final, nil
+func (e *RplEncap) Equal(x Encap) bool {
+	o, ok := x.(*RplEncap)
+	if !ok {
+		return false
+	}
+	if e == o {
+		return true
+	}
+	if e == nil || o == nil || len(e.Segments) != len(o.Segments) {
+		return false
+	}
+	for i := range e.Segments {
+		if !e.Segments[i].Equal(o.Segments[i]) {
+			return false
+		}
+	}
+	return true
 }
 
-func (e *IP6tnlEncap) String() string {
-	return fmt.Sprintf("id %d src %s dst %s hoplimit %d tc %d flags 0x%.4x", e.ID, e.Src, e.Dst, e.Hoplimit, e.TC, e.Flags)
+// Ioam6Encap definitions
+type Ioam6Encap struct {
+	// Mode is one of nl.IOAM6_IPTUNNEL_MODE_*, the kernel defaults to
+	// inline when it is 0.
+	Mode int
+	// Dst is the tunnel destination of the encap and auto modes.
+	Dst net.IP
+	// The trace is inserted in FreqK out of every FreqN packets. When
+	// FreqN is 0 no frequency is sent and the kernel inserts it in all of
+	// them, which routes read back report as FreqK and FreqN of 1.
+	FreqK uint32
+	FreqN uint32
+	// Namespace is the IOAM namespace id of the pre-allocated trace.
+	Namespace uint16
+	// TraceType is the 24 bit IOAM trace type bitmap.
+	TraceType uint32
+	// TraceSize is the room pre-allocated for node data in bytes, a
+	// multiple of 4 up to nl.IOAM6_TRACE_DATA_SIZE_MAX.
+	TraceSize uint8
+}
+
+func (e *Ioam6Encap) Type() int {
+	return nl.LWTUNNEL_ENCAP_IOAM6
+}
+
+func (e *Ioam6Encap) Decode(buf []byte) error {
+	attrs, err := nl.ParseRouteAttr(buf)
+	if err != nil {
+		return err
+	}
+	for _, attr := range attrs {
+		switch attr.Attr.Type {
+		case nl.IOAM6_IPTUNNEL_MODE:
+			e.Mode = int(attr.Value[0])
+		case nl.IOAM6_IPTUNNEL_DST:
+			e.Dst = net.IP(attr.Value[:net.IPv6len])
+		case nl.IOAM6_IPTUNNEL_FREQ_K:
+			e.FreqK = native.Uint32(attr.Value[0:4])
+		case nl.IOAM6_IPTUNNEL_FREQ_N:
+			e.FreqN = native.Uint32(attr.Value[0:4])
+		case nl.IOAM6_IPTUNNEL_TRACE:
+			if len(attr.Value) < nl.SizeofIoam6TraceHdr {
+				return fmt.Errorf("lwt ioam6 decode: invalid trace length %d", len(attr.Value))
+			}
+			e.Namespace = binary.BigEndian.Uint16(attr.Value[0:2])
+			e.TraceSize = (attr.Value[3] & 0x7f) * 4 // remlen is in 4-octets unit
+			e.TraceType = binary.BigEndian.Uint32(attr.Value[4:8]) >> 8
+		}
+	}
+	return nil
 }
 
-func (e *IP6tnlEncap) Equal(x Encap) bool {
-	o, ok := x.(*IP6tnlEncap)
+func (e *Ioam6Encap) Encode() ([]byte, error) {
+	if e.TraceSize%4 != 0 || e.TraceSize > nl.IOAM6_TRACE_DATA_SIZE_MAX {
+		return nil, fmt.Errorf("lwt ioam6 encode: trace size %d is not a multiple of 4 up to %d", e.TraceSize, nl.IOAM6_TRACE_DATA_SIZE_MAX)
+	}
+	if e.TraceType >= 1<<24 {
+		return nil, fmt.Errorf("lwt ioam6 encode: trace type 0x%x is wider than 24 bits", e.TraceType)
+	}
+	var buf []byte
+	if e.FreqN != 0 {
+		buf = append(buf, nl.NewRtAttr(nl.IOAM6_IPTUNNEL_FREQ_K, nl.Uint32Attr(e.FreqK)).Serialize()...)
+		buf = append(buf, nl.NewRtAttr(nl.IOAM6_IPTUNNEL_FREQ_N, nl.Uint32Attr(e.FreqN)).Serialize()...)
+	}
+	if e.Mode != 0 {
+		buf = append(buf, nl.NewRtAttr(nl.IOAM6_IPTUNNEL_MODE, nl.Uint8Attr(uint8(e.Mode))).Serialize()...)
+	}
+	if e.Dst != nil {
+		buf = append(buf, nl.NewRtAttr(nl.IOAM6_IPTUNNEL_DST, e.Dst.To16()).Serialize()...)
+	}
+	trace := make([]byte, nl.SizeofIoam6TraceHdr)
+	binary.BigEndian.PutUint16(trace[0:], e.Namespace)
+	trace[3] = e.TraceSize / 4
+	binary.BigEndian.PutUint32(trace[4:], e.TraceType<<8)
+	buf = append(buf, nl.NewRtAttr(nl.IOAM6_IPTUNNEL_TRACE, trace).Serialize()...)
+	return buf, nil
+}
+
+func (e *Ioam6Encap) String() string {
+	var str string
+	if e.FreqN != 0 {
+		str = fmt.Sprintf("freq %d/%d ", e.FreqK, e.FreqN)
+	}
+	switch e.Mode {
+	case nl.IOAM6_IPTUNNEL_MODE_INLINE:
+		str += "mode inline "
+	case nl.IOAM6_IPTUNNEL_MODE_ENCAP:
+		str += "mode encap "
+	case nl.IOAM6_IPTUNNEL_MODE_AUTO:
+		str += "mode auto "
+	}
+	if e.Dst != nil {
+		str += fmt.Sprintf("tundst %s ", e.Dst)
+	}
+	return str + fmt.Sprintf("trace prealloc type 0x%06x ns %d size %d", e.TraceType, e.Namespace, e.TraceSize)
+}
+
+func (e *Ioam6Encap) Equal(x Encap) bool {
+	o, ok := x.(*Ioam6Encap)
 	if !ok {
 		return false
 	}
-
-	if e.ID != o.ID || e.Flags != o.Flags || e.Hoplimit != o.Hoplimit || e.Src.Equal(o.Src) || e.Dst.Equal(o.Dst) || e.TC != o.TC {
+	if e == o {
+		return true
+	}
+	if e == nil || o == nil {
 		return false
 	}
-	return true
+	return e.Mode == o.Mode && e.Dst.Equal(o.Dst) && e.FreqK == o.FreqK && e.FreqN == o.FreqN &&
+		e.Namespace == o.Namespace && e.TraceType == o.TraceType && e.TraceSize == o.TraceSize
 }
 
 type Via struct {
@@ -820,6 +1122,75 @@ func (h *Handle) RouteAdd(route *Route) error {
 	return err
 }
 
+// RouteAddOptions contains a set of options to use with
+// RouteAddWithOptions.
+type RouteAddOptions struct {
+	// AutoOnlink sets FLAG_ONLINK on the IPv6 multipath nexthops whose
+	// gateway is not directly reachable through their link, instead of
+	// failing.
+	AutoOnlink bool
+}
+
+// RouteAddWithOptions will add a route to the system like RouteAdd. The
+// gateway of each IPv6 multipath nexthop is first looked up with RouteGet
+// restricted to the nexthop link, so that a gateway the kernel
+// would reject with EINVAL, one that is neither link-local nor on-link and
+// lacks FLAG_ONLINK, is reported with the offending nexthop. With
+// options.AutoOnlink such nexthops get FLAG_ONLINK set in route instead.
+// Equivalent to: `ip route add $route`
+func RouteAddWithOptions(route *Route, options *RouteAddOptions) error {
+	return pkgHandle.RouteAddWithOptions(route, options)
+}
+
+// RouteAddWithOptions will add a route to the system like RouteAdd. The
+// gateway of each IPv6 multipath nexthop is first looked up with RouteGet
+// restricted to the nexthop link, so that a gateway the kernel
+// would reject with EINVAL, one that is neither link-local nor on-link and
+// lacks FLAG_ONLINK, is reported with the offending nexthop. With
+// options.AutoOnlink such nexthops get FLAG_ONLINK set in route instead.
+// Equivalent to: `ip route add $route`
+func (h *Handle) RouteAddWithOptions(route *Route, options *RouteAddOptions) error {
+	if options == nil {
+		options = &RouteAddOptions{}
+	}
+	if err := h.checkMultipathOnlink(route, options.AutoOnlink); err != nil {
+		return err
+	}
+	return h.RouteAdd(route)
+}
+
+// checkMultipathOnlink verifies that the gateways of the IPv6 nexthops of
+// route are on-link, setting FLAG_ONLINK on those which are not when
+// autoOnlink is true. Like the kernel, a gateway is on-link when the route
+// to it through the nexthop link has no gateway of its own.
+func (h *Handle) checkMultipathOnlink(route *Route, autoOnlink bool) error {
+	for i := range route.MultiPath {
+		nh := route.MultiPath[i]
+		if nh.Gw == nil || nh.Gw.To4() != nil || nh.Gw.IsLinkLocalUnicast() ||
+			nh.LinkIndex == 0 || nh.Flags&int(FLAG_ONLINK) != 0 {
+			continue
+		}
+		onlink := false
+		routes, err := h.RouteGetWithOptions(nh.Gw, &RouteGetOptions{OifIndex: nh.LinkIndex})
+		switch {
+		case err == nil:
+			onlink = len(routes) > 0 && routes[0].Gw == nil && routes[0].LinkIndex == nh.LinkIndex
+		case errors.Is(err, unix.ENETUNREACH), errors.Is(err, unix.EHOSTUNREACH):
+		default:
+			return fmt.Errorf("nexthop %d: route lookup for gateway %s: %w", i, nh.Gw, err)
+		}
+		if onlink {
+			continue
+		}
+		if !autoOnlink {
+			return fmt.Errorf("nexthop %d: gateway %s is not on-link on interface %d, set FLAG_ONLINK or RouteAddOptions.AutoOnlink",
+				i, nh.Gw, nh.LinkIndex)
+		}
+		nh.Flags |= int(FLAG_ONLINK)
+	}
+	return nil
+}
+
 // RouteAppend will append a route to the system.
 // Equivalent to: `ip route append $route`
 func RouteAppend(route *Route) error {
@@ -896,7 +1267,12 @@ func (h *Handle) routeHandle(route *Route, req *nl.NetlinkRequest, msg *nl.RtMsg
 	if err := h.prepareRouteReq(route, req, msg); err != nil {
 		return nil, err
 	}
-	return req.Execute(unix.NETLINK_ROUTE, 0)
+	res, err := req.Execute(unix.NETLINK_ROUTE, 0)
+	// the hint reads the label table size of the namespace of the caller
+	if err != nil && req.Type == unix.RTM_NEWROUTE && route.MPLSDst != nil && h.inCallerNamespace() {
+		err = mplsRouteError(*route.MPLSDst, err)
+	}
+	return res, err
 }
 
 func (h *Handle) routeHandleIter(route *Route, req *nl.NetlinkRequest, msg *nl.RtMsg, f func(msg []byte) bool) error {
@@ -927,6 +1303,9 @@ func (h *Handle) prepareRouteReq(route *Route, req *nl.NetlinkRequest, msg *nl.R
 		}
 		rtAttrs = append(rtAttrs, nl.NewRtAttr(unix.RTA_DST, dstData))
 	} else if route.MPLSDst != nil {
+		if req.NlMsghdr.Type != unix.RTM_GETROUTE && nl.IsReservedMPLSLabel(*route.MPLSDst) {
+			return fmt.Errorf("MPLSDst %d is a reserved label", *route.MPLSDst)
+		}
 		family = nl.FAMILY_MPLS
 		msg.Dst_len = uint8(20)
 		msg.Type = unix.RTN_UNICAST
@@ -1000,6 +1379,17 @@ func (h *Handle) prepareRouteReq(route *Route, req *nl.NetlinkRequest, msg *nl.R
 		rtAttrs = append(rtAttrs, nl.NewRtAttr(unix.RTA_VIA, buf))
 	}
 
+	if route.TTLPropagate != nil {
+		if family != nl.FAMILY_MPLS {
+			return fmt.Errorf("TTLPropagate is only supported for MPLS routes")
+		}
+		var b uint8
+		if *route.TTLPropagate {
+			b = 1
+		}
+		rtAttrs = append(rtAttrs, nl.NewRtAttr(unix.RTA_TTL_PROPAGATE, nl.Uint8Attr(b)))
+	}
+
 	if len(route.MultiPath) > 0 {
 		buf := []byte{}
 		for _, nh := range route.MultiPath {
@@ -1087,12 +1477,14 @@ func (h *Handle) prepareRouteReq(route *Route, req *nl.NetlinkRequest, msg *nl.R
 	}
 
 	var metrics []*nl.RtAttr
+	// The kernel keeps a single lock bitmask, so all locked metrics must
+	// be sent in one RTAX_LOCK attribute.
+	var locks uint32
 	if route.MTU > 0 {
 		b := nl.Uint32Attr(uint32(route.MTU))
 		metrics = append(metrics, nl.NewRtAttr(unix.RTAX_MTU, b))
 		if route.MTULock {
-			b := nl.Uint32Attr(uint32(1 << unix.RTAX_MTU))
-			metrics = append(metrics, nl.NewRtAttr(unix.RTAX_LOCK, b))
+			locks |= 1 << unix.RTAX_MTU
 		}
 	}
 	if route.Window > 0 {
@@ -1139,8 +1531,7 @@ func (h *Handle) prepareRouteReq(route *Route, req *nl.NetlinkRequest, msg *nl.R
 		b := nl.Uint32Attr(uint32(route.RtoMin))
 		metrics = append(metrics, nl.NewRtAttr(unix.RTAX_RTO_MIN, b))
 		if route.RtoMinLock {
-			b := nl.Uint32Attr(uint32(1 << unix.RTAX_RTO_MIN))
-			metrics = append(metrics, nl.NewRtAttr(unix.RTAX_LOCK, b))
+			locks |= 1 << unix.RTAX_RTO_MIN
 		}
 	}
 	if route.InitRwnd > 0 {
@@ -1159,6 +1550,10 @@ func (h *Handle) prepareRouteReq(route *Route, req *nl.NetlinkRequest, msg *nl.R
 		b := nl.Uint32Attr(uint32(route.FastOpenNoCookie))
 		metrics = append(metrics, nl.NewRtAttr(unix.RTAX_FASTOPEN_NO_COOKIE, b))
 	}
+	if locks != 0 {
+		b := nl.Uint32Attr(locks)
+		metrics = append(metrics, nl.NewRtAttr(unix.RTAX_LOCK, b))
+	}
 
 	if metrics != nil {
 		attr := nl.NewRtAttr(unix.RTA_METRICS, nil)
@@ -1178,6 +1573,9 @@ func (h *Handle) prepareRouteReq(route *Route, req *nl.NetlinkRequest, msg *nl.R
 	for _, attr := range rtAttrs {
 		req.AddData(attr)
 	}
+	if req.NlMsghdr.Type == unix.RTM_NEWROUTE {
+		addExtraAttrs(req, route.Extras)
+	}
 
 	if (req.NlMsghdr.Type != unix.RTM_GETROUTE) || (req.NlMsghdr.Type == unix.RTM_GETROUTE && route.LinkIndex > 0) {
 		b := make([]byte, 4)
@@ -1223,17 +1621,26 @@ func RouteListFiltered(family int, filter *Route, filterMask uint64) ([]Route, e
 // All rules must be defined in RouteFilter struct
 //
 // If the returned error is [ErrDumpInterrupted], results may be inconsistent
-// or incomplete.
+// or incomplete. An interrupted dump is first retried as configured with
+// RetryInterruptedDumps.
 func (h *Handle) RouteListFiltered(family int, filter *Route, filterMask uint64) ([]Route, error) {
 	var res []Route
-	err := h.RouteListFilteredIter(family, filter, filterMask, func(route Route) (cont bool) {
-		res = append(res, route)
-		return true
-	})
-	if err != nil {
+	var err error
+	for i := 0; ; i++ {
+		res = nil
+		err = h.RouteListFilteredIter(family, filter, filterMask, func(route Route) (cont bool) {
+			res = append(res, route)
+			return true
+		})
+		if !errors.Is(err, ErrDumpInterrupted) || i >= h.options.dumpRetries {
+			break
+		}
+	}
+	var decodeErrs *MultiDecodeError
+	if err != nil && !errors.Is(err, ErrDumpInterrupted) && !errors.As(err, &decodeErrs) {
 		return nil, err
 	}
-	return res, nil
+	return res, err
 }
 
 // RouteListFilteredIter passes each route that matches the filter to the given iterator func.  Iteration continues
@@ -1246,14 +1653,22 @@ func RouteListFilteredIter(family int, filter *Route, filterMask uint64, f func(
 }
 
 // If the returned error is [ErrDumpInterrupted], results may be inconsistent
-// or incomplete.
+// or incomplete. The dump is not retried, as f has already been called
+// with the routes of the interrupted one.
 func (h *Handle) RouteListFilteredIter(family int, filter *Route, filterMask uint64, f func(Route) (cont bool)) error {
 	req := h.newNetlinkRequest(unix.RTM_GETROUTE, unix.NLM_F_DUMP)
 	rtmsg := &nl.RtMsg{}
 	rtmsg.Family = uint8(family)
 
+	// a nil filter is allowed for the masks that use no field of it
+	reqRoute := filter
+	if reqRoute == nil {
+		reqRoute = &Route{}
+	}
+
 	var parseErr error
-	executeErr := h.routeHandleIter(filter, req, rtmsg, func(m []byte) bool {
+	var decodeErrs *MultiDecodeError
+	executeErr := h.routeHandleIter(reqRoute, req, rtmsg, func(m []byte) bool {
 		msg := nl.DeserializeRtMsg(m)
 		if family != FAMILY_ALL && msg.Family != uint8(family) {
 			// Ignore routes not matching requested family
@@ -1269,8 +1684,11 @@ func (h *Handle) RouteListFilteredIter(family int, filter *Route, filterMask uin
 				return true
 			}
 		}
-		route, err := deserializeRoute(m)
+		route, err := deserializeRoute(m, h.options.keepExtras)
 		if err != nil {
+			if h.decodeFailed(&decodeErrs, m, err) {
+				return true
+			}
 			parseErr = err
 			return false
 		}
@@ -1309,6 +1727,10 @@ func (h *Handle) RouteListFilteredIter(family int, filter *Route, filterMask uin
 				return true
 			}
 		}
+		// RT_FILTER_NOAUTOCONF doesn't use any field of filter.
+		if filterMask&RT_FILTER_NOAUTOCONF != 0 && route.IsAutoconf() {
+			return true
+		}
 		return f(route)
 	})
 	if executeErr != nil && !errors.Is(executeErr, ErrDumpInterrupted) {
@@ -1317,11 +1739,45 @@ func (h *Handle) RouteListFilteredIter(family int, filter *Route, filterMask uin
 	if parseErr != nil {
 		return parseErr
 	}
-	return executeErr
+	return withDecodeErrors(executeErr, decodeErrs)
+}
+
+// routeAttrKnown holds the top-level route attributes deserializeRoute
+// decodes or deliberately ignores. Only the other ones go to Route.Extras,
+// the ignored ones are reported by the kernel and must not be sent back.
+var routeAttrKnown = map[uint16]bool{
+	unix.RTA_UNSPEC:        true,
+	unix.RTA_DST:           true,
+	unix.RTA_SRC:           true,
+	unix.RTA_IIF:           true,
+	unix.RTA_OIF:           true,
+	unix.RTA_GATEWAY:       true,
+	unix.RTA_PRIORITY:      true,
+	unix.RTA_PREFSRC:       true,
+	unix.RTA_METRICS:       true,
+	unix.RTA_MULTIPATH:     true,
+	unix.RTA_FLOW:          true,
+	unix.RTA_CACHEINFO:     true,
+	unix.RTA_TABLE:         true,
+	unix.RTA_MARK:          true,
+	unix.RTA_MFC_STATS:     true,
+	unix.RTA_VIA:           true,
+	unix.RTA_NEWDST:        true,
+	unix.RTA_PREF:          true,
+	unix.RTA_ENCAP_TYPE:    true,
+	unix.RTA_ENCAP:         true,
+	unix.RTA_EXPIRES:       true,
+	unix.RTA_PAD:           true,
+	unix.RTA_UID:           true,
+	unix.RTA_TTL_PROPAGATE: true,
+	unix.RTA_IP_PROTO:      true,
+	unix.RTA_SPORT:         true,
+	unix.RTA_DPORT:         true,
+	nl.RTA_NH_ID:           true,
 }
 
 // deserializeRoute decodes a binary netlink message into a Route struct
-func deserializeRoute(m []byte) (Route, error) {
+func deserializeRoute(m []byte, keepExtras bool) (Route, error) {
 	msg := nl.DeserializeRtMsg(m)
 	attrs, err := nl.ParseRouteAttr(m[msg.Len():])
 	if err != nil {
@@ -1457,6 +1913,20 @@ func deserializeRoute(m []byte) (Route, error) {
 			encapType = attr
 		case unix.RTA_ENCAP:
 			encap = attr
+		case unix.RTA_TTL_PROPAGATE:
+			ttlPropagate := attr.Value[0] != 0
+			route.TTLPropagate = &ttlPropagate
+		case unix.RTA_CACHEINFO:
+			if len(attr.Value) < 20 {
+				return route, fmt.Errorf("invalid RTA_CACHEINFO length %d", len(attr.Value))
+			}
+			route.CacheInfo = &RouteCacheInfo{
+				ClntRef: native.Uint32(attr.Value[0:4]),
+				LastUse: native.Uint32(attr.Value[4:8]),
+				Expires: int32(native.Uint32(attr.Value[8:12])),
+				Error:   native.Uint32(attr.Value[12:16]),
+				Used:    native.Uint32(attr.Value[16:20]),
+			}
 		case unix.RTA_METRICS:
 			metrics, err := nl.ParseRouteAttr(attr.Value)
 			if err != nil {
@@ -1467,8 +1937,9 @@ func deserializeRoute(m []byte) (Route, error) {
 				case unix.RTAX_MTU:
 					route.MTU = int(native.Uint32(metric.Value[0:4]))
 				case unix.RTAX_LOCK:
-					route.MTULock = native.Uint32(metric.Value[0:4]) == uint32(1<<unix.RTAX_MTU)
-					route.RtoMinLock = native.Uint32(metric.Value[0:4]) == uint32(1<<unix.RTAX_RTO_MIN)
+					locks := native.Uint32(metric.Value[0:4])
+					route.MTULock = locks&(1<<unix.RTAX_MTU) != 0
+					route.RtoMinLock = locks&(1<<unix.RTAX_RTO_MIN) != 0
 				case unix.RTAX_WINDOW:
 					route.Window = int(native.Uint32(metric.Value[0:4]))
 				case unix.RTAX_RTT:
@@ -1501,6 +1972,13 @@ func deserializeRoute(m []byte) (Route, error) {
 					route.FastOpenNoCookie = int(native.Uint32(metric.Value[0:4]))
 				}
 			}
+		default:
+			if keepExtras && !routeAttrKnown[attr.Attr.Type&nl.NLA_TYPE_MASK] {
+				if route.Extras == nil {
+					route.Extras = make(map[uint16][]byte)
+				}
+				route.Extras[attr.Attr.Type] = attr.Value
+			}
 		}
 	}
 
@@ -1549,6 +2027,26 @@ func deserializeRoute(m []byte) (Route, error) {
 			if err := e.Decode(encap.Value); err != nil {
 				return route, err
 			}
+		case nl.LWTUNNEL_ENCAP_RPL:
+			e = &RplEncap{}
+			if err := e.Decode(encap.Value); err != nil {
+				return route, err
+			}
+		case nl.LWTUNNEL_ENCAP_IOAM6:
+			e = &Ioam6Encap{}
+			if err := e.Decode(encap.Value); err != nil {
+				return route, err
+			}
+		case nl.LWTUNNEL_ENCAP_IP:
+			e = &IPEncap{}
+			if err := e.Decode(encap.Value); err != nil {
+				return route, err
+			}
+		case nl.LWTUNNEL_ENCAP_IP6:
+			e = &IP6tnlEncap{}
+			if err := e.Decode(encap.Value); err != nil {
+				return route, err
+			}
 		}
 		route.Encap = e
 	}
@@ -1568,6 +2066,12 @@ type RouteGetOptions struct {
 	UID      *uint32
 	Mark     uint32
 	FIBMatch bool
+	// IPProto, SPort and DPort describe the layer 4 flow looked up, so
+	// that rules matching them apply. The kernel accepts ports for TCP,
+	// UDP and SCTP.
+	IPProto int
+	SPort   uint16
+	DPort   uint16
 }
 
 // RouteGetWithOptions gets a route to a specific destination from the host system.
@@ -1685,6 +2189,18 @@ func (h *Handle) RouteGetWithOptions(destination net.IP, options *RouteGetOption
 
 			req.AddData(nl.NewRtAttr(unix.RTA_MARK, b))
 		}
+
+		if options.IPProto > 0 {
+			req.AddData(nl.NewRtAttr(unix.RTA_IP_PROTO, nl.Uint8Attr(uint8(options.IPProto))))
+		}
+
+		if options.SPort > 0 {
+			req.AddData(nl.NewRtAttr(unix.RTA_SPORT, nl.BEUint16Attr(options.SPort)))
+		}
+
+		if options.DPort > 0 {
+			req.AddData(nl.NewRtAttr(unix.RTA_DPORT, nl.BEUint16Attr(options.DPort)))
+		}
 	}
 
 	msgs, err := req.Execute(unix.NETLINK_ROUTE, unix.RTM_NEWROUTE)
@@ -1694,7 +2210,7 @@ func (h *Handle) RouteGetWithOptions(destination net.IP, options *RouteGetOption
 
 	var res []Route
 	for _, m := range msgs {
-		route, err := deserializeRoute(m)
+		route, err := deserializeRoute(m, h.options.keepExtras)
 		if err != nil {
 			return nil, err
 		}
@@ -1712,24 +2228,39 @@ func (h *Handle) RouteGet(destination net.IP) ([]Route, error) {
 // RouteSubscribe takes a chan down which notifications will be sent
 // when routes are added or deleted. Close the 'done' chan to stop subscription.
 func RouteSubscribe(ch chan<- RouteUpdate, done <-chan struct{}) error {
-	return routeSubscribeAt(netns.None(), netns.None(), ch, done, nil, false, 0, nil, false)
+	return routeSubscribeAt(netns.None(), netns.None(), ch, done, nil, false, 0, nil, false, FAMILY_ALL, false)
 }
 
 // RouteSubscribeAt works like RouteSubscribe plus it allows the caller
 // to choose the network namespace in which to subscribe (ns).
 func RouteSubscribeAt(ns netns.NsHandle, ch chan<- RouteUpdate, done <-chan struct{}) error {
-	return routeSubscribeAt(ns, netns.None(), ch, done, nil, false, 0, nil, false)
+	return routeSubscribeAt(ns, netns.None(), ch, done, nil, false, 0, nil, false, FAMILY_ALL, false)
 }
 
 // RouteSubscribeOptions contains a set of options to use with
 // RouteSubscribeWithOptions.
 type RouteSubscribeOptions struct {
-	Namespace              *netns.NsHandle
-	ErrorCallback          func(error)
-	ListExisting           bool
+	Namespace     *netns.NsHandle
+	ErrorCallback func(error)
+	ListExisting  bool
+
+	// ReceiveBufferSize sets SO_RCVBUF on the subscription socket. Under
+	// heavy route churn a small buffer overflows and the ErrorCallback
+	// is invoked with ENOBUFS. Max size is based on value of
+	// /proc/sys/net/core/rmem_max unless ReceiveBufferForceSize is set,
+	// which uses SO_RCVBUFFORCE and requires CAP_NET_ADMIN.
 	ReceiveBufferSize      int
 	ReceiveBufferForceSize bool
-	ReceiveTimeout         *unix.Timeval
+	// ReceiveTimeout bounds each blocking read on the subscription socket.
+	ReceiveTimeout *unix.Timeval
+	// Family restricts the subscription to the route multicast group of
+	// FAMILY_V4, FAMILY_V6 or FAMILY_MPLS. The default FAMILY_ALL joins
+	// both the IPv4 and IPv6 groups, like RouteSubscribe.
+	Family int
+	// ListenAllNsid sets NETLINK_LISTEN_ALL_NSID on the subscription
+	// socket so that updates from every namespace that has an id in the
+	// subscribing namespace are received too, tagged with their NsID.
+	ListenAllNsid bool
 }
 
 // RouteSubscribeWithOptions work like RouteSubscribe but enable to
@@ -1745,15 +2276,39 @@ func RouteSubscribeWithOptions(ch chan<- RouteUpdate, done <-chan struct{}, opti
 		options.Namespace = &none
 	}
 	return routeSubscribeAt(*options.Namespace, netns.None(), ch, done, options.ErrorCallback, options.ListExisting,
-		options.ReceiveBufferSize, options.ReceiveTimeout, options.ReceiveBufferForceSize)
+		options.ReceiveBufferSize, options.ReceiveTimeout, options.ReceiveBufferForceSize, options.Family, options.ListenAllNsid)
+}
+
+func routeSubscribeGroups(family int) ([]uint, error) {
+	switch family {
+	case FAMILY_ALL:
+		return []uint{unix.RTNLGRP_IPV4_ROUTE, unix.RTNLGRP_IPV6_ROUTE}, nil
+	case FAMILY_V4:
+		return []uint{unix.RTNLGRP_IPV4_ROUTE}, nil
+	case FAMILY_V6:
+		return []uint{unix.RTNLGRP_IPV6_ROUTE}, nil
+	case FAMILY_MPLS:
+		return []uint{unix.RTNLGRP_MPLS_ROUTE}, nil
+	}
+	return nil, fmt.Errorf("unsupported route subscription family %d", family)
 }
 
 func routeSubscribeAt(newNs, curNs netns.NsHandle, ch chan<- RouteUpdate, done <-chan struct{}, cberr func(error), listExisting bool,
-	rcvbuf int, rcvTimeout *unix.Timeval, rcvbufForce bool) error {
-	s, err := nl.SubscribeAt(newNs, curNs, unix.NETLINK_ROUTE, unix.RTNLGRP_IPV4_ROUTE, unix.RTNLGRP_IPV6_ROUTE)
+	rcvbuf int, rcvTimeout *unix.Timeval, rcvbufForce bool, family int, listenAllNsid bool) error {
+	groups, err := routeSubscribeGroups(family)
 	if err != nil {
 		return err
 	}
+	s, err := nl.SubscribeAt(newNs, curNs, unix.NETLINK_ROUTE, groups...)
+	if err != nil {
+		return err
+	}
+	if listenAllNsid {
+		if err := s.SetListenAllNsid(true); err != nil {
+			s.Close()
+			return err
+		}
+	}
 	if rcvTimeout != nil {
 		if err := s.SetReceiveTimeout(rcvTimeout); err != nil {
 			return err
@@ -1774,7 +2329,7 @@ func routeSubscribeAt(newNs, curNs netns.NsHandle, ch chan<- RouteUpdate, done <
 	if listExisting {
 		req := pkgHandle.newNetlinkRequest(unix.RTM_GETROUTE,
 			unix.NLM_F_DUMP)
-		infmsg := nl.NewIfInfomsg(unix.AF_UNSPEC)
+		infmsg := nl.NewIfInfomsg(family)
 		req.AddData(infmsg)
 		if err := s.Send(req); err != nil {
 			return err
@@ -1783,7 +2338,7 @@ func routeSubscribeAt(newNs, curNs netns.NsHandle, ch chan<- RouteUpdate, done <
 	go func() {
 		defer close(ch)
 		for {
-			msgs, from, err := s.Receive()
+			msgs, from, nsid, err := s.ReceiveNsid()
 			if err != nil {
 				if cberr != nil {
 					cberr(fmt.Errorf("Receive failed: %v",
@@ -1815,7 +2370,7 @@ func routeSubscribeAt(newNs, curNs netns.NsHandle, ch chan<- RouteUpdate, done <
 					}
 					continue
 				}
-				route, err := deserializeRoute(m.Data)
+				route, err := deserializeRoute(m.Data, false)
 				if err != nil {
 					if cberr != nil {
 						cberr(err)
@@ -1826,6 +2381,7 @@ func routeSubscribeAt(newNs, curNs netns.NsHandle, ch chan<- RouteUpdate, done <
 					Type:    m.Header.Type,
 					NlFlags: m.Header.Flags & (unix.NLM_F_REPLACE | unix.NLM_F_EXCL | unix.NLM_F_CREATE | unix.NLM_F_APPEND),
 					Route:   route,
+					NsID:    nsid,
 				}
 			}
 		}
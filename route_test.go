@@ -4,12 +4,20 @@
 package netlink
 
 import (
+	"bytes"
+	"encoding/hex"
+	"errors"
 	"net"
 	"os"
+	"path/filepath"
+	"reflect"
 	"runtime"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
+	"unsafe"
 
 	"github.com/vishvananda/netlink/nl"
 	"github.com/vishvananda/netns"
@@ -454,6 +462,100 @@ func TestRouteReplace(t *testing.T) {
 	}
 }
 
+func TestRouteExtras(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+
+	h, err := NewHandle(unix.NETLINK_ROUTE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	h.RetainUnknownAttributes()
+
+	var replies [][]byte
+	var sent []byte
+	h.SetTraceFunc(func(dir nl.Direction, proto int, msg []byte) {
+		if (*unix.NlMsghdr)(unsafe.Pointer(&msg[0])).Type != unix.RTM_NEWROUTE {
+			return
+		}
+		if dir == nl.DirectionReceive {
+			replies = append(replies, append([]byte(nil), msg...))
+		} else {
+			sent = append([]byte(nil), msg...)
+		}
+	})
+
+	link, err := h.LinkByName("lo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.LinkSetUp(link); err != nil {
+		t.Fatal(err)
+	}
+	dst := &net.IPNet{
+		IP:   net.IPv4(192, 168, 0, 0),
+		Mask: net.CIDRMask(24, 32),
+	}
+	route := Route{LinkIndex: link.Attrs().Index, Dst: dst}
+	if err := h.RouteAdd(&route); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.RouteList(link, FAMILY_V4); err != nil {
+		t.Fatal(err)
+	}
+
+	// pretend the kernel sent an attribute newer than the library
+	const fakeType = 1000
+	fakeValue := []byte{1, 2, 3, 4}
+	var listed *Route
+	// and one it knows but does not decode
+	uid := nl.NewRtAttr(unix.RTA_UID, nl.Uint32Attr(0)).Serialize()
+	for _, reply := range replies {
+		m := append(reply[unix.SizeofNlMsghdr:], nl.NewRtAttr(fakeType, fakeValue).Serialize()...)
+		m = append(m, uid...)
+		r, err := deserializeRoute(m, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ipNetEqual(r.Dst, dst) {
+			listed = &r
+		}
+	}
+	if listed == nil {
+		t.Fatal("Route not listed")
+	}
+	if !bytes.Equal(listed.Extras[fakeType], fakeValue) {
+		t.Fatalf("fake attribute not retained: %v", listed.Extras)
+	}
+	for typ := range listed.Extras {
+		if routeAttrKnown[typ&nl.NLA_TYPE_MASK] {
+			t.Fatalf("known attribute %d retained in Extras", typ)
+		}
+	}
+	plain := *listed
+	plain.Extras = nil
+	if !listed.Equal(plain) {
+		t.Fatal("Extras should not affect Equal")
+	}
+
+	if err := h.RouteReplace(listed); err != nil {
+		t.Fatal(err)
+	}
+	attrs, err := nl.ParseRouteAttr(sent[unix.SizeofNlMsghdr+unix.SizeofRtMsg:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, attr := range attrs {
+		if attr.Attr.Type == fakeType && bytes.Equal(attr.Value, fakeValue) {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("RouteReplace did not send the retained attribute")
+	}
+}
+
 func TestRouteAppend(t *testing.T) {
 	t.Cleanup(setUpNetlinkTest(t))
 
@@ -542,7 +644,10 @@ func expectRouteUpdate(ch <-chan RouteUpdate, t, f uint16, dst net.IP) bool {
 	for {
 		timeout := time.After(time.Minute)
 		select {
-		case update := <-ch:
+		case update, ok := <-ch:
+			if !ok {
+				return false
+			}
 			if update.Type == t &&
 				update.NlFlags == f &&
 				update.Route.Dst != nil &&
@@ -599,6 +704,57 @@ func TestRouteSubscribe(t *testing.T) {
 	}
 }
 
+func TestRouteSubscribeReceiveBufferOverflow(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+
+	ch := make(chan RouteUpdate)
+	done := make(chan struct{})
+	defer close(done)
+	errCh := make(chan error, 1)
+	if err := RouteSubscribeWithOptions(ch, done, RouteSubscribeOptions{
+		ReceiveBufferSize: 1,
+		ErrorCallback: func(err error) {
+			select {
+			case errCh <- err:
+			default:
+			}
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	link, err := LinkByName("lo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = LinkSetUp(link); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nobody reads ch yet, so the socket buffer fills up and the kernel
+	// starts dropping notifications.
+	for i := 0; i < 256; i++ {
+		dst := &net.IPNet{IP: net.IPv4(10, 1, byte(i), 0), Mask: net.CIDRMask(24, 32)}
+		if err := RouteAdd(&Route{LinkIndex: link.Attrs().Index, Dst: dst}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	go func() {
+		for range ch {
+		}
+	}()
+
+	select {
+	case err := <-errCh:
+		if !strings.Contains(err.Error(), unix.ENOBUFS.Error()) {
+			t.Fatalf("expected ENOBUFS, got %v", err)
+		}
+	case <-time.After(time.Minute):
+		t.Fatal("error callback not invoked on receive buffer overflow")
+	}
+}
+
 func TestRouteSubscribeWithOptions(t *testing.T) {
 	t.Cleanup(setUpNetlinkTest(t))
 
@@ -647,6 +803,68 @@ func TestRouteSubscribeWithOptions(t *testing.T) {
 	}
 }
 
+func TestRouteSubscribeFamily(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+
+	ch := make(chan RouteUpdate, 16)
+	done := make(chan struct{})
+	defer close(done)
+	link, err := LinkByName("lo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = LinkSetUp(link); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RouteSubscribeWithOptions(ch, done, RouteSubscribeOptions{
+		Family: FAMILY_V4,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// churn IPv6 routes, none of which should reach the subscription
+	for i := 0; i < 4; i++ {
+		route := Route{
+			LinkIndex: link.Attrs().Index,
+			Dst: &net.IPNet{
+				IP:   net.ParseIP("2001:db8:" + strconv.Itoa(i) + "::"),
+				Mask: net.CIDRMask(64, 128),
+			},
+		}
+		if err := RouteAdd(&route); err != nil {
+			t.Fatal(err)
+		}
+		if err := RouteDel(&route); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dst := &net.IPNet{
+		IP:   net.IPv4(192, 168, 0, 0),
+		Mask: net.CIDRMask(24, 32),
+	}
+	route := Route{LinkIndex: link.Attrs().Index, Dst: dst}
+	if err := RouteAdd(&route); err != nil {
+		t.Fatal(err)
+	}
+	// updates arrive in order, so the IPv4 route must be the first one
+	select {
+	case update := <-ch:
+		if update.Route.Family != FAMILY_V4 || !update.Route.Dst.IP.Equal(dst.IP) {
+			t.Fatalf("unexpected update on IPv4 only subscription: %+v", update)
+		}
+	case <-time.After(time.Minute):
+		t.Fatal("Add update not received as expected")
+	}
+
+	if err := RouteSubscribeWithOptions(ch, done, RouteSubscribeOptions{
+		Family: unix.AF_BRIDGE,
+	}); err == nil {
+		t.Fatal("subscribing to an unsupported family should fail")
+	}
+}
+
 func TestRouteSubscribeAt(t *testing.T) {
 	skipUnlessRoot(t)
 
@@ -1201,6 +1419,72 @@ func TestRouteMultiPath(t *testing.T) {
 	}
 }
 
+func TestRouteAddWithOptionsOnlink(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+
+	var links []Link
+	for _, l := range []struct{ name, prefix string }{
+		{"dummy0", "2001:db8:1::1/64"},
+		{"dummy1", "2001:db8:2::1/64"},
+	} {
+		link := &Dummy{LinkAttrs{Name: l.name}}
+		if err := LinkAdd(link); err != nil {
+			t.Fatal(err)
+		}
+		if err := LinkSetUp(link); err != nil {
+			t.Fatal(err)
+		}
+		addr, err := ParseAddr(l.prefix)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := AddrAdd(link, addr); err != nil {
+			t.Fatal(err)
+		}
+		links = append(links, link)
+	}
+
+	_, dst, _ := net.ParseCIDR("2001:db8:ff::/64")
+	newRoute := func() *Route {
+		return &Route{Dst: dst, MultiPath: []*NexthopInfo{
+			{LinkIndex: links[0].Attrs().Index, Gw: net.ParseIP("2001:db8:1::2")},
+			{LinkIndex: links[1].Attrs().Index, Gw: net.ParseIP("2001:db8:3::2")},
+		}}
+	}
+
+	err := RouteAddWithOptions(newRoute(), nil)
+	if err == nil {
+		t.Fatal("route with an off-link gateway was added")
+	}
+	if !strings.Contains(err.Error(), "nexthop 1: gateway 2001:db8:3::2 is not on-link") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	route := newRoute()
+	if err := RouteAddWithOptions(route, &RouteAddOptions{AutoOnlink: true}); err != nil {
+		t.Fatal(err)
+	}
+	if route.MultiPath[0].Flags&int(FLAG_ONLINK) != 0 {
+		t.Fatal("FLAG_ONLINK set on an on-link nexthop")
+	}
+	if route.MultiPath[1].Flags&int(FLAG_ONLINK) == 0 {
+		t.Fatal("FLAG_ONLINK not set on the off-link nexthop")
+	}
+
+	routes, err := RouteListFiltered(FAMILY_V6, &Route{Dst: dst}, RT_FILTER_DST)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) != 1 || len(routes[0].MultiPath) != 2 {
+		t.Fatalf("multipath route not found: %v", routes)
+	}
+	for _, nh := range routes[0].MultiPath {
+		if nh.Gw.Equal(net.ParseIP("2001:db8:3::2")) && nh.Flags&int(FLAG_ONLINK) == 0 {
+			t.Fatalf("nexthop %s is not onlink", nh)
+		}
+	}
+}
+
 func TestRouteIifOption(t *testing.T) {
 	skipUnlessRoot(t)
 
@@ -1546,12 +1830,14 @@ func TestMPLSRouteAddDel(t *testing.T) {
 	}
 
 	mplsDst := 100
+	ttlPropagate := false
 	route := Route{
 		LinkIndex: link.Attrs().Index,
 		MPLSDst:   &mplsDst,
 		NewDst: &MPLSDestination{
 			Labels: []int{200, 300},
 		},
+		TTLPropagate: &ttlPropagate,
 	}
 	if err := RouteAdd(&route); err != nil {
 		t.Fatal(err)
@@ -1563,6 +1849,23 @@ func TestMPLSRouteAddDel(t *testing.T) {
 	if len(routes) != 1 {
 		t.Fatal("Route not added properly")
 	}
+	if routes[0].TTLPropagate == nil || *routes[0].TTLPropagate {
+		t.Fatalf("TTLPropagate not decoded properly: %v", routes[0].TTLPropagate)
+	}
+
+	// reserved labels are refused before reaching the kernel
+	reserved := nl.MPLS_LABEL_IPV4NULL
+	if err := RouteAdd(&Route{LinkIndex: link.Attrs().Index, MPLSDst: &reserved}); err == nil {
+		t.Fatal("route with a reserved MPLSDst added")
+	}
+	implNull := &Route{
+		LinkIndex: link.Attrs().Index,
+		MPLSDst:   &mplsDst,
+		NewDst:    &MPLSDestination{Labels: []int{nl.MPLS_LABEL_IMPLNULL}},
+	}
+	if err := RouteAdd(implNull); err == nil {
+		t.Fatal("route pushing the implicit null label added")
+	}
 
 	if err := RouteDel(&route); err != nil {
 		t.Fatal(err)
@@ -1577,6 +1880,51 @@ func TestMPLSRouteAddDel(t *testing.T) {
 
 }
 
+func TestMPLSPlatformLabels(t *testing.T) {
+	defer func(path string) { mplsPlatformLabelsPath = path }(mplsPlatformLabelsPath)
+
+	mplsPlatformLabelsPath = filepath.Join(t.TempDir(), "missing")
+	if _, err := MPLSPlatformLabels(); !errors.Is(err, ErrMPLSUnavailable) {
+		t.Fatalf("expected ErrMPLSUnavailable, got %v", err)
+	}
+	if err := SetMPLSPlatformLabels(16); !errors.Is(err, ErrMPLSUnavailable) {
+		t.Fatalf("expected ErrMPLSUnavailable, got %v", err)
+	}
+
+	mplsPlatformLabelsPath = filepath.Join(t.TempDir(), "platform_labels")
+	if err := os.WriteFile(mplsPlatformLabelsPath, []byte("0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := MPLSPlatformLabels(); err != nil || n != 0 {
+		t.Fatalf("expected 0, got %d, %v", n, err)
+	}
+	if err := SetMPLSPlatformLabels(1024); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := MPLSPlatformLabels(); err != nil || n != 1024 {
+		t.Fatalf("expected 1024, got %d, %v", n, err)
+	}
+	if err := SetMPLSPlatformLabels(-1); err == nil {
+		t.Fatal("expected an error for a negative size")
+	}
+
+	// labels out of the table get the limit in the error
+	err := mplsRouteError(2048, unix.EINVAL)
+	if !errors.Is(err, unix.EINVAL) || !strings.Contains(err.Error(), "platform_labels (1024)") {
+		t.Fatalf("expected the platform_labels limit in the error, got %v", err)
+	}
+	err = mplsLabelError(100, 0, unix.ENETUNREACH)
+	if !errors.Is(err, unix.ENETUNREACH) || !strings.Contains(err.Error(), "platform_labels is 0") {
+		t.Fatalf("expected the platform_labels limit in the error, got %v", err)
+	}
+	if err := mplsRouteError(100, unix.EINVAL); err != unix.EINVAL {
+		t.Fatalf("expected the error of a label in the table unchanged, got %v", err)
+	}
+	if err := mplsRouteError(2048, unix.EEXIST); err != unix.EEXIST {
+		t.Fatalf("expected other errors unchanged, got %v", err)
+	}
+}
+
 func TestIP6tnlRouteAddDel(t *testing.T) {
 	_, err := RouteList(nil, FAMILY_V4)
 	if err != nil {
@@ -1636,6 +1984,68 @@ func TestIP6tnlRouteAddDel(t *testing.T) {
 
 }
 
+func TestRouteAddDelTunnelMetadataEncap(t *testing.T) {
+	minKernelRequired(t, 4, 3)
+	t.Cleanup(setUpNetlinkTest(t))
+
+	vxlan := &Vxlan{LinkAttrs: LinkAttrs{Name: "foo"}, Learning: false, FlowBased: true}
+	if err := LinkAdd(vxlan); err != nil {
+		t.Fatal(err)
+	}
+	link, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkSetUp(link); err != nil {
+		t.Fatal(err)
+	}
+
+	_, dst, _ := net.ParseCIDR("10.1.0.0/24")
+	_, dst6, _ := net.ParseCIDR("2001:db8:1::/64")
+	for _, route := range []*Route{
+		{
+			LinkIndex: link.Attrs().Index,
+			Dst:       dst,
+			Encap: &IPEncap{
+				ID:    100,
+				Dst:   net.ParseIP("192.0.2.10"),
+				Src:   net.ParseIP("192.0.2.1"),
+				TTL:   64,
+				Tos:   0x10,
+				Flags: nl.TUNNEL_KEY,
+			},
+		},
+		{
+			LinkIndex: link.Attrs().Index,
+			Dst:       dst6,
+			Encap: &IP6tnlEncap{
+				ID:       200,
+				Dst:      net.ParseIP("2001:db8::10"),
+				Hoplimit: 32,
+				TC:       0x20,
+				Flags:    nl.TUNNEL_KEY,
+			},
+		},
+	} {
+		if err := RouteAdd(route); err != nil {
+			t.Fatalf("Cannot add route %s: %v", route, err)
+		}
+		routes, err := RouteListFiltered(FAMILY_ALL, route, RT_FILTER_OIF|RT_FILTER_DST)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(routes) != 1 {
+			t.Fatalf("Route %s not added properly: %v", route, routes)
+		}
+		if routes[0].Encap == nil || !routes[0].Encap.Equal(route.Encap) {
+			t.Fatalf("Encap is %v, should be %v", routes[0].Encap, route.Encap)
+		}
+		if err := RouteDel(route); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
 func TestRouteEqual(t *testing.T) {
 	mplsDst := 100
 	seg6encap := &SEG6Encap{Mode: nl.SEG6_IPTUN_MODE_ENCAP}
@@ -1786,6 +2196,32 @@ func TestRouteEqual(t *testing.T) {
 			Dst:       nil,
 			MultiPath: []*NexthopInfo{{LinkIndex: 10}, {LinkIndex: 20}},
 		},
+		// locked and unlocked metrics of the same value differ
+		{
+			Gw:  net.IPv4(1, 1, 1, 1),
+			MTU: 1400,
+		},
+		{
+			Gw:      net.IPv4(1, 1, 1, 1),
+			MTU:     1400,
+			MTULock: true,
+		},
+		{
+			Gw:     net.IPv4(1, 1, 1, 1),
+			RtoMin: 200,
+		},
+		{
+			Gw:         net.IPv4(1, 1, 1, 1),
+			RtoMin:     200,
+			RtoMinLock: true,
+		},
+		{
+			Gw:         net.IPv4(1, 1, 1, 1),
+			MTU:        1400,
+			MTULock:    true,
+			RtoMin:     200,
+			RtoMinLock: true,
+		},
 		{
 			Dst: nil,
 			MultiPath: []*NexthopInfo{{
@@ -1834,6 +2270,17 @@ func TestRouteEqual(t *testing.T) {
 	}
 }
 
+func TestRouteStringMetricLocks(t *testing.T) {
+	route := Route{MTU: 1400, MTULock: true, RtoMin: 200}
+	s := route.String()
+	if !strings.Contains(s, " lock mtu 1400") {
+		t.Errorf("locked MTU missing from %q", s)
+	}
+	if !strings.Contains(s, " rto_min 200ms") || strings.Contains(s, "lock rto_min") {
+		t.Errorf("unlocked RtoMin missing from %q", s)
+	}
+}
+
 func TestIPNetEqual(t *testing.T) {
 	cases := []string{
 		"1.1.1.1/24", "1.1.1.0/24", "1.1.1.1/32",
@@ -1877,6 +2324,43 @@ func TestIPNetEqual(t *testing.T) {
 	}
 }
 
+func TestRouteDeserializeCacheInfo(t *testing.T) {
+	msg := nl.NewRtMsg()
+	msg.Family = FAMILY_V4
+	msg.Dst_len = 24
+	b := msg.Serialize()
+	b = append(b, nl.NewRtAttr(unix.RTA_DST, []byte{192, 168, 0, 0}).Serialize()...)
+	b = append(b, nl.NewRtAttr(unix.RTA_OIF, nl.Uint32Attr(2)).Serialize()...)
+
+	// struct rta_cacheinfo as dumped by the kernel, including the trailing
+	// id/ts/tsage fields that are not exposed.
+	ci := make([]byte, 32)
+	native.PutUint32(ci[0:4], 3)
+	native.PutUint32(ci[4:8], 1500)
+	native.PutUint32(ci[8:12], uint32(0xffffffff))
+	native.PutUint32(ci[12:16], 0)
+	native.PutUint32(ci[16:20], 42)
+	b = append(b, nl.NewRtAttr(unix.RTA_CACHEINFO, ci).Serialize()...)
+
+	route, err := deserializeRoute(b, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if route.CacheInfo == nil {
+		t.Fatal("expected CacheInfo to be decoded")
+	}
+	expected := RouteCacheInfo{ClntRef: 3, LastUse: 1500, Expires: -1, Error: 0, Used: 42}
+	if *route.CacheInfo != expected {
+		t.Fatalf("CacheInfo mismatch: got %+v, expected %+v", *route.CacheInfo, expected)
+	}
+
+	other := route
+	other.CacheInfo = nil
+	if !route.Equal(other) {
+		t.Fatal("Route.Equal should ignore CacheInfo")
+	}
+}
+
 func TestSEG6LocalEqual(t *testing.T) {
 	// Different attributes exists in different Actions. For example, Action
 	// SEG6_LOCAL_ACTION_END_X has In6Addr, SEG6_LOCAL_ACTION_END_T has Table etc.
@@ -1996,6 +2480,37 @@ func TestSEG6LocalEqual(t *testing.T) {
 		}
 	}
 }
+func TestSEG6EncapHmac(t *testing.T) {
+	// captured from iproute2 6.1:
+	//   ip route add 10.0.0.102/32 encap seg6 mode encap.red \
+	//     segs fc00:a000::22,fc00:a000::21 hmac 0x1001 dev lo
+	expected, _ := hex.DecodeString("58000100" + "03000000" + "0009040101080000" +
+		"fc00a000000000000000000000000021" + "fc00a000000000000000000000000022" +
+		"0526000000001001" + strings.Repeat("00", nl.SEG6_HMAC_FIELD_LEN))
+	e := &SEG6Encap{
+		Mode:     nl.SEG6_IPTUN_MODE_ENCAP_RED,
+		Segments: []net.IP{net.ParseIP("fc00:a000::21"), net.ParseIP("fc00:a000::22")},
+		Hmac:     0x1001,
+	}
+	b, err := e.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, expected) {
+		t.Fatalf("encoded\n%x\nexpected\n%x", b, expected)
+	}
+	decoded := &SEG6Encap{}
+	if err := decoded.Decode(b); err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Equal(e) {
+		t.Fatalf("decoded %s, expected %s", decoded, e)
+	}
+	if s := decoded.String(); s != "mode encap.red segs 2 [ fc00:a000::22 fc00:a000::21 ] hmac 0x1001" {
+		t.Fatalf("unexpected string %q", s)
+	}
+}
+
 func TestSEG6RouteAddDel(t *testing.T) {
 	if os.Getenv("CI") == "true" {
 		t.Skipf("Fails in CI with: route_test.go:*: Invalid Type. SEG6_IPTUN_MODE_INLINE routes not added properly")
@@ -2082,6 +2597,27 @@ func TestSEG6RouteAddDel(t *testing.T) {
 	if len(routes) != 0 {
 		t.Fatal("SEG6 routes not removed properly")
 	}
+
+	// reduced SRH encapsulation with an HMAC TLV
+	minKernelRequired(t, 5, 18)
+	e3 := &SEG6Encap{Mode: nl.SEG6_IPTUN_MODE_ENCAP_RED, Segments: s2, Hmac: 0x1001}
+	route3 := Route{LinkIndex: link.Attrs().Index, Dst: dst2, Encap: e3}
+	if err := RouteAdd(&route3); err != nil {
+		t.Fatal(err)
+	}
+	routes, err = RouteList(link, FAMILY_V4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) != 1 {
+		t.Fatal("SEG6 routes not added properly")
+	}
+	if !e3.Equal(routes[0].Encap) {
+		t.Fatalf("SEG6 encap %s does not match %s", routes[0].Encap, e3)
+	}
+	if err := RouteDel(&route3); err != nil {
+		t.Fatal(err)
+	}
 }
 
 // add/del routes with LWTUNNEL_ENCAP_SEG6_LOCAL to/from dummy interface.
@@ -2161,6 +2697,139 @@ func TestSEG6LocalRoute6AddDel(t *testing.T) {
 	}
 }
 
+func TestRplEncap(t *testing.T) {
+	// captured from iproute2 6.1:
+	//   ip -6 route add 2001:db8::1/128 encap rpl segs fc00::1,fc00::2 dev lo
+	expected, _ := hex.DecodeString("2c000100" + "0004030200000000" +
+		"fc000000000000000000000000000002" + "fc000000000000000000000000000001")
+	e := &RplEncap{Segments: []net.IP{net.ParseIP("fc00::2"), net.ParseIP("fc00::1")}}
+	b, err := e.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, expected) {
+		t.Fatalf("encoded\n%x\nexpected\n%x", b, expected)
+	}
+	decoded := &RplEncap{}
+	if err := decoded.Decode(b); err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Equal(e) {
+		t.Fatalf("decoded %s, expected %s", decoded, e)
+	}
+	if s := decoded.String(); s != "segs 2 [ fc00::1 fc00::2 ]" {
+		t.Fatalf("unexpected string %q", s)
+	}
+	if _, err := (&RplEncap{}).Encode(); err == nil {
+		t.Fatal("expected an error without segments")
+	}
+}
+
+func TestIoam6Encap(t *testing.T) {
+	// captured from iproute2 6.1:
+	//   ip -6 route add 2001:db8::3/128 encap ioam6 freq 2/5 mode encap tundst 2001:db8::9 \
+	//     trace prealloc type 0xf00000 ns 7 size 44 dev lo
+	expected, _ := hex.DecodeString("0800040002000000" + "0800050005000000" + "0500010002000000" +
+		"14000200" + "20010db8000000000000000000000009" + "0c000300" + "0007000bf0000000")
+	e := &Ioam6Encap{
+		Mode:      nl.IOAM6_IPTUNNEL_MODE_ENCAP,
+		Dst:       net.ParseIP("2001:db8::9"),
+		FreqK:     2,
+		FreqN:     5,
+		Namespace: 7,
+		TraceType: 0xf00000,
+		TraceSize: 44,
+	}
+	b, err := e.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, expected) {
+		t.Fatalf("encoded\n%x\nexpected\n%x", b, expected)
+	}
+	decoded := &Ioam6Encap{}
+	if err := decoded.Decode(b); err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Equal(e) {
+		t.Fatalf("decoded %s, expected %s", decoded, e)
+	}
+	if s := decoded.String(); s != "freq 2/5 mode encap tundst 2001:db8::9 trace prealloc type 0xf00000 ns 7 size 44" {
+		t.Fatalf("unexpected string %q", s)
+	}
+	if _, err := (&Ioam6Encap{TraceSize: 42}).Encode(); err == nil {
+		t.Fatal("expected an error for a trace size not multiple of 4")
+	}
+}
+
+func TestRplIoam6RouteAddDel(t *testing.T) {
+	minKernelRequired(t, 6, 0)
+	t.Cleanup(setUpNetlinkTest(t))
+
+	link, err := LinkByName("lo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkSetUp(link); err != nil {
+		t.Fatal(err)
+	}
+
+	encaps := []Encap{
+		&RplEncap{Segments: []net.IP{net.ParseIP("fc00::2"), net.ParseIP("fc00::1")}},
+		&Ioam6Encap{
+			Mode:      nl.IOAM6_IPTUNNEL_MODE_INLINE,
+			FreqK:     1,
+			FreqN:     1,
+			Namespace: 1,
+			TraceType: 0x800000,
+			TraceSize: 12,
+		},
+	}
+	for _, encap := range encaps {
+		route := Route{
+			LinkIndex: link.Attrs().Index,
+			Dst:       &net.IPNet{IP: net.ParseIP("2001:db8::1"), Mask: net.CIDRMask(128, 128)},
+			Encap:     encap,
+		}
+		if err := RouteAdd(&route); err != nil {
+			if errors.Is(err, unix.EOPNOTSUPP) {
+				t.Skipf("lwt encap type %d not supported by the kernel", encap.Type())
+			}
+			t.Fatal(err)
+		}
+		routes, err := RouteListFiltered(FAMILY_V6, &route, RT_FILTER_DST)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(routes) != 1 || !encap.Equal(routes[0].Encap) {
+			t.Fatalf("route encap %v does not match %s", routes, encap)
+		}
+		if err := RouteDel(&route); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Without a frequency the trace goes in every packet, read back as 1/1.
+	route := Route{
+		LinkIndex: link.Attrs().Index,
+		Dst:       &net.IPNet{IP: net.ParseIP("2001:db8::1"), Mask: net.CIDRMask(128, 128)},
+		Encap:     &Ioam6Encap{Namespace: 1, TraceType: 0x800000, TraceSize: 12},
+	}
+	if err := RouteAdd(&route); err != nil {
+		t.Fatal(err)
+	}
+	routes, err := RouteListFiltered(FAMILY_V6, &route, RT_FILTER_DST)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) != 1 {
+		t.Fatalf("route not found: %v", routes)
+	}
+	if e, ok := routes[0].Encap.(*Ioam6Encap); !ok || e.FreqK != 1 || e.FreqN != 1 {
+		t.Fatalf("unexpected encap %s", routes[0].Encap)
+	}
+}
+
 func TestBpfEncap(t *testing.T) {
 	tCase := &BpfEncap{}
 	if err := tCase.SetProg(nl.LWT_BPF_IN, 0, "test_in"); err == nil {
@@ -2378,6 +3047,120 @@ func TestRtoMinLockRouteAddDel(t *testing.T) {
 	}
 }
 
+func TestMTUAndRtoMinLockRouteAddDel(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+
+	link, err := LinkByName("lo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkSetUp(link); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := &net.IPNet{
+		IP:   net.IPv4(192, 168, 0, 0),
+		Mask: net.CIDRMask(24, 32),
+	}
+	route := Route{LinkIndex: link.Attrs().Index, Dst: dst, MTU: 500, MTULock: true, RtoMin: 40, RtoMinLock: true}
+	if err := RouteAdd(&route); err != nil {
+		t.Fatal(err)
+	}
+	routes, err := RouteList(link, FAMILY_V4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) != 1 {
+		t.Fatal("Route not added properly")
+	}
+	if !routes[0].MTULock || !routes[0].RtoMinLock {
+		t.Fatalf("Route locks not set properly: mtu %v rto_min %v", routes[0].MTULock, routes[0].RtoMinLock)
+	}
+	if routes[0].MTU != route.MTU || routes[0].RtoMin != route.RtoMin {
+		t.Fatalf("Route metrics not set properly: %s", routes[0])
+	}
+
+	if err := RouteDel(&route); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRouteListDumpInterrupted(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+
+	h, err := NewHandle()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	h.RetryInterruptedDumps(5)
+	var requests, interrupted int32
+	h.SetTraceFunc(func(dir nl.Direction, proto int, msg []byte) {
+		hdr := (*unix.NlMsghdr)(unsafe.Pointer(&msg[0]))
+		switch {
+		case dir == nl.DirectionSend && hdr.Type == unix.RTM_GETROUTE:
+			atomic.AddInt32(&requests, 1)
+		case dir == nl.DirectionReceive && hdr.Flags&unix.NLM_F_DUMP_INTR != 0:
+			atomic.AddInt32(&interrupted, 1)
+		}
+	})
+
+	link, err := LinkByName("lo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkSetUp(link); err != nil {
+		t.Fatal(err)
+	}
+	// enough routes for the dump to take several messages
+	for i := 0; i < 1000; i++ {
+		dst := &net.IPNet{IP: net.IPv4(10, 1, byte(i>>8), byte(i)), Mask: net.CIDRMask(32, 32)}
+		if err := RouteAdd(&Route{LinkIndex: link.Attrs().Index, Dst: dst}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stop := make(chan struct{})
+	churnDone := make(chan struct{})
+	go func() {
+		defer close(churnDone)
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			dst := &net.IPNet{IP: net.IPv4(10, 2, 0, byte(i)), Mask: net.CIDRMask(32, 32)}
+			route := &Route{LinkIndex: link.Attrs().Index, Dst: dst}
+			if err := RouteAdd(route); err != nil {
+				continue
+			}
+			RouteDel(route)
+		}
+	}()
+
+	const lists = 200
+	var failed int
+	for i := 0; i < lists; i++ {
+		_, err := h.RouteList(nil, FAMILY_V4)
+		if errors.Is(err, ErrDumpInterrupted) {
+			failed++
+			continue
+		}
+		if err != nil {
+			close(stop)
+			<-churnDone
+			t.Fatal(err)
+		}
+	}
+	close(stop)
+	<-churnDone
+	if atomic.LoadInt32(&interrupted) > 0 && atomic.LoadInt32(&requests) <= lists {
+		t.Fatalf("%d interrupted dumps were not retried", interrupted)
+	}
+	t.Logf("%d dumps interrupted, %d of %d lists still interrupted after retries", interrupted, failed, lists)
+}
+
 func TestRouteViaAddDel(t *testing.T) {
 	minKernelRequired(t, 5, 4)
 	t.Cleanup(setUpNetlinkTest(t))
@@ -2538,6 +3321,75 @@ func TestRouteUIDOption(t *testing.T) {
 	}
 }
 
+func TestRouteL4Option(t *testing.T) {
+	t.Cleanup(setUpNetlinkTest(t))
+
+	// setup eth0 so that network is reachable
+	err := LinkAdd(&Dummy{LinkAttrs{Name: "eth0"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	link, err := LinkByName("eth0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = LinkSetUp(link); err != nil {
+		t.Fatal(err)
+	}
+	addr := &Addr{
+		IPNet: &net.IPNet{
+			IP:   net.IPv4(192, 168, 1, 1),
+			Mask: net.CIDRMask(16, 32),
+		},
+	}
+	if err = AddrAdd(link, addr); err != nil {
+		t.Fatal(err)
+	}
+
+	// a table different than unix.RT_TABLE_MAIN
+	testtable := 1000
+
+	gw1 := net.IPv4(192, 168, 1, 254)
+	gw2 := net.IPv4(192, 168, 2, 254)
+
+	// add default route via gw1 (in main route table by default)
+	if err := RouteAdd(&Route{Gw: gw1}); err != nil {
+		t.Fatal(err)
+	}
+	// add default route via gw2 in test route table
+	if err := RouteAdd(&Route{Gw: gw2, Table: testtable}); err != nil {
+		t.Fatal(err)
+	}
+
+	// tcp traffic from source port 5000 looks up the test table
+	rule := NewRule()
+	rule.IPProto = unix.IPPROTO_TCP
+	rule.Sport = NewRulePortRange(5000, 5000)
+	rule.Table = testtable
+	if err := RuleAdd(rule); err != nil {
+		t.Fatal(err)
+	}
+
+	dstIP := net.IPv4(10, 1, 1, 1)
+	for _, tt := range []struct {
+		options *RouteGetOptions
+		gw      net.IP
+	}{
+		{&RouteGetOptions{}, gw1},
+		{&RouteGetOptions{IPProto: unix.IPPROTO_TCP, SPort: 5000, DPort: 80}, gw2},
+		{&RouteGetOptions{IPProto: unix.IPPROTO_TCP, SPort: 5001, DPort: 80}, gw1},
+		{&RouteGetOptions{IPProto: unix.IPPROTO_UDP, SPort: 5000, DPort: 80}, gw1},
+	} {
+		routes, err := RouteGetWithOptions(dstIP, tt.options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(routes) != 1 || !routes[0].Gw.Equal(tt.gw) {
+			t.Fatalf("expected a route via %s for %+v, got %v", tt.gw, tt.options, routes)
+		}
+	}
+}
+
 func TestRouteFWMarkOption(t *testing.T) {
 	t.Cleanup(setUpNetlinkTest(t))
 
@@ -2739,3 +3591,87 @@ func TestRouteGetFIBMatchOption(t *testing.T) {
 		t.Fatalf("Unexpected flag %s returned", flag)
 	}
 }
+
+func TestRouteIsAutoconf(t *testing.T) {
+	for _, tc := range []struct {
+		route    Route
+		autoconf bool
+		flags    []string
+	}{
+		{Route{Protocol: unix.RTPROT_RA}, true, []string{"autoconf"}},
+		{Route{Protocol: unix.RTPROT_RA, Flags: int(FLAG_ONLINK)}, true, []string{"onlink", "autoconf"}},
+		{Route{Protocol: unix.RTPROT_KERNEL}, false, nil},
+		{Route{Protocol: unix.RTPROT_STATIC}, false, nil},
+	} {
+		if tc.route.IsAutoconf() != tc.autoconf {
+			t.Errorf("IsAutoconf of protocol %s is %t", tc.route.Protocol, !tc.autoconf)
+		}
+		if flags := tc.route.ListFlags(); !reflect.DeepEqual(flags, tc.flags) {
+			t.Errorf("ListFlags of %v is %v, should be %v", tc.route, flags, tc.flags)
+		}
+	}
+
+	t.Cleanup(setUpNetlinkTest(t))
+
+	if err := LinkAdd(&Veth{LinkAttrs: LinkAttrs{Name: "foo"}, PeerName: "bar"}); err != nil {
+		t.Fatal(err)
+	}
+	link, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkSetUp(link); err != nil {
+		t.Fatal(err)
+	}
+	_, llPrefix, _ := net.ParseCIDR("fe80::/64")
+	// the link local prefix route is connected, not learned
+	if err := RouteAdd(&Route{LinkIndex: link.Attrs().Index, Dst: llPrefix}); err != nil && !errors.Is(err, unix.EEXIST) {
+		t.Fatal(err)
+	}
+	_, raPrefix, _ := net.ParseCIDR("2001:db8:1::/64")
+	if err := RouteAdd(&Route{LinkIndex: link.Attrs().Index, Dst: raPrefix, Protocol: unix.RTPROT_RA}); err != nil {
+		t.Fatal(err)
+	}
+
+	filter := &Route{LinkIndex: link.Attrs().Index}
+	routes, err := RouteListFiltered(FAMILY_V6, filter, RT_FILTER_OIF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found []string
+	for _, r := range routes {
+		if r.IsAutoconf() {
+			found = append(found, r.Dst.String())
+		}
+	}
+	if !reflect.DeepEqual(found, []string{raPrefix.String()}) {
+		t.Fatalf("autoconf routes are %v, should be %s", found, raPrefix)
+	}
+
+	routes, err = RouteListFiltered(FAMILY_V6, filter, RT_FILTER_OIF|RT_FILTER_NOAUTOCONF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hasLL bool
+	for _, r := range routes {
+		if r.IsAutoconf() {
+			t.Fatalf("autoconf route %v not filtered", r)
+		}
+		if r.Dst != nil && r.Dst.String() == llPrefix.String() {
+			hasLL = true
+		}
+	}
+	if !hasLL {
+		t.Fatalf("%s missing from %v", llPrefix, routes)
+	}
+
+	routes, err = RouteListFiltered(FAMILY_V6, nil, RT_FILTER_NOAUTOCONF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range routes {
+		if r.IsAutoconf() {
+			t.Fatalf("autoconf route %v not filtered without a filter", r)
+		}
+	}
+}
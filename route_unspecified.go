@@ -9,6 +9,10 @@ func (r *Route) ListFlags() []string {
 	return []string{}
 }
 
+func (r *Route) IsAutoconf() bool {
+	return false
+}
+
 func (n *NexthopInfo) ListFlags() []string {
 	return []string{}
 }
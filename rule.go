@@ -3,8 +3,44 @@ package netlink
 import (
 	"fmt"
 	"net"
+
+	"github.com/vishvananda/netlink/nl"
+)
+
+// RuleAction is what a rule does with the packets it matches, the FR_ACT_*
+// value of the rule.
+type RuleAction uint8
+
+const (
+	RULE_ACTION_UNSPEC      RuleAction = nl.FR_ACT_UNSPEC
+	RULE_ACTION_TO_TBL      RuleAction = nl.FR_ACT_TO_TBL
+	RULE_ACTION_GOTO        RuleAction = nl.FR_ACT_GOTO
+	RULE_ACTION_NOP         RuleAction = nl.FR_ACT_NOP
+	RULE_ACTION_BLACKHOLE   RuleAction = nl.FR_ACT_BLACKHOLE
+	RULE_ACTION_UNREACHABLE RuleAction = nl.FR_ACT_UNREACHABLE
+	RULE_ACTION_PROHIBIT    RuleAction = nl.FR_ACT_PROHIBIT
 )
 
+func (a RuleAction) String() string {
+	switch a {
+	case RULE_ACTION_UNSPEC:
+		return "unspec"
+	case RULE_ACTION_TO_TBL:
+		return "table"
+	case RULE_ACTION_GOTO:
+		return "goto"
+	case RULE_ACTION_NOP:
+		return "nop"
+	case RULE_ACTION_BLACKHOLE:
+		return "blackhole"
+	case RULE_ACTION_UNREACHABLE:
+		return "unreachable"
+	case RULE_ACTION_PROHIBIT:
+		return "prohibit"
+	}
+	return fmt.Sprintf("action(%d)", uint8(a))
+}
+
 // Rule represents a netlink rule.
 type Rule struct {
 	Priority          int
@@ -13,7 +49,7 @@ type Rule struct {
 	Mark              uint32
 	Mask              *uint32
 	Tos               uint
-	TunID             uint
+	TunID             uint64 // tunnel id of the metadata of a collect_metadata device
 	Goto              int
 	Src               *net.IPNet
 	Dst               *net.IPNet
@@ -28,7 +64,17 @@ type Rule struct {
 	IPProto           int
 	UIDRange          *RuleUIDRange
 	Protocol          uint8
-	Type              uint8
+	// Type is the raw FR_ACT_* value of the rule, the one Action holds. It
+	// is left 0 when listing rules.
+	Type uint8
+	// Action is set when listing rules. When adding one, it replaces the
+	// table lookup, RULE_ACTION_GOTO jumping to the rule of priority Goto.
+	// Type must then be 0 or the same value, a rule setting both to
+	// different values is rejected.
+	Action RuleAction
+	// Unresolved is set when listing a goto rule whose target priority
+	// has no rule.
+	Unresolved bool
 }
 
 func (r Rule) String() string {
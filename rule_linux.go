@@ -2,6 +2,7 @@ package netlink
 
 import (
 	"bytes"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"net"
@@ -10,7 +11,10 @@ import (
 	"golang.org/x/sys/unix"
 )
 
-const FibRuleInvert = 0x2
+const (
+	FibRuleInvert     = 0x2
+	FibRuleUnresolved = 0x4
+)
 
 // RuleAdd adds a rule to the system.
 // Equivalent to: ip rule add
@@ -45,6 +49,18 @@ func ruleHandle(rule *Rule, req *nl.NetlinkRequest) error {
 	msg.Scope = unix.RT_SCOPE_UNIVERSE
 	msg.Table = unix.RT_TABLE_UNSPEC
 	msg.Type = rule.Type // usually 0, same as unix.RTN_UNSPEC
+	if rule.Action != RULE_ACTION_UNSPEC {
+		if rule.Type != 0 && rule.Type != uint8(rule.Action) {
+			return fmt.Errorf("rule type %d conflicts with action %s", rule.Type, rule.Action)
+		}
+		if rule.Action == RULE_ACTION_GOTO && rule.Goto < 0 {
+			return fmt.Errorf("goto action requires a Goto target priority")
+		}
+		if rule.Action != RULE_ACTION_GOTO && rule.Goto >= 0 {
+			return fmt.Errorf("Goto target set on a rule with action %s", rule.Action)
+		}
+		msg.Type = uint8(rule.Action)
+	}
 	if msg.Type == 0 && req.NlMsghdr.Flags&unix.NLM_F_CREATE > 0 {
 		msg.Type = unix.RTN_UNICAST
 	}
@@ -119,8 +135,9 @@ func ruleHandle(rule *Rule, req *nl.NetlinkRequest) error {
 		req.AddData(nl.NewRtAttr(nl.FRA_FLOW, b))
 	}
 	if rule.TunID > 0 {
-		b := make([]byte, 4)
-		native.PutUint32(b, uint32(rule.TunID))
+		// the kernel takes the tunnel id as a __be64
+		b := make([]byte, 8)
+		binary.BigEndian.PutUint64(b, rule.TunID)
 		req.AddData(nl.NewRtAttr(nl.FRA_TUN_ID, b))
 	}
 	if rule.Table >= 256 {
@@ -237,6 +254,8 @@ func (h *Handle) RuleListFiltered(family int, filter *Rule, filterMask uint64) (
 		rule.Priority = 0 // The default priority from kernel
 
 		rule.Invert = msg.Flags&FibRuleInvert > 0
+		rule.Unresolved = msg.Flags&FibRuleUnresolved > 0
+		rule.Action = RuleAction(msg.Type)
 		rule.Family = int(msg.Family)
 		rule.Tos = uint(msg.Tos)
 
@@ -260,7 +279,7 @@ func (h *Handle) RuleListFiltered(family int, filter *Rule, filterMask uint64) (
 				mask := native.Uint32(attrs[j].Value[0:4])
 				rule.Mask = &mask
 			case nl.FRA_TUN_ID:
-				rule.TunID = uint(native.Uint64(attrs[j].Value[0:8]))
+				rule.TunID = binary.BigEndian.Uint64(attrs[j].Value[0:8])
 			case nl.FRA_IIFNAME:
 				rule.IifName = string(attrs[j].Value[:len(attrs[j].Value)-1])
 			case nl.FRA_OIFNAME:
@@ -313,6 +332,8 @@ func (h *Handle) RuleListFiltered(family int, filter *Rule, filterMask uint64) (
 				continue
 			case filterMask&RT_FILTER_MASK != 0 && !ptrEqual(rule.Mask, filter.Mask):
 				continue
+			case filterMask&RT_FILTER_TUN_ID != 0 && rule.TunID != filter.TunID:
+				continue
 			}
 		}
 
@@ -347,6 +368,14 @@ func ptrEqual(a, b *uint32) bool {
 }
 
 func (r Rule) typeString() string {
+	if r.Type == 0 {
+		switch r.Action {
+		case RULE_ACTION_GOTO:
+			return fmt.Sprintf("goto %d", r.Goto)
+		case RULE_ACTION_NOP, RULE_ACTION_BLACKHOLE, RULE_ACTION_UNREACHABLE, RULE_ACTION_PROHIBIT:
+			return r.Action.String()
+		}
+	}
 	switch r.Type {
 	case unix.RTN_UNSPEC: // zero
 		return ""
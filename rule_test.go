@@ -4,10 +4,13 @@
 package netlink
 
 import (
+	"bytes"
 	"net"
 	"testing"
 	"time"
+	"unsafe"
 
+	"github.com/vishvananda/netlink/nl"
 	"golang.org/x/sys/unix"
 )
 
@@ -71,6 +74,157 @@ func TestRuleAddDel(t *testing.T) {
 	}
 }
 
+func TestRuleActions(t *testing.T) {
+	skipUnlessRoot(t)
+	t.Cleanup(setUpNetlinkTest(t))
+
+	findRule := func(priority int) *Rule {
+		t.Helper()
+		rules, err := RuleList(FAMILY_V4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range rules {
+			if rules[i].Priority == priority {
+				return &rules[i]
+			}
+		}
+		return nil
+	}
+
+	gotoRule := NewRule()
+	gotoRule.Family = FAMILY_V4
+	gotoRule.Priority = 100
+	gotoRule.Action = RULE_ACTION_GOTO
+	gotoRule.Goto = 200
+	if err := RuleAdd(gotoRule); err != nil {
+		t.Fatal(err)
+	}
+	r := findRule(100)
+	if r == nil {
+		t.Fatal("goto rule not added")
+	}
+	if r.Action != RULE_ACTION_GOTO || r.Goto != 200 {
+		t.Fatalf("goto rule listed as %s %d", r.Action, r.Goto)
+	}
+	if !r.Unresolved {
+		t.Fatal("goto rule without target not unresolved")
+	}
+	if s := r.String(); s != "ip rule 100: from all to all table 0 goto 200" {
+		t.Fatalf("unexpected String %q", s)
+	}
+
+	blackhole := NewRule()
+	blackhole.Family = FAMILY_V4
+	blackhole.Priority = 200
+	blackhole.Action = RULE_ACTION_BLACKHOLE
+	if err := RuleAdd(blackhole); err != nil {
+		t.Fatal(err)
+	}
+	r = findRule(200)
+	if r == nil {
+		t.Fatal("blackhole rule not added")
+	}
+	if r.Action != RULE_ACTION_BLACKHOLE {
+		t.Fatalf("blackhole rule listed as %s", r.Action)
+	}
+	if r = findRule(100); r == nil || r.Unresolved {
+		t.Fatalf("goto rule not resolved by its target: %+v", r)
+	}
+
+	if err := RuleDel(blackhole); err != nil {
+		t.Fatal(err)
+	}
+	if err := RuleDel(gotoRule); err != nil {
+		t.Fatal(err)
+	}
+	if findRule(100) != nil || findRule(200) != nil {
+		t.Fatal("rules not removed")
+	}
+
+	invalid := NewRule()
+	invalid.Priority = 300
+	invalid.Action = RULE_ACTION_GOTO
+	if err := RuleAdd(invalid); err == nil {
+		t.Fatal("goto rule without target added")
+	}
+
+	conflict := NewRule()
+	conflict.Family = FAMILY_V4
+	conflict.Priority = 300
+	conflict.Action = RULE_ACTION_BLACKHOLE
+	conflict.Type = nl.FR_ACT_PROHIBIT
+	if err := RuleAdd(conflict); err == nil {
+		t.Fatal("rule with a type conflicting with its action added")
+	}
+	conflict.Type = nl.FR_ACT_BLACKHOLE
+	if err := RuleAdd(conflict); err != nil {
+		t.Fatal(err)
+	}
+	if r = findRule(300); r == nil || r.Action != RULE_ACTION_BLACKHOLE {
+		t.Fatalf("rule with a matching type and action not added: %+v", r)
+	}
+	if err := RuleDel(conflict); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRuleTunID(t *testing.T) {
+	skipUnlessRoot(t)
+	t.Cleanup(setUpNetlinkTest(t))
+
+	h, err := NewHandle(unix.NETLINK_ROUTE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	var sent []byte
+	h.SetTraceFunc(func(dir nl.Direction, proto int, msg []byte) {
+		if dir == nl.DirectionSend && (*unix.NlMsghdr)(unsafe.Pointer(&msg[0])).Type == unix.RTM_NEWRULE {
+			sent = append([]byte(nil), msg...)
+		}
+	})
+
+	rule := NewRule()
+	rule.Family = FAMILY_V4
+	rule.Table = unix.RT_TABLE_MAIN
+	rule.Priority = 10
+	rule.TunID = 1<<32 | 42
+	if err := h.RuleAdd(rule); err != nil {
+		t.Fatal(err)
+	}
+
+	attrs, err := nl.ParseRouteAttr(sent[unix.SizeofNlMsghdr+unix.SizeofRtMsg:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, attr := range attrs {
+		if attr.Attr.Type != nl.FRA_TUN_ID {
+			continue
+		}
+		found = true
+		if !bytes.Equal(attr.Value, []byte{0, 0, 0, 1, 0, 0, 0, 42}) {
+			t.Fatalf("FRA_TUN_ID sent as %v, should be big endian", attr.Value)
+		}
+	}
+	if !found {
+		t.Fatal("FRA_TUN_ID not sent")
+	}
+
+	rules, err := h.RuleListFiltered(FAMILY_V4, &Rule{TunID: rule.TunID}, RT_FILTER_TUN_ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 1 || rules[0].TunID != rule.TunID || rules[0].Priority != 10 {
+		t.Fatalf("rule with TunID %d not listed: %v", rule.TunID, rules)
+	}
+
+	if err := h.RuleDel(rule); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestRuleListFiltered(t *testing.T) {
 	skipUnlessRoot(t)
 
@@ -1,6 +1,19 @@
 package netlink
 
-import "net"
+import (
+	"errors"
+	"net"
+)
+
+var (
+	// ErrSocketNotFound is returned by SocketDestroy when no socket matches
+	// the given addresses. It wraps the kernel's ENOENT.
+	ErrSocketNotFound = errors.New("socket not found")
+	// ErrSocketDestroyNotSupported is returned by SocketDestroy when the
+	// kernel was built without CONFIG_INET_DIAG_DESTROY. It wraps the
+	// kernel's EOPNOTSUPP.
+	ErrSocketDestroyNotSupported = errors.New("socket destroy not supported")
+)
 
 // SocketID identifies a single socket.
 type SocketID struct {
@@ -242,7 +242,12 @@ func SocketGet(local, remote net.Addr) (*Socket, error) {
 	return pkgHandle.SocketGet(local, remote)
 }
 
-// SocketDestroy kills the Socket identified by its local and remote addresses.
+// SocketDestroy kills the TCP Socket identified by its local and remote
+// addresses. The peer sees the connection reset.
+//
+// ErrSocketNotFound is returned when no such socket exists and
+// ErrSocketDestroyNotSupported when the kernel lacks
+// CONFIG_INET_DIAG_DESTROY.
 func (h *Handle) SocketDestroy(local, remote net.Addr) error {
 	localTCP, ok := local.(*net.TCPAddr)
 	if !ok {
@@ -252,42 +257,98 @@ func (h *Handle) SocketDestroy(local, remote net.Addr) error {
 	if !ok {
 		return ErrNotImplemented
 	}
-	localIP := localTCP.IP.To4()
-	if localIP == nil {
-		return ErrNotImplemented
-	}
-	remoteIP := remoteTCP.IP.To4()
-	if remoteIP == nil {
-		return ErrNotImplemented
+
+	var family uint8
+	localIP, remoteIP := localTCP.IP.To4(), remoteTCP.IP.To4()
+	if localIP != nil && remoteIP != nil {
+		family = unix.AF_INET
+	} else {
+		localIP, remoteIP = localTCP.IP.To16(), remoteTCP.IP.To16()
+		if localIP == nil || remoteIP == nil {
+			return ErrNotImplemented
+		}
+		family = unix.AF_INET6
 	}
 
-	s, err := nl.Subscribe(unix.NETLINK_INET_DIAG)
-	if err != nil {
-		return err
+	return h.socketDestroy(family, SocketID{
+		SourcePort:      uint16(localTCP.Port),
+		DestinationPort: uint16(remoteTCP.Port),
+		Source:          localIP,
+		Destination:     remoteIP,
+		Cookie:          [2]uint32{nl.TCPDIAG_NOCOOKIE, nl.TCPDIAG_NOCOOKIE},
+	})
+}
+
+// SocketDestroy kills the TCP Socket identified by its local and remote
+// addresses. The peer sees the connection reset.
+//
+// ErrSocketNotFound is returned when no such socket exists and
+// ErrSocketDestroyNotSupported when the kernel lacks
+// CONFIG_INET_DIAG_DESTROY.
+func SocketDestroy(local, remote net.Addr) error {
+	return pkgHandle.SocketDestroy(local, remote)
+}
+
+// SocketDestroyTCP kills every TCP socket of the given family for which
+// filter returns true, and returns the number of sockets destroyed.
+// Sockets that went away between the dump and the destroy request are
+// skipped.
+//
+// If the returned error is [ErrDumpInterrupted], some matching sockets may
+// have been missed and the caller should retry.
+func (h *Handle) SocketDestroyTCP(family uint8, filter func(*Socket) bool) (int, error) {
+	sockets, dumpErr := h.SocketDiagTCP(family)
+	if dumpErr != nil && !errors.Is(dumpErr, ErrDumpInterrupted) {
+		return 0, dumpErr
 	}
-	defer s.Close()
+	destroyed := 0
+	for _, s := range sockets {
+		if filter != nil && !filter(s) {
+			continue
+		}
+		// The cookie pins the request to this exact socket, so a new
+		// connection reusing the same tuple is left alone.
+		err := h.socketDestroy(s.Family, s.ID)
+		if errors.Is(err, ErrSocketNotFound) {
+			continue
+		}
+		if err != nil {
+			return destroyed, err
+		}
+		destroyed++
+	}
+	return destroyed, dumpErr
+}
+
+// SocketDestroyTCP kills every TCP socket of the given family for which
+// filter returns true, and returns the number of sockets destroyed.
+// Sockets that went away between the dump and the destroy request are
+// skipped.
+//
+// If the returned error is [ErrDumpInterrupted], some matching sockets may
+// have been missed and the caller should retry.
+func SocketDestroyTCP(family uint8, filter func(*Socket) bool) (int, error) {
+	return pkgHandle.SocketDestroyTCP(family, filter)
+}
+
+func (h *Handle) socketDestroy(family uint8, id SocketID) error {
 	req := h.newNetlinkRequest(nl.SOCK_DESTROY, unix.NLM_F_ACK)
 	req.AddData(&socketRequest{
-		Family:   unix.AF_INET,
+		Family:   family,
 		Protocol: unix.IPPROTO_TCP,
-		ID: SocketID{
-			SourcePort:      uint16(localTCP.Port),
-			DestinationPort: uint16(remoteTCP.Port),
-			Source:          localIP,
-			Destination:     remoteIP,
-			Cookie:          [2]uint32{nl.TCPDIAG_NOCOOKIE, nl.TCPDIAG_NOCOOKIE},
-		},
+		ID:       id,
 	})
 
-	_, err = req.Execute(unix.NETLINK_INET_DIAG, 0)
+	_, err := req.Execute(unix.NETLINK_INET_DIAG, 0)
+	switch {
+	case errors.Is(err, unix.ENOENT):
+		return fmt.Errorf("%w: %w", ErrSocketNotFound, err)
+	case errors.Is(err, unix.EOPNOTSUPP):
+		return fmt.Errorf("%w: %w", ErrSocketDestroyNotSupported, err)
+	}
 	return err
 }
 
-// SocketDestroy kills the Socket identified by its local and remote addresses.
-func SocketDestroy(local, remote net.Addr) error {
-	return pkgHandle.SocketDestroy(local, remote)
-}
-
 // SocketDiagTCPInfo requests INET_DIAG_INFO for TCP protocol for specified family type and return with extension TCP info.
 //
 // If the returned error is [ErrDumpInterrupted], results may be inconsistent
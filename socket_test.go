@@ -4,11 +4,13 @@
 package netlink
 
 import (
+	"errors"
 	"net"
 	"os/user"
 	"strconv"
 	"syscall"
 	"testing"
+	"time"
 )
 
 func TestSocketGet(t *testing.T) {
@@ -120,12 +122,72 @@ func TestSocketDestroy(t *testing.T) {
 	}
 	defer conn.Close()
 
+	accepted, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer accepted.Close()
+
 	localAddr := conn.LocalAddr().(*net.TCPAddr)
 	remoteAddr := conn.RemoteAddr().(*net.TCPAddr)
 	err = SocketDestroy(localAddr, remoteAddr)
+	if errors.Is(err, ErrSocketDestroyNotSupported) {
+		t.Skip("kernel built without CONFIG_INET_DIAG_DESTROY")
+	}
 	if err != nil {
 		t.Fatal(err)
 	}
+
+	accepted.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := accepted.Read(make([]byte, 1)); !errors.Is(err, syscall.ECONNRESET) {
+		t.Fatalf("expected the peer to see a reset, got %v", err)
+	}
+
+	err = SocketDestroy(localAddr, remoteAddr)
+	if !errors.Is(err, ErrSocketNotFound) {
+		t.Fatalf("expected ErrSocketNotFound, got %v", err)
+	}
+}
+
+func TestSocketDestroyTCP(t *testing.T) {
+	t.Cleanup(setUpNetlinkTestWithLoopback(t))
+
+	l, err := net.Listen("tcp6", "[::1]:0")
+	if err != nil {
+		t.Skip("IPv6 loopback not available:", err)
+	}
+	defer l.Close()
+	port := uint16(l.Addr().(*net.TCPAddr).Port)
+
+	var conns []net.Conn
+	for i := 0; i < 2; i++ {
+		conn, err := net.Dial("tcp6", l.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		conns = append(conns, conn)
+	}
+
+	// Only destroy the client ends of the connections to the listener.
+	n, err := SocketDestroyTCP(syscall.AF_INET6, func(s *Socket) bool {
+		return s.ID.DestinationPort == port
+	})
+	if errors.Is(err, ErrSocketDestroyNotSupported) {
+		t.Skip("kernel built without CONFIG_INET_DIAG_DESTROY")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(conns) {
+		t.Fatalf("destroyed %d sockets, expected %d", n, len(conns))
+	}
+	for _, conn := range conns {
+		_, err := SocketGet(conn.LocalAddr(), conn.RemoteAddr())
+		if err == nil {
+			t.Fatalf("socket %v still exists", conn.LocalAddr())
+		}
+	}
 }
 
 func TestSocketDiagTCPInfo(t *testing.T) {
@@ -15,6 +15,10 @@ func SocketDestroy(local, remote net.Addr) error {
 	return ErrNotImplemented
 }
 
+func SocketDestroyTCP(family uint8, filter func(*Socket) bool) (int, error) {
+	return 0, ErrNotImplemented
+}
+
 func SocketDiagTCPInfo(family uint8) ([]*InetDiagTCPInfoResp, error) {
 	return nil, ErrNotImplemented
 }
@@ -0,0 +1,286 @@
+package netlink
+
+import (
+	"fmt"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/vishvananda/netlink/nl"
+)
+
+// TeamOption is an option of a team device or of one of its ports, as
+// exchanged with the team generic netlink family.
+type TeamOption struct {
+	Name string
+	Type uint8 // nl.TEAM_OPTION_TYPE_*
+	// Data is the raw option value in native endianness. Strings are zero
+	// terminated and a false bool option has no data.
+	Data []byte
+	// PortIndex is the ifindex of the port of a per port option.
+	PortIndex int
+	// ArrayIndex is the index of an array option such as bpf_hash_func.
+	ArrayIndex *uint32
+}
+
+// TeamPort is a port of a team device.
+type TeamPort struct {
+	Index  int
+	LinkUp bool
+	Speed  uint32 // Mb/s
+	Duplex uint8  // 0 half, 1 full, 0xff unknown
+}
+
+func (o *TeamOption) parseAttributes(attrs []syscall.NetlinkRouteAttr) {
+	for _, a := range attrs {
+		switch a.Attr.Type {
+		case nl.TEAM_ATTR_OPTION_NAME:
+			o.Name = nl.BytesToString(a.Value)
+		case nl.TEAM_ATTR_OPTION_TYPE:
+			o.Type = a.Value[0]
+		case nl.TEAM_ATTR_OPTION_DATA:
+			o.Data = a.Value
+		case nl.TEAM_ATTR_OPTION_PORT_IFINDEX:
+			o.PortIndex = int(native.Uint32(a.Value))
+		case nl.TEAM_ATTR_OPTION_ARRAY_INDEX:
+			index := native.Uint32(a.Value)
+			o.ArrayIndex = &index
+		}
+	}
+}
+
+func (p *TeamPort) parseAttributes(attrs []syscall.NetlinkRouteAttr) {
+	for _, a := range attrs {
+		switch a.Attr.Type {
+		case nl.TEAM_ATTR_PORT_IFINDEX:
+			p.Index = int(native.Uint32(a.Value))
+		case nl.TEAM_ATTR_PORT_LINKUP:
+			p.LinkUp = true
+		case nl.TEAM_ATTR_PORT_SPEED:
+			p.Speed = native.Uint32(a.Value)
+		case nl.TEAM_ATTR_PORT_DUPLEX:
+			p.Duplex = a.Value[0]
+		}
+	}
+}
+
+// parseTeamList decodes the items of a TEAM_ATTR_LIST_OPTION or
+// TEAM_ATTR_LIST_PORT attribute of every message of a team reply.
+func parseTeamList(msgs [][]byte, listType uint16, parse func([]syscall.NetlinkRouteAttr)) error {
+	for _, m := range msgs {
+		attrs, err := nl.ParseRouteAttr(m[nl.SizeofGenlmsg:])
+		if err != nil {
+			return err
+		}
+		for _, attr := range attrs {
+			if attr.Attr.Type&nl.NLA_TYPE_MASK != listType {
+				continue
+			}
+			items, err := nl.ParseRouteAttr(attr.Value)
+			if err != nil {
+				return err
+			}
+			for _, item := range items {
+				itemAttrs, err := nl.ParseRouteAttr(item.Value)
+				if err != nil {
+					return err
+				}
+				parse(itemAttrs)
+			}
+		}
+	}
+	return nil
+}
+
+// teamRequest sends a team command for link. Get commands are answered
+// with a multipart reply and are sent without NLM_F_ACK.
+func (h *Handle) teamRequest(link Link, command uint8, flags int, attrs ...*nl.RtAttr) ([][]byte, error) {
+	f, err := h.GenlFamilyGet(nl.TEAM_GENL_NAME)
+	if err != nil {
+		return nil, err
+	}
+	base := link.Attrs()
+	h.ensureIndex(base)
+	req := h.newNetlinkRequest(int(f.ID), flags)
+	req.AddData(&nl.Genlmsg{
+		Command: command,
+		Version: nl.TEAM_GENL_VERSION,
+	})
+	req.AddData(nl.NewRtAttr(nl.TEAM_ATTR_TEAM_IFINDEX, nl.Uint32Attr(uint32(base.Index))))
+	for _, a := range attrs {
+		req.AddData(a)
+	}
+	return req.Execute(unix.NETLINK_GENERIC, 0)
+}
+
+// TeamOptionList returns the options of the team device link and of its
+// ports.
+// Equivalent to: `teamnl DEV options`
+func TeamOptionList(link Link) ([]TeamOption, error) {
+	return pkgHandle.TeamOptionList(link)
+}
+
+// TeamOptionList returns the options of the team device link and of its
+// ports.
+// Equivalent to: `teamnl DEV options`
+func (h *Handle) TeamOptionList(link Link) ([]TeamOption, error) {
+	msgs, err := h.teamRequest(link, nl.TEAM_CMD_OPTIONS_GET, 0)
+	if err != nil {
+		return nil, err
+	}
+	var ret []TeamOption
+	err = parseTeamList(msgs, nl.TEAM_ATTR_LIST_OPTION, func(attrs []syscall.NetlinkRouteAttr) {
+		var o TeamOption
+		o.parseAttributes(attrs)
+		ret = append(ret, o)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// TeamOptionSet sets an option of the team device link, or of one of its
+// ports if PortIndex is set.
+// Equivalent to: `teamnl DEV setoption NAME VALUE [ --port PORT ] [ --array_index INDEX ]`
+func TeamOptionSet(link Link, option TeamOption) error {
+	return pkgHandle.TeamOptionSet(link, option)
+}
+
+// TeamOptionSet sets an option of the team device link, or of one of its
+// ports if PortIndex is set.
+// Equivalent to: `teamnl DEV setoption NAME VALUE [ --port PORT ] [ --array_index INDEX ]`
+func (h *Handle) TeamOptionSet(link Link, option TeamOption) error {
+	list := nl.NewRtAttr(nl.TEAM_ATTR_LIST_OPTION|unix.NLA_F_NESTED, nil)
+	item := list.AddRtAttr(nl.TEAM_ATTR_ITEM_OPTION|unix.NLA_F_NESTED, nil)
+	item.AddRtAttr(nl.TEAM_ATTR_OPTION_NAME, nl.ZeroTerminated(option.Name))
+	item.AddRtAttr(nl.TEAM_ATTR_OPTION_TYPE, nl.Uint8Attr(option.Type))
+	if option.Type != nl.TEAM_OPTION_TYPE_BOOL || len(option.Data) > 0 {
+		item.AddRtAttr(nl.TEAM_ATTR_OPTION_DATA, option.Data)
+	}
+	if option.PortIndex != 0 {
+		item.AddRtAttr(nl.TEAM_ATTR_OPTION_PORT_IFINDEX, nl.Uint32Attr(uint32(option.PortIndex)))
+	}
+	if option.ArrayIndex != nil {
+		item.AddRtAttr(nl.TEAM_ATTR_OPTION_ARRAY_INDEX, nl.Uint32Attr(*option.ArrayIndex))
+	}
+	_, err := h.teamRequest(link, nl.TEAM_CMD_OPTIONS_SET, unix.NLM_F_ACK, list)
+	return err
+}
+
+func (h *Handle) teamOptionGet(link Link, name string) (*TeamOption, error) {
+	options, err := h.TeamOptionList(link)
+	if err != nil {
+		return nil, err
+	}
+	for i := range options {
+		if options[i].Name == name && options[i].PortIndex == 0 {
+			return &options[i], nil
+		}
+	}
+	return nil, fmt.Errorf("team option %q not found", name)
+}
+
+// TeamSetRunner sets the runner (mode) of the team device link, such as
+// "roundrobin", "activebackup", "loadbalance", "broadcast" or "random". The
+// kernel refuses to change the runner while the team has ports.
+// Equivalent to: `teamnl DEV setoption mode RUNNER`
+func TeamSetRunner(link Link, runner string) error {
+	return pkgHandle.TeamSetRunner(link, runner)
+}
+
+// TeamSetRunner sets the runner (mode) of the team device link, such as
+// "roundrobin", "activebackup", "loadbalance", "broadcast" or "random". The
+// kernel refuses to change the runner while the team has ports.
+// Equivalent to: `teamnl DEV setoption mode RUNNER`
+func (h *Handle) TeamSetRunner(link Link, runner string) error {
+	return h.TeamOptionSet(link, TeamOption{
+		Name: "mode",
+		Type: nl.TEAM_OPTION_TYPE_STRING,
+		Data: nl.ZeroTerminated(runner),
+	})
+}
+
+// TeamGetRunner returns the runner (mode) of the team device link.
+// Equivalent to: `teamnl DEV getoption mode`
+func TeamGetRunner(link Link) (string, error) {
+	return pkgHandle.TeamGetRunner(link)
+}
+
+// TeamGetRunner returns the runner (mode) of the team device link.
+// Equivalent to: `teamnl DEV getoption mode`
+func (h *Handle) TeamGetRunner(link Link) (string, error) {
+	option, err := h.teamOptionGet(link, "mode")
+	if err != nil {
+		return "", err
+	}
+	return nl.BytesToString(option.Data), nil
+}
+
+// TeamGetActivePort returns the ifindex of the active port of the team
+// device link, 0 if there is none. Only the activebackup runner has an
+// active port.
+// Equivalent to: `teamnl DEV getoption activeport`
+func TeamGetActivePort(link Link) (int, error) {
+	return pkgHandle.TeamGetActivePort(link)
+}
+
+// TeamGetActivePort returns the ifindex of the active port of the team
+// device link, 0 if there is none. Only the activebackup runner has an
+// active port.
+// Equivalent to: `teamnl DEV getoption activeport`
+func (h *Handle) TeamGetActivePort(link Link) (int, error) {
+	option, err := h.teamOptionGet(link, "activeport")
+	if err != nil {
+		return 0, err
+	}
+	if len(option.Data) < 4 {
+		return 0, fmt.Errorf("invalid activeport option value %v", option.Data)
+	}
+	return int(native.Uint32(option.Data)), nil
+}
+
+// TeamSetActivePort makes port the active port of the team device link,
+// which must use the activebackup runner.
+// Equivalent to: `teamnl DEV setoption activeport PORT`
+func TeamSetActivePort(link Link, port Link) error {
+	return pkgHandle.TeamSetActivePort(link, port)
+}
+
+// TeamSetActivePort makes port the active port of the team device link,
+// which must use the activebackup runner.
+// Equivalent to: `teamnl DEV setoption activeport PORT`
+func (h *Handle) TeamSetActivePort(link Link, port Link) error {
+	base := port.Attrs()
+	h.ensureIndex(base)
+	return h.TeamOptionSet(link, TeamOption{
+		Name: "activeport",
+		Type: nl.TEAM_OPTION_TYPE_U32,
+		Data: nl.Uint32Attr(uint32(base.Index)),
+	})
+}
+
+// TeamPortList returns the ports of the team device link.
+// Equivalent to: `teamnl DEV ports`
+func TeamPortList(link Link) ([]TeamPort, error) {
+	return pkgHandle.TeamPortList(link)
+}
+
+// TeamPortList returns the ports of the team device link.
+// Equivalent to: `teamnl DEV ports`
+func (h *Handle) TeamPortList(link Link) ([]TeamPort, error) {
+	msgs, err := h.teamRequest(link, nl.TEAM_CMD_PORT_LIST_GET, 0)
+	if err != nil {
+		return nil, err
+	}
+	var ret []TeamPort
+	err = parseTeamList(msgs, nl.TEAM_ATTR_LIST_PORT, func(attrs []syscall.NetlinkRouteAttr) {
+		var p TeamPort
+		p.parseAttributes(attrs)
+		ret = append(ret, p)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
@@ -0,0 +1,117 @@
+package netlink
+
+import (
+	"syscall"
+	"testing"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/vishvananda/netlink/nl"
+)
+
+func TestParseTeamList(t *testing.T) {
+	genl := &nl.Genlmsg{Command: nl.TEAM_CMD_PORT_LIST_GET, Version: nl.TEAM_GENL_VERSION}
+	list := nl.NewRtAttr(nl.TEAM_ATTR_LIST_PORT|unix.NLA_F_NESTED, nil)
+	port := list.AddRtAttr(nl.TEAM_ATTR_ITEM_PORT|unix.NLA_F_NESTED, nil)
+	port.AddRtAttr(nl.TEAM_ATTR_PORT_IFINDEX, nl.Uint32Attr(5))
+	port.AddRtAttr(nl.TEAM_ATTR_PORT_LINKUP, nil)
+	port.AddRtAttr(nl.TEAM_ATTR_PORT_SPEED, nl.Uint32Attr(10000))
+	port.AddRtAttr(nl.TEAM_ATTR_PORT_DUPLEX, nl.Uint8Attr(1))
+	port = list.AddRtAttr(nl.TEAM_ATTR_ITEM_PORT|unix.NLA_F_NESTED, nil)
+	port.AddRtAttr(nl.TEAM_ATTR_PORT_IFINDEX, nl.Uint32Attr(6))
+
+	msg := append(genl.Serialize(), nl.NewRtAttr(nl.TEAM_ATTR_TEAM_IFINDEX, nl.Uint32Attr(4)).Serialize()...)
+	msg = append(msg, list.Serialize()...)
+
+	var ports []TeamPort
+	err := parseTeamList([][]byte{msg}, nl.TEAM_ATTR_LIST_PORT, func(attrs []syscall.NetlinkRouteAttr) {
+		var p TeamPort
+		p.parseAttributes(attrs)
+		ports = append(ports, p)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []TeamPort{
+		{Index: 5, LinkUp: true, Speed: 10000, Duplex: 1},
+		{Index: 6},
+	}
+	if len(ports) != len(expected) {
+		t.Fatalf("expected %d ports, got %+v", len(expected), ports)
+	}
+	for i := range expected {
+		if ports[i] != expected[i] {
+			t.Fatalf("expected port %+v, got %+v", expected[i], ports[i])
+		}
+	}
+}
+
+func TestTeamAddPorts(t *testing.T) {
+	t.Cleanup(setUpNetlinkTestWithKModule(t, "team", "team_mode_activebackup"))
+
+	team := &Team{LinkAttrs: LinkAttrs{Name: "team0"}}
+	if err := LinkAdd(team); err != nil {
+		t.Fatal(err)
+	}
+	link, err := LinkByName("team0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := link.(*Team); !ok {
+		t.Fatalf("unexpected link type %T", link)
+	}
+
+	// the runner can only be changed while the team has no ports
+	if err := TeamSetRunner(link, "activebackup"); err != nil {
+		t.Fatal(err)
+	}
+	runner, err := TeamGetRunner(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runner != "activebackup" {
+		t.Fatalf("expected runner activebackup, got %q", runner)
+	}
+
+	var ports []Link
+	for _, name := range []string{"dum1", "dum2"} {
+		dummy := &Dummy{LinkAttrs: LinkAttrs{Name: name}}
+		if err := LinkAdd(dummy); err != nil {
+			t.Fatal(err)
+		}
+		if err := LinkSetMaster(dummy, link); err != nil {
+			t.Fatal(err)
+		}
+		ports = append(ports, dummy)
+	}
+
+	teamPorts, err := TeamPortList(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(teamPorts) != 2 {
+		t.Fatalf("expected 2 team ports, got %+v", teamPorts)
+	}
+	for _, port := range ports {
+		found := false
+		for _, teamPort := range teamPorts {
+			if teamPort.Index == port.Attrs().Index {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("port %s not found in %+v", port.Attrs().Name, teamPorts)
+		}
+	}
+
+	if err := TeamSetActivePort(link, ports[1]); err != nil {
+		t.Fatal(err)
+	}
+	active, err := TeamGetActivePort(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if active != ports[1].Attrs().Index {
+		t.Fatalf("expected active port %d, got %d", ports[1].Attrs().Index, active)
+	}
+}
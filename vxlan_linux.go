@@ -0,0 +1,195 @@
+package netlink
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+// VxlanVni is a VNI configured on a vxlan device created with VniFilter.
+// VniEnd is set when the kernel reports a range of VNIs sharing the same
+// configuration.
+type VxlanVni struct {
+	Vni    uint32
+	VniEnd uint32
+	Group  net.IP
+	Stats  *VxlanVniStats
+}
+
+// VxlanVniStats are the per-VNI counters of a vnifilter vxlan device.
+type VxlanVniStats struct {
+	RxBytes   uint64
+	RxPackets uint64
+	RxDrops   uint64
+	RxErrors  uint64
+	TxBytes   uint64
+	TxPackets uint64
+	TxDrops   uint64
+	TxErrors  uint64
+}
+
+func (v VxlanVni) String() string {
+	if v.VniEnd != 0 {
+		return fmt.Sprintf("{Vni: %d-%d, Group: %s}", v.Vni, v.VniEnd, v.Group)
+	}
+	return fmt.Sprintf("{Vni: %d, Group: %s}", v.Vni, v.Group)
+}
+
+// VxlanVniAdd adds a VNI to a vxlan device created with VniFilter.
+// The group may be nil to use the device default remote.
+// Equivalent to: `bridge vni add dev DEV vni VNI [ group GROUP ]`
+func VxlanVniAdd(link *Vxlan, vni uint32, group net.IP) error {
+	return pkgHandle.VxlanVniAdd(link, vni, group)
+}
+
+// VxlanVniAdd adds a VNI to a vxlan device created with VniFilter.
+// The group may be nil to use the device default remote.
+// Equivalent to: `bridge vni add dev DEV vni VNI [ group GROUP ]`
+func (h *Handle) VxlanVniAdd(link *Vxlan, vni uint32, group net.IP) error {
+	return h.vxlanVniModify(unix.RTM_NEWTUNNEL, unix.NLM_F_CREATE|unix.NLM_F_EXCL, link, vni, group)
+}
+
+// VxlanVniDel removes a VNI from a vxlan device created with VniFilter.
+// Equivalent to: `bridge vni del dev DEV vni VNI`
+func VxlanVniDel(link *Vxlan, vni uint32) error {
+	return pkgHandle.VxlanVniDel(link, vni)
+}
+
+// VxlanVniDel removes a VNI from a vxlan device created with VniFilter.
+// Equivalent to: `bridge vni del dev DEV vni VNI`
+func (h *Handle) VxlanVniDel(link *Vxlan, vni uint32) error {
+	return h.vxlanVniModify(unix.RTM_DELTUNNEL, 0, link, vni, nil)
+}
+
+func (h *Handle) vxlanVniModify(cmd, flags int, link *Vxlan, vni uint32, group net.IP) error {
+	base := link.Attrs()
+	h.ensureIndex(base)
+	req := h.newNetlinkRequest(cmd, flags|unix.NLM_F_ACK)
+	req.AddData(&nl.TunnelMsg{
+		Family:  unix.AF_BRIDGE,
+		Ifindex: uint32(base.Index),
+	})
+
+	entry := nl.NewRtAttr(nl.VXLAN_VNIFILTER_ENTRY|unix.NLA_F_NESTED, nil)
+	entry.AddRtAttr(nl.VXLAN_VNIFILTER_ENTRY_START, nl.Uint32Attr(vni))
+	if group != nil {
+		if ip4 := group.To4(); ip4 != nil {
+			entry.AddRtAttr(nl.VXLAN_VNIFILTER_ENTRY_GROUP, []byte(ip4))
+		} else {
+			entry.AddRtAttr(nl.VXLAN_VNIFILTER_ENTRY_GROUP6, []byte(group.To16()))
+		}
+	}
+	req.AddData(entry)
+
+	_, err := req.Execute(unix.NETLINK_ROUTE, 0)
+	return err
+}
+
+// VxlanVniList lists the VNIs configured on a vxlan device created with
+// VniFilter, together with their statistics.
+// Equivalent to: `bridge -s vni show dev DEV`
+//
+// If the returned error is [ErrDumpInterrupted], results may be inconsistent
+// or incomplete.
+func VxlanVniList(link *Vxlan) ([]VxlanVni, error) {
+	return pkgHandle.VxlanVniList(link)
+}
+
+// VxlanVniList lists the VNIs configured on a vxlan device created with
+// VniFilter, together with their statistics.
+// Equivalent to: `bridge -s vni show dev DEV`
+//
+// If the returned error is [ErrDumpInterrupted], results may be inconsistent
+// or incomplete.
+func (h *Handle) VxlanVniList(link *Vxlan) ([]VxlanVni, error) {
+	base := link.Attrs()
+	h.ensureIndex(base)
+	req := h.newNetlinkRequest(unix.RTM_GETTUNNEL, unix.NLM_F_DUMP)
+	req.AddData(&nl.TunnelMsg{
+		Family:  unix.AF_BRIDGE,
+		Flags:   nl.TUNNEL_MSG_FLAG_STATS,
+		Ifindex: uint32(base.Index),
+	})
+
+	msgs, executeErr := req.Execute(unix.NETLINK_ROUTE, unix.RTM_NEWTUNNEL)
+	if executeErr != nil && !errors.Is(executeErr, ErrDumpInterrupted) {
+		return nil, executeErr
+	}
+
+	var res []VxlanVni
+	for _, m := range msgs {
+		msg := nl.DeserializeTunnelMsg(m)
+		if msg.Ifindex != uint32(base.Index) {
+			continue
+		}
+		attrs, err := nl.ParseRouteAttr(m[msg.Len():])
+		if err != nil {
+			return nil, err
+		}
+		for _, attr := range attrs {
+			if attr.Attr.Type&^unix.NLA_F_NESTED != nl.VXLAN_VNIFILTER_ENTRY {
+				continue
+			}
+			vni, err := parseVxlanVni(attr.Value)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, vni)
+		}
+	}
+	return res, executeErr
+}
+
+func parseVxlanVni(data []byte) (VxlanVni, error) {
+	var vni VxlanVni
+	attrs, err := nl.ParseRouteAttr(data)
+	if err != nil {
+		return vni, err
+	}
+	for _, attr := range attrs {
+		switch attr.Attr.Type &^ unix.NLA_F_NESTED {
+		case nl.VXLAN_VNIFILTER_ENTRY_START:
+			vni.Vni = native.Uint32(attr.Value[0:4])
+		case nl.VXLAN_VNIFILTER_ENTRY_END:
+			vni.VniEnd = native.Uint32(attr.Value[0:4])
+		case nl.VXLAN_VNIFILTER_ENTRY_GROUP:
+			vni.Group = net.IP(attr.Value[0:4])
+		case nl.VXLAN_VNIFILTER_ENTRY_GROUP6:
+			vni.Group = net.IP(attr.Value[0:16])
+		case nl.VXLAN_VNIFILTER_ENTRY_STATS:
+			stats, err := nl.ParseRouteAttr(attr.Value)
+			if err != nil {
+				return vni, err
+			}
+			vni.Stats = &VxlanVniStats{}
+			for _, stat := range stats {
+				if len(stat.Value) < 8 {
+					continue
+				}
+				val := native.Uint64(stat.Value[0:8])
+				switch stat.Attr.Type {
+				case nl.VNIFILTER_ENTRY_STATS_RX_BYTES:
+					vni.Stats.RxBytes = val
+				case nl.VNIFILTER_ENTRY_STATS_RX_PKTS:
+					vni.Stats.RxPackets = val
+				case nl.VNIFILTER_ENTRY_STATS_RX_DROPS:
+					vni.Stats.RxDrops = val
+				case nl.VNIFILTER_ENTRY_STATS_RX_ERRORS:
+					vni.Stats.RxErrors = val
+				case nl.VNIFILTER_ENTRY_STATS_TX_BYTES:
+					vni.Stats.TxBytes = val
+				case nl.VNIFILTER_ENTRY_STATS_TX_PKTS:
+					vni.Stats.TxPackets = val
+				case nl.VNIFILTER_ENTRY_STATS_TX_DROPS:
+					vni.Stats.TxDrops = val
+				case nl.VNIFILTER_ENTRY_STATS_TX_ERRORS:
+					vni.Stats.TxErrors = val
+				}
+			}
+		}
+	}
+	return vni, nil
+}
@@ -0,0 +1,73 @@
+package netlink
+
+import (
+	"net"
+	"testing"
+)
+
+func TestVxlanVniAddListDel(t *testing.T) {
+	minKernelRequired(t, 5, 18)
+	t.Cleanup(setUpNetlinkTest(t))
+
+	vxlan := &Vxlan{
+		LinkAttrs: LinkAttrs{Name: "vx0"},
+		Port:      4789,
+		FlowBased: true,
+		VniFilter: true,
+	}
+	if err := LinkAdd(vxlan); err != nil {
+		t.Fatal(err)
+	}
+	link, err := LinkByName("vx0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	vxlan = link.(*Vxlan)
+	if !vxlan.VniFilter {
+		t.Fatal("expected vxlan device to have VniFilter enabled")
+	}
+
+	// a multicast group would need a VtepDevIndex, the remote is unicast
+	group := net.ParseIP("192.0.2.1")
+	if err := VxlanVniAdd(vxlan, 100, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := VxlanVniAdd(vxlan, 200, group); err != nil {
+		t.Fatal(err)
+	}
+
+	vnis, err := VxlanVniList(vxlan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := map[uint32]VxlanVni{}
+	for _, v := range vnis {
+		found[v.Vni] = v
+	}
+	if _, ok := found[100]; !ok {
+		t.Fatalf("vni 100 not found in %v", vnis)
+	}
+	v, ok := found[200]
+	if !ok {
+		t.Fatalf("vni 200 not found in %v", vnis)
+	}
+	if !v.Group.Equal(group) {
+		t.Fatalf("vni 200 group mismatch: expected %s, got %s", group, v.Group)
+	}
+	if v.Stats == nil {
+		t.Fatal("expected vni stats to be reported")
+	}
+
+	if err := VxlanVniDel(vxlan, 100); err != nil {
+		t.Fatal(err)
+	}
+	vnis, err = VxlanVniList(vxlan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range vnis {
+		if v.Vni == 100 {
+			t.Fatal("vni 100 was not deleted")
+		}
+	}
+}